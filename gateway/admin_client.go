@@ -0,0 +1,129 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/openfaas/faas/gateway/types"
+)
+
+// adminSocketClient talks to a running gateway process over its admin unix
+// socket, backing the `gateway status`/`gateway drain`/`gateway cache flush`
+// operational subcommands.
+func adminSocketClient(socketPath string) *http.Client {
+	return &http.Client{
+		Timeout: time.Second * 5,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+}
+
+// runCLI dispatches the CLI subcommands supported by the gateway binary. It
+// returns true if args contained a recognised subcommand, i.e. the caller
+// should exit after runCLI returns rather than starting the HTTP server.
+func runCLI(args []string, socketPath string) bool {
+	if len(args) < 2 {
+		return false
+	}
+
+	client := adminSocketClient(socketPath)
+
+	switch args[1] {
+	case "status":
+		res, err := client.Get("http://unix/status")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to reach gateway admin socket %s: %s\n", socketPath, err.Error())
+			os.Exit(1)
+		}
+		defer res.Body.Close()
+
+		var status struct {
+			Draining bool `json:"draining"`
+		}
+		json.NewDecoder(res.Body).Decode(&status)
+		fmt.Printf("Draining: %v\n", status.Draining)
+		return true
+
+	case "drain":
+		res, err := client.Post("http://unix/drain", "application/json", nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to reach gateway admin socket %s: %s\n", socketPath, err.Error())
+			os.Exit(1)
+		}
+		res.Body.Close()
+		fmt.Println("Draining requested")
+		return true
+
+	case "cache":
+		if len(args) >= 3 && args[2] == "flush" {
+			res, err := client.Post("http://unix/cache/flush", "application/json", nil)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "unable to reach gateway admin socket %s: %s\n", socketPath, err.Error())
+				os.Exit(1)
+			}
+			res.Body.Close()
+			fmt.Println("Cache flushed")
+			return true
+		}
+		fmt.Fprintln(os.Stderr, "usage: gateway cache flush")
+		os.Exit(1)
+
+	case "config":
+		if len(args) >= 3 && args[2] == "validate" {
+			osEnv := types.OsEnv{}
+			readConfig := types.ReadConfig{}
+			config := readConfig.Read(osEnv)
+			validateOrExit(config, true)
+			return true
+		}
+		fmt.Fprintln(os.Stderr, "usage: gateway config validate")
+		os.Exit(1)
+	}
+
+	return false
+}
+
+// hasArg reports whether flag is present anywhere in args.
+func hasArg(args []string, flag string) bool {
+	for _, arg := range args {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOrExit runs startup validation, printing any problems found along
+// with remediation hints. When reachable is true, connectivity to the
+// provider/NATS/Prometheus is also checked; this is skipped during normal
+// startup so a slow/absent dependency cannot delay boot, but is performed
+// for `--validate-only` and `gateway config validate` where fail-fast
+// diagnostics are the whole point.
+func validateOrExit(config types.GatewayConfig, reachable bool) {
+	dialTimeout := time.Duration(0)
+	if reachable {
+		dialTimeout = time.Second * 3
+	}
+
+	problems := types.Validate(config, dialTimeout)
+	if len(problems) == 0 {
+		fmt.Println("Configuration OK")
+		return
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintln(os.Stderr, problem.String())
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,143 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package bench provides a synthetic function backend for load-testing the
+// gateway. It stands in for a real provider with a fixed catalog of
+// in-process functions (echo, sleep, payload-size) that do the work
+// themselves instead of proxying to a container, so operators can measure
+// gateway throughput, timeouts and scaling middleware in isolation from any
+// real provider.
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// FunctionNames are the synthetic functions a Backend serves.
+var FunctionNames = []string{"echo", "sleep", "payload-size"}
+
+const (
+	defaultSleep        = 100 * time.Millisecond
+	defaultPayloadBytes = 1024
+)
+
+// Backend implements enough of the faas-provider REST contract to stand in
+// for a real provider - the system/functions catalog and an invocation
+// endpoint - backed by a fixed set of synthetic functions rather than
+// deployed containers.
+type Backend struct{}
+
+// NewBackend creates a Backend serving FunctionNames.
+func NewBackend() *Backend {
+	return &Backend{}
+}
+
+// NewServer starts Backend as an httptest.Server. Callers must Close it.
+func (b *Backend) NewServer() *httptest.Server {
+	return httptest.NewServer(b.router())
+}
+
+func (b *Backend) router() *mux.Router {
+	r := mux.NewRouter()
+
+	r.HandleFunc("/system/functions", b.listFunctions).Methods(http.MethodGet)
+	r.HandleFunc("/system/function/{name}", b.getFunction).Methods(http.MethodGet)
+	r.HandleFunc("/system/scale-function/{name}", b.scaleFunction).Methods(http.MethodPost)
+
+	r.HandleFunc("/function/{name:[-a-zA-Z_0-9]+}", b.invoke)
+	r.HandleFunc("/function/{name:[-a-zA-Z_0-9]+}/{params:.*}", b.invoke)
+
+	return r
+}
+
+func (b *Backend) listFunctions(w http.ResponseWriter, r *http.Request) {
+	out := make([]requests.Function, 0, len(FunctionNames))
+	for _, name := range FunctionNames {
+		out = append(out, syntheticFunction(name))
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func (b *Backend) getFunction(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if !isSynthetic(name) {
+		http.Error(w, "function not found: "+name, http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(syntheticFunction(name))
+}
+
+func (b *Backend) scaleFunction(w http.ResponseWriter, r *http.Request) {
+	// Synthetic functions are always available at a fixed replica count -
+	// there is nothing to scale - so just acknowledge the request the way a
+	// provider would once it had applied the change.
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// invoke performs the work of whichever synthetic function was named in the
+// request path, in place of proxying to a container:
+//
+//   - echo copies the request body back as the response body.
+//   - sleep waits for the duration given in its "duration" query parameter
+//     (a Go duration string, e.g. "250ms"), to exercise gateway/function
+//     timeouts.
+//   - payload-size writes back a body of the size given in its "bytes"
+//     query parameter, to exercise throughput under large responses.
+func (b *Backend) invoke(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	switch name {
+	case "echo":
+		io.Copy(w, r.Body)
+	case "sleep":
+		time.Sleep(parseDuration(r.URL.Query().Get("duration"), defaultSleep))
+		w.WriteHeader(http.StatusOK)
+	case "payload-size":
+		w.Write(bytes.Repeat([]byte("x"), parseSize(r.URL.Query().Get("bytes"), defaultPayloadBytes)))
+	default:
+		http.Error(w, "function not found: "+name, http.StatusNotFound)
+	}
+}
+
+func syntheticFunction(name string) requests.Function {
+	return requests.Function{
+		Name:              name,
+		Image:             "bench/" + name,
+		Replicas:          1,
+		AvailableReplicas: 1,
+	}
+}
+
+func isSynthetic(name string) bool {
+	for _, candidate := range FunctionNames {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDuration(raw string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+func parseSize(raw string, fallback int) int {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,65 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package bench
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func Test_Backend_EchoReturnsTheRequestBody(t *testing.T) {
+	ts := NewBackend().NewServer()
+	defer ts.Close()
+
+	res, err := http.Post(ts.URL+"/function/echo", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	if string(body) != "hello" {
+		t.Fatalf("want echo to return the request body, got %q", string(body))
+	}
+}
+
+func Test_Backend_PayloadSizeReturnsTheRequestedNumberOfBytes(t *testing.T) {
+	ts := NewBackend().NewServer()
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/function/payload-size?bytes=42")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	if len(body) != 42 {
+		t.Fatalf("want a 42 byte payload, got %d", len(body))
+	}
+}
+
+func Test_Backend_InvokeReturnsNotFoundForAnUnknownFunction(t *testing.T) {
+	ts := NewBackend().NewServer()
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/function/missing")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("want 404 for an unknown synthetic function, got %d", res.StatusCode)
+	}
+}
+
+func Test_Backend_ListFunctionsReturnsTheSyntheticCatalog(t *testing.T) {
+	ts := NewBackend().NewServer()
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/system/functions")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 listing functions, got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+)
+
+// AdminStatus is returned by the admin socket's /status endpoint.
+type AdminStatus struct {
+	Draining bool `json:"draining"`
+}
+
+// AdminServer exposes operational endpoints (status, drain, cache flush)
+// over a local unix socket, so that `gateway status`/`gateway drain`/
+// `gateway cache flush` subcommands can talk to a running gateway process
+// without exposing these operations over the network.
+type AdminServer struct {
+	// SocketPath is the filesystem path of the unix socket to listen on.
+	SocketPath string
+
+	// Cache, when set, is flushed by the /cache/flush endpoint.
+	Cache *FunctionCache
+
+	draining int32
+}
+
+// Draining reports whether the gateway has been put into drain mode.
+func (a *AdminServer) Draining() bool {
+	return atomic.LoadInt32(&a.draining) == 1
+}
+
+// Listen starts serving the admin API on the configured unix socket. It
+// removes any stale socket file left behind by a previous process first.
+func (a *AdminServer) Listen() error {
+	os.Remove(a.SocketPath)
+
+	listener, err := net.Listen("unix", a.SocketPath)
+	if err != nil {
+		return err
+	}
+
+	return http.Serve(listener, a.routes())
+}
+
+func (a *AdminServer) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(AdminStatus{Draining: a.Draining()})
+	})
+
+	mux.HandleFunc("/drain", func(w http.ResponseWriter, r *http.Request) {
+		atomic.StoreInt32(&a.draining, 1)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux.HandleFunc("/cache/flush", func(w http.ResponseWriter, r *http.Request) {
+		if a.Cache != nil {
+			a.Cache.Sync.Lock()
+			a.Cache.Cache = make(map[string]*FunctionMeta)
+			a.Cache.Sync.Unlock()
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return mux
+}
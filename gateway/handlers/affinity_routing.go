@@ -0,0 +1,104 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// NodeAffinity tracks which region an edge node last registered under, so
+// invocations can be preferred towards nodes close to the caller.
+//
+// Region is whatever string the node and caller agree on, e.g. a cloud
+// provider region name; mapping a caller's IP to a region (IP-geo lookup)
+// is outside the gateway's scope and left to whatever sets the resolved
+// region hint header.
+type NodeAffinity struct {
+	lock         sync.RWMutex
+	regionOfNode map[string]string
+}
+
+// NewNodeAffinity creates an empty NodeAffinity.
+func NewNodeAffinity() *NodeAffinity {
+	return &NodeAffinity{
+		regionOfNode: make(map[string]string),
+	}
+}
+
+// SetRegion records the region a node most recently registered under.
+func (a *NodeAffinity) SetRegion(nodeID string, region string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.regionOfNode[nodeID] = region
+}
+
+// OrderByRegion returns nodeIDs reordered so that nodes registered under
+// preferredRegion come first, in their original relative order, followed by
+// every other node. An empty preferredRegion leaves the order unchanged.
+func (a *NodeAffinity) OrderByRegion(preferredRegion string, nodeIDs []string) []string {
+	if len(preferredRegion) == 0 {
+		return nodeIDs
+	}
+
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	ordered := make([]string, 0, len(nodeIDs))
+	rest := make([]string, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		if a.regionOfNode[nodeID] == preferredRegion {
+			ordered = append(ordered, nodeID)
+		} else {
+			rest = append(rest, nodeID)
+		}
+	}
+
+	return append(ordered, rest...)
+}
+
+// MakeAffinityForwardingHandler proxies a request to the node closest to
+// the caller, trying candidate nodes returned by regionResolver/affinity in
+// order and failing over to the next one if a node's tunnel is unreachable
+// or returns an error.
+func MakeAffinityForwardingHandler(registry *TunnelRegistry, affinity *NodeAffinity, regionResolver func(r *http.Request) string, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		preferredRegion := regionResolver(r)
+		candidates := affinity.OrderByRegion(preferredRegion, registry.Nodes())
+
+		if len(candidates) == 0 {
+			http.Error(w, "no edge nodes are registered", http.StatusBadGateway)
+			return
+		}
+
+		var lastErr error
+		for _, nodeID := range candidates {
+			tunnel, exists := registry.Next(nodeID)
+			if !exists {
+				continue
+			}
+
+			// Buffer into a recorder so a failed attempt doesn't leave a
+			// partial response already sent before failing over.
+			recorder := httptest.NewRecorder()
+			if err := callOverTunnel(recorder, r, tunnel, timeout); err != nil {
+				lastErr = err
+				continue
+			}
+
+			copyHeaders(w.Header(), &recorder.HeaderMap)
+			w.WriteHeader(recorder.Code)
+			w.Write(recorder.Body.Bytes())
+			return
+		}
+
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no reachable tunnel for any candidate node")
+		}
+		http.Error(w, lastErr.Error(), http.StatusBadGateway)
+	}
+}
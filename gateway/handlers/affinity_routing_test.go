@@ -0,0 +1,56 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_NodeAffinity_OrderByRegion_PrefersMatchingRegion(t *testing.T) {
+	affinity := NewNodeAffinity()
+	affinity.SetRegion("us-node", "us-east")
+	affinity.SetRegion("eu-node", "eu-west")
+
+	ordered := affinity.OrderByRegion("eu-west", []string{"us-node", "eu-node"})
+
+	if ordered[0] != "eu-node" {
+		t.Errorf("want matching region first, got %v", ordered)
+	}
+}
+
+func Test_AffinityForwardingHandler_FailsOverToNextCandidate(t *testing.T) {
+	registry := NewTunnelRegistry()
+	affinity := NewNodeAffinity()
+
+	unreachableTunnel, unreachableEdge := newTestTunnel(t)
+	unreachableEdge.Close() // closed immediately so the first attempt fails
+
+	healthyTunnel, healthyEdge := newTestTunnel(t)
+	defer healthyEdge.Close()
+
+	registry.RegisterNode("unreachable", unreachableTunnel)
+	registry.RegisterNode("healthy", healthyTunnel)
+	affinity.SetRegion("unreachable", "eu-west")
+	affinity.SetRegion("healthy", "us-east")
+
+	go serveOneTunnelRequest(t, healthyEdge, http.StatusOK, "served by healthy node")
+
+	handler := MakeAffinityForwardingHandler(registry, affinity, func(r *http.Request) string {
+		return "eu-west"
+	}, time.Second*5)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200 from the healthy node, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Body.String() != "served by healthy node" {
+		t.Errorf("want body from the failed-over-to node, got %s", rr.Body.String())
+	}
+}
@@ -31,6 +31,16 @@ const (
 
 	// ScalingFactorLabel label indicates the scaling factor for a function
 	ScalingFactorLabel = "com.openfaas.scale.factor"
+
+	// PollIntervalLabel overrides ScalingConfig.FunctionPollInterval for a
+	// single function, e.g. "5ms". Invalid or absent values fall back to
+	// the gateway-wide interval.
+	PollIntervalLabel = "com.openfaas.scale.poll-interval"
+
+	// MaxPollCountLabel overrides ScalingConfig.MaxPollCount for a single
+	// function. Invalid or absent values fall back to the gateway-wide
+	// count.
+	MaxPollCountLabel = "com.openfaas.scale.max-poll-count"
 )
 
 // MakeAlertHandler handles alerts from Prometheus Alertmanager
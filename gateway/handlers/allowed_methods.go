@@ -0,0 +1,68 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AllowedMethodsLabel lists the HTTP methods a function accepts, as a
+// comma-separated value, e.g. "POST" or "GET,POST". Any other method is
+// rejected with 405 before the function is invoked or scaled from zero.
+// Absent, empty, or set on a function with no recorded spec, every method
+// is allowed, as before this label existed.
+const AllowedMethodsLabel = "com.openfaas.allowed-methods"
+
+// MakeAllowedMethodsHandler wraps next, rejecting a request with 405 and a
+// populated Allow header when the called function declares
+// AllowedMethodsLabel and it doesn't include r.Method.
+func MakeAllowedMethodsHandler(next http.HandlerFunc, specs *FunctionSpecStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := getServiceName(r.URL.String())
+
+		spec, exists := specs.Get(functionName)
+		if !exists || spec.Labels == nil {
+			next(w, r)
+			return
+		}
+
+		allowed, declared := allowedMethods(*spec.Labels)
+		if !declared || methodListContains(allowed, r.Method) {
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		http.Error(w, fmt.Sprintf("method %s is not allowed for this function", r.Method), http.StatusMethodNotAllowed)
+	}
+}
+
+// allowedMethods parses AllowedMethodsLabel out of labels, reporting
+// whether it was set at all.
+func allowedMethods(labels map[string]string) ([]string, bool) {
+	raw, exists := labels[AllowedMethodsLabel]
+	if !exists || len(strings.TrimSpace(raw)) == 0 {
+		return nil, false
+	}
+
+	var methods []string
+	for _, part := range strings.Split(raw, ",") {
+		method := strings.ToUpper(strings.TrimSpace(part))
+		if len(method) > 0 {
+			methods = append(methods, method)
+		}
+	}
+	return methods, len(methods) > 0
+}
+
+func methodListContains(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
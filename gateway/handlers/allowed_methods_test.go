@@ -0,0 +1,71 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_MakeAllowedMethodsHandler_RejectsDisallowedMethod(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{AllowedMethodsLabel: "POST"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeAllowedMethodsHandler(next, specs)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Fatal("want next not to be called for a disallowed method")
+	}
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("want 405, got %d", rec.Code)
+	}
+	if rec.Header().Get("Allow") != "POST" {
+		t.Fatalf("want Allow: POST, got %q", rec.Header().Get("Allow"))
+	}
+}
+
+func Test_MakeAllowedMethodsHandler_AllowsDeclaredMethod(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{AllowedMethodsLabel: "GET, POST"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeAllowedMethodsHandler(next, specs)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called for a declared method")
+	}
+}
+
+func Test_MakeAllowedMethodsHandler_AllowsEverythingWithoutTheLabel(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet"})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeAllowedMethodsHandler(next, specs)
+
+	req := httptest.NewRequest(http.MethodDelete, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called when the function declares no allowed methods")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// InvocationEvent is a compact record of a single function invocation,
+// emitted for analytics warehouses without them needing to scrape metrics
+// or parse logs.
+type InvocationEvent struct {
+	Function       string    `json:"function"`
+	StatusCode     int       `json:"statusCode"`
+	DurationMillis int64     `json:"durationMillis"`
+	RequestBytes   int64     `json:"requestBytes"`
+	ResponseBytes  int64     `json:"responseBytes"`
+	ColdStart      bool      `json:"coldStart"`
+	Caller         string    `json:"caller"`
+	CorrelationID  string    `json:"correlationId"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// EventEmitter publishes a compact event for each function invocation to
+// an analytics topic.
+type EventEmitter interface {
+	Emit(event InvocationEvent) error
+}
+
+// NATSEventEmitter publishes invocation events as JSON to a NATS subject.
+type NATSEventEmitter struct {
+	Conn    NATSPublisher
+	Subject string
+}
+
+// NATSPublisher is the subset of *nats.Conn used to publish events, kept
+// as an interface so it can be faked in tests without a NATS server.
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// Emit publishes event as JSON to the configured NATS subject.
+func (n NATSEventEmitter) Emit(event InvocationEvent) error {
+	bytesOut, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return n.Conn.Publish(n.Subject, bytesOut)
+}
+
+// MakeAnalyticsNotifier adapts an EventEmitter to the HTTPNotifier
+// interface used by MakeForwardingProxyHandler, so invocation events can
+// be emitted alongside the existing logging/metrics notifiers.
+func MakeAnalyticsNotifier(emitter EventEmitter, caller string) HTTPNotifier {
+	return analyticsNotifier{emitter: emitter, caller: caller}
+}
+
+type analyticsNotifier struct {
+	emitter EventEmitter
+	caller  string
+}
+
+// Notify emits a compact InvocationEvent for the completed request.
+//
+// RequestBytes, ResponseBytes and ColdStart are left at their zero value:
+// the HTTPNotifier interface only carries method/URL/status/duration/
+// callID, so populating them would need the proxy to measure body sizes
+// and consult the replica cache before forwarding, which isn't wired up
+// here.
+func (a analyticsNotifier) Notify(method string, URL string, originalURL string, statusCode int, duration time.Duration, callID string) {
+	event := InvocationEvent{
+		Function:       getServiceName(originalURL),
+		StatusCode:     statusCode,
+		DurationMillis: duration.Milliseconds(),
+		Caller:         a.caller,
+		CorrelationID:  callID,
+		Timestamp:      time.Now(),
+	}
+
+	a.emitter.Emit(event)
+}
@@ -0,0 +1,59 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type fakeNATSPublisher struct {
+	subject string
+	data    []byte
+}
+
+func (f *fakeNATSPublisher) Publish(subject string, data []byte) error {
+	f.subject = subject
+	f.data = data
+	return nil
+}
+
+func Test_NATSEventEmitter_PublishesEventAsJSON(t *testing.T) {
+	publisher := &fakeNATSPublisher{}
+	emitter := NATSEventEmitter{Conn: publisher, Subject: "faas.invocations"}
+
+	err := emitter.Emit(InvocationEvent{Function: "echo", StatusCode: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if publisher.subject != "faas.invocations" {
+		t.Errorf("want subject faas.invocations, got %s", publisher.subject)
+	}
+
+	var got InvocationEvent
+	if err := json.Unmarshal(publisher.data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Function != "echo" || got.StatusCode != 200 {
+		t.Errorf("want published event for echo/200, got %+v", got)
+	}
+}
+
+func Test_AnalyticsNotifier_EmitsEventFromNotifyCall(t *testing.T) {
+	publisher := &fakeNATSPublisher{}
+	emitter := NATSEventEmitter{Conn: publisher, Subject: "faas.invocations"}
+	notifier := MakeAnalyticsNotifier(emitter, "gateway")
+
+	notifier.Notify("GET", "/function/echo", "/function/echo", 200, 15*time.Millisecond, "call-123")
+
+	var got InvocationEvent
+	if err := json.Unmarshal(publisher.data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Function != "echo" || got.Caller != "gateway" || got.CorrelationID != "call-123" {
+		t.Errorf("want event for echo from gateway with correlation ID call-123, got %+v", got)
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// APIKey is a managed, per-tenant credential for invoking functions
+// through the gateway. Scope, when non-empty, restricts the key to
+// exactly those function names; an empty Scope may invoke any function.
+// Scope entries are matched against the whole function name only - there
+// is no namespace or name-prefix matching.
+type APIKey struct {
+	ID                 string   `json:"id"`
+	Key                string   `json:"key"`
+	Name               string   `json:"name"`
+	Scope              []string `json:"scope,omitempty"`
+	RateLimitPerMinute int      `json:"rateLimitPerMinute,omitempty"`
+	Revoked            bool     `json:"revoked"`
+}
+
+// apiKeyUsage tracks one key's rolling per-minute call count (for rate
+// limiting) and lifetime call count (for metering).
+type apiKeyUsage struct {
+	lock          sync.Mutex
+	windowStart   time.Time
+	windowCount   int
+	lifetimeCount int64
+}
+
+// APIKeyStore manages API keys and their usage. Keys, usage counters and
+// rate-limit windows are all held in memory; like the gateway's other
+// in-process stores, they do not survive a restart.
+type APIKeyStore struct {
+	lock  sync.RWMutex
+	byID  map[string]*APIKey
+	byKey map[string]string // secret key value -> ID, for fast lookup on every invocation
+	usage map[string]*apiKeyUsage
+}
+
+// NewAPIKeyStore creates an empty APIKeyStore.
+func NewAPIKeyStore() *APIKeyStore {
+	return &APIKeyStore{
+		byID:  make(map[string]*APIKey),
+		byKey: make(map[string]string),
+		usage: make(map[string]*apiKeyUsage),
+	}
+}
+
+// Create mints a new API key scoped to scope, with an optional
+// rateLimitPerMinute (zero or negative means unlimited).
+func (s *APIKeyStore) Create(name string, scope []string, rateLimitPerMinute int) APIKey {
+	key := APIKey{
+		ID:                 uuid.Generate().String(),
+		Key:                uuid.Generate().String(),
+		Name:               name,
+		Scope:              scope,
+		RateLimitPerMinute: rateLimitPerMinute,
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.byID[key.ID] = &key
+	s.byKey[key.Key] = key.ID
+	s.usage[key.ID] = &apiKeyUsage{}
+
+	return key
+}
+
+// Rotate replaces id's secret value with a freshly generated one and
+// returns the updated key. Returns false if id is unknown.
+func (s *APIKeyStore) Rotate(id string) (APIKey, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	existing, exists := s.byID[id]
+	if !exists {
+		return APIKey{}, false
+	}
+
+	delete(s.byKey, existing.Key)
+	existing.Key = uuid.Generate().String()
+	s.byKey[existing.Key] = id
+
+	return *existing, true
+}
+
+// Revoke marks id's key as no longer usable. Returns false if id is
+// unknown.
+func (s *APIKeyStore) Revoke(id string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	existing, exists := s.byID[id]
+	if !exists {
+		return false
+	}
+	existing.Revoked = true
+	return true
+}
+
+// List returns every managed API key.
+func (s *APIKeyStore) List() []APIKey {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	keys := make([]APIKey, 0, len(s.byID))
+	for _, key := range s.byID {
+		keys = append(keys, *key)
+	}
+	return keys
+}
+
+// Usage returns id's lifetime call count. Returns false if id is unknown.
+func (s *APIKeyStore) Usage(id string) (int64, bool) {
+	s.lock.RLock()
+	usage, exists := s.usage[id]
+	s.lock.RUnlock()
+	if !exists {
+		return 0, false
+	}
+
+	usage.lock.Lock()
+	defer usage.lock.Unlock()
+	return usage.lifetimeCount, true
+}
+
+// authenticate looks up the key presented by a caller. It returns the
+// matching APIKey and ok=true only when the key exists and is not
+// revoked.
+func (s *APIKeyStore) authenticate(presentedKey string) (APIKey, bool) {
+	s.lock.RLock()
+	id, exists := s.byKey[presentedKey]
+	if !exists {
+		s.lock.RUnlock()
+		return APIKey{}, false
+	}
+	key := *s.byID[id]
+	s.lock.RUnlock()
+
+	if key.Revoked {
+		return APIKey{}, false
+	}
+	return key, true
+}
+
+// allow records one call against id, returning false if doing so would
+// exceed its RateLimitPerMinute. A key with no rate limit always allows.
+func (s *APIKeyStore) allow(id string, rateLimitPerMinute int) bool {
+	s.lock.RLock()
+	usage := s.usage[id]
+	s.lock.RUnlock()
+	if usage == nil {
+		return true
+	}
+
+	usage.lock.Lock()
+	defer usage.lock.Unlock()
+
+	usage.lifetimeCount++
+
+	if rateLimitPerMinute <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	if now.Sub(usage.windowStart) >= time.Minute {
+		usage.windowStart = now
+		usage.windowCount = 0
+	}
+
+	if usage.windowCount >= rateLimitPerMinute {
+		return false
+	}
+	usage.windowCount++
+	return true
+}
+
+// keyAllowsFunction reports whether key's Scope permits invoking
+// functionName; an empty Scope permits any function.
+func keyAllowsFunction(key APIKey, functionName string) bool {
+	if len(key.Scope) == 0 {
+		return true
+	}
+	for _, allowed := range key.Scope {
+		if allowed == functionName {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeAPIKeyAuthHandler wraps next so that it is only reached with a
+// valid, unrevoked, in-scope, under-its-rate-limit API key presented via
+// the X-Api-Key header. usageMetric, if non-nil, is incremented per
+// successful call, labelled by key ID, so per-tenant usage is also
+// visible through /metrics alongside the usage API.
+func MakeAPIKeyAuthHandler(next http.HandlerFunc, store *APIKeyStore, usageMetric *prometheus.CounterVec) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		presented := r.Header.Get("X-Api-Key")
+		if len(presented) == 0 {
+			http.Error(w, "an X-Api-Key header is required", http.StatusUnauthorized)
+			return
+		}
+
+		key, ok := store.authenticate(presented)
+		if !ok {
+			http.Error(w, "invalid or revoked API key", http.StatusUnauthorized)
+			return
+		}
+
+		functionName := getServiceName(r.URL.Path)
+		if !keyAllowsFunction(key, functionName) {
+			http.Error(w, "this API key is not scoped to call "+functionName, http.StatusForbidden)
+			return
+		}
+
+		if !store.allow(key.ID, key.RateLimitPerMinute) {
+			http.Error(w, "rate limit exceeded for this API key", http.StatusTooManyRequests)
+			return
+		}
+
+		if usageMetric != nil {
+			usageMetric.WithLabelValues(key.ID).Inc()
+		}
+
+		r.Header.Set("X-Caller-Identity", key.ID)
+
+		next(w, r)
+	}
+}
+
+// CreateAPIKeyRequest is the body POSTed to /system/apikeys.
+type CreateAPIKeyRequest struct {
+	Name               string   `json:"name"`
+	Scope              []string `json:"scope,omitempty"`
+	RateLimitPerMinute int      `json:"rateLimitPerMinute,omitempty"`
+}
+
+// MakeCreateAPIKeyHandler creates a new API key, POST /system/apikeys.
+func MakeCreateAPIKeyHandler(store *APIKeyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		var createReq CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		key := store.Create(createReq.Name, createReq.Scope, createReq.RateLimitPerMinute)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(key)
+	}
+}
+
+// MakeListAPIKeysHandler lists every managed API key, GET /system/apikeys.
+func MakeListAPIKeysHandler(store *APIKeyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(store.List())
+	}
+}
+
+// MakeRotateAPIKeyHandler replaces a key's secret value,
+// POST /system/apikeys/{id}/rotate.
+func MakeRotateAPIKeyHandler(store *APIKeyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		key, exists := store.Rotate(id)
+		if !exists {
+			http.Error(w, "api key not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(key)
+	}
+}
+
+// MakeRevokeAPIKeyHandler revokes a key, DELETE /system/apikeys/{id}.
+func MakeRevokeAPIKeyHandler(store *APIKeyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		if !store.Revoke(id) {
+			http.Error(w, "api key not found", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeAPIKeyUsageHandler reports a key's lifetime call count,
+// GET /system/apikeys/{id}/usage.
+func MakeAPIKeyUsageHandler(store *APIKeyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		count, exists := store.Usage(id)
+		if !exists {
+			http.Error(w, "api key not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int64{"calls": count})
+	}
+}
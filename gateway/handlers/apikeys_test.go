@@ -0,0 +1,137 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_APIKeyStore_CreateRotateRevoke(t *testing.T) {
+	store := NewAPIKeyStore()
+
+	key := store.Create("ci", nil, 0)
+	if len(key.Key) == 0 || len(key.ID) == 0 {
+		t.Fatal("want a generated ID and secret value")
+	}
+
+	if _, ok := store.authenticate(key.Key); !ok {
+		t.Fatal("want a freshly created key to authenticate")
+	}
+
+	rotated, ok := store.Rotate(key.ID)
+	if !ok {
+		t.Fatal("want Rotate to succeed for a known ID")
+	}
+	if rotated.Key == key.Key {
+		t.Fatal("want Rotate to change the secret value")
+	}
+	if _, ok := store.authenticate(key.Key); ok {
+		t.Fatal("want the old secret value to stop working after rotation")
+	}
+
+	if !store.Revoke(key.ID) {
+		t.Fatal("want Revoke to succeed for a known ID")
+	}
+	if _, ok := store.authenticate(rotated.Key); ok {
+		t.Fatal("want a revoked key to stop authenticating")
+	}
+}
+
+func Test_KeyAllowsFunction(t *testing.T) {
+	unrestricted := APIKey{}
+	if !keyAllowsFunction(unrestricted, "anything") {
+		t.Error("want an empty scope to allow any function")
+	}
+
+	scoped := APIKey{Scope: []string{"billing-reports"}}
+	if !keyAllowsFunction(scoped, "billing-reports") {
+		t.Error("want a scoped key to allow its listed function")
+	}
+	if keyAllowsFunction(scoped, "other-function") {
+		t.Error("want a scoped key to reject a function outside its scope")
+	}
+}
+
+func Test_APIKeyStore_AllowEnforcesRateLimit(t *testing.T) {
+	store := NewAPIKeyStore()
+	key := store.Create("ci", nil, 2)
+
+	if !store.allow(key.ID, key.RateLimitPerMinute) {
+		t.Fatal("want the 1st call within the limit to be allowed")
+	}
+	if !store.allow(key.ID, key.RateLimitPerMinute) {
+		t.Fatal("want the 2nd call within the limit to be allowed")
+	}
+	if store.allow(key.ID, key.RateLimitPerMinute) {
+		t.Fatal("want the 3rd call to exceed a limit of 2/minute")
+	}
+
+	count, ok := store.Usage(key.ID)
+	if !ok || count != 3 {
+		t.Errorf("want lifetime usage of 3 (including the rejected call), got %d (exists=%v)", count, ok)
+	}
+}
+
+func Test_MakeAPIKeyAuthHandler_RejectsMissingKey(t *testing.T) {
+	store := NewAPIKeyStore()
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeAPIKeyAuthHandler(next, store, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401, got %d", rec.Code)
+	}
+	if called {
+		t.Fatal("want next not to be called without an API key")
+	}
+}
+
+func Test_MakeAPIKeyAuthHandler_RejectsOutOfScopeKey(t *testing.T) {
+	store := NewAPIKeyStore()
+	key := store.Create("ci", []string{"figlet"}, 0)
+	next := func(w http.ResponseWriter, r *http.Request) {}
+
+	handler := MakeAPIKeyAuthHandler(next, store, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/other-function", nil)
+	req.Header.Set("X-Api-Key", key.Key)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("want 403, got %d", rec.Code)
+	}
+}
+
+func Test_MakeAPIKeyAuthHandler_AllowsValidScopedKey(t *testing.T) {
+	store := NewAPIKeyStore()
+	key := store.Create("ci", []string{"figlet"}, 0)
+	called := false
+	var gotIdentity string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		gotIdentity = r.Header.Get("X-Caller-Identity")
+	}
+
+	handler := MakeAPIKeyAuthHandler(next, store, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	req.Header.Set("X-Api-Key", key.Key)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called for a valid, in-scope key")
+	}
+	if gotIdentity != key.ID {
+		t.Errorf("want X-Caller-Identity set to the key's ID %q, got %q", key.ID, gotIdentity)
+	}
+}
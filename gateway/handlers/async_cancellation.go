@@ -0,0 +1,160 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AsyncCallStatus is the lifecycle state of one async invocation, keyed by
+// its X-Call-Id.
+type AsyncCallStatus string
+
+const (
+	AsyncCallQueued    AsyncCallStatus = "queued"
+	AsyncCallCancelled AsyncCallStatus = "cancelled"
+	AsyncCallCompleted AsyncCallStatus = "completed"
+	AsyncCallFailed    AsyncCallStatus = "failed"
+)
+
+// AsyncCallStore tracks the status of async invocations by call ID, so a
+// caller can request cancellation of one it no longer needs and poll
+// whether that took effect.
+//
+// This only covers what the gateway itself can see: it records queued,
+// cancelled and (once reported) completed/failed status, and exposes
+// cancellation intent for a queue worker to honour. Actually skipping an
+// unstarted message, or aborting one already executing, happens inside
+// the queue worker process - a separate component from this repository -
+// so it must itself check GetStatus before/while running a message; this
+// store does not reach into the worker to force it.
+type AsyncCallStore struct {
+	lock    sync.RWMutex
+	status  map[string]AsyncCallStatus
+	updated map[string]time.Time
+}
+
+// NewAsyncCallStore creates an empty AsyncCallStore.
+func NewAsyncCallStore() *AsyncCallStore {
+	return &AsyncCallStore{
+		status:  make(map[string]AsyncCallStatus),
+		updated: make(map[string]time.Time),
+	}
+}
+
+// Record sets callID's status, unless it is already Cancelled - a report
+// of completion arriving after a cancellation request shouldn't overwrite
+// the caller's cancellation.
+func (s *AsyncCallStore) Record(callID string, status AsyncCallStatus) {
+	if len(callID) == 0 {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.status[callID] == AsyncCallCancelled {
+		return
+	}
+	s.status[callID] = status
+	s.updated[callID] = time.Now()
+}
+
+// Cancel marks callID as Cancelled, unless it has already reached a
+// terminal status (completed/failed), and reports whether the status
+// actually changed.
+func (s *AsyncCallStore) Cancel(callID string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current, exists := s.status[callID]
+	if !exists || current == AsyncCallCompleted || current == AsyncCallFailed || current == AsyncCallCancelled {
+		return false
+	}
+
+	s.status[callID] = AsyncCallCancelled
+	s.updated[callID] = time.Now()
+	return true
+}
+
+// GetStatus returns callID's current status, if known.
+func (s *AsyncCallStore) GetStatus(callID string) (AsyncCallStatus, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	status, exists := s.status[callID]
+	return status, exists
+}
+
+// Remove forgets callID's status entirely, e.g. once AsyncStateJanitor has
+// reclaimed it.
+func (s *AsyncCallStore) Remove(callID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.status, callID)
+	delete(s.updated, callID)
+}
+
+// asyncCallTerminalStatuses are the statuses Expired considers safe to
+// reclaim - a queued call is presumably still in flight and isn't touched
+// no matter how old its last update is.
+var asyncCallTerminalStatuses = map[AsyncCallStatus]bool{
+	AsyncCallCompleted: true,
+	AsyncCallFailed:    true,
+	AsyncCallCancelled: true,
+}
+
+// Expired returns the call IDs of every terminal (completed, failed or
+// cancelled) entry whose status hasn't been updated for at least
+// retention, as of now.
+func (s *AsyncCallStore) Expired(retention time.Duration, now time.Time) []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var expired []string
+	for callID, status := range s.status {
+		if !asyncCallTerminalStatuses[status] {
+			continue
+		}
+		if now.Sub(s.updated[callID]) >= retention {
+			expired = append(expired, callID)
+		}
+	}
+	return expired
+}
+
+// MakeCancelAsyncCallHandler cancels a queued or in-flight async
+// invocation, DELETE /system/async/{callId}.
+func MakeCancelAsyncCallHandler(store *AsyncCallStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		callID := mux.Vars(r)["callId"]
+
+		if !store.Cancel(callID) {
+			http.Error(w, "call not found or already finished", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeAsyncCallStatusHandler reports a tracked async invocation's current
+// status, GET /system/async/{callId}.
+func MakeAsyncCallStatusHandler(store *AsyncCallStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		callID := mux.Vars(r)["callId"]
+
+		status, exists := store.GetStatus(callID)
+		if !exists {
+			http.Error(w, "call not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"callId": callID, "status": string(status)})
+	}
+}
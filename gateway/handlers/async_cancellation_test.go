@@ -0,0 +1,127 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func Test_AsyncCallStore_CancelQueuedCall(t *testing.T) {
+	store := NewAsyncCallStore()
+	store.Record("call-1", AsyncCallQueued)
+
+	if !store.Cancel("call-1") {
+		t.Fatal("want Cancel to succeed on a queued call")
+	}
+
+	status, exists := store.GetStatus("call-1")
+	if !exists || status != AsyncCallCancelled {
+		t.Errorf("want status cancelled, got %s (exists=%v)", status, exists)
+	}
+}
+
+func Test_AsyncCallStore_CancelUnknownCallFails(t *testing.T) {
+	store := NewAsyncCallStore()
+
+	if store.Cancel("missing") {
+		t.Fatal("want Cancel to fail for an unknown call")
+	}
+}
+
+func Test_AsyncCallStore_CancelAlreadyCompletedCallFails(t *testing.T) {
+	store := NewAsyncCallStore()
+	store.Record("call-1", AsyncCallQueued)
+	store.Record("call-1", AsyncCallCompleted)
+
+	if store.Cancel("call-1") {
+		t.Fatal("want Cancel to fail once the call has already completed")
+	}
+}
+
+func Test_AsyncCallStore_LateCompletionDoesNotOverrideCancellation(t *testing.T) {
+	store := NewAsyncCallStore()
+	store.Record("call-1", AsyncCallQueued)
+	store.Cancel("call-1")
+	store.Record("call-1", AsyncCallCompleted)
+
+	status, _ := store.GetStatus("call-1")
+	if status != AsyncCallCancelled {
+		t.Errorf("want status to remain cancelled, got %s", status)
+	}
+}
+
+func Test_MakeCancelAsyncCallHandler_404sOnUnknownCall(t *testing.T) {
+	store := NewAsyncCallStore()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/async/{callId}", MakeCancelAsyncCallHandler(store)).Methods(http.MethodDelete)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/async/missing", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("want 404, got %d", rec.Code)
+	}
+}
+
+func Test_MakeAsyncCallStatusHandler_ReportsStatus(t *testing.T) {
+	store := NewAsyncCallStore()
+	store.Record("call-1", AsyncCallQueued)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/async/{callId}", MakeAsyncCallStatusHandler(store)).Methods(http.MethodGet)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/async/call-1", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != `{"callId":"call-1","status":"queued"}`+"\n" {
+		t.Errorf("unexpected body: %s", got)
+	}
+}
+
+func Test_AsyncCallStore_Expired_OnlyReturnsTerminalEntries(t *testing.T) {
+	store := NewAsyncCallStore()
+	store.Record("still-queued", AsyncCallQueued)
+	store.Record("completed-1", AsyncCallCompleted)
+	store.Record("completed-2", AsyncCallFailed)
+
+	now := time.Now()
+	expired := store.Expired(-time.Second, now)
+
+	if len(expired) != 2 {
+		t.Fatalf("want 2 expired entries (a queued call is never reaped), got %v", expired)
+	}
+	for _, callID := range expired {
+		if callID == "still-queued" {
+			t.Errorf("want a queued entry to never be reported as expired, got %v", expired)
+		}
+	}
+
+	if expired := store.Expired(time.Hour, now); len(expired) != 0 {
+		t.Errorf("want nothing expired with a retention that hasn't elapsed yet, got %v", expired)
+	}
+}
+
+func Test_AsyncCallStore_Remove(t *testing.T) {
+	store := NewAsyncCallStore()
+	store.Record("call-1", AsyncCallCompleted)
+
+	store.Remove("call-1")
+
+	if _, exists := store.GetStatus("call-1"); exists {
+		t.Fatal("want call-1 to be forgotten after Remove")
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/openfaas/faas/gateway/metrics"
+)
+
+// asyncJanitorInterval is how often an AsyncStateJanitor checks for async
+// state past its retention window, mirroring trashReapInterval.
+const asyncJanitorInterval = time.Minute
+
+// AsyncStateRetention bounds how long each kind of orphaned async state is
+// kept before AsyncStateJanitor reclaims it. Zero disables reaping for
+// that kind, leaving it to grow unbounded as before this existed.
+//
+// This only covers the async state this gateway actually keeps in memory:
+// AsyncCallStore's terminal call statuses, PendingAsyncRequests entries
+// that never received a report, and DeadLetterStore entries. This
+// repository has no separate idempotency-key or invocation-result cache
+// to reap - see handlers.AsyncCallStore's package doc and
+// RetryCoordinator for what request tracking actually exists today.
+type AsyncStateRetention struct {
+	CallStatus time.Duration
+	Pending    time.Duration
+	DeadLetter time.Duration
+}
+
+// AsyncStateJanitor periodically reclaims orphaned async invocation state:
+// terminal AsyncCallStore entries, PendingAsyncRequests entries that were
+// never reported back on (e.g. because the queue worker or function pod
+// that would have reported died), and DeadLetterStore entries operators
+// never requeued. Any of CallStore, Pending/DeadLetter or Metrics may be
+// nil to skip that part.
+type AsyncStateJanitor struct {
+	CallStore  *AsyncCallStore
+	Pending    *PendingAsyncRequests
+	DeadLetter *DeadLetterStore
+	Retention  AsyncStateRetention
+	Metrics    *metrics.MetricOptions
+}
+
+// ReapOnce reclaims every piece of async state past its retention window,
+// returning how many items were reclaimed in total. An orphaned pending
+// request is moved into DeadLetter rather than simply dropped, so it stays
+// visible and requeueable through /system/async/dead-letter.
+func (j *AsyncStateJanitor) ReapOnce() int {
+	now := time.Now()
+	reclaimed := 0
+
+	if j.CallStore != nil && j.Retention.CallStatus > 0 {
+		callIDs := j.CallStore.Expired(j.Retention.CallStatus, now)
+		for _, callID := range callIDs {
+			j.CallStore.Remove(callID)
+		}
+		j.track("call_status", len(callIDs))
+		reclaimed += len(callIDs)
+	}
+
+	if j.Pending != nil && j.Retention.Pending > 0 {
+		callIDs := j.Pending.Expired(j.Retention.Pending, now)
+		for _, callID := range callIDs {
+			pending, exists := j.Pending.take(callID)
+			j.Pending.Forget(callID)
+			if !exists {
+				continue
+			}
+			if j.DeadLetter != nil {
+				j.DeadLetter.Add(DeadLetterEntry{
+					CallID:        callID,
+					Request:       pending.request,
+					Attempts:      pending.attempts,
+					FailureReason: "orphaned: no report received within the retention window",
+					FailedAt:      now,
+				})
+			}
+		}
+		j.track("pending", len(callIDs))
+		reclaimed += len(callIDs)
+	}
+
+	if j.DeadLetter != nil && j.Retention.DeadLetter > 0 {
+		callIDs := j.DeadLetter.Expired(j.Retention.DeadLetter, now)
+		for _, callID := range callIDs {
+			j.DeadLetter.Remove(callID)
+		}
+		j.track("dead_letter", len(callIDs))
+		reclaimed += len(callIDs)
+	}
+
+	return reclaimed
+}
+
+func (j *AsyncStateJanitor) track(store string, count int) {
+	if count == 0 || j.Metrics == nil || j.Metrics.AsyncStateReclaimed == nil {
+		return
+	}
+	j.Metrics.AsyncStateReclaimed.WithLabelValues(store).Add(float64(count))
+}
+
+// Start runs ReapOnce every asyncJanitorInterval until the returned func is
+// called.
+func (j *AsyncStateJanitor) Start() (stop func()) {
+	ticker := time.NewTicker(asyncJanitorInterval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if reclaimed := j.ReapOnce(); reclaimed > 0 {
+					log.Printf("async state janitor: reclaimed %d orphaned item(s)", reclaimed)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,91 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/metrics"
+	"github.com/openfaas/faas/gateway/queue"
+)
+
+func Test_AsyncStateJanitor_ReapOnce_ReclaimsExpiredStateFromEveryStore(t *testing.T) {
+	callStore := NewAsyncCallStore()
+	callStore.Record("call-1", AsyncCallCompleted)
+
+	pending := NewPendingAsyncRequests()
+	pending.Track("call-2", &queue.Request{Function: "figlet"})
+
+	deadLetter := NewDeadLetterStore()
+	deadLetter.Add(DeadLetterEntry{CallID: "call-3", FailedAt: time.Now()})
+
+	time.Sleep(time.Millisecond)
+
+	metricsOptions := metrics.BuildMetricsOptions()
+	janitor := &AsyncStateJanitor{
+		CallStore:  callStore,
+		Pending:    pending,
+		DeadLetter: deadLetter,
+		Retention: AsyncStateRetention{
+			CallStatus: time.Nanosecond,
+			Pending:    time.Nanosecond,
+			DeadLetter: time.Nanosecond,
+		},
+		Metrics: &metricsOptions,
+	}
+
+	if reclaimed := janitor.ReapOnce(); reclaimed != 3 {
+		t.Fatalf("want 3 items reclaimed, got %d", reclaimed)
+	}
+
+	if _, exists := callStore.GetStatus("call-1"); exists {
+		t.Error("want call-1 removed from the call store")
+	}
+	if _, exists := pending.take("call-2"); exists {
+		t.Error("want call-2 removed from pending")
+	}
+	if _, exists := deadLetter.Remove("call-3"); exists {
+		t.Error("want call-3 already removed from the dead letter store")
+	}
+}
+
+func Test_AsyncStateJanitor_ReapOnce_MovesOrphanedPendingToDeadLetter(t *testing.T) {
+	pending := NewPendingAsyncRequests()
+	pending.Track("call-1", &queue.Request{Function: "figlet"})
+
+	time.Sleep(time.Millisecond)
+
+	deadLetter := NewDeadLetterStore()
+
+	janitor := &AsyncStateJanitor{
+		Pending:    pending,
+		DeadLetter: deadLetter,
+		Retention:  AsyncStateRetention{Pending: time.Nanosecond},
+	}
+
+	janitor.ReapOnce()
+
+	entry, exists := deadLetter.Remove("call-1")
+	if !exists {
+		t.Fatal("want the orphaned pending call moved into the dead letter store")
+	}
+	if entry.Request.Function != "figlet" {
+		t.Errorf("want the original request retained, got %+v", entry.Request)
+	}
+}
+
+func Test_AsyncStateJanitor_ReapOnce_DisabledRetentionSkipsStore(t *testing.T) {
+	callStore := NewAsyncCallStore()
+	callStore.Record("call-1", AsyncCallCompleted)
+
+	janitor := &AsyncStateJanitor{CallStore: callStore}
+
+	if reclaimed := janitor.ReapOnce(); reclaimed != 0 {
+		t.Fatalf("want nothing reclaimed with a zero retention, got %d", reclaimed)
+	}
+	if _, exists := callStore.GetStatus("call-1"); !exists {
+		t.Error("want call-1 left untouched")
+	}
+}
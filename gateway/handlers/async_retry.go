@@ -0,0 +1,171 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/openfaas/faas/gateway/queue"
+)
+
+// AsyncRetryPolicy bounds how many times a failed async invocation is
+// republished before it is moved to a DeadLetterStore, and how long to
+// wait between attempts.
+type AsyncRetryPolicy struct {
+	// MaxAttempts is the total number of times an invocation is
+	// published, including its first attempt. Zero defaults to 3.
+	MaxAttempts uint
+
+	// Backoff decides the delay before each retry. Nil defaults to
+	// FixedBackoff.
+	Backoff BackoffStrategy
+
+	// Clock, overridden in tests, sleeps between retry attempts.
+	Clock Clock
+}
+
+func (p AsyncRetryPolicy) maxAttempts() uint {
+	if p.MaxAttempts == 0 {
+		return 3
+	}
+	return p.MaxAttempts
+}
+
+func (p AsyncRetryPolicy) backoff() BackoffStrategy {
+	if p.Backoff == nil {
+		return FixedBackoff{}
+	}
+	return p.Backoff
+}
+
+func (p AsyncRetryPolicy) clock() Clock {
+	if p.Clock == nil {
+		return RealClock
+	}
+	return p.Clock
+}
+
+// pendingAsyncRequest is a tracked async invocation still within its retry
+// budget.
+type pendingAsyncRequest struct {
+	request   *queue.Request
+	attempts  uint
+	trackedAt time.Time
+}
+
+// PendingAsyncRequests retains a copy of every async invocation currently
+// queued, keyed by its X-Call-Id, for exactly as long as a RetryCoordinator
+// might need to republish it on failure or move it to a DeadLetterStore.
+// Gateway's own queue-worker report only carries a status code and timing
+// (see requests.AsyncReport) - not the original body - so this is what
+// makes retrying or dead-lettering possible at all without changing that
+// wire format.
+type PendingAsyncRequests struct {
+	lock     sync.Mutex
+	requests map[string]*pendingAsyncRequest
+}
+
+// NewPendingAsyncRequests creates an empty PendingAsyncRequests.
+func NewPendingAsyncRequests() *PendingAsyncRequests {
+	return &PendingAsyncRequests{
+		requests: make(map[string]*pendingAsyncRequest),
+	}
+}
+
+// Track retains req under callID. A no-op when callID is empty.
+func (p *PendingAsyncRequests) Track(callID string, req *queue.Request) {
+	if len(callID) == 0 {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.requests[callID] = &pendingAsyncRequest{request: req, attempts: 1, trackedAt: time.Now()}
+}
+
+// Forget discards callID's tracked request, once it has either succeeded
+// or been dead-lettered.
+func (p *PendingAsyncRequests) Forget(callID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.requests, callID)
+}
+
+func (p *PendingAsyncRequests) take(callID string) (*pendingAsyncRequest, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	pending, exists := p.requests[callID]
+	return pending, exists
+}
+
+// Expired returns the call IDs of every tracked request that has gone
+// unreported for at least retention, as of now - an invocation whose
+// queue-worker process died, or whose async report never reached the
+// gateway, so it never moved on to a retry or DeadLetterStore entry on its
+// own.
+func (p *PendingAsyncRequests) Expired(retention time.Duration, now time.Time) []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var expired []string
+	for callID, pending := range p.requests {
+		if now.Sub(pending.trackedAt) >= retention {
+			expired = append(expired, callID)
+		}
+	}
+	return expired
+}
+
+// RetryCoordinator republishes a failed async invocation, with Policy's
+// backoff between attempts, until it has been published Policy.maxAttempts
+// times in total (its first attempt included), before giving up and moving
+// it into DeadLetter.
+type RetryCoordinator struct {
+	Pending    *PendingAsyncRequests
+	DeadLetter *DeadLetterStore
+	Queue      queue.CanQueueRequests
+	Policy     AsyncRetryPolicy
+}
+
+// HandleFailure is called for a callID whose queue-worker report carried a
+// failing status code and failureReason. If callID has retry attempts
+// remaining, it's republished after a backoff delay; otherwise it is moved
+// to DeadLetter and forgotten. Runs synchronously - callers that can't
+// afford to block on the backoff delay should call it from a goroutine.
+func (c *RetryCoordinator) HandleFailure(callID string, failureReason string) {
+	pending, exists := c.Pending.take(callID)
+	if !exists {
+		return
+	}
+
+	if pending.attempts >= c.Policy.maxAttempts() {
+		c.Pending.Forget(callID)
+		c.DeadLetter.Add(DeadLetterEntry{
+			CallID:        callID,
+			Request:       pending.request,
+			Attempts:      pending.attempts,
+			FailureReason: failureReason,
+			FailedAt:      c.Policy.clock().Now(),
+		})
+		return
+	}
+
+	delay := c.Policy.backoff().NextDelay(int(pending.attempts)-1, time.Second)
+	c.Policy.clock().Sleep(delay)
+
+	pending.attempts++
+	if err := c.Queue.Queue(pending.request); err != nil {
+		log.Printf("[AsyncRetry] unable to republish call %s (attempt %d): %s", callID, pending.attempts, err.Error())
+		c.Pending.Forget(callID)
+		c.DeadLetter.Add(DeadLetterEntry{
+			CallID:        callID,
+			Request:       pending.request,
+			Attempts:      pending.attempts,
+			FailureReason: fmt.Sprintf("requeue failed: %s", err.Error()),
+			FailedAt:      c.Policy.clock().Now(),
+		})
+	}
+}
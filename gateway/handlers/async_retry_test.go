@@ -0,0 +1,125 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/queue"
+)
+
+func Test_RetryCoordinator_RepublishesWithinMaxAttempts(t *testing.T) {
+	pending := NewPendingAsyncRequests()
+	deadLetter := NewDeadLetterStore()
+	provider := &fakeQueueProvider{}
+	clock := &fakeClock{}
+
+	req := &queue.Request{Function: "figlet"}
+	pending.Track("call-1", req)
+
+	coordinator := &RetryCoordinator{
+		Pending:    pending,
+		DeadLetter: deadLetter,
+		Queue:      provider,
+		Policy:     AsyncRetryPolicy{MaxAttempts: 3, Clock: clock},
+	}
+
+	coordinator.HandleFailure("call-1", "boom")
+
+	if len(provider.queued) != 1 {
+		t.Fatalf("want the request to be republished once, got %d", len(provider.queued))
+	}
+	if len(deadLetter.List()) != 0 {
+		t.Fatalf("want nothing dead-lettered yet, got %d", len(deadLetter.List()))
+	}
+}
+
+func Test_RetryCoordinator_DeadLettersOnceMaxAttemptsExhausted(t *testing.T) {
+	pending := NewPendingAsyncRequests()
+	deadLetter := NewDeadLetterStore()
+	provider := &fakeQueueProvider{}
+	clock := &fakeClock{}
+
+	req := &queue.Request{Function: "figlet"}
+	pending.Track("call-1", req)
+
+	coordinator := &RetryCoordinator{
+		Pending:    pending,
+		DeadLetter: deadLetter,
+		Queue:      provider,
+		Policy:     AsyncRetryPolicy{MaxAttempts: 2, Clock: clock},
+	}
+
+	coordinator.HandleFailure("call-1", "first failure")
+	coordinator.HandleFailure("call-1", "second failure")
+
+	if len(provider.queued) != 1 {
+		t.Fatalf("want exactly one republish attempt (max 2 total), got %d", len(provider.queued))
+	}
+
+	entries := deadLetter.List()
+	if len(entries) != 1 {
+		t.Fatalf("want one dead-lettered entry, got %d", len(entries))
+	}
+	if entries[0].CallID != "call-1" || entries[0].FailureReason != "second failure" {
+		t.Fatalf("unexpected dead-letter entry: %+v", entries[0])
+	}
+
+	if _, exists := pending.take("call-1"); exists {
+		t.Fatal("want call-1 to be forgotten once dead-lettered")
+	}
+}
+
+func Test_RetryCoordinator_IgnoresUnknownCallID(t *testing.T) {
+	coordinator := &RetryCoordinator{
+		Pending:    NewPendingAsyncRequests(),
+		DeadLetter: NewDeadLetterStore(),
+		Queue:      &fakeQueueProvider{},
+		Policy:     AsyncRetryPolicy{Clock: &fakeClock{}},
+	}
+
+	coordinator.HandleFailure("unknown", "boom")
+}
+
+func Test_AsyncRetryPolicy_DefaultsMaxAttemptsAndBackoff(t *testing.T) {
+	policy := AsyncRetryPolicy{}
+
+	if policy.maxAttempts() != 3 {
+		t.Fatalf("want default max attempts 3, got %d", policy.maxAttempts())
+	}
+	if _, ok := policy.backoff().(FixedBackoff); !ok {
+		t.Fatalf("want default backoff FixedBackoff, got %T", policy.backoff())
+	}
+	if policy.backoff().NextDelay(0, time.Second) != time.Second {
+		t.Fatalf("want FixedBackoff to return base unchanged")
+	}
+}
+
+func Test_PendingAsyncRequests_ForgetRemovesTrackedRequest(t *testing.T) {
+	pending := NewPendingAsyncRequests()
+	pending.Track("call-1", &queue.Request{Function: "figlet"})
+
+	pending.Forget("call-1")
+
+	if _, exists := pending.take("call-1"); exists {
+		t.Fatal("want call-1 to be forgotten")
+	}
+}
+
+func Test_PendingAsyncRequests_Expired(t *testing.T) {
+	pending := NewPendingAsyncRequests()
+	pending.Track("call-1", &queue.Request{Function: "figlet"})
+
+	now := time.Now()
+
+	if expired := pending.Expired(time.Hour, now); len(expired) != 0 {
+		t.Errorf("want nothing expired with a retention that hasn't elapsed yet, got %v", expired)
+	}
+
+	expired := pending.Expired(-time.Second, now)
+	if len(expired) != 1 || expired[0] != "call-1" {
+		t.Fatalf("want call-1 expired, got %v", expired)
+	}
+}
@@ -5,6 +5,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"time"
@@ -13,8 +14,12 @@ import (
 	"github.com/openfaas/faas/gateway/requests"
 )
 
-// MakeAsyncReport makes a handler for asynchronous invocations to report back into.
-func MakeAsyncReport(metrics metrics.MetricOptions) http.HandlerFunc {
+// MakeAsyncReport makes a handler for asynchronous invocations to report
+// back into. asyncCalls, if non-nil, has the reporting call's X-Call-Id
+// marked completed or failed depending on report.StatusCode. retry, if
+// non-nil, additionally retries or dead-letters a failing call - see
+// RetryCoordinator.
+func MakeAsyncReport(metrics metrics.MetricOptions, asyncCalls *AsyncCallStore, retry *RetryCoordinator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		defer r.Body.Close()
 
@@ -27,5 +32,22 @@ func MakeAsyncReport(metrics metrics.MetricOptions) http.HandlerFunc {
 		var taken time.Duration
 		taken = time.Duration(report.TimeTaken)
 		trackTimeExact(taken, metrics, report.FunctionName)
+
+		callID := r.Header.Get("X-Call-Id")
+		failed := report.StatusCode >= 400
+
+		if asyncCalls != nil {
+			status := AsyncCallCompleted
+			if failed {
+				status = AsyncCallFailed
+			}
+			asyncCalls.Record(callID, status)
+		}
+
+		if failed && retry != nil {
+			go retry.HandleFailure(callID, fmt.Sprintf("function %s reported status %d", report.FunctionName, report.StatusCode))
+		} else if retry != nil {
+			retry.Pending.Forget(callID)
+		}
 	}
 }
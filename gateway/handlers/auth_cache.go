@@ -0,0 +1,117 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/openfaas/faas-provider/auth"
+)
+
+// AuthDecisionCache remembers recent positive auth decisions for a short
+// TTL, keyed by a fingerprint of the credentials presented - never the
+// credentials themselves. It exists so that CacheAuthDecisions can skip
+// re-running a request's auth check on every single request; today that
+// check is an in-memory basic auth comparison and the saving is marginal,
+// but the same cache pays for itself once auth is backed by something with
+// real per-call latency, such as an external IdP or an OPA policy query.
+type AuthDecisionCache struct {
+	// TTL is how long a positive decision is trusted for. Zero disables
+	// caching - every request is re-checked.
+	TTL time.Duration
+
+	// Clock provides the current time. When nil, RealClock is used.
+	Clock Clock
+
+	mutex   sync.Mutex
+	expires map[string]time.Time
+}
+
+func (c *AuthDecisionCache) clock() Clock {
+	if c.Clock == nil {
+		return RealClock
+	}
+	return c.Clock
+}
+
+// Allow reports whether fingerprint has a positive decision cached that
+// has not yet expired.
+func (c *AuthDecisionCache) Allow(fingerprint string) bool {
+	if c.TTL <= 0 || fingerprint == "" {
+		return false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	expiry, exists := c.expires[fingerprint]
+	return exists && c.clock().Now().Before(expiry)
+}
+
+// Remember records a positive decision for fingerprint, valid for TTL from
+// now.
+func (c *AuthDecisionCache) Remember(fingerprint string) {
+	if c.TTL <= 0 || fingerprint == "" {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.expires == nil {
+		c.expires = make(map[string]time.Time)
+	}
+	c.expires[fingerprint] = c.clock().Now().Add(c.TTL)
+}
+
+// InvalidateCache implements CacheInvalidator. AuthDecisionCache is keyed
+// by credential fingerprint rather than function name, so functionName is
+// ignored and every remembered decision is dropped - the next request from
+// any caller re-runs the real auth check.
+func (c *AuthDecisionCache) InvalidateCache(functionName string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.expires = make(map[string]time.Time)
+}
+
+// authFingerprint derives a cache key for r's credentials without
+// retaining the credentials themselves, so a leaked cache can't be used to
+// recover a password or token from it.
+func authFingerprint(r *http.Request) string {
+	value := r.Header.Get("Authorization")
+	if value == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// CacheAuthDecisions wraps next with basic auth, same as
+// auth.DecorateWithBasicAuth, except that a request whose Authorization
+// header matches one that passed this same check within cache.TTL skips
+// the check entirely and goes straight to next. A request that presents no
+// Authorization header, or one not yet seen, always runs the real check -
+// and, on success, is remembered for next time.
+func CacheAuthDecisions(next http.HandlerFunc, credentials *auth.BasicAuthCredentials, cache *AuthDecisionCache) http.HandlerFunc {
+	checked := auth.DecorateWithBasicAuth(func(w http.ResponseWriter, r *http.Request) {
+		cache.Remember(authFingerprint(r))
+		next(w, r)
+	}, credentials)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		fingerprint := authFingerprint(r)
+
+		if cache.Allow(fingerprint) {
+			next(w, r)
+			return
+		}
+
+		checked(w, r)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"math/rand"
+	"time"
+)
+
+// BackoffStrategy computes how long a scale-from-zero poll loop should
+// wait before its next attempt, given the (0-indexed) attempt number and
+// ScalingConfig.FunctionPollInterval as the base interval.
+type BackoffStrategy interface {
+	NextDelay(attempt int, base time.Duration) time.Duration
+}
+
+// FixedBackoff always waits base between polls - the poll loop's only
+// behaviour before back-off strategies became pluggable, and the
+// default when ScalingConfig.BackoffStrategy is unset.
+type FixedBackoff struct{}
+
+// NextDelay returns base unchanged.
+func (FixedBackoff) NextDelay(attempt int, base time.Duration) time.Duration {
+	return base
+}
+
+// LinearBackoff waits base * (attempt+1) between polls, capped at Max
+// once set.
+type LinearBackoff struct {
+	// Max caps the delay once the linear growth would exceed it. Zero
+	// means uncapped.
+	Max time.Duration
+}
+
+// NextDelay returns base scaled linearly by attempt, capped at Max.
+func (b LinearBackoff) NextDelay(attempt int, base time.Duration) time.Duration {
+	delay := base * time.Duration(attempt+1)
+	if b.Max > 0 && delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
+
+// ExponentialBackoff doubles the delay every attempt starting from base,
+// capped at Max once set, then adds up to Jitter of random delay on top
+// so many concurrent cold-starts for the same function don't all retry
+// in lockstep against the provider.
+type ExponentialBackoff struct {
+	// Max caps the delay once the exponential growth would exceed it.
+	// Zero means uncapped - not recommended for a large MaxPollCount,
+	// since the delay would otherwise overflow time.Duration.
+	Max time.Duration
+
+	// Jitter adds a random delay in [0, Jitter) on top of the computed
+	// exponential delay. Zero disables jitter.
+	Jitter time.Duration
+}
+
+// NextDelay returns base doubled attempt times, capped at Max, plus
+// jitter.
+func (b ExponentialBackoff) NextDelay(attempt int, base time.Duration) time.Duration {
+	delay := base
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if b.Max > 0 && delay >= b.Max {
+			delay = b.Max
+			break
+		}
+	}
+
+	if b.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(b.Jitter)))
+	}
+
+	return delay
+}
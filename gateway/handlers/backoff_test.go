@@ -0,0 +1,69 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_FixedBackoff_AlwaysReturnsBase(t *testing.T) {
+	strategy := FixedBackoff{}
+	for attempt := 0; attempt < 5; attempt++ {
+		if got := strategy.NextDelay(attempt, time.Second); got != time.Second {
+			t.Fatalf("attempt %d: want 1s, got %s", attempt, got)
+		}
+	}
+}
+
+func Test_LinearBackoff_GrowsLinearlyAndCaps(t *testing.T) {
+	strategy := LinearBackoff{Max: 3 * time.Second}
+
+	scenarios := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 3 * time.Second},
+		{3, 3 * time.Second}, // would be 4s uncapped
+	}
+
+	for _, s := range scenarios {
+		if got := strategy.NextDelay(s.attempt, time.Second); got != s.want {
+			t.Errorf("attempt %d: want %s, got %s", s.attempt, s.want, got)
+		}
+	}
+}
+
+func Test_ExponentialBackoff_DoublesAndCaps(t *testing.T) {
+	strategy := ExponentialBackoff{Max: 4 * time.Second}
+
+	scenarios := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 4 * time.Second}, // would be 8s uncapped
+	}
+
+	for _, s := range scenarios {
+		if got := strategy.NextDelay(s.attempt, time.Second); got != s.want {
+			t.Errorf("attempt %d: want %s, got %s", s.attempt, s.want, got)
+		}
+	}
+}
+
+func Test_ExponentialBackoff_JitterStaysInRange(t *testing.T) {
+	strategy := ExponentialBackoff{Jitter: 100 * time.Millisecond}
+
+	for i := 0; i < 20; i++ {
+		got := strategy.NextDelay(0, time.Second)
+		if got < time.Second || got >= time.Second+100*time.Millisecond {
+			t.Fatalf("want delay in [1s, 1.1s), got %s", got)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// CacheHint is what the gateway recorded about a function response's
+// downstream caching directives.
+type CacheHint struct {
+	URL              string   `json:"url"`
+	SurrogateKeys    []string `json:"surrogateKeys"`
+	SurrogateControl string   `json:"surrogateControl"`
+}
+
+// CacheHintStore records the Surrogate-Key/Surrogate-Control headers a
+// function emitted on its last response to each URL, so a purge can later
+// look up which URLs carried a given surrogate key.
+type CacheHintStore struct {
+	lock  sync.RWMutex
+	byURL map[string]CacheHint
+}
+
+// NewCacheHintStore creates an empty CacheHintStore.
+func NewCacheHintStore() *CacheHintStore {
+	return &CacheHintStore{
+		byURL: make(map[string]CacheHint),
+	}
+}
+
+// Record stores the caching hint a function emitted for requestURL.
+func (s *CacheHintStore) Record(hint CacheHint) {
+	if len(hint.SurrogateKeys) == 0 && len(hint.SurrogateControl) == 0 {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.byURL[hint.URL] = hint
+}
+
+// PurgeBySurrogateKey removes every recorded hint carrying key and returns
+// the URLs that were purged.
+func (s *CacheHintStore) PurgeBySurrogateKey(key string) []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var purged []string
+	for url, hint := range s.byURL {
+		for _, hintKey := range hint.SurrogateKeys {
+			if hintKey == key {
+				purged = append(purged, url)
+				delete(s.byURL, url)
+				break
+			}
+		}
+	}
+	return purged
+}
+
+// MakeCacheHintsHandler wraps next, recording any Surrogate-Key and
+// Surrogate-Control headers the function set on its response so they can
+// later be looked up by POST /system/cache/purge.
+func MakeCacheHintsHandler(next http.HandlerFunc, store *CacheHintStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder := httptest.NewRecorder()
+		next(recorder, r)
+
+		surrogateKeyHeader := recorder.Header().Get("Surrogate-Key")
+		surrogateControl := recorder.Header().Get("Surrogate-Control")
+		if len(surrogateKeyHeader) > 0 || len(surrogateControl) > 0 {
+			store.Record(CacheHint{
+				URL:              r.URL.String(),
+				SurrogateKeys:    strings.Fields(surrogateKeyHeader),
+				SurrogateControl: surrogateControl,
+			})
+		}
+
+		copyHeaders(w.Header(), &recorder.HeaderMap)
+		w.WriteHeader(recorder.Code)
+		w.Write(recorder.Body.Bytes())
+	}
+}
+
+// CachePurgeRequest is the body POSTed to /system/cache/purge.
+type CachePurgeRequest struct {
+	SurrogateKeys []string `json:"surrogateKeys"`
+}
+
+// CachePurgeResponse reports which URLs were invalidated.
+type CachePurgeResponse struct {
+	PurgedURLs []string `json:"purgedUrls"`
+}
+
+// MakePurgeCacheHandler purges every recorded hint matching a surrogate key
+// in the request body, and, when cdnPurgeURL is set, relays the same keys
+// to it as a JSON POST so an actual CDN can invalidate its edge cache too.
+func MakePurgeCacheHandler(store *CacheHintStore, cdnPurgeURL string, httpClient *http.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var purgeReq CachePurgeRequest
+		if err := json.NewDecoder(r.Body).Decode(&purgeReq); err != nil {
+			http.Error(w, "invalid purge request", http.StatusBadRequest)
+			return
+		}
+
+		var purgedURLs []string
+		for _, key := range purgeReq.SurrogateKeys {
+			purgedURLs = append(purgedURLs, store.PurgeBySurrogateKey(key)...)
+		}
+
+		if len(cdnPurgeURL) > 0 {
+			bodyBytes, _ := json.Marshal(purgeReq)
+			if resp, err := httpClient.Post(cdnPurgeURL, "application/json", bytes.NewReader(bodyBytes)); err == nil {
+				resp.Body.Close()
+			}
+		}
+
+		bytesOut, err := json.Marshal(CachePurgeResponse{PurgedURLs: purgedURLs})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(bytesOut)
+	}
+}
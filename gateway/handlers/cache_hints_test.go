@@ -0,0 +1,50 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_CacheHintsHandler_RecordsSurrogateKeyHeader(t *testing.T) {
+	store := NewCacheHintStore()
+
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Surrogate-Key", "product-123 catalog")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	handler := MakeCacheHintsHandler(upstream, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/catalog", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	purged := store.PurgeBySurrogateKey("product-123")
+	if len(purged) != 1 || purged[0] != req.URL.String() {
+		t.Errorf("want url purged by surrogate key, got %v", purged)
+	}
+}
+
+func Test_PurgeCacheHandler_RemovesMatchingEntries(t *testing.T) {
+	store := NewCacheHintStore()
+	store.Record(CacheHint{URL: "/function/catalog", SurrogateKeys: []string{"catalog"}})
+
+	handler := MakePurgeCacheHandler(store, "", http.DefaultClient)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/cache/purge", strings.NewReader(`{"surrogateKeys":["catalog"]}`))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+
+	if got := store.PurgeBySurrogateKey("catalog"); len(got) != 0 {
+		t.Errorf("want entry already purged, got %v", got)
+	}
+}
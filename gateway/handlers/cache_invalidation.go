@@ -0,0 +1,99 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// CacheInvalidationScope names one of the gateway's internal caches that
+// POST /system/cache/invalidate can reset without a restart.
+type CacheInvalidationScope string
+
+const (
+	// CacheScopeScaling covers the replica-count cache (handlers.FunctionCache)
+	// scale-from-zero polling consults between GetReplicas calls.
+	CacheScopeScaling CacheInvalidationScope = "scaling"
+
+	// CacheScopeRouting covers the cached function catalog
+	// (handlers.FunctionCatalogCache) served when the provider is briefly
+	// unreachable.
+	CacheScopeRouting CacheInvalidationScope = "routing"
+
+	// CacheScopeAuth covers remembered positive auth decisions
+	// (handlers.AuthDecisionCache).
+	CacheScopeAuth CacheInvalidationScope = "auth"
+
+	// CacheScopeResponses covers cached function responses
+	// (handlers.ResponseCacheStore, e.g. InMemoryResponseCache).
+	CacheScopeResponses CacheInvalidationScope = "responses"
+)
+
+// CacheInvalidator resets one of the gateway's internal caches, either in
+// full or, for a cache keyed by function name, for a single function.
+type CacheInvalidator interface {
+	// InvalidateCache resets the cache. An empty functionName resets it in
+	// full; a non-empty one is honoured by caches keyed by function name
+	// and ignored by caches that aren't.
+	InvalidateCache(functionName string)
+}
+
+// NoopCacheInvalidator is a CacheInvalidator with no cache behind it:
+// invalidating it resets nothing.
+type NoopCacheInvalidator struct{}
+
+// InvalidateCache implements CacheInvalidator as a no-op.
+func (NoopCacheInvalidator) InvalidateCache(functionName string) {}
+
+// CacheInvalidationRequest is the POST /system/cache/invalidate body.
+type CacheInvalidationRequest struct {
+	// Scopes lists which caches to reset. Empty resets every scope this
+	// handler was registered with.
+	Scopes []CacheInvalidationScope `json:"scopes"`
+
+	// FunctionName, when set, limits invalidation to state held for a
+	// single function, for scopes whose CacheInvalidator is keyed by
+	// function name. A scope keyed some other way ignores it and resets
+	// in full.
+	FunctionName string `json:"functionName"`
+}
+
+// MakeCacheInvalidationHandler decodes a CacheInvalidationRequest and resets
+// the caches named in Scopes via invalidators, so operators can force the
+// gateway to refresh stale state after an out-of-band change without
+// restarting it. A scope with no entry in invalidators is rejected with
+// 400 rather than silently skipped, since a caller relying on a scope
+// actually being reset should notice if it isn't supported.
+func MakeCacheInvalidationHandler(invalidators map[CacheInvalidationScope]CacheInvalidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request CacheInvalidationRequest
+		if r.Body != nil {
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+				http.Error(w, "invalid cache invalidation request", http.StatusBadRequest)
+				return
+			}
+		}
+
+		scopes := request.Scopes
+		if len(scopes) == 0 {
+			for scope := range invalidators {
+				scopes = append(scopes, scope)
+			}
+		}
+
+		for _, scope := range scopes {
+			invalidator, exists := invalidators[scope]
+			if !exists {
+				http.Error(w, fmt.Sprintf("unknown cache scope %q", scope), http.StatusBadRequest)
+				return
+			}
+			invalidator.InvalidateCache(request.FunctionName)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
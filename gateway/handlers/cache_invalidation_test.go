@@ -0,0 +1,121 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type recordingInvalidator struct {
+	calls []string
+}
+
+func (r *recordingInvalidator) InvalidateCache(functionName string) {
+	r.calls = append(r.calls, functionName)
+}
+
+func Test_MakeCacheInvalidationHandler_InvalidatesNamedScopesOnly(t *testing.T) {
+	scaling := &recordingInvalidator{}
+	auth := &recordingInvalidator{}
+	invalidators := map[CacheInvalidationScope]CacheInvalidator{
+		CacheScopeScaling: scaling,
+		CacheScopeAuth:    auth,
+	}
+	handler := MakeCacheInvalidationHandler(invalidators)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/cache/invalidate", bytes.NewBufferString(`{"scopes":["scaling"]}`))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+	if len(scaling.calls) != 1 {
+		t.Errorf("want scaling invalidated, got %d calls", len(scaling.calls))
+	}
+	if len(auth.calls) != 0 {
+		t.Errorf("want auth left untouched, got %d calls", len(auth.calls))
+	}
+}
+
+func Test_MakeCacheInvalidationHandler_EmptyScopesInvalidatesEverything(t *testing.T) {
+	scaling := &recordingInvalidator{}
+	auth := &recordingInvalidator{}
+	invalidators := map[CacheInvalidationScope]CacheInvalidator{
+		CacheScopeScaling: scaling,
+		CacheScopeAuth:    auth,
+	}
+	handler := MakeCacheInvalidationHandler(invalidators)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/cache/invalidate", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+	if len(scaling.calls) != 1 || len(auth.calls) != 1 {
+		t.Errorf("want every registered scope invalidated, got scaling=%d auth=%d", len(scaling.calls), len(auth.calls))
+	}
+}
+
+func Test_MakeCacheInvalidationHandler_PassesThroughFunctionName(t *testing.T) {
+	scaling := &recordingInvalidator{}
+	invalidators := map[CacheInvalidationScope]CacheInvalidator{CacheScopeScaling: scaling}
+	handler := MakeCacheInvalidationHandler(invalidators)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/cache/invalidate", bytes.NewBufferString(`{"scopes":["scaling"],"functionName":"figlet"}`))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if len(scaling.calls) != 1 || scaling.calls[0] != "figlet" {
+		t.Errorf("want functionName passed through, got %v", scaling.calls)
+	}
+}
+
+func Test_MakeCacheInvalidationHandler_RejectsUnknownScope(t *testing.T) {
+	handler := MakeCacheInvalidationHandler(map[CacheInvalidationScope]CacheInvalidator{})
+
+	req := httptest.NewRequest(http.MethodPost, "/system/cache/invalidate", bytes.NewBufferString(`{"scopes":["bogus"]}`))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("want 400 for an unknown scope, got %d", rr.Code)
+	}
+}
+
+func Test_FunctionCache_InvalidateCache(t *testing.T) {
+	fc := &FunctionCache{Cache: make(map[string]*FunctionMeta), Expiry: time.Minute}
+	fc.Set("figlet", ServiceQueryResponse{AvailableReplicas: 1})
+	fc.Set("nodeinfo", ServiceQueryResponse{AvailableReplicas: 1})
+
+	fc.InvalidateCache("figlet")
+	if _, hit := fc.Get("figlet"); hit {
+		t.Error("want figlet's cache entry dropped")
+	}
+	if _, hit := fc.Get("nodeinfo"); !hit {
+		t.Error("want nodeinfo's cache entry left alone")
+	}
+
+	fc.InvalidateCache("")
+	if _, hit := fc.Get("nodeinfo"); hit {
+		t.Error("want every entry dropped when functionName is empty")
+	}
+}
+
+func Test_AuthDecisionCache_InvalidateCache(t *testing.T) {
+	cache := &AuthDecisionCache{TTL: time.Minute}
+	cache.Remember("fingerprint-a")
+
+	cache.InvalidateCache("")
+
+	if cache.Allow("fingerprint-a") {
+		t.Error("want every remembered decision dropped")
+	}
+}
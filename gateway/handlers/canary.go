@@ -0,0 +1,218 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// CanaryStickyCookie is the cookie MakeCanaryHandler sets on a client's
+// first canary-eligible request, recording which version it was assigned,
+// so repeat requests from the same client keep landing on that version
+// rather than being re-rolled against CanaryRule.Weight every time.
+const CanaryStickyCookie = "faas_canary"
+
+// CanaryRule configures weighted traffic-splitting between FunctionName
+// and CanaryFunctionName - e.g. "foo" and "foo-canary" - so a new version
+// can be rolled out to a percentage of traffic before replacing the
+// primary function outright.
+type CanaryRule struct {
+	FunctionName string `json:"functionName"`
+
+	CanaryFunctionName string `json:"canaryFunctionName"`
+
+	// Weight is the percentage (0-100) of traffic routed to
+	// CanaryFunctionName; the remainder stays on FunctionName.
+	Weight int `json:"weight"`
+
+	// StickyHeader, when set, names a request header used to derive a
+	// stable per-client assignment instead of CanaryStickyCookie - useful
+	// for callers that don't carry cookies, e.g. service-to-service
+	// traffic identified by an API key or tenant header.
+	StickyHeader string `json:"stickyHeader"`
+}
+
+// CanaryStore holds the canary rule currently configured per function,
+// keyed by FunctionName since only one rule can run against a given
+// function at a time.
+type CanaryStore struct {
+	lock  sync.RWMutex
+	rules map[string]CanaryRule
+}
+
+// NewCanaryStore creates an empty CanaryStore.
+func NewCanaryStore() *CanaryStore {
+	return &CanaryStore{
+		rules: make(map[string]CanaryRule),
+	}
+}
+
+// Set registers or replaces the canary rule running against its
+// FunctionName.
+func (s *CanaryStore) Set(rule CanaryRule) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.rules[rule.FunctionName] = rule
+}
+
+// Get returns the canary rule configured for functionName, if any.
+func (s *CanaryStore) Get(functionName string) (CanaryRule, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	rule, exists := s.rules[functionName]
+	return rule, exists
+}
+
+// Delete removes the canary rule configured for functionName, if any.
+func (s *CanaryStore) Delete(functionName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.rules, functionName)
+}
+
+// List returns every configured canary rule.
+func (s *CanaryStore) List() []CanaryRule {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	rules := make([]CanaryRule, 0, len(s.rules))
+	for _, rule := range s.rules {
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// assignCanary deterministically maps key into rule's weighted split.
+func assignCanary(rule CanaryRule, key string) bool {
+	if rule.Weight <= 0 {
+		return false
+	}
+	if rule.Weight >= 100 {
+		return true
+	}
+
+	hash := sha1.Sum([]byte(key))
+	bucket := int(binary.BigEndian.Uint32(hash[:4]) % 100)
+	return bucket < rule.Weight
+}
+
+// canaryAssignment decides whether r should be routed to rule's canary,
+// preferring an existing sticky signal - rule.StickyHeader if configured
+// and present on the request, otherwise a previously-set
+// CanaryStickyCookie - over a fresh weighted roll, so a client already
+// assigned a version keeps seeing it for the lifetime of the rollout. A
+// fresh decision for a cookie-tracked client is written back onto w, so
+// the next request from the same client reads it from the branch above
+// instead of rolling again.
+func canaryAssignment(r *http.Request, w http.ResponseWriter, rule CanaryRule) bool {
+	if rule.StickyHeader != "" {
+		if key := r.Header.Get(rule.StickyHeader); key != "" {
+			return assignCanary(rule, key)
+		}
+	}
+
+	if cookie, err := r.Cookie(CanaryStickyCookie); err == nil {
+		return cookie.Value == "canary"
+	}
+
+	useCanary := rule.Weight > 0 && rand.Intn(100) < rule.Weight
+	value := "primary"
+	if useCanary {
+		value = "canary"
+	}
+	http.SetCookie(w, &http.Cookie{Name: CanaryStickyCookie, Value: value, Path: "/"})
+	return useCanary
+}
+
+// rewriteFunctionName replaces the from function-name segment of a
+// "/function/<name>" (or "/function/<name>/<params>") path with to,
+// leaving everything else - including any sub-path - untouched.
+func rewriteFunctionName(path, from, to string) string {
+	prefix := "/function/" + from
+	if !strings.HasPrefix(path, prefix) {
+		return path
+	}
+
+	rest := strings.TrimPrefix(path, prefix)
+	if rest != "" && rest[0] != '/' {
+		// from is only a prefix of a longer, distinct function name.
+		return path
+	}
+
+	return "/function/" + to + rest
+}
+
+// MakeCanaryHandler wraps next, routing a percentage of traffic to a
+// function's configured CanaryRule.CanaryFunctionName instead of the
+// function named in the request path. A function with no configured rule
+// is forwarded unchanged, as before canary rules existed.
+func MakeCanaryHandler(next http.HandlerFunc, store *CanaryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := getServiceName(r.URL.Path)
+
+		rule, exists := store.Get(functionName)
+		if !exists || rule.CanaryFunctionName == "" {
+			next(w, r)
+			return
+		}
+
+		if canaryAssignment(r, w, rule) {
+			r.URL.Path = rewriteFunctionName(r.URL.Path, functionName, rule.CanaryFunctionName)
+		}
+
+		next(w, r)
+	}
+}
+
+// MakeListCanaryRulesHandler returns every configured canary rule as JSON.
+func MakeListCanaryRulesHandler(store *CanaryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bytesOut, err := json.Marshal(store.List())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(bytesOut)
+	}
+}
+
+// MakeSetCanaryRuleHandler decodes a CanaryRule from the request body and
+// registers it against its FunctionName.
+func MakeSetCanaryRuleHandler(store *CanaryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rule CanaryRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "invalid canary rule", http.StatusBadRequest)
+			return
+		}
+
+		if len(rule.FunctionName) == 0 || len(rule.CanaryFunctionName) == 0 {
+			http.Error(w, "functionName and canaryFunctionName are required", http.StatusBadRequest)
+			return
+		}
+
+		store.Set(rule)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeDeleteCanaryRuleHandler removes the canary rule running against the
+// {name} function.
+func MakeDeleteCanaryRuleHandler(store *CanaryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := mux.Vars(r)["name"]
+		store.Delete(functionName)
+		w.WriteHeader(http.StatusOK)
+	}
+}
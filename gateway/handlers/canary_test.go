@@ -0,0 +1,170 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func Test_CanaryStore_SetGetDelete(t *testing.T) {
+	store := NewCanaryStore()
+
+	rule := CanaryRule{FunctionName: "foo", CanaryFunctionName: "foo-canary", Weight: 50}
+	store.Set(rule)
+
+	got, exists := store.Get("foo")
+	if !exists || got.CanaryFunctionName != "foo-canary" {
+		t.Fatalf("want rule recorded, got %+v exists=%v", got, exists)
+	}
+
+	store.Delete("foo")
+	if _, exists := store.Get("foo"); exists {
+		t.Error("want rule removed after Delete")
+	}
+}
+
+func Test_MakeCanaryHandler_PassesThroughWithoutRule(t *testing.T) {
+	store := NewCanaryStore()
+
+	var gotPath string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeCanaryHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if gotPath != "/function/foo" {
+		t.Errorf("want the request untouched, got %s", gotPath)
+	}
+}
+
+func Test_MakeCanaryHandler_FullWeightAlwaysRoutesToCanary(t *testing.T) {
+	store := NewCanaryStore()
+	store.Set(CanaryRule{FunctionName: "foo", CanaryFunctionName: "foo-canary", Weight: 100})
+
+	var gotPath string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeCanaryHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo/sub/path", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if gotPath != "/function/foo-canary/sub/path" {
+		t.Errorf("want the sub-path preserved under the canary function, got %s", gotPath)
+	}
+}
+
+func Test_MakeCanaryHandler_ZeroWeightNeverRoutesToCanary(t *testing.T) {
+	store := NewCanaryStore()
+	store.Set(CanaryRule{FunctionName: "foo", CanaryFunctionName: "foo-canary", Weight: 0})
+
+	var gotPath string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeCanaryHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if gotPath != "/function/foo" {
+		t.Errorf("want zero weight to never select the canary, got %s", gotPath)
+	}
+}
+
+func Test_MakeCanaryHandler_StickyHeaderIsDeterministic(t *testing.T) {
+	store := NewCanaryStore()
+	store.Set(CanaryRule{FunctionName: "foo", CanaryFunctionName: "foo-canary", Weight: 50, StickyHeader: "X-User-Id"})
+
+	var paths []string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeCanaryHandler(next, store)
+
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+		req.Header.Set("X-User-Id", "alex")
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+	}
+
+	for i := 1; i < len(paths); i++ {
+		if paths[i] != paths[0] {
+			t.Fatalf("want the same client to consistently resolve to the same version, got %v", paths)
+		}
+	}
+}
+
+func Test_MakeCanaryHandler_StickyCookieIsHonoured(t *testing.T) {
+	store := NewCanaryStore()
+	store.Set(CanaryRule{FunctionName: "foo", CanaryFunctionName: "foo-canary", Weight: 50})
+
+	var gotPath string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeCanaryHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	req.AddCookie(&http.Cookie{Name: CanaryStickyCookie, Value: "canary"})
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if gotPath != "/function/foo-canary" {
+		t.Errorf("want the sticky cookie's decision honoured, got %s", gotPath)
+	}
+}
+
+func Test_MakeDeleteCanaryRuleHandler_RemovesRule(t *testing.T) {
+	store := NewCanaryStore()
+	store.Set(CanaryRule{FunctionName: "foo", CanaryFunctionName: "foo-canary", Weight: 50})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/canary/{name}", MakeDeleteCanaryRuleHandler(store)).Methods(http.MethodDelete)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/canary/foo", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+	if _, exists := store.Get("foo"); exists {
+		t.Error("want the rule removed")
+	}
+}
+
+func Test_RewriteFunctionName(t *testing.T) {
+	cases := []struct {
+		path, from, to, want string
+	}{
+		{"/function/foo", "foo", "foo-canary", "/function/foo-canary"},
+		{"/function/foo/sub", "foo", "foo-canary", "/function/foo-canary/sub"},
+		{"/function/foobar", "foo", "foo-canary", "/function/foobar"},
+		{"/system/info", "foo", "foo-canary", "/system/info"},
+	}
+
+	for _, c := range cases {
+		if got := rewriteFunctionName(c.path, c.from, c.to); got != c.want {
+			t.Errorf("rewriteFunctionName(%q, %q, %q) = %q, want %q", c.path, c.from, c.to, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProviderCapabilities declares which optional features the connected
+// provider supports, so CLIs and UIs can adapt instead of failing on
+// unsupported calls.
+type ProviderCapabilities struct {
+	Namespaces   bool `json:"namespaces"`
+	Logs         bool `json:"logs"`
+	ScaleToZero  bool `json:"scaleToZero"`
+	GPUResources bool `json:"gpuResources"`
+	AsyncInvoke  bool `json:"asyncInvoke"`
+	DirectInvoke bool `json:"directInvoke"`
+}
+
+// CapabilityProbe probes an external provider to determine its supported
+// optional features. Implementations typically issue lightweight requests
+// against known provider endpoints (e.g. /system/namespaces, /system/logs).
+type CapabilityProbe interface {
+	Probe() ProviderCapabilities
+}
+
+// MakeCapabilitiesHandler exposes GET /system/info/capabilities, describing
+// which optional features the connected provider supports.
+func MakeCapabilitiesHandler(probe CapabilityProbe) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(probe.Probe())
+	}
+}
+
+// HTTPCapabilityProbe probes provider capabilities by issuing lightweight
+// HTTP requests against known optional endpoints and treating a non-404
+// response as support for that feature.
+type HTTPCapabilityProbe struct {
+	Client               *http.Client
+	FunctionsProviderURL string
+	ScaleFromZero        bool
+}
+
+// Probe checks the provider for known optional endpoints.
+func (h HTTPCapabilityProbe) Probe() ProviderCapabilities {
+	return ProviderCapabilities{
+		Namespaces:   h.endpointExists("/system/namespaces"),
+		Logs:         h.endpointExists("/system/logs"),
+		ScaleToZero:  h.ScaleFromZero,
+		GPUResources: false,
+		AsyncInvoke:  true,
+		DirectInvoke: true,
+	}
+}
+
+func (h HTTPCapabilityProbe) endpointExists(path string) bool {
+	if h.Client == nil || len(h.FunctionsProviderURL) == 0 {
+		return false
+	}
+
+	res, err := h.Client.Get(h.FunctionsProviderURL + path)
+	if err != nil {
+		return false
+	}
+	defer res.Body.Close()
+
+	return res.StatusCode != http.StatusNotFound
+}
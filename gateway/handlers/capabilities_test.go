@@ -0,0 +1,39 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_HTTPCapabilityProbe_Probe(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/system/namespaces" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer testServer.Close()
+
+	probe := HTTPCapabilityProbe{
+		Client:               testServer.Client(),
+		FunctionsProviderURL: testServer.URL,
+		ScaleFromZero:        true,
+	}
+
+	caps := probe.Probe()
+
+	if !caps.Namespaces {
+		t.Error("want Namespaces true")
+	}
+	if caps.Logs {
+		t.Error("want Logs false")
+	}
+	if !caps.ScaleToZero {
+		t.Error("want ScaleToZero true")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// FunctionCatalogCache persists the last-known function list to disk so it
+// can still be served if the provider is unreachable, e.g. immediately
+// after the gateway restarts while the provider is still coming up.
+type FunctionCatalogCache struct {
+	// Path is the file used to persist the cached catalog between restarts.
+	Path string
+
+	lock      sync.RWMutex
+	functions []requests.Function
+}
+
+// Load reads a previously persisted catalog from disk, if one exists. It is
+// not an error for the file to be missing, e.g. on first-ever start.
+func (f *FunctionCatalogCache) Load() error {
+	bytesIn, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var functions []requests.Function
+	if err := json.Unmarshal(bytesIn, &functions); err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	f.functions = functions
+	f.lock.Unlock()
+
+	return nil
+}
+
+// Set replaces the cached catalog and persists it to disk.
+func (f *FunctionCatalogCache) Set(functions []requests.Function) error {
+	f.lock.Lock()
+	f.functions = functions
+	f.lock.Unlock()
+
+	bytesOut, err := json.Marshal(functions)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(f.Path, bytesOut, 0600)
+}
+
+// Get returns the last cached catalog.
+func (f *FunctionCatalogCache) Get() []requests.Function {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.functions
+}
+
+// InvalidateCache implements CacheInvalidator, dropping the cached catalog
+// so the next provider-backed ListFunctions failure is passed through to
+// the caller as-is rather than answered with a list that may be stale,
+// instead of waiting for the next successful call to overwrite it.
+// functionName is ignored - the catalog is a single combined list, not
+// keyed per function.
+func (f *FunctionCatalogCache) InvalidateCache(functionName string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.functions = nil
+}
+
+// MakeCatalogCachingHandler wraps a ListFunctions handler so that a
+// successful response refreshes the on-disk catalog cache, and a failed
+// one (the provider being briefly unreachable, e.g. during a restart) is
+// served from the last cached catalog instead of failing the request.
+func MakeCatalogCachingHandler(next http.HandlerFunc, cache *FunctionCatalogCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder := httptest.NewRecorder()
+		next(recorder, r)
+
+		if recorder.Code == http.StatusOK {
+			var functions []requests.Function
+			if err := json.Unmarshal(recorder.Body.Bytes(), &functions); err == nil {
+				cache.Set(functions)
+			}
+
+			for k, v := range recorder.HeaderMap {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(recorder.Code)
+			w.Write(recorder.Body.Bytes())
+			return
+		}
+
+		cached := cache.Get()
+		if cached == nil {
+			for k, v := range recorder.HeaderMap {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(recorder.Code)
+			w.Write(recorder.Body.Bytes())
+			return
+		}
+
+		bytesOut, err := json.Marshal(cached)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Served-From-Cache", "true")
+		w.WriteHeader(http.StatusOK)
+		w.Write(bytesOut)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_CatalogCachingHandler_ServesCacheWhenProviderIsUnavailable(t *testing.T) {
+	cache := &FunctionCatalogCache{Path: filepath.Join(t.TempDir(), "catalog.json")}
+
+	cachedFunctions := []requests.Function{{Name: "echo"}}
+	if err := cache.Set(cachedFunctions); err != nil {
+		t.Fatal(err)
+	}
+
+	failingUpstream := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	handler := MakeCatalogCachingHandler(failingUpstream, cache)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status 200 from cache, got %d", rr.Code)
+	}
+
+	var got []requests.Function
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0].Name != "echo" {
+		t.Errorf("want cached catalog [echo], got %v", got)
+	}
+}
+
+func Test_CatalogCachingHandler_RefreshesCacheOnSuccess(t *testing.T) {
+	cache := &FunctionCatalogCache{Path: filepath.Join(t.TempDir(), "catalog.json")}
+
+	upstreamFunctions := []requests.Function{{Name: "nodeinfo"}}
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		bytesOut, _ := json.Marshal(upstreamFunctions)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(bytesOut)
+	}
+
+	handler := MakeCatalogCachingHandler(upstream, cache)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status 200, got %d", rr.Code)
+	}
+
+	cached := cache.Get()
+	if len(cached) != 1 || cached[0].Name != "nodeinfo" {
+		t.Errorf("want cache refreshed with [nodeinfo], got %v", cached)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FreezeWindow is a span of time during which mutating system API calls
+// are rejected. Windows are explicit start/end timestamps rather than
+// cron expressions or an external calendar-API integration, since
+// neither a cron parser nor a calendar client is vendored in this
+// codebase; an operator (or a small script on their own schedule) adds
+// one window per freeze via POST /system/freeze-windows.
+type FreezeWindow struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// contains reports whether now falls within the window.
+func (w FreezeWindow) contains(now time.Time) bool {
+	return !now.Before(w.Start) && now.Before(w.End)
+}
+
+// FreezeWindowStore holds the configured change-freeze windows. Like the
+// gateway's other in-memory stores, it does not survive a restart.
+type FreezeWindowStore struct {
+	lock    sync.RWMutex
+	windows []FreezeWindow
+}
+
+// NewFreezeWindowStore creates an empty FreezeWindowStore.
+func NewFreezeWindowStore() *FreezeWindowStore {
+	return &FreezeWindowStore{}
+}
+
+// Add registers a new freeze window.
+func (s *FreezeWindowStore) Add(window FreezeWindow) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.windows = append(s.windows, window)
+}
+
+// List returns every configured freeze window.
+func (s *FreezeWindowStore) List() []FreezeWindow {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	windows := make([]FreezeWindow, len(s.windows))
+	copy(windows, s.windows)
+	return windows
+}
+
+// Active returns the first configured window containing now, if any.
+func (s *FreezeWindowStore) Active(now time.Time) (FreezeWindow, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, window := range s.windows {
+		if window.contains(now) {
+			return window, true
+		}
+	}
+	return FreezeWindow{}, false
+}
+
+// AuditEntry is one recorded attempt at a mutating system API call made
+// during an active change-freeze window.
+type AuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Identity  string    `json:"identity,omitempty"`
+	// Verified reports whether Identity came from a verified source (an
+	// OIDC subject claim or API key ID) rather than the caller-supplied,
+	// spoofable X-Deployer-Identity header - see callerIdentity.
+	Verified bool   `json:"verified"`
+	Allowed  bool   `json:"allowed"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AuditLog keeps a fixed-size, in-memory record of attempted changes
+// made during a change freeze, for later review.
+type AuditLog struct {
+	// Capacity is the maximum number of entries retained. Defaults to 500
+	// when unset.
+	Capacity int
+
+	lock    sync.Mutex
+	entries []AuditEntry
+}
+
+// Record appends entry to the log, evicting the oldest entry once
+// Capacity is reached.
+func (a *AuditLog) Record(entry AuditEntry) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	capacity := a.Capacity
+	if capacity <= 0 {
+		capacity = 500
+	}
+
+	a.entries = append(a.entries, entry)
+	if len(a.entries) > capacity {
+		a.entries = a.entries[len(a.entries)-capacity:]
+	}
+}
+
+// List returns a copy of the recorded entries, oldest first.
+func (a *AuditLog) List() []AuditEntry {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	entries := make([]AuditEntry, len(a.entries))
+	copy(entries, a.entries)
+	return entries
+}
+
+// MakeChangeFreezeHandler wraps a mutating system API handler so that,
+// while a FreezeWindow from windows is active, the request is rejected
+// with 423 Locked unless the caller's identity names a break-glass
+// identity. That identity comes from callerIdentity - a verified OIDC
+// subject claim or API key ID when one is available, and only otherwise
+// the caller-supplied X-Deployer-Identity header (the same header used for
+// function ownership), which is advisory only: without a verified identity
+// configured, any caller that can already reach this endpoint can set it
+// to a guessed or leaked break-glass name and bypass the freeze outright.
+// Every attempt made during an active window, allowed or not, is recorded
+// to audit, including whether the identity it was allowed or rejected
+// against was verified.
+func MakeChangeFreezeHandler(next http.HandlerFunc, windows *FreezeWindowStore, breakGlassIdentities map[string]bool, audit *AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		window, frozen := windows.Active(time.Now())
+		if !frozen {
+			next(w, r)
+			return
+		}
+
+		identity, verified := callerIdentity(r)
+		allowed := breakGlassIdentities[identity]
+
+		audit.Record(AuditEntry{
+			Timestamp: time.Now(),
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Identity:  identity,
+			Verified:  verified,
+			Allowed:   allowed,
+			Reason:    window.Reason,
+		})
+
+		if !allowed {
+			http.Error(w, "change freeze in effect: "+window.Reason, http.StatusLocked)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// MakeAddFreezeWindowHandler registers a new freeze window,
+// POST /system/freeze-windows.
+func MakeAddFreezeWindowHandler(windows *FreezeWindowStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		var window FreezeWindow
+		if err := json.NewDecoder(r.Body).Decode(&window); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !window.Start.Before(window.End) {
+			http.Error(w, "start must be before end", http.StatusBadRequest)
+			return
+		}
+
+		windows.Add(window)
+
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// MakeListFreezeWindowsHandler lists every configured freeze window,
+// GET /system/freeze-windows.
+func MakeListFreezeWindowsHandler(windows *FreezeWindowStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(windows.List())
+	}
+}
+
+// MakeListAuditLogHandler lists every recorded audit entry,
+// GET /system/audit-log.
+func MakeListAuditLogHandler(audit *AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(audit.List())
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_FreezeWindowStore_Active(t *testing.T) {
+	store := NewFreezeWindowStore()
+	now := time.Now()
+	store.Add(FreezeWindow{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "black friday"})
+
+	window, active := store.Active(now)
+	if !active || window.Reason != "black friday" {
+		t.Fatalf("want an active window, got active=%v window=%v", active, window)
+	}
+
+	_, active = store.Active(now.Add(2 * time.Hour))
+	if active {
+		t.Fatal("want no active window once the configured window has passed")
+	}
+}
+
+func Test_MakeChangeFreezeHandler_BlocksOutsideBreakGlass(t *testing.T) {
+	windows := NewFreezeWindowStore()
+	now := time.Now()
+	windows.Add(FreezeWindow{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "release freeze"})
+	audit := &AuditLog{}
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeChangeFreezeHandler(next, windows, map[string]bool{"oncall": true}, audit)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", nil)
+	req.Header.Set("X-Deployer-Identity", "alice")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusLocked {
+		t.Fatalf("want 423, got %d", rec.Code)
+	}
+	if called {
+		t.Fatal("want next not to be called during a freeze for a non-break-glass identity")
+	}
+
+	entries := audit.List()
+	if len(entries) != 1 || entries[0].Allowed {
+		t.Fatalf("want one denied audit entry, got %v", entries)
+	}
+}
+
+func Test_MakeChangeFreezeHandler_AllowsBreakGlass(t *testing.T) {
+	windows := NewFreezeWindowStore()
+	now := time.Now()
+	windows.Add(FreezeWindow{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "release freeze"})
+	audit := &AuditLog{}
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeChangeFreezeHandler(next, windows, map[string]bool{"oncall": true}, audit)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", nil)
+	req.Header.Set("X-Deployer-Identity", "oncall")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called for a break-glass identity")
+	}
+
+	entries := audit.List()
+	if len(entries) != 1 || !entries[0].Allowed {
+		t.Fatalf("want one allowed audit entry, got %v", entries)
+	}
+}
+
+// Test_MakeChangeFreezeHandler_VerifiedIdentityIgnoresSpoofedBreakGlassHeader
+// guards the fix for the break-glass check trusting a caller-supplied
+// X-Deployer-Identity outright: with a verified OIDC subject claim
+// attached to the request context, a spoofed header naming a break-glass
+// identity no longer bypasses the freeze.
+func Test_MakeChangeFreezeHandler_VerifiedIdentityIgnoresSpoofedBreakGlassHeader(t *testing.T) {
+	windows := NewFreezeWindowStore()
+	now := time.Now()
+	windows.Add(FreezeWindow{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "release freeze"})
+	audit := &AuditLog{}
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeChangeFreezeHandler(next, windows, map[string]bool{"oncall": true}, audit)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", nil)
+	req.Header.Set("X-Deployer-Identity", "oncall")
+	ctx := context.WithValue(req.Context(), oidcClaimsContextKey{}, map[string]interface{}{"sub": "mallory"})
+	rec := httptest.NewRecorder()
+	handler(rec, req.WithContext(ctx))
+
+	if rec.Code != http.StatusLocked {
+		t.Fatalf("want 423 for a verified non-break-glass identity, even with a spoofed break-glass header, got %d", rec.Code)
+	}
+	if called {
+		t.Fatal("want next not to be called")
+	}
+
+	entries := audit.List()
+	if len(entries) != 1 || entries[0].Identity != "mallory" || !entries[0].Verified || entries[0].Allowed {
+		t.Fatalf("want one denied, verified audit entry for mallory, got %v", entries)
+	}
+}
+
+func Test_MakeChangeFreezeHandler_AllowsOutsideAnyWindow(t *testing.T) {
+	windows := NewFreezeWindowStore()
+	audit := &AuditLog{}
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeChangeFreezeHandler(next, windows, map[string]bool{}, audit)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called when no freeze window is active")
+	}
+	if len(audit.List()) != 0 {
+		t.Fatal("want no audit entries recorded outside a freeze window")
+	}
+}
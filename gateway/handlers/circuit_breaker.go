@@ -0,0 +1,287 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/openfaas/faas/gateway/metrics"
+)
+
+// CircuitBreakerState is a per-function circuit breaker's current state.
+type CircuitBreakerState int
+
+const (
+	// BreakerClosed forwards every request as normal.
+	BreakerClosed CircuitBreakerState = iota
+
+	// BreakerOpen rejects every request immediately, without forwarding.
+	BreakerOpen
+
+	// BreakerHalfOpen allows exactly one trial request through to decide
+	// whether to close again.
+	BreakerHalfOpen
+)
+
+// String implements fmt.Stringer, matching the state names used in log and
+// error messages.
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreakerConfig controls when a function's breaker opens and how
+// long it stays open before allowing a half-open trial request.
+type CircuitBreakerConfig struct {
+	// ConsecutiveFailureThreshold opens the breaker once this many
+	// requests in a row have failed. Zero disables this trigger.
+	ConsecutiveFailureThreshold int
+
+	// FailureRateThreshold opens the breaker once the failure rate over
+	// the last FailureRateWindow requests reaches this fraction (0-1).
+	// Zero disables this trigger.
+	FailureRateThreshold float64
+
+	// FailureRateWindow is how many of the most recent requests
+	// FailureRateThreshold is evaluated over, once that many have been
+	// seen. Defaults to 10 when FailureRateThreshold is set but this
+	// isn't.
+	FailureRateWindow int
+
+	// OpenDuration is how long the breaker stays open before allowing a
+	// half-open trial request through. Defaults to 30s.
+	OpenDuration time.Duration
+}
+
+func (c CircuitBreakerConfig) window() int {
+	if c.FailureRateWindow > 0 {
+		return c.FailureRateWindow
+	}
+	return 10
+}
+
+func (c CircuitBreakerConfig) openDuration() time.Duration {
+	if c.OpenDuration > 0 {
+		return c.OpenDuration
+	}
+	return 30 * time.Second
+}
+
+// enabled reports whether config can ever open a breaker at all.
+func (c CircuitBreakerConfig) enabled() bool {
+	return c.ConsecutiveFailureThreshold > 0 || c.FailureRateThreshold > 0
+}
+
+// circuitBreaker tracks one function's consecutive failures and recent
+// outcome history, and decides whether to let a request through.
+type circuitBreaker struct {
+	lock                sync.Mutex
+	config              CircuitBreakerConfig
+	state               CircuitBreakerState
+	consecutiveFailures int
+	recentFailures      []bool
+	openedAt            time.Time
+	halfOpenInFlight    bool
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig) *circuitBreaker {
+	return &circuitBreaker{config: config}
+}
+
+// allow reports whether a request may proceed and, when it may not, how
+// long the caller should wait before retrying.
+func (b *circuitBreaker) allow() (bool, time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		remaining := b.config.openDuration() - time.Since(b.openedAt)
+		if remaining > 0 {
+			return false, remaining
+		}
+		b.state = BreakerHalfOpen
+		b.halfOpenInFlight = true
+		return true, 0
+	case BreakerHalfOpen:
+		if b.halfOpenInFlight {
+			return false, b.config.openDuration()
+		}
+		b.halfOpenInFlight = true
+		return true, 0
+	default:
+		return true, 0
+	}
+}
+
+// recordResult updates the breaker with the outcome of a request it just
+// allowed through.
+func (b *circuitBreaker) recordResult(failed bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.halfOpenInFlight = false
+		if failed {
+			b.open()
+		} else {
+			b.close()
+		}
+		return
+	}
+
+	if failed {
+		b.consecutiveFailures++
+	} else {
+		b.consecutiveFailures = 0
+	}
+
+	b.recentFailures = append(b.recentFailures, failed)
+	if len(b.recentFailures) > b.config.window() {
+		b.recentFailures = b.recentFailures[1:]
+	}
+
+	if b.shouldOpen() {
+		b.open()
+	}
+}
+
+func (b *circuitBreaker) shouldOpen() bool {
+	if b.config.ConsecutiveFailureThreshold > 0 && b.consecutiveFailures >= b.config.ConsecutiveFailureThreshold {
+		return true
+	}
+
+	if b.config.FailureRateThreshold > 0 && len(b.recentFailures) >= b.config.window() {
+		failed := 0
+		for _, f := range b.recentFailures {
+			if f {
+				failed++
+			}
+		}
+		if float64(failed)/float64(len(b.recentFailures)) >= b.config.FailureRateThreshold {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *circuitBreaker) open() {
+	b.state = BreakerOpen
+	b.openedAt = time.Now()
+	b.halfOpenInFlight = false
+}
+
+func (b *circuitBreaker) close() {
+	b.state = BreakerClosed
+	b.consecutiveFailures = 0
+	b.recentFailures = nil
+}
+
+func (b *circuitBreaker) currentState() CircuitBreakerState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// CircuitBreakerStore holds one circuitBreaker per function, each created
+// on first use with Config, and reports state changes to Metrics.
+type CircuitBreakerStore struct {
+	lock     sync.Mutex
+	breakers map[string]*circuitBreaker
+	Config   CircuitBreakerConfig
+	Metrics  *metrics.MetricOptions
+}
+
+// NewCircuitBreakerStore creates a store whose breakers are all governed by
+// config, reporting state via metricsOptions.CircuitBreakerState if it is
+// non-nil.
+func NewCircuitBreakerStore(config CircuitBreakerConfig, metricsOptions *metrics.MetricOptions) *CircuitBreakerStore {
+	return &CircuitBreakerStore{
+		breakers: make(map[string]*circuitBreaker),
+		Config:   config,
+		Metrics:  metricsOptions,
+	}
+}
+
+func (s *CircuitBreakerStore) get(function string) *circuitBreaker {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	b, exists := s.breakers[function]
+	if !exists {
+		b = newCircuitBreaker(s.Config)
+		s.breakers[function] = b
+	}
+	return b
+}
+
+func (s *CircuitBreakerStore) observeState(function string, state CircuitBreakerState) {
+	if s.Metrics == nil || s.Metrics.CircuitBreakerState == nil {
+		return
+	}
+	s.Metrics.CircuitBreakerState.WithLabelValues(function).Set(float64(state))
+}
+
+// statusCapturingResponseWriter records the status code a handler wrote,
+// without buffering or otherwise altering the response - unlike
+// retryableStatusWriter, nothing here is ever held back.
+type statusCapturingResponseWriter struct {
+	http.ResponseWriter
+	statusCode    int
+	headerWritten bool
+}
+
+func (w *statusCapturingResponseWriter) WriteHeader(statusCode int) {
+	if !w.headerWritten {
+		w.headerWritten = true
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusCapturingResponseWriter) Write(b []byte) (int, error) {
+	if !w.headerWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// MakeCircuitBreakerMiddleware wraps next with a per-function circuit
+// breaker: once a function has failed enough requests - see
+// CircuitBreakerConfig - further requests are rejected immediately with
+// 503 and a Retry-After header until a cooldown elapses, at which point one
+// trial request is allowed through to decide whether to close again. A
+// request is counted as a failure when next writes a 5xx status. The
+// function name is resolved the same way as for metrics and logging, via
+// getServiceName.
+func MakeCircuitBreakerMiddleware(next http.HandlerFunc, store *CircuitBreakerStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		function := getServiceName(r.URL.Path)
+		breaker := store.get(function)
+
+		allowed, retryAfter := breaker.allow()
+		if !allowed {
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(retryAfter.Seconds())+1))
+			http.Error(w, fmt.Sprintf("circuit breaker open for function %q", function), http.StatusServiceUnavailable)
+			store.observeState(function, breaker.currentState())
+			return
+		}
+
+		capturing := &statusCapturingResponseWriter{ResponseWriter: w}
+		next(capturing, r)
+
+		breaker.recordResult(capturing.statusCode >= http.StatusInternalServerError)
+		store.observeState(function, breaker.currentState())
+	}
+}
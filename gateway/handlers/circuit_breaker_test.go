@@ -0,0 +1,97 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func failingHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func Test_CircuitBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	store := NewCircuitBreakerStore(CircuitBreakerConfig{ConsecutiveFailureThreshold: 2}, nil)
+	handler := MakeCircuitBreakerMiddleware(failingHandler, store)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/function/figlet", nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: want 500 from the underlying handler, got %d", i, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/function/figlet", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("want 503 once the breaker opens, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("want a Retry-After header while the breaker is open")
+	}
+}
+
+func Test_CircuitBreaker_ClosedFunctionsAreIndependent(t *testing.T) {
+	store := NewCircuitBreakerStore(CircuitBreakerConfig{ConsecutiveFailureThreshold: 1}, nil)
+	handler := MakeCircuitBreakerMiddleware(failingHandler, store)
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/function/figlet", nil))
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/function/other", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("want an unrelated function's breaker to still be closed, got %d", rec.Code)
+	}
+}
+
+func Test_CircuitBreaker_HalfOpenTrialClosesOnSuccess(t *testing.T) {
+	store := NewCircuitBreakerStore(CircuitBreakerConfig{
+		ConsecutiveFailureThreshold: 1,
+		OpenDuration:                10 * time.Millisecond,
+	}, nil)
+
+	breaker := store.get("figlet")
+	breaker.recordResult(true)
+	if breaker.currentState() != BreakerOpen {
+		t.Fatalf("want the breaker open after one failure, got %s", breaker.currentState())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	handler := MakeCircuitBreakerMiddleware(okHandler, store)
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/function/figlet", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want the half-open trial to reach the handler, got %d", rec.Code)
+	}
+	if breaker.currentState() != BreakerClosed {
+		t.Fatalf("want the breaker closed after a successful trial, got %s", breaker.currentState())
+	}
+}
+
+func Test_CircuitBreaker_OpensOnFailureRate(t *testing.T) {
+	store := NewCircuitBreakerStore(CircuitBreakerConfig{FailureRateThreshold: 0.5, FailureRateWindow: 4}, nil)
+	breaker := store.get("figlet")
+
+	breaker.recordResult(true)
+	breaker.recordResult(false)
+	breaker.recordResult(true)
+	if breaker.currentState() != BreakerClosed {
+		t.Fatalf("want closed before the window fills, got %s", breaker.currentState())
+	}
+
+	breaker.recordResult(false)
+	if breaker.currentState() != BreakerOpen {
+		t.Fatalf("want open once the failure rate over the window reaches the threshold, got %s", breaker.currentState())
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_ClientIdentity_PrefersCallerIdentityHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.Header.Set("X-Caller-Identity", "api-key-1")
+	req.Header.Set("X-User-Id", "alex")
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	if got := clientIdentity(req); got != "api-key-1" {
+		t.Errorf("want X-Caller-Identity preferred, got %s", got)
+	}
+}
+
+func Test_ClientIdentity_FallsBackToUserIDThenRemoteAddr(t *testing.T) {
+	withUserID := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	withUserID.Header.Set("X-User-Id", "alex")
+	withUserID.RemoteAddr = "10.0.0.1:1234"
+	if got := clientIdentity(withUserID); got != "alex" {
+		t.Errorf("want X-User-Id used when no caller identity, got %s", got)
+	}
+
+	anonymous := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	anonymous.RemoteAddr = "10.0.0.1:1234"
+	if got := clientIdentity(anonymous); got != "10.0.0.1" {
+		t.Errorf("want source IP used as a last resort, got %s", got)
+	}
+}
+
+func Test_ClientRateLimiterStore_ThrottlesEachClientIndependently(t *testing.T) {
+	store := NewClientRateLimiterStore(1, 0)
+
+	if !store.Allow("client-a") {
+		t.Fatal("want client-a's first request admitted")
+	}
+	if store.Allow("client-a") {
+		t.Fatal("want client-a throttled once its bucket is spent")
+	}
+	if !store.Allow("client-b") {
+		t.Fatal("want client-b unaffected by client-a's throttling")
+	}
+}
+
+func Test_MakeClientRateLimitHandler_RejectsOverLimitAndCountsRejection(t *testing.T) {
+	store := NewClientRateLimiterStore(1, 0)
+	rejections := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_client_rate_limit_rejections"})
+
+	called := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		called++
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeClientRateLimitHandler(next, store, rejections)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.Header.Set("X-Caller-Identity", "client-a")
+
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want the first request admitted, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("want the second request throttled, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("want a Retry-After header on the 429")
+	}
+	if called != 1 {
+		t.Errorf("want next called exactly once, got %d", called)
+	}
+
+	m := &dto.Metric{}
+	rejections.Write(m)
+	if got := m.GetCounter().GetValue(); got != 1 {
+		t.Errorf("want one rejection recorded, got %f", got)
+	}
+}
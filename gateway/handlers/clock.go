@@ -0,0 +1,25 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import "time"
+
+// Clock abstracts time.Now and time.Sleep so scaling and caching logic can
+// be driven by something other than the wall clock - a fake that reports a
+// controlled time and returns from Sleep immediately, letting expiry,
+// polling and back-off behaviour be tested deterministically and at
+// simulated speed rather than waiting out real durations.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// realClock is the Clock every production call site defaults to.
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// RealClock is the default, wall-clock backed Clock.
+var RealClock Clock = realClock{}
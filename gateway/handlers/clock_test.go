@@ -0,0 +1,26 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import "time"
+
+// fakeClock is a Clock double: Now returns whatever was last set, and
+// Sleep returns immediately instead of actually waiting, so polling and
+// back-off tests run at full speed regardless of the durations involved.
+type fakeClock struct {
+	now      time.Time
+	slept    []time.Duration
+	sleepFor func(d time.Duration)
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.slept = append(c.slept, d)
+	if c.sleepFor != nil {
+		c.sleepFor(d)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MaxInflightLabel caps how many requests to a function the gateway will
+// forward concurrently. A request arriving once the function is already at
+// its limit is rejected immediately with 429, rather than queueing behind
+// requests that may themselves be stuck, so a slow or stuck function can't
+// back up the gateway's own connection pool.
+const MaxInflightLabel = "com.openfaas.max-inflight"
+
+// concurrencyLimitRetryAfterSeconds is the Retry-After value returned
+// alongside a 429 - deliberately short, since an in-flight slot is usually
+// freed within milliseconds to a few seconds by a function of normal
+// latency, not minutes.
+const concurrencyLimitRetryAfterSeconds = 1
+
+// InflightTracker counts in-flight requests per function, so
+// MakeConcurrencyLimitHandler can enforce MaxInflightLabel without every
+// function needing its own counter wired up by hand.
+type InflightTracker struct {
+	lock     sync.Mutex
+	inflight map[string]int
+}
+
+// NewInflightTracker creates an empty InflightTracker.
+func NewInflightTracker() *InflightTracker {
+	return &InflightTracker{
+		inflight: make(map[string]int),
+	}
+}
+
+// Acquire increments functionName's in-flight count and reports whether it
+// was done within limit - a limit of zero means unlimited. When the count is
+// already at limit, Acquire returns false and leaves the count unchanged;
+// call Release exactly once per successful Acquire to undo it.
+func (t *InflightTracker) Acquire(functionName string, limit int) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	current := t.inflight[functionName]
+	if limit > 0 && current >= limit {
+		return false
+	}
+
+	t.inflight[functionName] = current + 1
+	return true
+}
+
+// Release decrements functionName's in-flight count.
+func (t *InflightTracker) Release(functionName string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.inflight[functionName] > 0 {
+		t.inflight[functionName]--
+	}
+}
+
+// Count returns functionName's current in-flight count.
+func (t *InflightTracker) Count(functionName string) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.inflight[functionName]
+}
+
+// maxInflight parses MaxInflightLabel from labels, returning zero - meaning
+// unlimited - if it's absent, blank or not a positive integer.
+func maxInflight(labels map[string]string) int {
+	raw, exists := labels[MaxInflightLabel]
+	if !exists {
+		return 0
+	}
+
+	parsed, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || parsed <= 0 {
+		return 0
+	}
+	return parsed
+}
+
+// MakeConcurrencyLimitHandler wraps next, rejecting a request with 429 and a
+// Retry-After header once the target function already has MaxInflightLabel
+// requests in flight. A function with no such label set, or a label that
+// doesn't parse to a positive integer, is left unlimited, as before this
+// existed.
+func MakeConcurrencyLimitHandler(next http.HandlerFunc, specs *FunctionSpecStore, tracker *InflightTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := getServiceName(r.URL.String())
+
+		limit := 0
+		if spec, exists := specs.Get(functionName); exists && spec.Labels != nil {
+			limit = maxInflight(*spec.Labels)
+		}
+
+		if !tracker.Acquire(functionName, limit) {
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", concurrencyLimitRetryAfterSeconds))
+			http.Error(w, fmt.Sprintf("function %q is at its concurrency limit of %d in-flight requests", functionName, limit), http.StatusTooManyRequests)
+			return
+		}
+		defer tracker.Release(functionName)
+
+		next(w, r)
+	}
+}
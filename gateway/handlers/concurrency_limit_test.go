@@ -0,0 +1,112 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_InflightTracker_AcquireRelease(t *testing.T) {
+	tracker := NewInflightTracker()
+
+	if !tracker.Acquire("figlet", 1) {
+		t.Fatal("want first acquire under the limit to succeed")
+	}
+	if tracker.Acquire("figlet", 1) {
+		t.Fatal("want second acquire at the limit to fail")
+	}
+
+	tracker.Release("figlet")
+	if !tracker.Acquire("figlet", 1) {
+		t.Fatal("want acquire to succeed again after a release")
+	}
+}
+
+func Test_InflightTracker_ZeroLimitIsUnlimited(t *testing.T) {
+	tracker := NewInflightTracker()
+
+	for i := 0; i < 100; i++ {
+		if !tracker.Acquire("figlet", 0) {
+			t.Fatalf("want acquire %d to succeed with no limit set", i)
+		}
+	}
+}
+
+func Test_MakeConcurrencyLimitHandler_RejectsOverLimit(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{MaxInflightLabel: "1"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	tracker := NewInflightTracker()
+
+	release := make(chan struct{})
+	next := func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}
+
+	handler := MakeConcurrencyLimitHandler(next, specs, tracker)
+
+	firstDone := make(chan struct{})
+	go func() {
+		req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		close(firstDone)
+	}()
+
+	for tracker.Count("figlet") == 0 {
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("want 429 once the function is at its limit, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("want a Retry-After header on the 429")
+	}
+
+	close(release)
+	<-firstDone
+}
+
+func Test_MakeConcurrencyLimitHandler_NoLimitWhenLabelUnset(t *testing.T) {
+	specs := NewFunctionSpecStore()
+
+	tracker := NewInflightTracker()
+	var wg sync.WaitGroup
+	codes := make(chan int, 10)
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeConcurrencyLimitHandler(next, specs, tracker)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+			codes <- rr.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("want every request to succeed with no limit configured, got %d", code)
+		}
+	}
+}
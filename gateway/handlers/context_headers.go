@@ -0,0 +1,54 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MakeContextHeadersHandler wraps next so that each proxied request
+// carries a consistent set of context headers, letting a function make
+// decisions and log without an extra call back to the gateway:
+//
+//   - X-Gateway-Region: the value of region, when configured.
+//   - X-Function-Namespace: the namespace segment of the called function's
+//     name, using the name.namespace convention, when present.
+//   - X-Function-Version: the function's deployed image reference, looked
+//     up from specs, when the function was deployed through this gateway.
+//
+// X-Cold-Start (set by MakeScalingHandler) and X-Caller-Identity (set by
+// MakeAPIKeyAuthHandler) are two further context headers populated
+// earlier in the proxy chain; this handler only adds the ones it alone
+// is responsible for.
+func MakeContextHeadersHandler(next http.HandlerFunc, region string, specs *FunctionSpecStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := getServiceName(r.URL.String())
+
+		if len(region) > 0 {
+			r.Header.Set("X-Gateway-Region", region)
+		}
+
+		if namespace := functionNamespace(functionName); len(namespace) > 0 {
+			r.Header.Set("X-Function-Namespace", namespace)
+		}
+
+		if spec, exists := specs.Get(functionName); exists && len(spec.Image) > 0 {
+			r.Header.Set("X-Function-Version", spec.Image)
+		}
+
+		next(w, r)
+	}
+}
+
+// functionNamespace extracts the namespace segment from a function name
+// using the upstream name.namespace convention, e.g. "figlet.staging"
+// returns "staging". A name with no namespace segment returns "".
+func functionNamespace(functionName string) string {
+	idx := strings.LastIndex(functionName, ".")
+	if idx == -1 {
+		return ""
+	}
+	return functionName[idx+1:]
+}
@@ -0,0 +1,68 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_MakeContextHeadersHandler_InjectsRegionNamespaceAndVersion(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	specs.Set("figlet.staging", requests.CreateFunctionRequest{Service: "figlet.staging", Image: "figlet:0.2"})
+
+	var gotRegion, gotNamespace, gotVersion string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotRegion = r.Header.Get("X-Gateway-Region")
+		gotNamespace = r.Header.Get("X-Function-Namespace")
+		gotVersion = r.Header.Get("X-Function-Version")
+	}
+
+	handler := MakeContextHeadersHandler(next, "eu-west-1", specs)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet.staging", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if gotRegion != "eu-west-1" {
+		t.Errorf("want X-Gateway-Region eu-west-1, got %q", gotRegion)
+	}
+	if gotNamespace != "staging" {
+		t.Errorf("want X-Function-Namespace staging, got %q", gotNamespace)
+	}
+	if gotVersion != "figlet:0.2" {
+		t.Errorf("want X-Function-Version figlet:0.2, got %q", gotVersion)
+	}
+}
+
+func Test_MakeContextHeadersHandler_OmitsHeadersWhenUnset(t *testing.T) {
+	specs := NewFunctionSpecStore()
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if len(r.Header.Get("X-Gateway-Region")) != 0 {
+			t.Error("want no X-Gateway-Region when region is unset")
+		}
+		if len(r.Header.Get("X-Function-Namespace")) != 0 {
+			t.Error("want no X-Function-Namespace for an unnamespaced function")
+		}
+		if len(r.Header.Get("X-Function-Version")) != 0 {
+			t.Error("want no X-Function-Version for an unknown function")
+		}
+	}
+
+	handler := MakeContextHeadersHandler(next, "", specs)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas/gateway/queue"
+)
+
+// DeadLetterEntry is one async invocation that exhausted its retry
+// attempts, kept with enough of the original request to be requeued by
+// hand once whatever was failing it is fixed.
+type DeadLetterEntry struct {
+	CallID        string         `json:"callId"`
+	Request       *queue.Request `json:"request"`
+	Attempts      uint           `json:"attempts"`
+	FailureReason string         `json:"failureReason"`
+	FailedAt      time.Time      `json:"failedAt"`
+}
+
+// DeadLetterStore holds exhausted async invocations by call ID, so an
+// operator can inspect and requeue them through /system/async/dead-letter.
+type DeadLetterStore struct {
+	lock    sync.RWMutex
+	entries map[string]DeadLetterEntry
+}
+
+// NewDeadLetterStore creates an empty DeadLetterStore.
+func NewDeadLetterStore() *DeadLetterStore {
+	return &DeadLetterStore{
+		entries: make(map[string]DeadLetterEntry),
+	}
+}
+
+// Add records entry, replacing any previous entry for the same CallID.
+func (s *DeadLetterStore) Add(entry DeadLetterEntry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entries[entry.CallID] = entry
+}
+
+// List returns every dead-lettered entry, in no particular order.
+func (s *DeadLetterStore) List() []DeadLetterEntry {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	entries := make([]DeadLetterEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Expired returns the call IDs of every entry that failed at least
+// retention ago, as of now.
+func (s *DeadLetterStore) Expired(retention time.Duration, now time.Time) []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var expired []string
+	for callID, entry := range s.entries {
+		if now.Sub(entry.FailedAt) >= retention {
+			expired = append(expired, callID)
+		}
+	}
+	return expired
+}
+
+// Remove deletes and returns callID's entry, reporting whether it existed.
+func (s *DeadLetterStore) Remove(callID string) (DeadLetterEntry, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, exists := s.entries[callID]
+	if exists {
+		delete(s.entries, callID)
+	}
+	return entry, exists
+}
+
+// MakeListDeadLetterHandler lists every dead-lettered async invocation,
+// GET /system/async/dead-letter.
+func MakeListDeadLetterHandler(store *DeadLetterStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(store.List())
+	}
+}
+
+// MakeRequeueDeadLetterHandler re-publishes a dead-lettered invocation onto
+// canQueueRequests and removes it from store, POST
+// /system/async/dead-letter/{callId}/requeue.
+func MakeRequeueDeadLetterHandler(store *DeadLetterStore, canQueueRequests queue.CanQueueRequests) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		callID := mux.Vars(r)["callId"]
+
+		entry, exists := store.Remove(callID)
+		if !exists {
+			http.Error(w, "dead-lettered call not found", http.StatusNotFound)
+			return
+		}
+
+		if err := canQueueRequests.Queue(entry.Request); err != nil {
+			store.Add(entry)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas/gateway/queue"
+)
+
+func Test_DeadLetterStore_AddListRemove(t *testing.T) {
+	store := NewDeadLetterStore()
+
+	store.Add(DeadLetterEntry{CallID: "call-1", Request: &queue.Request{Function: "figlet"}})
+
+	entries := store.List()
+	if len(entries) != 1 || entries[0].CallID != "call-1" {
+		t.Fatalf("want one entry for call-1, got %+v", entries)
+	}
+
+	entry, exists := store.Remove("call-1")
+	if !exists || entry.CallID != "call-1" {
+		t.Fatalf("want Remove to return call-1's entry")
+	}
+
+	if len(store.List()) != 0 {
+		t.Fatal("want the store to be empty after Remove")
+	}
+
+	if _, exists := store.Remove("call-1"); exists {
+		t.Fatal("want a second Remove of the same call to report it missing")
+	}
+}
+
+func Test_MakeRequeueDeadLetterHandler_RequeuesAndRemovesEntry(t *testing.T) {
+	store := NewDeadLetterStore()
+	store.Add(DeadLetterEntry{CallID: "call-1", Request: &queue.Request{Function: "figlet"}})
+
+	provider := &fakeQueueProvider{}
+	handler := MakeRequeueDeadLetterHandler(store, provider)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/async/dead-letter/{callId}/requeue", handler).Methods(http.MethodPost)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/async/dead-letter/call-1/requeue", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("want status 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(provider.queued) != 1 {
+		t.Fatalf("want the entry to be republished, got %d", len(provider.queued))
+	}
+	if len(store.List()) != 0 {
+		t.Fatal("want the entry removed from the store after a successful requeue")
+	}
+}
+
+func Test_MakeRequeueDeadLetterHandler_404sOnUnknownCallID(t *testing.T) {
+	store := NewDeadLetterStore()
+	handler := MakeRequeueDeadLetterHandler(store, &fakeQueueProvider{})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/async/dead-letter/{callId}/requeue", handler).Methods(http.MethodPost)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/async/dead-letter/unknown/requeue", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("want status 404, got %d", rr.Code)
+	}
+}
+
+func Test_MakeRequeueDeadLetterHandler_RestoresEntryOnRequeueFailure(t *testing.T) {
+	store := NewDeadLetterStore()
+	store.Add(DeadLetterEntry{CallID: "call-1", Request: &queue.Request{Function: "figlet"}})
+
+	provider := &fakeQueueProvider{err: errors.New("queue is down")}
+	handler := MakeRequeueDeadLetterHandler(store, provider)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/async/dead-letter/{callId}/requeue", handler).Methods(http.MethodPost)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/async/dead-letter/call-1/requeue", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("want status 500, got %d", rr.Code)
+	}
+	if len(store.List()) != 1 {
+		t.Fatal("want the entry restored to the store after a failed requeue")
+	}
+}
+
+func Test_DeadLetterStore_Expired(t *testing.T) {
+	store := NewDeadLetterStore()
+	store.Add(DeadLetterEntry{CallID: "call-1", FailedAt: time.Now()})
+
+	now := time.Now()
+
+	if expired := store.Expired(time.Hour, now); len(expired) != 0 {
+		t.Errorf("want nothing expired with a retention that hasn't elapsed yet, got %v", expired)
+	}
+
+	expired := store.Expired(-time.Second, now)
+	if len(expired) != 1 || expired[0] != "call-1" {
+		t.Fatalf("want call-1 expired, got %v", expired)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"time"
+)
+
+// DebugSample captures a sampled invocation's request/response for offline
+// inspection, e.g. when tracking down a malformed payload in production.
+type DebugSample struct {
+	Timestamp       time.Time   `json:"timestamp"`
+	FunctionName    string      `json:"functionName"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	StatusCode      int         `json:"statusCode"`
+	RequestHeaders  http.Header `json:"requestHeaders"`
+	RequestBody     string      `json:"requestBody"`
+	ResponseHeaders http.Header `json:"responseHeaders"`
+	ResponseBody    string      `json:"responseBody"`
+}
+
+// DebugSink receives sampled invocations.
+type DebugSink interface {
+	Write(sample DebugSample) error
+}
+
+// FileDebugSink appends sampled invocations as newline-delimited JSON to a file.
+type FileDebugSink struct {
+	Path string
+
+	lock sync.Mutex
+}
+
+// Write appends sample to the sink's file as a single line of JSON.
+func (f *FileDebugSink) Write(sample DebugSample) error {
+	bytesOut, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	bytesOut = append(bytesOut, '\n')
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(bytesOut)
+	return err
+}
+
+// SamplingConfig controls how invocations are sampled to a DebugSink.
+type SamplingConfig struct {
+	// Rate is the fraction of requests to sample, between 0 and 1.
+	Rate float64
+
+	// MaxBodyBytes truncates sampled request/response bodies to this many bytes.
+	MaxBodyBytes int
+
+	// Sink receives sampled invocations. Sampling is disabled when nil.
+	Sink DebugSink
+
+	// Redact is applied to a sampled invocation's headers and body before
+	// it reaches the sink, e.g. to strip PII or credentials.
+	Redact func(headers http.Header, body []byte) (http.Header, []byte)
+}
+
+// MakeSamplingHandler wraps next so that config.Rate of invocations have
+// their request/response headers and a truncated body written to
+// config.Sink, for debugging malformed payloads without logging all
+// production traffic.
+func MakeSamplingHandler(next http.HandlerFunc, config SamplingConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if config.Rate <= 0 || config.Sink == nil || rand.Float64() >= config.Rate {
+			next(w, r)
+			return
+		}
+
+		var requestBody []byte
+		if r.Body != nil {
+			requestBody, _ = ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			r.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+		}
+
+		recorder := httptest.NewRecorder()
+		next(recorder, r)
+
+		for k, v := range recorder.HeaderMap {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(recorder.Code)
+		w.Write(recorder.Body.Bytes())
+
+		reqHeaders, reqBody := config.truncateAndRedact(r.Header, requestBody)
+		respHeaders, respBody := config.truncateAndRedact(recorder.HeaderMap, recorder.Body.Bytes())
+
+		sample := DebugSample{
+			Timestamp:       time.Now(),
+			FunctionName:    getServiceName(r.URL.Path),
+			Method:          r.Method,
+			URL:             r.URL.String(),
+			StatusCode:      recorder.Code,
+			RequestHeaders:  reqHeaders,
+			RequestBody:     string(reqBody),
+			ResponseHeaders: respHeaders,
+			ResponseBody:    string(respBody),
+		}
+
+		config.Sink.Write(sample)
+	}
+}
+
+func (config SamplingConfig) truncateAndRedact(headers http.Header, body []byte) (http.Header, []byte) {
+	truncated := body
+	if config.MaxBodyBytes > 0 && len(truncated) > config.MaxBodyBytes {
+		truncated = truncated[:config.MaxBodyBytes]
+	}
+
+	if config.Redact != nil {
+		return config.Redact(headers, truncated)
+	}
+
+	return headers, truncated
+}
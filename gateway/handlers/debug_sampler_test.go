@@ -0,0 +1,66 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingSink struct {
+	samples []DebugSample
+}
+
+func (r *recordingSink) Write(sample DebugSample) error {
+	r.samples = append(r.samples, sample)
+	return nil
+}
+
+func Test_SamplingHandler_AlwaysSamplesAtFullRate(t *testing.T) {
+	sink := &recordingSink{}
+
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("pong"))
+	}
+
+	handler := MakeSamplingHandler(upstream, SamplingConfig{Rate: 1, MaxBodyBytes: 1024, Sink: sink})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/echo", strings.NewReader("ping"))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK || rr.Body.String() != "pong" {
+		t.Fatalf("want passthrough response, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	if len(sink.samples) != 1 {
+		t.Fatalf("want one sample recorded, got %d", len(sink.samples))
+	}
+
+	sample := sink.samples[0]
+	if sample.FunctionName != "echo" || sample.RequestBody != "ping" || sample.ResponseBody != "pong" {
+		t.Errorf("want sample of echo request/response, got %+v", sample)
+	}
+}
+
+func Test_SamplingHandler_NeverSamplesAtZeroRate(t *testing.T) {
+	sink := &recordingSink{}
+
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	handler := MakeSamplingHandler(upstream, SamplingConfig{Rate: 0, Sink: sink})
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if len(sink.samples) != 0 {
+		t.Errorf("want no samples at rate 0, got %d", len(sink.samples))
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stubServiceQuery struct {
+	responses []ServiceQueryResponse
+	err       error
+	calls     int
+}
+
+func (s *stubServiceQuery) GetReplicas(service string) (ServiceQueryResponse, error) {
+	if s.err != nil {
+		return ServiceQueryResponse{}, s.err
+	}
+	response := s.responses[s.calls]
+	if s.calls < len(s.responses)-1 {
+		s.calls++
+	}
+	return response, nil
+}
+
+func (s *stubServiceQuery) SetReplicas(service string, count uint64) error {
+	return nil
+}
+
+func readSSEEvents(t *testing.T, body string) []DeployProgressEvent {
+	t.Helper()
+
+	events := []DeployProgressEvent{}
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var event DeployProgressEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			t.Fatalf("unexpected error decoding event %q: %s", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func Test_MakeDeployProgressHandler_PassesThroughWithoutOptIn(t *testing.T) {
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusAccepted)
+	}
+
+	handler := MakeDeployProgressHandler(next, &stubServiceQuery{}, time.Millisecond, 1)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", bytes.NewReader([]byte(`{"service":"figlet"}`)))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next called when caller did not opt into streaming")
+	}
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("want status %d, got %d", http.StatusAccepted, rec.Code)
+	}
+	if strings.Contains(rec.Header().Get("Content-Type"), "text/event-stream") {
+		t.Error("want no event-stream content type when caller did not opt in")
+	}
+}
+
+func Test_MakeDeployProgressHandler_StreamsUntilReady(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}
+
+	serviceQuery := &stubServiceQuery{
+		responses: []ServiceQueryResponse{
+			{AvailableReplicas: 0},
+			{AvailableReplicas: 1},
+		},
+	}
+
+	handler := MakeDeployProgressHandler(next, serviceQuery, time.Millisecond, 10)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions?stream=true", bytes.NewReader([]byte(`{"service":"figlet"}`)))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	events := readSSEEvents(t, rec.Body.String())
+	if len(events) != 3 {
+		t.Fatalf("want 3 events (accepted, scaling, ready), got %d: %+v", len(events), events)
+	}
+	if events[0].Stage != "accepted" {
+		t.Errorf("want first event accepted, got %s", events[0].Stage)
+	}
+	if events[1].Stage != "scaling" {
+		t.Errorf("want second event scaling, got %s", events[1].Stage)
+	}
+	if events[2].Stage != "ready" || events[2].AvailableReplicas != 1 {
+		t.Errorf("want final event ready with 1 replica, got %+v", events[2])
+	}
+}
+
+func Test_MakeDeployProgressHandler_TimesOutWhenNeverReady(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}
+
+	serviceQuery := &stubServiceQuery{responses: []ServiceQueryResponse{{AvailableReplicas: 0}}}
+
+	handler := MakeDeployProgressHandler(next, serviceQuery, time.Millisecond, 2)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", bytes.NewReader([]byte(`{"service":"figlet"}`)))
+	req.Header.Set("Accept", "text/event-stream")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	events := readSSEEvents(t, rec.Body.String())
+	last := events[len(events)-1]
+	if last.Stage != "timeout" {
+		t.Errorf("want final event timeout, got %s", last.Stage)
+	}
+}
+
+func Test_MakeDeployProgressHandler_EmitsFailedWhenRejected(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid image", http.StatusBadRequest)
+	}
+
+	handler := MakeDeployProgressHandler(next, &stubServiceQuery{}, time.Millisecond, 1)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions?stream=true", bytes.NewReader([]byte(`{"service":"figlet"}`)))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	events := readSSEEvents(t, rec.Body.String())
+	if len(events) != 1 || events[0].Stage != "failed" {
+		t.Fatalf("want a single failed event, got %+v", events)
+	}
+}
+
+func Test_MakeDeployProgressHandler_FallsBackWhenBodyUndecodable(t *testing.T) {
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusAccepted)
+	}
+
+	handler := MakeDeployProgressHandler(next, &stubServiceQuery{}, time.Millisecond, 1)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions?stream=true", bytes.NewReader([]byte(`not-json`)))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want fallback to next when request body doesn't decode")
+	}
+}
+
+func Test_WantsDeployProgressStream(t *testing.T) {
+	cases := []struct {
+		query  string
+		accept string
+		want   bool
+	}{
+		{query: "stream=true", want: true},
+		{accept: "text/event-stream", want: true},
+		{want: false},
+	}
+
+	for _, testCase := range cases {
+		url := "/system/functions"
+		if testCase.query != "" {
+			url = fmt.Sprintf("%s?%s", url, testCase.query)
+		}
+		req := httptest.NewRequest(http.MethodPost, url, nil)
+		if testCase.accept != "" {
+			req.Header.Set("Accept", testCase.accept)
+		}
+		if got := wantsDeployProgressStream(req); got != testCase.want {
+			t.Errorf("wantsDeployProgressStream(%+v) = %v, want %v", testCase, got, testCase.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// DRReplicator continuously mirrors function specs (not traffic) from this
+// gateway's own /system/functions to a standby cluster's gateway, so a
+// disaster-recovery failover only needs a DNS change rather than a
+// redeploy from scratch.
+//
+// It replicates whatever fields the gateway's own API already exposes on
+// requests.Function (image, env process, labels, annotations); anything a
+// given provider needs beyond that to redeploy (secrets, volumes) is
+// outside what this gateway's API surfaces, so it isn't replicated.
+type DRReplicator struct {
+	SourceURL string
+
+	TargetURL      string
+	TargetUsername string
+	TargetPassword string
+
+	Client *http.Client
+}
+
+// ReplicateOnce lists the functions currently known to SourceURL and pushes
+// each one to TargetURL. A failure pushing one function is logged and does
+// not stop the others from being replicated; it returns the count of
+// functions successfully pushed, plus the first listing error encountered,
+// if any.
+func (d *DRReplicator) ReplicateOnce() (int, error) {
+	functions, err := d.listFunctions()
+	if err != nil {
+		return 0, fmt.Errorf("unable to list functions from %s: %w", d.SourceURL, err)
+	}
+
+	replicated := 0
+	for _, function := range functions {
+		if err := d.replicateFunction(function); err != nil {
+			log.Printf("dr replication: unable to replicate %s to %s: %s", function.Name, d.TargetURL, err.Error())
+			continue
+		}
+		replicated++
+	}
+
+	return replicated, nil
+}
+
+func (d *DRReplicator) listFunctions() ([]requests.Function, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(d.SourceURL, "/")+"/system/functions", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := d.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	bytesOut, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var functions []requests.Function
+	if err := json.Unmarshal(bytesOut, &functions); err != nil {
+		return nil, err
+	}
+
+	return functions, nil
+}
+
+func (d *DRReplicator) replicateFunction(function requests.Function) error {
+	bodyBytes, err := json.Marshal(function)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, strings.TrimSuffix(d.TargetURL, "/")+"/system/functions", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(d.TargetUsername) > 0 {
+		req.SetBasicAuth(d.TargetUsername, d.TargetPassword)
+	}
+
+	res, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("standby gateway returned %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// Start runs ReplicateOnce every interval until the returned func is
+// called.
+func (d *DRReplicator) Start(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if replicated, err := d.ReplicateOnce(); err != nil {
+					log.Printf("dr replication: %s", err.Error())
+				} else {
+					log.Printf("dr replication: replicated %d function(s) to %s", replicated, d.TargetURL)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
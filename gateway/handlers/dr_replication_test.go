@@ -0,0 +1,87 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_DRReplicator_ReplicateOnce_MirrorsFunctionsToStandby(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		functions := []requests.Function{
+			{Name: "echo", Image: "functions/echo:latest"},
+		}
+		bytesOut, _ := json.Marshal(functions)
+		w.Write(bytesOut)
+	}))
+	defer source.Close()
+
+	var replicatedName string
+	standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var function requests.Function
+		json.NewDecoder(r.Body).Decode(&function)
+		replicatedName = function.Name
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer standby.Close()
+
+	replicator := &DRReplicator{
+		SourceURL: source.URL,
+		TargetURL: standby.URL,
+		Client:    http.DefaultClient,
+	}
+
+	replicated, err := replicator.ReplicateOnce()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if replicated != 1 {
+		t.Errorf("want 1 function replicated, got %d", replicated)
+	}
+	if replicatedName != "echo" {
+		t.Errorf("want echo replicated to standby, got %s", replicatedName)
+	}
+}
+
+func Test_DRReplicator_ReplicateOnce_ContinuesPastAFailedFunction(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		functions := []requests.Function{
+			{Name: "echo"},
+			{Name: "nodeinfo"},
+		}
+		bytesOut, _ := json.Marshal(functions)
+		w.Write(bytesOut)
+	}))
+	defer source.Close()
+
+	standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var function requests.Function
+		json.NewDecoder(r.Body).Decode(&function)
+		if function.Name == "echo" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer standby.Close()
+
+	replicator := &DRReplicator{
+		SourceURL: source.URL,
+		TargetURL: standby.URL,
+		Client:    http.DefaultClient,
+	}
+
+	replicated, err := replicator.ReplicateOnce()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if replicated != 1 {
+		t.Errorf("want 1 function replicated after the other failed, got %d", replicated)
+	}
+}
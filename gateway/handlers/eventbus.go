@@ -0,0 +1,86 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is a single item published onto the gateway's system event bus,
+// e.g. a deployment, a scaling decision, an invocation summary or an alert.
+type Event struct {
+	Type         string      `json:"type"`
+	FunctionName string      `json:"functionName,omitempty"`
+	Data         interface{} `json:"data,omitempty"`
+	Timestamp    time.Time   `json:"timestamp"`
+}
+
+// EventBus fans out published events to any number of subscribers, each
+// with its own filter, backing real-time dashboards and ChatOps bots.
+type EventBus struct {
+	sync.Mutex
+	nextID      int
+	subscribers map[int]*eventSubscription
+}
+
+type eventSubscription struct {
+	filter func(Event) bool
+	ch     chan Event
+}
+
+// NewEventBus creates an empty EventBus.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[int]*eventSubscription),
+	}
+}
+
+// Publish stamps event with the current time, unless it already carries a
+// non-zero Timestamp, and delivers it to every
+// subscriber whose filter matches. Slow subscribers are skipped rather than
+// blocking the publisher.
+func (b *EventBus) Publish(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	for _, sub := range b.subscribers {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a new subscriber. filter may be nil to receive every
+// event. The returned channel is closed by Unsubscribe.
+func (b *EventBus) Subscribe(filter func(Event) bool) (id int, events <-chan Event) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.nextID++
+	id = b.nextID
+	ch := make(chan Event, 32)
+	b.subscribers[id] = &eventSubscription{filter: filter, ch: ch}
+
+	return id, ch
+}
+
+// Unsubscribe removes a subscriber and closes its channel.
+func (b *EventBus) Unsubscribe(id int) {
+	b.Lock()
+	defer b.Unlock()
+
+	if sub, exists := b.subscribers[id]; exists {
+		close(sub.ch)
+		delete(b.subscribers, id)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import "testing"
+
+func Test_EventBus_PublishDeliversToMatchingSubscriber(t *testing.T) {
+	bus := NewEventBus()
+
+	_, events := bus.Subscribe(func(e Event) bool { return e.Type == "deployment" })
+
+	bus.Publish(Event{Type: "scaling", FunctionName: "echo"})
+	bus.Publish(Event{Type: "deployment", FunctionName: "echo"})
+
+	select {
+	case e := <-events:
+		if e.Type != "deployment" {
+			t.Errorf("want deployment event, got %s", e.Type)
+		}
+	default:
+		t.Fatal("expected a deployment event to be delivered")
+	}
+}
+
+func Test_EventBus_Unsubscribe(t *testing.T) {
+	bus := NewEventBus()
+
+	id, events := bus.Subscribe(nil)
+	bus.Unsubscribe(id)
+
+	if _, open := <-events; open {
+		t.Error("expected channel to be closed after Unsubscribe")
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ExperimentVariant is one named branch of an Experiment, with a relative
+// Weight used to split traffic between variants.
+type ExperimentVariant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// Experiment assigns invocations of FunctionName to one of Variants,
+// deterministically keyed by AssignmentKey so the same caller keeps seeing
+// the same variant for the lifetime of the experiment.
+type Experiment struct {
+	Name string `json:"name"`
+
+	FunctionName string `json:"functionName"`
+
+	Variants []ExperimentVariant `json:"variants"`
+
+	// AssignmentKey names the request header used to derive a stable
+	// identity for variant assignment, e.g. "X-User-Id". If the header is
+	// absent on a given request, RemoteAddr is used instead.
+	AssignmentKey string `json:"assignmentKey"`
+}
+
+// ExperimentStore holds the experiments currently configured per function,
+// keyed by function name since only one experiment can run against a given
+// function at a time.
+type ExperimentStore struct {
+	lock        sync.RWMutex
+	experiments map[string]Experiment
+}
+
+// NewExperimentStore creates an empty ExperimentStore.
+func NewExperimentStore() *ExperimentStore {
+	return &ExperimentStore{
+		experiments: make(map[string]Experiment),
+	}
+}
+
+// Set registers or replaces the experiment running against its
+// FunctionName.
+func (s *ExperimentStore) Set(experiment Experiment) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.experiments[experiment.FunctionName] = experiment
+}
+
+// Get returns the experiment configured for functionName, if any.
+func (s *ExperimentStore) Get(functionName string) (Experiment, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	experiment, exists := s.experiments[functionName]
+	return experiment, exists
+}
+
+// Delete removes the experiment configured for functionName, if any.
+func (s *ExperimentStore) Delete(functionName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.experiments, functionName)
+}
+
+// List returns every configured experiment.
+func (s *ExperimentStore) List() []Experiment {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	experiments := make([]Experiment, 0, len(s.experiments))
+	for _, experiment := range s.experiments {
+		experiments = append(experiments, experiment)
+	}
+	return experiments
+}
+
+// assignVariant deterministically maps key into one of variants, weighted
+// by each variant's Weight. Variants with a total weight of zero fall back
+// to an even split.
+func assignVariant(variants []ExperimentVariant, key string) string {
+	if len(variants) == 0 {
+		return ""
+	}
+
+	totalWeight := 0
+	for _, variant := range variants {
+		totalWeight += variant.Weight
+	}
+	evenSplit := totalWeight <= 0
+	if evenSplit {
+		totalWeight = len(variants)
+	}
+
+	hash := sha1.Sum([]byte(key))
+	bucket := int(binary.BigEndian.Uint32(hash[:4]) % uint32(totalWeight))
+
+	cursor := 0
+	for _, variant := range variants {
+		weight := variant.Weight
+		if evenSplit {
+			weight = 1
+		}
+		cursor += weight
+		if bucket < cursor {
+			return variant.Name
+		}
+	}
+
+	return variants[len(variants)-1].Name
+}
+
+// MakeExperimentsHandler wraps next so that, when an experiment is
+// configured for the invoked function, the caller is deterministically
+// assigned a variant, recorded as the X-Experiment-Variant header on the
+// upstream request and counted in assignments.
+func MakeExperimentsHandler(next http.HandlerFunc, store *ExperimentStore, assignments *prometheus.CounterVec) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := mux.Vars(r)["name"]
+		if functionName == "" {
+			functionName = getServiceName(r.URL.Path)
+		}
+
+		experiment, exists := store.Get(functionName)
+		if !exists {
+			next(w, r)
+			return
+		}
+
+		key := r.Header.Get(experiment.AssignmentKey)
+		if key == "" {
+			key = r.RemoteAddr
+		}
+
+		variant := assignVariant(experiment.Variants, key)
+		if variant != "" {
+			r.Header.Set("X-Experiment-Variant", variant)
+			if assignments != nil {
+				assignments.WithLabelValues(experiment.Name, functionName, variant).Inc()
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// MakeListExperimentsHandler returns every configured experiment as JSON.
+func MakeListExperimentsHandler(store *ExperimentStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bytesOut, err := json.Marshal(store.List())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(bytesOut)
+	}
+}
+
+// MakeSetExperimentHandler decodes an Experiment from the request body and
+// registers it against its FunctionName.
+func MakeSetExperimentHandler(store *ExperimentStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var experiment Experiment
+		if err := json.NewDecoder(r.Body).Decode(&experiment); err != nil {
+			http.Error(w, "invalid experiment", http.StatusBadRequest)
+			return
+		}
+
+		if len(experiment.FunctionName) == 0 || len(experiment.Variants) == 0 {
+			http.Error(w, "functionName and variants are required", http.StatusBadRequest)
+			return
+		}
+
+		store.Set(experiment)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeDeleteExperimentHandler removes the experiment running against the
+// {name} function.
+func MakeDeleteExperimentHandler(store *ExperimentStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := mux.Vars(r)["name"]
+		store.Delete(functionName)
+		w.WriteHeader(http.StatusOK)
+	}
+}
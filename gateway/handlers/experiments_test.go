@@ -0,0 +1,75 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_AssignVariant_IsDeterministicForTheSameKey(t *testing.T) {
+	variants := []ExperimentVariant{
+		{Name: "control", Weight: 1},
+		{Name: "treatment", Weight: 1},
+	}
+
+	first := assignVariant(variants, "user-1")
+	second := assignVariant(variants, "user-1")
+
+	if first != second {
+		t.Errorf("want same variant for the same key, got %s then %s", first, second)
+	}
+}
+
+func Test_ExperimentsHandler_SetsVariantHeaderWhenExperimentConfigured(t *testing.T) {
+	store := NewExperimentStore()
+	store.Set(Experiment{
+		Name:          "echo-experiment",
+		FunctionName:  "echo",
+		AssignmentKey: "X-User-Id",
+		Variants: []ExperimentVariant{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 1},
+		},
+	})
+
+	var seenVariant string
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		seenVariant = r.Header.Get("X-Experiment-Variant")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	handler := MakeExperimentsHandler(upstream, store, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	req.Header.Set("X-User-Id", "user-42")
+	rr := httptest.NewRecorder()
+
+	handler(rr, req)
+
+	if seenVariant == "" {
+		t.Errorf("want variant assigned, got none")
+	}
+}
+
+func Test_ExperimentsHandler_NoopWhenNoExperimentConfigured(t *testing.T) {
+	store := NewExperimentStore()
+
+	var seenVariant string
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		seenVariant = r.Header.Get("X-Experiment-Variant")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	handler := MakeExperimentsHandler(upstream, store, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if seenVariant != "" {
+		t.Errorf("want no variant assigned, got %s", seenVariant)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ExternalScaler decides the desired replica count for a function, allowing
+// bespoke scaling logic to be plugged into the gateway without modifying it.
+type ExternalScaler interface {
+	GetDesiredReplicas(functionName string, load ServiceQueryResponse) (uint64, error)
+}
+
+// ExternalScalerRequest is posted to the external scaler webhook so it can
+// make a scaling decision based on the current load for a function.
+type ExternalScalerRequest struct {
+	FunctionName      string `json:"functionName"`
+	Replicas          uint64 `json:"replicas"`
+	AvailableReplicas uint64 `json:"availableReplicas"`
+	MaxReplicas       uint64 `json:"maxReplicas"`
+	MinReplicas       uint64 `json:"minReplicas"`
+}
+
+// ExternalScalerResponse is the expected response from an external scaler webhook.
+type ExternalScalerResponse struct {
+	Replicas uint64 `json:"replicas"`
+}
+
+// WebhookExternalScaler calls a user-provided HTTP webhook to obtain the
+// desired replica count for a function.
+type WebhookExternalScaler struct {
+	URL     string
+	Client  *http.Client
+	Timeout time.Duration
+}
+
+// NewWebhookExternalScaler creates a WebhookExternalScaler which POSTs load
+// data to url and expects a JSON body containing the desired replica count.
+func NewWebhookExternalScaler(url string, timeout time.Duration) *WebhookExternalScaler {
+	return &WebhookExternalScaler{
+		URL:     url,
+		Client:  &http.Client{Timeout: timeout},
+		Timeout: timeout,
+	}
+}
+
+// GetDesiredReplicas calls the external scaler webhook and returns the
+// replica count it recommends for functionName.
+func (w *WebhookExternalScaler) GetDesiredReplicas(functionName string, load ServiceQueryResponse) (uint64, error) {
+	body, marshalErr := json.Marshal(ExternalScalerRequest{
+		FunctionName:      functionName,
+		Replicas:          load.Replicas,
+		AvailableReplicas: load.AvailableReplicas,
+		MaxReplicas:       load.MaxReplicas,
+		MinReplicas:       load.MinReplicas,
+	})
+	if marshalErr != nil {
+		return 0, marshalErr
+	}
+
+	req, reqErr := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if reqErr != nil {
+		return 0, reqErr
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, resErr := w.Client.Do(req)
+	if resErr != nil {
+		return 0, fmt.Errorf("external scaler webhook %s: %s", w.URL, resErr.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("external scaler webhook %s returned status %d", w.URL, res.StatusCode)
+	}
+
+	var scalerRes ExternalScalerResponse
+	if err := json.NewDecoder(res.Body).Decode(&scalerRes); err != nil {
+		return 0, fmt.Errorf("external scaler webhook %s returned invalid JSON: %s", w.URL, err.Error())
+	}
+
+	return scalerRes.Replicas, nil
+}
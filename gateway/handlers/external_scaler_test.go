@@ -0,0 +1,46 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookExternalScaler_GetDesiredReplicas(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(res, `{"replicas": 4}`)
+		}))
+	defer testServer.Close()
+
+	scaler := NewWebhookExternalScaler(testServer.URL, time.Second*5)
+
+	replicas, err := scaler.GetDesiredReplicas("burt", ServiceQueryResponse{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if replicas != 4 {
+		t.Errorf("want replicas: %d, got: %d", 4, replicas)
+	}
+}
+
+func TestWebhookExternalScaler_NonOKStatus(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(http.StatusInternalServerError)
+		}))
+	defer testServer.Close()
+
+	scaler := NewWebhookExternalScaler(testServer.URL, time.Second*5)
+
+	if _, err := scaler.GetDesiredReplicas("burt", ServiceQueryResponse{}); err == nil {
+		t.Error("expected an error for a non-200 response, got nil")
+	}
+}
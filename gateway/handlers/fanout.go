@@ -0,0 +1,276 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/uuid"
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas/gateway/queue"
+)
+
+// CallbackSignatureHeader carries the HMAC-SHA256 signature of a signed
+// callback body, formatted as "sha256=<hex>" - the same convention used by
+// GitHub and Stripe webhooks. Only set when FanOutStore was created with a
+// signing secret.
+const CallbackSignatureHeader = "X-Callback-Signature"
+
+// signCallbackBody returns secret's HMAC-SHA256 signature of body,
+// formatted for CallbackSignatureHeader.
+func signCallbackBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// FanOutRequest is the body POSTed to /system/fanout. The same Body is sent
+// to every function named in Functions; once all of them have reported a
+// result - or DeadlineSeconds has elapsed, whichever comes first - the
+// gateway POSTs a single FanOutCallback to CallbackURL.
+type FanOutRequest struct {
+	Functions       []string        `json:"functions"`
+	Body            json.RawMessage `json:"body,omitempty"`
+	CallbackURL     string          `json:"callbackUrl"`
+	DeadlineSeconds int             `json:"deadlineSeconds,omitempty"`
+}
+
+// FanOutResult is one function's contribution to a fan-out, reported back
+// to the gateway via POST /system/fanout/{id}/result/{function}.
+type FanOutResult struct {
+	FunctionName string `json:"functionName"`
+	StatusCode   int    `json:"statusCode"`
+	Body         string `json:"body,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// FanOutCallback is the payload delivered to a FanOutRequest's CallbackURL.
+// Complete is false when delivery was triggered by the deadline rather than
+// every function reporting in; Results then only covers whichever
+// functions finished in time, and Missing names the rest.
+type FanOutCallback struct {
+	ID       string         `json:"id"`
+	Complete bool           `json:"complete"`
+	Results  []FanOutResult `json:"results"`
+	Missing  []string       `json:"missing,omitempty"`
+}
+
+// fanOutAggregation tracks one in-flight fan-out's pending functions and
+// collected results until it is delivered, either because every function
+// reported in or the deadline fired.
+type fanOutAggregation struct {
+	lock        sync.Mutex
+	id          string
+	pending     map[string]bool
+	results     []FanOutResult
+	callbackURL string
+	delivered   bool
+	timer       *time.Timer
+}
+
+// FanOutStore tracks in-flight fan-out aggregations by ID.
+type FanOutStore struct {
+	lock          sync.Mutex
+	byID          map[string]*fanOutAggregation
+	httpClient    *http.Client
+	signingSecret string
+}
+
+// NewFanOutStore creates an empty FanOutStore which delivers callbacks
+// using httpClient. When signingSecret is non-empty, every callback is
+// signed - see CallbackSignatureHeader.
+func NewFanOutStore(httpClient *http.Client, signingSecret string) *FanOutStore {
+	return &FanOutStore{
+		byID:          make(map[string]*fanOutAggregation),
+		httpClient:    httpClient,
+		signingSecret: signingSecret,
+	}
+}
+
+// create registers a new aggregation awaiting a result from each of
+// functions, delivering to callbackURL once complete or after deadline.
+func (s *FanOutStore) create(functions []string, callbackURL string, deadline time.Duration) *fanOutAggregation {
+	pending := make(map[string]bool, len(functions))
+	for _, fn := range functions {
+		pending[fn] = true
+	}
+
+	agg := &fanOutAggregation{
+		id:          uuid.Generate().String(),
+		pending:     pending,
+		callbackURL: callbackURL,
+	}
+
+	s.lock.Lock()
+	s.byID[agg.id] = agg
+	s.lock.Unlock()
+
+	agg.timer = time.AfterFunc(deadline, func() {
+		s.deliver(agg)
+	})
+
+	return agg
+}
+
+// RecordResult attaches result to the aggregation named by id. Once every
+// expected function has reported in, the aggregation is delivered
+// immediately rather than waiting for its deadline. A result for an
+// unknown or already-delivered id is ignored, since its deadline may
+// already have fired.
+func (s *FanOutStore) RecordResult(id string, result FanOutResult) {
+	s.lock.Lock()
+	agg, exists := s.byID[id]
+	s.lock.Unlock()
+	if !exists {
+		return
+	}
+
+	agg.lock.Lock()
+	delete(agg.pending, result.FunctionName)
+	agg.results = append(agg.results, result)
+	done := len(agg.pending) == 0
+	agg.lock.Unlock()
+
+	if done {
+		agg.timer.Stop()
+		s.deliver(agg)
+	}
+}
+
+// deliver POSTs agg's current results to its CallbackURL, at most once.
+func (s *FanOutStore) deliver(agg *fanOutAggregation) {
+	agg.lock.Lock()
+	if agg.delivered {
+		agg.lock.Unlock()
+		return
+	}
+	agg.delivered = true
+
+	missing := make([]string, 0, len(agg.pending))
+	for fn := range agg.pending {
+		missing = append(missing, fn)
+	}
+	callback := FanOutCallback{
+		ID:       agg.id,
+		Complete: len(missing) == 0,
+		Results:  agg.results,
+		Missing:  missing,
+	}
+	agg.lock.Unlock()
+
+	s.lock.Lock()
+	delete(s.byID, agg.id)
+	s.lock.Unlock()
+
+	bodyBytes, err := json.Marshal(callback)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, agg.callbackURL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.signingSecret) > 0 {
+		req.Header.Set(CallbackSignatureHeader, signCallbackBody(s.signingSecret, bodyBytes))
+	}
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+}
+
+// MakeFanOutHandler accepts a FanOutRequest, queues an invocation of each
+// named function via canQueueRequests with its callback pointed at this
+// gateway's own /system/fanout/{id}/result/{function} endpoint, and
+// returns the aggregation ID so progress can be correlated if needed.
+func MakeFanOutHandler(canQueueRequests queue.CanQueueRequests, store *FanOutStore, publicURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		var fanOutReq FanOutRequest
+		if err := json.NewDecoder(r.Body).Decode(&fanOutReq); err != nil {
+			http.Error(w, "invalid fan-out request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(fanOutReq.Functions) == 0 {
+			http.Error(w, "functions must list at least one function", http.StatusBadRequest)
+			return
+		}
+		if len(fanOutReq.CallbackURL) == 0 {
+			http.Error(w, "callbackUrl is required", http.StatusBadRequest)
+			return
+		}
+
+		deadline := time.Duration(fanOutReq.DeadlineSeconds) * time.Second
+		if deadline <= 0 {
+			deadline = time.Minute
+		}
+
+		agg := store.create(fanOutReq.Functions, fanOutReq.CallbackURL, deadline)
+
+		for _, function := range fanOutReq.Functions {
+			resultURL := fmt.Sprintf("%s/system/fanout/%s/result/%s", strings.TrimSuffix(publicURL, "/"), agg.id, function)
+
+			callbackURL, err := url.Parse(resultURL)
+			if err != nil {
+				store.RecordResult(agg.id, FanOutResult{FunctionName: function, Error: err.Error()})
+				continue
+			}
+
+			err = canQueueRequests.Queue(&queue.Request{
+				Function:    function,
+				Body:        []byte(fanOutReq.Body),
+				Method:      http.MethodPost,
+				Header:      r.Header,
+				CallbackURL: callbackURL,
+			})
+			if err != nil {
+				store.RecordResult(agg.id, FanOutResult{FunctionName: function, Error: err.Error()})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"id": agg.id})
+	}
+}
+
+// MakeFanOutResultHandler records a single function's outcome against its
+// fan-out aggregation; the queue worker calls this instead of the caller's
+// own CallbackURL since that is handled centrally once every function
+// completes.
+func MakeFanOutResultHandler(store *FanOutStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		vars := mux.Vars(r)
+		id := vars["id"]
+		function := vars["function"]
+
+		bodyBytes, _ := ioutil.ReadAll(r.Body)
+
+		store.RecordResult(id, FanOutResult{
+			FunctionName: function,
+			StatusCode:   http.StatusOK,
+			Body:         string(bodyBytes),
+		})
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/queue"
+)
+
+type recordingQueue struct {
+	lock     sync.Mutex
+	queued   []*queue.Request
+	queueErr error
+}
+
+func (q *recordingQueue) Queue(req *queue.Request) error {
+	if q.queueErr != nil {
+		return q.queueErr
+	}
+	q.lock.Lock()
+	q.queued = append(q.queued, req)
+	q.lock.Unlock()
+	return nil
+}
+
+func Test_FanOut_DeliversCallbackOnceEveryFunctionReports(t *testing.T) {
+	var callbackBody FanOutCallback
+	done := make(chan struct{})
+	callbackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&callbackBody)
+		close(done)
+	}))
+	defer callbackServer.Close()
+
+	store := NewFanOutStore(http.DefaultClient, "")
+	rq := &recordingQueue{}
+
+	handler := MakeFanOutHandler(rq, store, "http://gateway.local")
+
+	reqBody := strings.NewReader(`{"functions":["a","b"],"callbackUrl":"` + callbackServer.URL + `"}`)
+	req := httptest.NewRequest(http.MethodPost, "/system/fanout", reqBody)
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("want 202, got %d", rec.Code)
+	}
+
+	var accepted map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &accepted)
+	id := accepted["id"]
+	if len(id) == 0 {
+		t.Fatal("want a non-empty fan-out id")
+	}
+
+	if len(rq.queued) != 2 {
+		t.Fatalf("want 2 queued invocations, got %d", len(rq.queued))
+	}
+
+	store.RecordResult(id, FanOutResult{FunctionName: "a", StatusCode: http.StatusOK, Body: "ok-a"})
+	store.RecordResult(id, FanOutResult{FunctionName: "b", StatusCode: http.StatusOK, Body: "ok-b"})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for fan-out callback")
+	}
+
+	if !callbackBody.Complete {
+		t.Error("want callback to report Complete once both functions reported")
+	}
+	if len(callbackBody.Results) != 2 {
+		t.Errorf("want 2 results, got %d", len(callbackBody.Results))
+	}
+}
+
+func Test_FanOut_DeliversPartialResultsAfterDeadline(t *testing.T) {
+	var callbackBody FanOutCallback
+	done := make(chan struct{})
+	callbackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&callbackBody)
+		close(done)
+	}))
+	defer callbackServer.Close()
+
+	store := NewFanOutStore(http.DefaultClient, "")
+	rq := &recordingQueue{}
+
+	handler := MakeFanOutHandler(rq, store, "http://gateway.local")
+
+	reqBody := strings.NewReader(`{"functions":["a","b"],"callbackUrl":"` + callbackServer.URL + `","deadlineSeconds":1}`)
+	req := httptest.NewRequest(http.MethodPost, "/system/fanout", reqBody)
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	var accepted map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &accepted)
+	id := accepted["id"]
+
+	store.RecordResult(id, FanOutResult{FunctionName: "a", StatusCode: http.StatusOK, Body: "ok-a"})
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for deadline-triggered fan-out callback")
+	}
+
+	if callbackBody.Complete {
+		t.Error("want Complete false when the deadline fires before every function reports")
+	}
+	if len(callbackBody.Missing) != 1 || callbackBody.Missing[0] != "b" {
+		t.Errorf("want missing=[b], got %v", callbackBody.Missing)
+	}
+}
+
+func Test_FanOut_SignsCallbackWhenSecretConfigured(t *testing.T) {
+	var gotBody []byte
+	var gotSignature string
+	done := make(chan struct{})
+	callbackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		gotSignature = r.Header.Get(CallbackSignatureHeader)
+		close(done)
+	}))
+	defer callbackServer.Close()
+
+	store := NewFanOutStore(http.DefaultClient, "top-secret")
+	rq := &recordingQueue{}
+	handler := MakeFanOutHandler(rq, store, "http://gateway.local")
+
+	reqBody := strings.NewReader(`{"functions":["a"],"callbackUrl":"` + callbackServer.URL + `"}`)
+	req := httptest.NewRequest(http.MethodPost, "/system/fanout", reqBody)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	var accepted map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &accepted)
+	store.RecordResult(accepted["id"], FanOutResult{FunctionName: "a", StatusCode: http.StatusOK, Body: "ok-a"})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for fan-out callback")
+	}
+
+	want := signCallbackBody("top-secret", gotBody)
+	if gotSignature != want {
+		t.Fatalf("want signature %q, got %q", want, gotSignature)
+	}
+}
+
+func Test_FanOut_RejectsRequestWithoutFunctions(t *testing.T) {
+	store := NewFanOutStore(http.DefaultClient, "")
+	rq := &recordingQueue{}
+	handler := MakeFanOutHandler(rq, store, "http://gateway.local")
+
+	req := httptest.NewRequest(http.MethodPost, "/system/fanout", strings.NewReader(`{"callbackUrl":"http://example.com"}`))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("want 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas/gateway/types"
+)
+
+// PeerGateway is another OpenFaaS gateway this one federates invocations
+// out to for /function/{name}@{cluster} requests.
+type PeerGateway struct {
+	URL      string
+	Username string
+	Password string
+}
+
+// PeerGatewayResolver looks up the peer gateway registered for a cluster
+// name.
+type PeerGatewayResolver interface {
+	Resolve(cluster string) (PeerGateway, bool)
+}
+
+// StaticPeerGateways is a PeerGatewayResolver backed by a fixed map, built
+// once from configuration at startup.
+type StaticPeerGateways map[string]PeerGateway
+
+// Resolve implements PeerGatewayResolver.
+func (p StaticPeerGateways) Resolve(cluster string) (PeerGateway, bool) {
+	peer, exists := p[cluster]
+	return peer, exists
+}
+
+// MakeFederatedProxyHandler forwards a /function/{name}@{cluster} or
+// /async-function/{name}@{cluster} request to the peer gateway registered
+// for cluster, preserving the call ID set by MakeCallIDMiddleware so traces
+// can be correlated across clusters.
+//
+// Unlike MakeForwardingProxyHandler there is no retry/buffering of a
+// temporarily unreachable peer: a federated invocation fails fast, since a
+// peer gateway being down is a cross-cluster networking concern the caller
+// needs to know about immediately rather than have masked by local retries.
+func MakeFederatedProxyHandler(resolver PeerGatewayResolver, routePrefix string, client *http.Client, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		name := vars["name"]
+		cluster := vars["cluster"]
+		params := vars["params"]
+
+		peer, exists := resolver.Resolve(cluster)
+		if !exists {
+			http.Error(w, fmt.Sprintf("no peer gateway registered for cluster %q", cluster), http.StatusBadGateway)
+			return
+		}
+
+		requestURL := routePrefix + name
+		if len(params) > 0 {
+			requestURL += "/" + strings.TrimPrefix(params, "/")
+		}
+
+		baseURL := strings.TrimSuffix(peer.URL, "/")
+
+		upstreamReq := buildUpstreamRequest(r, baseURL, requestURL, types.HeaderFilterConfig{}, ForwardedHeaderAppend)
+		if upstreamReq.Body != nil {
+			defer upstreamReq.Body.Close()
+		}
+
+		if len(peer.Username) > 0 {
+			upstreamReq.SetBasicAuth(peer.Username, peer.Password)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		res, err := client.Do(upstreamReq.WithContext(ctx))
+		if err != nil {
+			statusCode, errorCode := classifyUpstreamError(err)
+			writeUpstreamError(w, statusCode, errorCode, err)
+			return
+		}
+		defer res.Body.Close()
+
+		copyHeaders(w.Header(), &res.Header)
+		w.WriteHeader(res.StatusCode)
+		io.CopyBuffer(w, res.Body, nil)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func Test_FederatedProxyHandler_ForwardsToPeerGateway(t *testing.T) {
+	peerGateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/function/echo" {
+			t.Errorf("want peer request to /function/echo, got %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("hello from peer"))
+	}))
+	defer peerGateway.Close()
+
+	peers := StaticPeerGateways{
+		"cluster-b": {URL: peerGateway.URL},
+	}
+
+	handler := MakeFederatedProxyHandler(peers, "/function/", peerGateway.Client(), time.Second*5)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/function/{name:[-a-zA-Z_0-9]+}@{cluster:[-a-zA-Z_0-9]+}", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo@cluster-b", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+	if rr.Body.String() != "hello from peer" {
+		t.Errorf("want body from peer gateway, got %s", rr.Body.String())
+	}
+}
+
+func Test_FederatedProxyHandler_502sForUnknownCluster(t *testing.T) {
+	handler := MakeFederatedProxyHandler(StaticPeerGateways{}, "/function/", http.DefaultClient, time.Second)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/function/{name:[-a-zA-Z_0-9]+}@{cluster:[-a-zA-Z_0-9]+}", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo@unknown-cluster", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("want 502, got %d", rr.Code)
+	}
+}
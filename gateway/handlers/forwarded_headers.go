@@ -0,0 +1,76 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Forwarded header modes, controlling how X-Forwarded-For/Proto/Host and
+// Forwarded are populated on the upstream request depending on how much the
+// gateway trusts values a caller may have already set.
+const (
+	// ForwardedHeaderPassthrough only fills in values that are not already
+	// set, trusting an upstream load balancer that set them correctly. This
+	// is the default, matching the gateway's previous behaviour.
+	ForwardedHeaderPassthrough = "passthrough"
+
+	// ForwardedHeaderAppend appends the gateway's own view of the request
+	// onto any existing value, the conventional behaviour for a proxy that
+	// sits behind other proxies it trusts.
+	ForwardedHeaderAppend = "append"
+
+	// ForwardedHeaderReplace always overwrites any caller-supplied value
+	// with the gateway's own view of the request, for deployments where the
+	// gateway is the first trusted hop and incoming values must not be
+	// trusted.
+	ForwardedHeaderReplace = "replace"
+)
+
+// applyForwardedHeaders sets X-Forwarded-For, X-Forwarded-Proto,
+// X-Forwarded-Host and the standard Forwarded header (RFC 7239) on
+// upstreamReq, using mode to decide whether to trust headers the original
+// request, r, already carries. An unrecognised mode is treated as
+// ForwardedHeaderPassthrough.
+func applyForwardedHeaders(upstreamReq *http.Request, r *http.Request, mode string) {
+	proto := "http"
+	if r.TLS != nil {
+		proto = "https"
+	}
+
+	forwarded := fmt.Sprintf("for=%q;proto=%s;host=%q", r.RemoteAddr, proto, r.Host)
+
+	switch mode {
+	case ForwardedHeaderReplace:
+		upstreamReq.Header.Set("X-Forwarded-For", r.RemoteAddr)
+		upstreamReq.Header.Set("X-Forwarded-Proto", proto)
+		upstreamReq.Header.Set("X-Forwarded-Host", r.Host)
+		upstreamReq.Header.Set("Forwarded", forwarded)
+	case ForwardedHeaderAppend:
+		appendHeaderValue(upstreamReq.Header, "X-Forwarded-For", r.RemoteAddr)
+		appendHeaderValue(upstreamReq.Header, "Forwarded", forwarded)
+		setHeaderIfAbsent(upstreamReq.Header, "X-Forwarded-Proto", proto)
+		setHeaderIfAbsent(upstreamReq.Header, "X-Forwarded-Host", r.Host)
+	default:
+		setHeaderIfAbsent(upstreamReq.Header, "X-Forwarded-For", r.RemoteAddr)
+		setHeaderIfAbsent(upstreamReq.Header, "X-Forwarded-Proto", proto)
+		setHeaderIfAbsent(upstreamReq.Header, "X-Forwarded-Host", r.Host)
+		setHeaderIfAbsent(upstreamReq.Header, "Forwarded", forwarded)
+	}
+}
+
+func setHeaderIfAbsent(headers http.Header, name string, value string) {
+	if headers.Get(name) == "" {
+		headers.Set(name, value)
+	}
+}
+
+func appendHeaderValue(headers http.Header, name string, value string) {
+	if existing := headers.Get(name); existing != "" {
+		headers.Set(name, existing+", "+value)
+		return
+	}
+	headers.Set(name, value)
+}
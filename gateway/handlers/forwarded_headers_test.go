@@ -0,0 +1,58 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func Test_ApplyForwardedHeaders_Passthrough_KeepsExistingValues(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "http://gateway/function/echo", nil)
+	r.RemoteAddr = "203.0.113.5:1234"
+
+	upstreamReq, _ := http.NewRequest(http.MethodGet, "http://xyz:8080/", nil)
+	upstreamReq.Header.Set("X-Forwarded-For", "10.0.0.1")
+
+	applyForwardedHeaders(upstreamReq, r, ForwardedHeaderPassthrough)
+
+	if upstreamReq.Header.Get("X-Forwarded-For") != "10.0.0.1" {
+		t.Errorf("want existing X-Forwarded-For kept, got %s", upstreamReq.Header.Get("X-Forwarded-For"))
+	}
+	if upstreamReq.Header.Get("X-Forwarded-Host") != r.Host {
+		t.Errorf("want X-Forwarded-Host filled in, got %s", upstreamReq.Header.Get("X-Forwarded-Host"))
+	}
+	if upstreamReq.Header.Get("Forwarded") == "" {
+		t.Errorf("want Forwarded header set")
+	}
+}
+
+func Test_ApplyForwardedHeaders_Append_AddsToExistingValue(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "http://gateway/function/echo", nil)
+	r.RemoteAddr = "203.0.113.5:1234"
+
+	upstreamReq, _ := http.NewRequest(http.MethodGet, "http://xyz:8080/", nil)
+	upstreamReq.Header.Set("X-Forwarded-For", "10.0.0.1")
+
+	applyForwardedHeaders(upstreamReq, r, ForwardedHeaderAppend)
+
+	want := "10.0.0.1, 203.0.113.5:1234"
+	if upstreamReq.Header.Get("X-Forwarded-For") != want {
+		t.Errorf("want: %s, got: %s", want, upstreamReq.Header.Get("X-Forwarded-For"))
+	}
+}
+
+func Test_ApplyForwardedHeaders_Replace_OverwritesExistingValue(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "http://gateway/function/echo", nil)
+	r.RemoteAddr = "203.0.113.5:1234"
+
+	upstreamReq, _ := http.NewRequest(http.MethodGet, "http://xyz:8080/", nil)
+	upstreamReq.Header.Set("X-Forwarded-For", "10.0.0.1")
+
+	applyForwardedHeaders(upstreamReq, r, ForwardedHeaderReplace)
+
+	if upstreamReq.Header.Get("X-Forwarded-For") != r.RemoteAddr {
+		t.Errorf("want: %s, got: %s", r.RemoteAddr, upstreamReq.Header.Get("X-Forwarded-For"))
+	}
+}
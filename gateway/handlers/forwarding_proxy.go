@@ -4,11 +4,19 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -16,6 +24,7 @@ import (
 	"time"
 
 	"github.com/openfaas/faas/gateway/metrics"
+	"github.com/openfaas/faas/gateway/tracing"
 	"github.com/openfaas/faas/gateway/types"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -33,7 +42,7 @@ const (
 
 // HTTPNotifier notify about HTTP request/response
 type HTTPNotifier interface {
-	Notify(method string, URL string, originalURL string, statusCode int, duration time.Duration)
+	Notify(method string, URL string, originalURL string, statusCode int, duration time.Duration, callID string)
 }
 
 // BaseURLResolver URL resolver for upstream requests
@@ -54,22 +63,33 @@ func MakeForwardingProxyHandler(proxy *types.HTTPClientReverseProxy, notifiers [
 
 		requestURL := urlPathTransformer.Transform(r)
 
+		traceParent := tracing.FromHeaderOrNew(r.Header.Get(tracing.TraceParentHeader))
+		var span *tracing.Span
+		if proxy.Tracer != nil {
+			span = proxy.Tracer.StartSpan("forward_request", traceParent, getServiceName(originalURL))
+		}
+
 		start := time.Now()
 
-		statusCode, err := forwardRequest(w, r, proxy.Client, baseURL, requestURL, proxy.Timeout)
+		statusCode, err := forwardRequestBuffered(w, r, proxy, baseURL, requestURL)
 
 		seconds := time.Since(start)
 		if err != nil {
 			log.Printf("error with upstream request to: %s, %s\n", requestURL, err.Error())
 		}
 
+		if span != nil {
+			span.End(proxy.Tracer.Exporter)
+		}
+
+		callID := r.Header.Get("X-Call-Id")
 		for _, notifier := range notifiers {
-			notifier.Notify(r.Method, requestURL, originalURL, statusCode, seconds)
+			notifier.Notify(r.Method, requestURL, originalURL, statusCode, seconds, callID)
 		}
 	}
 }
 
-func buildUpstreamRequest(r *http.Request, baseURL string, requestURL string) *http.Request {
+func buildUpstreamRequest(r *http.Request, baseURL string, requestURL string, filter types.HeaderFilterConfig, forwardedHeaderMode string) *http.Request {
 	url := baseURL + requestURL
 
 	if len(r.URL.RawQuery) > 0 {
@@ -78,14 +98,10 @@ func buildUpstreamRequest(r *http.Request, baseURL string, requestURL string) *h
 
 	upstreamReq, _ := http.NewRequest(r.Method, url, nil)
 
-	copyHeaders(upstreamReq.Header, &r.Header)
+	filteredHeaders := filterHeaders(r.Header, filter.AllowInboundHeaders, filter.DenyInboundHeaders)
+	copyHeaders(upstreamReq.Header, &filteredHeaders)
 
-	if len(r.Host) > 0 && upstreamReq.Header.Get("X-Forwarded-Host") == "" {
-		upstreamReq.Header["X-Forwarded-Host"] = []string{r.Host}
-	}
-	if upstreamReq.Header.Get("X-Forwarded-For") == "" {
-		upstreamReq.Header["X-Forwarded-For"] = []string{r.RemoteAddr}
-	}
+	applyForwardedHeaders(upstreamReq, r, forwardedHeaderMode)
 
 	if r.Body != nil {
 		upstreamReq.Body = r.Body
@@ -94,9 +110,175 @@ func buildUpstreamRequest(r *http.Request, baseURL string, requestURL string) *h
 	return upstreamReq
 }
 
-func forwardRequest(w http.ResponseWriter, r *http.Request, proxyClient *http.Client, baseURL string, requestURL string, timeout time.Duration) (int, error) {
+// effectiveTimeout returns defaultTimeout, unless r carries a valid,
+// positive X-Timeout-Seconds header and maxOverride is greater than zero,
+// in which case it returns the requested timeout capped at maxOverride. A
+// zero maxOverride (the default) disables the header entirely, so
+// defaultTimeout alone bounds every call as before it existed.
+func effectiveTimeout(r *http.Request, defaultTimeout time.Duration, maxOverride time.Duration) time.Duration {
+	if maxOverride <= 0 {
+		return defaultTimeout
+	}
+
+	headerValue := r.Header.Get("X-Timeout-Seconds")
+	if len(headerValue) == 0 {
+		return defaultTimeout
+	}
+
+	seconds, err := strconv.Atoi(headerValue)
+	if err != nil || seconds <= 0 {
+		return defaultTimeout
+	}
+
+	requested := time.Duration(seconds) * time.Second
+	if requested > maxOverride {
+		return maxOverride
+	}
+	return requested
+}
+
+// forwardRequestBuffered forwards a request, and if the upstream provider is
+// unreachable (e.g. briefly restarting) retries within proxy.BufferWindow
+// rather than failing immediately. This is a no-op wrapper around
+// forwardRequest when BufferWindow is zero.
+func forwardRequestBuffered(w http.ResponseWriter, r *http.Request, proxy *types.HTTPClientReverseProxy, baseURL string, requestURL string) (int, error) {
+	timeout := effectiveTimeout(r, proxy.Timeout, proxy.MaxTimeoutOverride)
+
+	if proxy.BufferWindow <= 0 {
+		return forwardRequest(w, r, proxy.Client, baseURL, requestURL, timeout, proxy.IdleStreamTimeout, proxy.FlushInterval, proxy.HeaderFilter, proxy.ForwardedHeaderMode)
+	}
+
+	retryInterval := proxy.BufferRetryInterval
+	if retryInterval <= 0 {
+		retryInterval = time.Millisecond * 250
+	}
+
+	deadline := time.Now().Add(proxy.BufferWindow)
+	buffered := false
+
+	// Read the body once up-front so it can be replayed on a retry; a
+	// consumed Reader from the first attempt would otherwise send an
+	// empty body on the next one.
+	var bodyBytes []byte
+	if r.Body != nil {
+		bodyBytes, _ = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+	}
+
+	for {
+		if bodyBytes != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(bodyBytes))
+		}
+
+		// Buffer into a recorder rather than writing straight to w, so a
+		// retried attempt doesn't leave a partial response already sent.
+		recorder := httptest.NewRecorder()
+		statusCode, err := forwardRequest(recorder, r, proxy.Client, baseURL, requestURL, timeout, proxy.IdleStreamTimeout, proxy.FlushInterval, proxy.HeaderFilter, proxy.ForwardedHeaderMode)
+
+		if err != nil && isProviderUnavailable(err) && time.Now().Before(deadline) {
+			buffered = true
+			time.Sleep(retryInterval)
+			continue
+		}
+
+		if proxy.Metrics != nil {
+			if buffered && err == nil {
+				proxy.Metrics.GatewayBufferedRequests.Inc()
+			} else if err != nil {
+				proxy.Metrics.GatewayDroppedRequests.Inc()
+			}
+		}
 
-	upstreamReq := buildUpstreamRequest(r, baseURL, requestURL)
+		copyHeaders(w.Header(), &recorder.HeaderMap)
+		w.WriteHeader(statusCode)
+		w.Write(recorder.Body.Bytes())
+
+		return statusCode, err
+	}
+}
+
+// isProviderUnavailable reports whether err looks like the provider is
+// briefly unreachable (connection refused/reset) rather than a genuine
+// upstream application error.
+func isProviderUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "connection refused") ||
+		strings.Contains(msg, "connection reset") ||
+		strings.Contains(msg, "no such host") ||
+		strings.Contains(msg, "EOF")
+}
+
+// upstreamError is the JSON body written when a request to the upstream
+// provider/function fails outright, so callers can distinguish "the
+// function is unavailable" from "the function returned an error".
+type upstreamError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// classifyUpstreamError maps a failure to reach the upstream into a
+// distinct status code and error code, rather than a blanket 500/502.
+func classifyUpstreamError(err error) (int, string) {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return http.StatusBadGateway, "RESOLUTION_FAILED"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return http.StatusGatewayTimeout, "FUNCTION_TIMEOUT"
+	}
+
+	if strings.Contains(err.Error(), "connection refused") {
+		return http.StatusServiceUnavailable, "FUNCTION_UNAVAILABLE"
+	}
+
+	return http.StatusBadGateway, "FUNCTION_UNREACHABLE"
+}
+
+// writeUpstreamError writes statusCode along with a JSON body describing
+// errorCode, so clients can branch on the failure without parsing text.
+func writeUpstreamError(w http.ResponseWriter, statusCode int, errorCode string, err error) {
+	bytesOut, marshalErr := json.Marshal(upstreamError{Code: errorCode, Message: err.Error()})
+	if marshalErr != nil {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(bytesOut)
+}
+
+// withUpstreamServerTiming attaches an httptrace.ClientTrace to ctx that
+// records the "upstream-connect" phase (only fired when a new TCP
+// connection is actually dialled, not on pooled-connection reuse) and the
+// "upstream-ttfb" phase - time to the first response byte, measured from
+// doStart - onto recorder.
+func withUpstreamServerTiming(ctx context.Context, recorder *ServerTimingRecorder, doStart time.Time) context.Context {
+	var connectStart time.Time
+	trace := &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) {
+			connectStart = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if !connectStart.IsZero() {
+				recorder.Record("upstream-connect", time.Since(connectStart))
+			}
+		},
+		GotFirstResponseByte: func() {
+			recorder.Record("upstream-ttfb", time.Since(doStart))
+		},
+	}
+	return httptrace.WithClientTrace(ctx, trace)
+}
+
+func forwardRequest(w http.ResponseWriter, r *http.Request, proxyClient *http.Client, baseURL string, requestURL string, timeout time.Duration, idleStreamTimeout time.Duration, flushInterval time.Duration, filter types.HeaderFilterConfig, forwardedHeaderMode string) (int, error) {
+
+	upstreamReq := buildUpstreamRequest(r, baseURL, requestURL, filter, forwardedHeaderMode)
 	if upstreamReq.Body != nil {
 		defer upstreamReq.Body.Close()
 	}
@@ -105,33 +287,145 @@ func forwardRequest(w http.ResponseWriter, r *http.Request, proxyClient *http.Cl
 		log.Printf("forwardRequest: %s %s\n", upstreamReq.Host, upstreamReq.URL.String())
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// absoluteTimer enforces timeout for the call as a whole. Once the
+	// response body starts streaming, it is stopped in favour of the
+	// idle-reset timer below, if one is configured, so a function that
+	// keeps emitting data isn't reaped purely for running past timeout.
+	absoluteTimer := time.AfterFunc(timeout, cancel)
+	defer absoluteTimer.Stop()
+
+	doStart := time.Now()
+	if recorder, ok := serverTimingRecorderFromContext(r.Context()); ok {
+		ctx = withUpstreamServerTiming(ctx, recorder, doStart)
+	}
+
 	res, resErr := proxyClient.Do(upstreamReq.WithContext(ctx))
 	if resErr != nil {
-		badStatus := http.StatusBadGateway
-		w.WriteHeader(badStatus)
-		return badStatus, resErr
+		statusCode, errorCode := classifyUpstreamError(resErr)
+		writeUpstreamError(w, statusCode, errorCode, resErr)
+		return statusCode, resErr
 	}
 
 	if res.Body != nil {
 		defer res.Body.Close()
 	}
 
-	copyHeaders(w.Header(), &res.Header)
+	filteredHeaders := filterHeaders(res.Header, filter.AllowOutboundHeaders, filter.DenyOutboundHeaders)
+	copyHeaders(w.Header(), &filteredHeaders)
 
 	// Write status code
 	w.WriteHeader(res.StatusCode)
 
 	if res.Body != nil {
-		// Copy the body over
-		io.CopyBuffer(w, res.Body, nil)
+		if idleStreamTimeout > 0 {
+			absoluteTimer.Stop()
+			copyWithIdleTimeout(w, res.Body, idleStreamTimeout, flushInterval, cancel)
+		} else {
+			copyResponseBody(w, res.Body, flushInterval)
+		}
 	}
 
+	// res.Trailer is only populated once res.Body has been fully read, so
+	// this has to happen after the copy above, not alongside the header
+	// copy before WriteHeader. copyHeaders isn't reused here because
+	// trailers need the http.TrailerPrefix treatment rather than being set
+	// as ordinary headers - this is what lets gRPC status/message
+	// trailers (Grpc-Status, Grpc-Message) on an application/grpc response
+	// reach the client instead of being silently dropped.
+	copyTrailers(w.Header(), res.Trailer)
+
 	return res.StatusCode, nil
 }
 
+// copyTrailers copies each value in trailer into dst using the
+// http.TrailerPrefix convention, so it still works when the handler
+// already called WriteHeader (the case here - forwardRequest doesn't know
+// the upstream's trailer keys in advance, since a gRPC unary call's
+// Grpc-Status/Grpc-Message trailers are only known once the proxied
+// response has finished streaming).
+func copyTrailers(dst http.Header, trailer http.Header) {
+	for key, values := range trailer {
+		for _, value := range values {
+			dst.Add(http.TrailerPrefix+key, value)
+		}
+	}
+}
+
+// copyWithIdleTimeout copies from src to dst, resetting idleTimeout on
+// every byte read. If no byte arrives within idleTimeout, onIdle is
+// called once and the copy stops once the resulting error or EOF
+// surfaces from src.Read; onIdle is expected to cancel src's underlying
+// request context so a blocked Read returns promptly. Flushing is handled
+// the same way copyResponseBody does, via a background ticker, so a slow
+// trickle of bytes (the case this function exists for) still reaches the
+// client as it arrives rather than waiting for the next idle check.
+func copyWithIdleTimeout(dst io.Writer, src io.Reader, idleTimeout time.Duration, flushInterval time.Duration, onIdle func()) {
+	stopFlushing := startFlushing(dst, flushInterval)
+	defer stopFlushing()
+
+	buf := make([]byte, 32*1024)
+	timer := time.AfterFunc(idleTimeout, onIdle)
+	defer timer.Stop()
+
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			timer.Reset(idleTimeout)
+			dst.Write(buf[:n])
+		}
+		if readErr != nil {
+			return
+		}
+	}
+}
+
+// copyResponseBody copies from src to dst, periodically flushing dst while
+// the copy is in progress if flushInterval is greater than zero and dst
+// implements http.Flusher. Without this, a response streamed a few bytes
+// at a time - server-sent events, a slow long-poll - can sit unsent behind
+// io.CopyBuffer's internal buffering until enough of it has accumulated,
+// rather than reaching the client as it's produced. FlushInterval of zero
+// (the default) preserves the old, unflushed copy behaviour.
+func copyResponseBody(dst io.Writer, src io.Reader, flushInterval time.Duration) {
+	stopFlushing := startFlushing(dst, flushInterval)
+	defer stopFlushing()
+
+	io.CopyBuffer(dst, src, nil)
+}
+
+// startFlushing flushes dst on a ticker of flushInterval until the
+// returned stop function is called, which also performs one last flush to
+// catch anything written since the previous tick. A no-op if flushInterval
+// is zero or dst doesn't implement http.Flusher.
+func startFlushing(dst io.Writer, flushInterval time.Duration) func() {
+	flusher, canFlush := dst.(http.Flusher)
+	if !canFlush || flushInterval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				flusher.Flush()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		flusher.Flush()
+	}
+}
+
 func copyHeaders(destination http.Header, source *http.Header) {
 	for k, v := range *source {
 		vClone := make([]string, len(v))
@@ -140,13 +434,58 @@ func copyHeaders(destination http.Header, source *http.Header) {
 	}
 }
 
+// hopByHopHeaders are always stripped between the client and a function,
+// regardless of any configured allow/deny list, since they are specific to
+// one TCP connection and meaningless when relayed across another.
+var hopByHopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// filterHeaders returns the subset of headers allowed to cross the
+// gateway: hop-by-hop headers are always stripped, then, if allow is
+// non-empty, only headers named in allow are kept, then any header named
+// in deny is stripped.
+func filterHeaders(headers http.Header, allow []string, deny []string) http.Header {
+	filtered := make(http.Header, len(headers))
+	for name, values := range headers {
+		if headerListContains(hopByHopHeaders, name) {
+			continue
+		}
+		if len(allow) > 0 && !headerListContains(allow, name) {
+			continue
+		}
+		if headerListContains(deny, name) {
+			continue
+		}
+		filtered[name] = values
+	}
+	return filtered
+}
+
+func headerListContains(list []string, name string) bool {
+	for _, item := range list {
+		if http.CanonicalHeaderKey(item) == http.CanonicalHeaderKey(name) {
+			return true
+		}
+	}
+	return false
+}
+
 // PrometheusFunctionNotifier records metrics to Prometheus
 type PrometheusFunctionNotifier struct {
 	Metrics *metrics.MetricOptions
 }
 
-// Notify records metrics in Prometheus
-func (p PrometheusFunctionNotifier) Notify(method string, URL string, originalURL string, statusCode int, duration time.Duration) {
+// Notify records metrics in Prometheus.
+//
+// callID is accepted to satisfy HTTPNotifier but is not attached to the
+// histogram observation below as an OpenMetrics exemplar: the vendored
+// github.com/prometheus/client_golang/prometheus in this tree predates
+// ObserveWithExemplar/ExemplarObserver, so there is no API here to record
+// one against GatewayFunctionsHistogram. ExemplarLoggingNotifier logs the
+// same correlation out-of-band as the closest available substitute.
+func (p PrometheusFunctionNotifier) Notify(method string, URL string, originalURL string, statusCode int, duration time.Duration, callID string) {
 	seconds := duration.Seconds()
 	serviceName := getServiceName(originalURL)
 
@@ -161,22 +500,104 @@ func (p PrometheusFunctionNotifier) Notify(method string, URL string, originalUR
 		Inc()
 }
 
-func getServiceName(urlValue string) string {
-	var serviceName string
-	forward := "/function/"
-	if strings.HasPrefix(urlValue, forward) {
-		// With a path like `/function/xyz/rest/of/path?q=a`, the service
-		// name we wish to locate is just the `xyz` portion.  With a postive
-		// match on the regex below, it will return a three-element slice.
-		// The item at index `0` is the same as `urlValue`, at `1`
-		// will be the service name we need, and at `2` the rest of the path.
-		matcher := functionMatcher.Copy()
-		matches := matcher.FindStringSubmatch(urlValue)
-		if len(matches) == hasPathCount {
-			serviceName = matches[nameIndex]
+// ExemplarLoggingNotifier logs the call ID alongside each invocation's
+// latency, as a stand-in for an OpenMetrics exemplar linking the
+// GatewayFunctionsHistogram observation back to the request that produced
+// it. A real exemplar is attached to the Prometheus time series itself and
+// exposed through the OpenMetrics text format, but the client_golang
+// version vendored in this tree has no exemplar support to attach one
+// through (see PrometheusFunctionNotifier.Notify) - this notifier instead
+// writes the function name, duration and X-Call-Id to the log, so the two
+// can still be correlated by hand or by a log-aware tracing backend
+// watching for MakeCallIDMiddleware's call IDs.
+type ExemplarLoggingNotifier struct {
+}
+
+// Notify logs serviceName, duration and callID for later correlation.
+func (ExemplarLoggingNotifier) Notify(method string, URL string, originalURL string, statusCode int, duration time.Duration, callID string) {
+	if len(callID) == 0 {
+		return
+	}
+	log.Printf("exemplar function=%s duration_seconds=%f call_id=%s", getServiceName(originalURL), duration.Seconds(), callID)
+}
+
+// FunctionNameResolver extracts a function name from a request URL (path
+// plus an optional query string). getServiceName delegates to
+// DefaultFunctionNameResolver so every call site that reports a function
+// name - metrics, invocation history, context headers, the scaling and
+// pre-stop handlers - picks up a custom resolver without being changed
+// individually.
+type FunctionNameResolver interface {
+	ResolveFunctionName(urlValue string) string
+}
+
+// PrefixFunctionNameResolver resolves a function name from a URL whose path
+// starts with one of Prefixes, e.g. "/function/". It handles nested paths
+// ("/function/xyz/rest/of/path"), a trailing slash, a query string, and
+// percent-escaped characters within the name segment. If NamespaceSeparator
+// is non-empty, anything from the first occurrence of it onwards is
+// trimmed off the name, so a vanity URL like "/function/echo.staging" with
+// NamespaceSeparator "." resolves to "echo".
+type PrefixFunctionNameResolver struct {
+	// Prefixes are tried in order; the first one the URL's path starts
+	// with wins. Defaults to {"/function/"} when empty.
+	Prefixes []string
+
+	// NamespaceSeparator, when set, splits a trailing namespace off the
+	// resolved name. Leave empty to disable namespace splitting.
+	NamespaceSeparator string
+}
+
+func (p PrefixFunctionNameResolver) prefixes() []string {
+	if len(p.Prefixes) > 0 {
+		return p.Prefixes
+	}
+	return []string{"/function/"}
+}
+
+// ResolveFunctionName implements FunctionNameResolver.
+func (p PrefixFunctionNameResolver) ResolveFunctionName(urlValue string) string {
+	path := urlValue
+	if idx := strings.IndexByte(path, '?'); idx >= 0 {
+		path = path[:idx]
+	}
+
+	for _, prefix := range p.prefixes() {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(path, prefix)
+		name := rest
+		if slash := strings.IndexByte(rest, '/'); slash >= 0 {
+			name = rest[:slash]
+		}
+		name = strings.Trim(name, "/")
+
+		if p.NamespaceSeparator != "" {
+			if idx := strings.Index(name, p.NamespaceSeparator); idx >= 0 {
+				name = name[:idx]
+			}
+		}
+
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
 		}
+
+		return name
 	}
-	return strings.Trim(serviceName, "/")
+
+	return ""
+}
+
+// DefaultFunctionNameResolver is the FunctionNameResolver used by
+// getServiceName. Override it - e.g. via server.WithFunctionNameResolver -
+// to support additional prefixes, vanity URLs or a namespace separator,
+// without touching the handlers that call getServiceName.
+var DefaultFunctionNameResolver FunctionNameResolver = PrefixFunctionNameResolver{}
+
+func getServiceName(urlValue string) string {
+	return DefaultFunctionNameResolver.ResolveFunctionName(urlValue)
 }
 
 // LoggingNotifier notifies a log about a request
@@ -184,7 +605,7 @@ type LoggingNotifier struct {
 }
 
 // Notify a log about a request
-func (LoggingNotifier) Notify(method string, URL string, originalURL string, statusCode int, duration time.Duration) {
+func (LoggingNotifier) Notify(method string, URL string, originalURL string, statusCode int, duration time.Duration, callID string) {
 	log.Printf("Forwarded [%s] to %s - [%d] - %f seconds", method, originalURL, statusCode, duration.Seconds())
 }
 
@@ -6,10 +6,16 @@ package handlers
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/types"
 )
 
 func Test_buildUpstreamRequest_Body_Method_Query(t *testing.T) {
@@ -24,7 +30,7 @@ func Test_buildUpstreamRequest_Body_Method_Query(t *testing.T) {
 		t.Fail()
 	}
 
-	upstream := buildUpstreamRequest(request, "/", "")
+	upstream := buildUpstreamRequest(request, "/", "", types.HeaderFilterConfig{}, "")
 
 	if request.Method != upstream.Method {
 		t.Errorf("Method - want: %s, got: %s", request.Method, upstream.Method)
@@ -53,7 +59,7 @@ func Test_buildUpstreamRequest_Body_Method_Query(t *testing.T) {
 func Test_buildUpstreamRequest_NoBody_GetMethod_NoQuery(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodGet, "/", nil)
 
-	upstream := buildUpstreamRequest(request, "/", "")
+	upstream := buildUpstreamRequest(request, "/", "", types.HeaderFilterConfig{}, "")
 
 	if request.Method != upstream.Method {
 		t.Errorf("Method - want: %s, got: %s", request.Method, upstream.Method)
@@ -82,7 +88,7 @@ func Test_buildUpstreamRequest_HasXForwardedHostHeaderWhenSet(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	upstream := buildUpstreamRequest(request, "/", "/")
+	upstream := buildUpstreamRequest(request, "/", "/", types.HeaderFilterConfig{}, "")
 
 	if request.Host != upstream.Header.Get("X-Forwarded-Host") {
 		t.Errorf("Host - want: %s, got: %s", request.Host, upstream.Header.Get("X-Forwarded-Host"))
@@ -99,7 +105,7 @@ func Test_buildUpstreamRequest_XForwardedHostHeader_Empty_WhenNotSet(t *testing.
 		t.Fatal(err)
 	}
 
-	upstream := buildUpstreamRequest(request, "/", "/")
+	upstream := buildUpstreamRequest(request, "/", "/", types.HeaderFilterConfig{}, "")
 
 	if request.Host != upstream.Header.Get("X-Forwarded-Host") {
 		t.Errorf("Host - want: %s, got: %s", request.Host, upstream.Header.Get("X-Forwarded-Host"))
@@ -117,7 +123,7 @@ func Test_buildUpstreamRequest_XForwardedHostHeader_WhenAlreadyPresent(t *testin
 	}
 
 	request.Header.Set("X-Forwarded-Host", headerValue)
-	upstream := buildUpstreamRequest(request, "/", "/")
+	upstream := buildUpstreamRequest(request, "/", "/", types.HeaderFilterConfig{}, "")
 
 	if upstream.Header.Get("X-Forwarded-Host") != headerValue {
 		t.Errorf("X-Forwarded-Host - want: %s, got: %s", headerValue, upstream.Header.Get("X-Forwarded-Host"))
@@ -173,6 +179,72 @@ func Test_getServiceName(t *testing.T) {
 	}
 }
 
+func Test_getServiceName_NestedPath(t *testing.T) {
+	service := getServiceName("/function/testFunc/employee/123?name=foo")
+	if service != "testFunc" {
+		t.Fatalf("want testFunc for a nested path, got %s", service)
+	}
+}
+
+func Test_PrefixFunctionNameResolver_CustomPrefixesAndNamespaceSeparator(t *testing.T) {
+	resolver := PrefixFunctionNameResolver{
+		Prefixes:           []string{"/v2/invoke/", "/function/"},
+		NamespaceSeparator: ".",
+	}
+
+	scenarios := []struct {
+		name        string
+		url         string
+		serviceName string
+	}{
+		{
+			name:        "matches a custom prefix",
+			url:         "/v2/invoke/echo",
+			serviceName: "echo",
+		},
+		{
+			name:        "still matches the default prefix when listed",
+			url:         "/function/echo/rest/of/path",
+			serviceName: "echo",
+		},
+		{
+			name:        "splits off a namespace suffix",
+			url:         "/function/echo.openfaas-fn",
+			serviceName: "echo",
+		},
+		{
+			name:        "unescapes percent-encoded characters in the name",
+			url:         "/function/my%2Dfunc",
+			serviceName: "my-func",
+		},
+		{
+			name:        "returns empty for an unmatched prefix",
+			url:         "/system/functions",
+			serviceName: "",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			got := resolver.ResolveFunctionName(s.url)
+			if got != s.serviceName {
+				t.Fatalf("want %q, got %q", s.serviceName, got)
+			}
+		})
+	}
+}
+
+func Test_getServiceName_DelegatesToDefaultFunctionNameResolver(t *testing.T) {
+	original := DefaultFunctionNameResolver
+	defer func() { DefaultFunctionNameResolver = original }()
+
+	DefaultFunctionNameResolver = PrefixFunctionNameResolver{Prefixes: []string{"/v2/invoke/"}}
+
+	if got := getServiceName("/v2/invoke/echo"); got != "echo" {
+		t.Fatalf("want getServiceName to use the installed resolver, got %q", got)
+	}
+}
+
 func Test_buildUpstreamRequest_WithPathNoQuery(t *testing.T) {
 	srcBytes := []byte("hello world")
 	functionPath := "/employee/info/300"
@@ -198,7 +270,7 @@ func Test_buildUpstreamRequest_WithPathNoQuery(t *testing.T) {
 		t.Errorf("transformedPath want: %s, got %s", wantTransformedPath, transformedPath)
 	}
 
-	upstream := buildUpstreamRequest(request, "http://xyz:8080", transformedPath)
+	upstream := buildUpstreamRequest(request, "http://xyz:8080", transformedPath, types.HeaderFilterConfig{}, "")
 
 	if request.Method != upstream.Method {
 		t.Errorf("Method - want: %s, got: %s", request.Method, upstream.Method)
@@ -254,7 +326,7 @@ func Test_buildUpstreamRequest_WithNoPathNoQuery(t *testing.T) {
 		t.Errorf("transformedPath want: %s, got %s", wantTransformedPath, transformedPath)
 	}
 
-	upstream := buildUpstreamRequest(request, "http://xyz:8080", transformedPath)
+	upstream := buildUpstreamRequest(request, "http://xyz:8080", transformedPath, types.HeaderFilterConfig{}, "")
 
 	if request.Method != upstream.Method {
 		t.Errorf("Method - want: %s, got: %s", request.Method, upstream.Method)
@@ -308,7 +380,7 @@ func Test_buildUpstreamRequest_WithPathAndQuery(t *testing.T) {
 		t.Errorf("transformedPath want: %s, got %s", wantTransformedPath, transformedPath)
 	}
 
-	upstream := buildUpstreamRequest(request, "http://xyz:8080", transformedPath)
+	upstream := buildUpstreamRequest(request, "http://xyz:8080", transformedPath, types.HeaderFilterConfig{}, "")
 
 	if request.Method != upstream.Method {
 		t.Errorf("Method - want: %s, got: %s", request.Method, upstream.Method)
@@ -338,3 +410,150 @@ func Test_buildUpstreamRequest_WithPathAndQuery(t *testing.T) {
 	}
 
 }
+
+func Test_effectiveTimeout_DisabledWithoutMaxOverride(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.Header.Set("X-Timeout-Seconds", "1")
+
+	got := effectiveTimeout(req, 10*time.Second, 0)
+	if got != 10*time.Second {
+		t.Errorf("want the default timeout when MaxTimeoutOverride is disabled, got %s", got)
+	}
+}
+
+func Test_effectiveTimeout_UsesRequestedValueWithinCap(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.Header.Set("X-Timeout-Seconds", "5")
+
+	got := effectiveTimeout(req, 10*time.Second, 30*time.Second)
+	if got != 5*time.Second {
+		t.Errorf("want the requested 5s timeout, got %s", got)
+	}
+}
+
+func Test_effectiveTimeout_CapsAtMaxOverride(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.Header.Set("X-Timeout-Seconds", "120")
+
+	got := effectiveTimeout(req, 10*time.Second, 30*time.Second)
+	if got != 30*time.Second {
+		t.Errorf("want the requested timeout capped at 30s, got %s", got)
+	}
+}
+
+func Test_effectiveTimeout_FallsBackOnInvalidHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.Header.Set("X-Timeout-Seconds", "not-a-number")
+
+	got := effectiveTimeout(req, 10*time.Second, 30*time.Second)
+	if got != 10*time.Second {
+		t.Errorf("want the default timeout for an invalid header, got %s", got)
+	}
+}
+
+type flushRecordingWriter struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *flushRecordingWriter) Flush() {
+	f.flushes++
+	f.ResponseRecorder.Flush()
+}
+
+func Test_copyResponseBody_FlushesPeriodicallyWhenIntervalSet(t *testing.T) {
+	w := &flushRecordingWriter{ResponseRecorder: httptest.NewRecorder()}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.Write([]byte("chunk-1"))
+		time.Sleep(20 * time.Millisecond)
+		writer.Write([]byte("chunk-2"))
+		writer.Close()
+	}()
+
+	copyResponseBody(w, reader, 5*time.Millisecond)
+
+	if w.flushes == 0 {
+		t.Fatal("want at least one flush while the response streamed")
+	}
+	if w.Body.String() != "chunk-1chunk-2" {
+		t.Fatalf("want the full body copied, got %q", w.Body.String())
+	}
+}
+
+func Test_copyResponseBody_NoFlushingWhenIntervalZero(t *testing.T) {
+	w := &flushRecordingWriter{ResponseRecorder: httptest.NewRecorder()}
+
+	copyResponseBody(w, strings.NewReader("hello"), 0)
+
+	if w.flushes != 0 {
+		t.Fatalf("want no flushes when FlushInterval is disabled, got %d", w.flushes)
+	}
+	if w.Body.String() != "hello" {
+		t.Fatalf("want the body still copied, got %q", w.Body.String())
+	}
+}
+
+func Test_ExemplarLoggingNotifier_NoopsWithoutCallID(t *testing.T) {
+	notifier := ExemplarLoggingNotifier{}
+
+	// No assertion beyond "does not panic": callID is empty so the
+	// notifier has nothing to correlate and should return without logging.
+	notifier.Notify(http.MethodGet, "/function/echo", "/function/echo", http.StatusOK, 10*time.Millisecond, "")
+}
+
+func Test_ExemplarLoggingNotifier_AcceptsACallID(t *testing.T) {
+	notifier := ExemplarLoggingNotifier{}
+
+	notifier.Notify(http.MethodGet, "/function/echo", "/function/echo", http.StatusOK, 10*time.Millisecond, "call-123")
+}
+
+func Test_copyTrailers_UsesTrailerPrefixConvention(t *testing.T) {
+	dst := http.Header{}
+	trailer := http.Header{"Grpc-Status": []string{"0"}, "Grpc-Message": []string{"OK"}}
+
+	copyTrailers(dst, trailer)
+
+	if got := dst.Get(http.TrailerPrefix + "Grpc-Status"); got != "0" {
+		t.Errorf("want trailer Grpc-Status 0, got %q", got)
+	}
+	if got := dst.Get(http.TrailerPrefix + "Grpc-Message"); got != "OK" {
+		t.Errorf("want trailer Grpc-Message OK, got %q", got)
+	}
+}
+
+func Test_copyTrailers_NoopOnEmptyTrailer(t *testing.T) {
+	dst := http.Header{}
+
+	copyTrailers(dst, http.Header{})
+
+	if len(dst) != 0 {
+		t.Errorf("want no headers added for an empty trailer, got %+v", dst)
+	}
+}
+
+func Test_forwardRequest_RecordsUpstreamServerTimingPhases(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	recorder := NewServerTimingRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req = req.WithContext(withServerTimingRecorder(req.Context(), recorder))
+
+	rec := httptest.NewRecorder()
+	_, err := forwardRequest(rec, req, upstream.Client(), upstream.URL, "/", time.Second, 0, 0, types.HeaderFilterConfig{}, "")
+	if err != nil {
+		t.Fatalf("unable to forward request: %s", err.Error())
+	}
+
+	header := recorder.Header()
+	if !strings.Contains(header, "upstream-connect;dur=") {
+		t.Fatalf("want an upstream-connect entry, got %q", header)
+	}
+	if !strings.Contains(header, "upstream-ttfb;dur=") {
+		t.Fatalf("want an upstream-ttfb entry, got %q", header)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	// AuthRequiredLabel, set to "required", marks a function whose
+	// invocation route needs authentication checked against that
+	// function's own labels. This is separate from MakeAPIKeyAuthHandler,
+	// which, when enabled, applies one gateway-wide API key check to every
+	// function's invocation route, and from MakeOIDCAuthHandler, which
+	// only guards /system/*.
+	AuthRequiredLabel = "com.openfaas.auth"
+
+	// AuthModeLabel selects which validator enforces AuthRequiredLabel:
+	// "basic", "bearer" or "hmac". Defaults to "bearer" when unset.
+	AuthModeLabel = "com.openfaas.auth.mode"
+
+	// AuthSecretLabel carries the credential the chosen validator checks
+	// against - "user:pass" for basic, a token for bearer, or a signing
+	// key for hmac. Like every other com.openfaas.* label it's stored and
+	// transmitted as plain function metadata, not a Kubernetes Secret, so
+	// treat it as sensitive as the function spec itself and rotate it via
+	// the normal function-update path if it leaks.
+	AuthSecretLabel = "com.openfaas.auth.secret"
+
+	// AuthHMACHeaderLabel names the request header carrying a hex-encoded
+	// HMAC-SHA256 signature of the request body, for AuthModeLabel
+	// "hmac". Defaults to "X-Hub-Signature-256" when unset.
+	AuthHMACHeaderLabel = "com.openfaas.auth.hmac-header"
+)
+
+// MakeFunctionAuthHandler wraps next, enforcing AuthRequiredLabel on the
+// called function's invocation route. A function with no AuthRequiredLabel
+// (or any value other than "required") is unaffected, preserving today's
+// behaviour of no authentication on /function/*. One with it set is
+// rejected with 401 unless it satisfies whichever of AuthModeLabel's
+// validators applies.
+func MakeFunctionAuthHandler(next http.HandlerFunc, specs *FunctionSpecStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := getServiceName(r.URL.String())
+		labels := functionLabels(specs, functionName)
+
+		if labels[AuthRequiredLabel] != "required" {
+			next(w, r)
+			return
+		}
+
+		if err := validateFunctionAuth(r, labels); err != nil {
+			w.Header().Set("WWW-Authenticate", authModeChallenge(labels))
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func authModeChallenge(labels map[string]string) string {
+	switch authMode(labels) {
+	case "basic":
+		return `Basic realm="openfaas"`
+	default:
+		return `Bearer realm="openfaas"`
+	}
+}
+
+func authMode(labels map[string]string) string {
+	mode := strings.ToLower(strings.TrimSpace(labels[AuthModeLabel]))
+	if len(mode) == 0 {
+		return "bearer"
+	}
+	return mode
+}
+
+func validateFunctionAuth(r *http.Request, labels map[string]string) error {
+	secret := labels[AuthSecretLabel]
+	if len(secret) == 0 {
+		return fmt.Errorf("function requires authentication but has no %s configured", AuthSecretLabel)
+	}
+
+	switch authMode(labels) {
+	case "basic":
+		return validateBasicAuth(r, secret)
+	case "bearer":
+		return validateBearerAuth(r, secret)
+	case "hmac":
+		headerName := labels[AuthHMACHeaderLabel]
+		if len(headerName) == 0 {
+			headerName = "X-Hub-Signature-256"
+		}
+		return validateHMACAuth(r, secret, headerName)
+	default:
+		return fmt.Errorf("unsupported %s %q", AuthModeLabel, labels[AuthModeLabel])
+	}
+}
+
+func validateBasicAuth(r *http.Request, secret string) error {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return fmt.Errorf("missing basic auth credentials")
+	}
+
+	parts := strings.SplitN(secret, ":", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("function's %s is not in \"user:pass\" form", AuthSecretLabel)
+	}
+	expectedUser, expectedPass := parts[0], parts[1]
+
+	userMatch := subtle.ConstantTimeCompare([]byte(username), []byte(expectedUser)) == 1
+	passMatch := subtle.ConstantTimeCompare([]byte(password), []byte(expectedPass)) == 1
+	if !userMatch || !passMatch {
+		return fmt.Errorf("invalid basic auth credentials")
+	}
+	return nil
+}
+
+func validateBearerAuth(r *http.Request, secret string) error {
+	const prefix = "Bearer "
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, prefix) {
+		return fmt.Errorf("missing bearer token")
+	}
+
+	token := strings.TrimPrefix(authHeader, prefix)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return fmt.Errorf("invalid bearer token")
+	}
+	return nil
+}
+
+// validateHMACAuth checks headerName against an HMAC-SHA256 of the request
+// body keyed by secret, the same scheme GitHub/Stripe-style webhooks use.
+// It consumes r.Body to compute the digest and replaces it with an
+// equivalent in-memory reader so next still sees the full body.
+func validateHMACAuth(r *http.Request, secret string, headerName string) error {
+	signature := r.Header.Get(headerName)
+	if len(signature) == 0 {
+		return fmt.Errorf("missing %s header", headerName)
+	}
+	signature = strings.TrimPrefix(signature, "sha256=")
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read request body: %w", err)
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	given, err := decodeHexOrBase64(signature)
+	if err != nil || !hmac.Equal(given, expected) {
+		return fmt.Errorf("invalid %s signature", headerName)
+	}
+	return nil
+}
+
+func decodeHexOrBase64(value string) ([]byte, error) {
+	if decoded, err := hex.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	return base64.StdEncoding.DecodeString(value)
+}
@@ -0,0 +1,248 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_MakeFunctionAuthHandler_AllowsWhenLabelNotSet(t *testing.T) {
+	specs := NewFunctionSpecStore()
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeFunctionAuthHandler(next, specs)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called when the function has no AuthRequiredLabel")
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rec.Code)
+	}
+}
+
+func Test_MakeFunctionAuthHandler_BearerRejectsMissingToken(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{AuthRequiredLabel: "required", AuthSecretLabel: "s3cr3t"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	handler := MakeFunctionAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called")
+	}, specs)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401, got %d", rec.Code)
+	}
+}
+
+func Test_MakeFunctionAuthHandler_BearerAllowsMatchingToken(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{AuthRequiredLabel: "required", AuthSecretLabel: "s3cr3t"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	called := false
+	handler := MakeFunctionAuthHandler(func(w http.ResponseWriter, r *http.Request) { called = true }, specs)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called with a matching bearer token")
+	}
+}
+
+func Test_MakeFunctionAuthHandler_BasicAuthChecksUserAndPass(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{
+		AuthRequiredLabel: "required",
+		AuthModeLabel:     "basic",
+		AuthSecretLabel:   "alice:wonderland",
+	}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	handler := MakeFunctionAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, specs)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.SetBasicAuth("alice", "wrong-password")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401 for a wrong password, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.SetBasicAuth("alice", "wonderland")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200 for matching basic auth credentials, got %d", rec.Code)
+	}
+}
+
+func Test_MakeFunctionAuthHandler_HMACVerifiesBodySignatureAndPreservesBody(t *testing.T) {
+	secret := "webhook-secret"
+	body := []byte(`{"hello":"world"}`)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{
+		AuthRequiredLabel: "required",
+		AuthModeLabel:     "hmac",
+		AuthSecretLabel:   secret,
+	}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	var bodySeenByNext []byte
+	handler := MakeFunctionAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		bodySeenByNext, _ = readAllAndClose(r)
+		w.WriteHeader(http.StatusOK)
+	}, specs)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", bytes.NewReader(body))
+	req.Header.Set("X-Hub-Signature-256", signature)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200 for a valid HMAC signature, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if string(bodySeenByNext) != string(body) {
+		t.Fatalf("want next to still see the full request body, got %q", bodySeenByNext)
+	}
+}
+
+func Test_MakeFunctionAuthHandler_HMACRejectsBadSignature(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{
+		AuthRequiredLabel: "required",
+		AuthModeLabel:     "hmac",
+		AuthSecretLabel:   "webhook-secret",
+	}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	handler := MakeFunctionAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called")
+	}, specs)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("X-Hub-Signature-256", "0000")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401 for a bad HMAC signature, got %d", rec.Code)
+	}
+}
+
+func readAllAndClose(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(r.Body)
+	return buf.Bytes(), err
+}
+
+// Test_MakeFunctionAuthHandler_WrapsOutsideResponseCache guards the wrap
+// order server.go relies on: MakeFunctionAuthHandler must sit outside
+// MakeResponseCacheHandler, since the cache key (method + URL) carries no
+// caller identity. Wrapped the other way around, one authenticated
+// caller's response would be cached and then handed straight to the next,
+// completely unauthenticated, caller for the same URL.
+func Test_MakeFunctionAuthHandler_WrapsOutsideResponseCache(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{
+		AuthRequiredLabel:     "required",
+		AuthSecretLabel:       "s3cr3t",
+		ResponseCacheTTLLabel: "1m",
+	}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	calls := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("secret response"))
+	}
+
+	cache := NewInMemoryResponseCache(10)
+	handler := MakeResponseCacheHandler(next, cache, specs, nil)
+	handler = MakeFunctionAuthHandler(handler, specs)
+
+	authedReq := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	authedReq.Header.Set("Authorization", "Bearer s3cr3t")
+	handler(httptest.NewRecorder(), authedReq)
+	if calls != 1 {
+		t.Fatalf("want the authenticated request to reach next, got %d calls", calls)
+	}
+
+	anonRec := httptest.NewRecorder()
+	handler(anonRec, httptest.NewRequest(http.MethodGet, "/function/figlet", nil))
+
+	if anonRec.Code != http.StatusUnauthorized {
+		t.Fatalf("want an unauthenticated caller rejected with 401, got %d: %q", anonRec.Code, anonRec.Body.String())
+	}
+	if calls != 1 {
+		t.Fatalf("want next still only called once - an unauthenticated caller must not get the cached response")
+	}
+}
+
+// Test_MakeFunctionAuthHandler_WrapsOutsideMaxBodySize guards the other
+// half of the wrap order: MakeRequestPreconditionsHandler/
+// MakeMaxBodySizeHandler must sit outside MakeFunctionAuthHandler, so an
+// hmac-mode auth check's full-body read is already bounded by
+// http.MaxBytesReader before it ever runs.
+func Test_MakeFunctionAuthHandler_WrapsOutsideMaxBodySize(t *testing.T) {
+	secret := "webhook-secret"
+	body := bytes.Repeat([]byte("a"), 1024)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{
+		AuthRequiredLabel: "required",
+		AuthModeLabel:     "hmac",
+		AuthSecretLabel:   secret,
+	}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called once the body exceeds the configured limit")
+	}
+
+	handler := MakeFunctionAuthHandler(next, specs)
+	handler = MakeRequestPreconditionsHandler(handler, specs, 256)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("X-Hub-Signature-256", signature)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want 413 once the body-size limit rejects the oversized request before the hmac check reads it, got %d: %q", rec.Code, rec.Body.String())
+	}
+}
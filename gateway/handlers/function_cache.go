@@ -4,6 +4,9 @@
 package handlers
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
 	"sync"
 	"time"
 )
@@ -17,15 +20,31 @@ type FunctionMeta struct {
 
 // Expired find out whether the cache item has expired with
 // the given expiry duration from when it was stored.
-func (fm *FunctionMeta) Expired(expiry time.Duration) bool {
-	return time.Now().After(fm.LastRefresh.Add(expiry))
+func (fm *FunctionMeta) Expired(expiry time.Duration, now time.Time) bool {
+	return now.After(fm.LastRefresh.Add(expiry))
 }
 
-// FunctionCache provides a cache of Function replica counts
+// FunctionCache provides a cache of Function replica counts. It is the
+// only persistable state standing between a cold gateway and a
+// thundering herd of GetReplicas calls on restart. SaveToDisk/LoadFromDisk
+// cover this cache only: circuit breaker state (CircuitBreakerStore) is
+// not saved and starts empty after a restart.
 type FunctionCache struct {
 	Cache  map[string]*FunctionMeta
 	Expiry time.Duration
 	Sync   sync.Mutex
+
+	// Clock provides the current time for LastRefresh/expiry checks. When
+	// nil, RealClock is used - a test can inject a fake to make expiry
+	// deterministic instead of racing the wall clock.
+	Clock Clock
+}
+
+func (fc *FunctionCache) clock() Clock {
+	if fc.Clock == nil {
+		return RealClock
+	}
+	return fc.Clock
 }
 
 // Set replica count for functionName
@@ -38,7 +57,7 @@ func (fc *FunctionCache) Set(functionName string, serviceQueryResponse ServiceQu
 	}
 
 	entry := fc.Cache[functionName]
-	entry.LastRefresh = time.Now()
+	entry.LastRefresh = fc.clock().Now()
 	entry.ServiceQueryResponse = serviceQueryResponse
 
 }
@@ -56,8 +75,63 @@ func (fc *FunctionCache) Get(functionName string) (ServiceQueryResponse, bool) {
 	hit := false
 	if val, exists := fc.Cache[functionName]; exists {
 		replicas = val.ServiceQueryResponse
-		hit = !val.Expired(fc.Expiry)
+		hit = !val.Expired(fc.Expiry, fc.clock().Now())
 	}
 
 	return replicas, hit
 }
+
+// InvalidateCache implements CacheInvalidator, dropping functionName's
+// cached replica count - or, when functionName is empty, every function's -
+// so the next GetReplicas call is treated as a miss instead of returning
+// state that may be stale after an out-of-band scale change.
+func (fc *FunctionCache) InvalidateCache(functionName string) {
+	fc.Sync.Lock()
+	defer fc.Sync.Unlock()
+
+	if functionName == "" {
+		fc.Cache = make(map[string]*FunctionMeta)
+		return
+	}
+	delete(fc.Cache, functionName)
+}
+
+// SaveToDisk writes the cache's current contents to path as JSON, for a
+// warm shutdown: reloading it via LoadFromDisk on the next start avoids a
+// thundering herd of GetReplicas calls, and false cold-start handling,
+// while the cache would otherwise be empty.
+func (fc *FunctionCache) SaveToDisk(path string) error {
+	fc.Sync.Lock()
+	defer fc.Sync.Unlock()
+
+	body, err := json.Marshal(fc.Cache)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, body, 0600)
+}
+
+// LoadFromDisk replaces the cache's contents with whatever SaveToDisk
+// last wrote to path. A missing file is not an error - it just means
+// there is no prior state to warm the cache with, e.g. on first start.
+func (fc *FunctionCache) LoadFromDisk(path string) error {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	loaded := make(map[string]*FunctionMeta)
+	if err := json.Unmarshal(body, &loaded); err != nil {
+		return err
+	}
+
+	fc.Sync.Lock()
+	defer fc.Sync.Unlock()
+	fc.Cache = loaded
+
+	return nil
+}
@@ -4,6 +4,7 @@
 package handlers
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -93,6 +94,29 @@ func Test_CacheFunctionExists(t *testing.T) {
 		t.Errorf("hit, want: %v, got %v", wantHit, hit)
 	}
 }
+func Test_CacheExpiresDeterministicallyWithAFakeClock(t *testing.T) {
+	fnName := "echo"
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	cache := FunctionCache{
+		Cache:  make(map[string]*FunctionMeta),
+		Expiry: time.Minute,
+		Clock:  clock,
+	}
+
+	cache.Set(fnName, ServiceQueryResponse{AvailableReplicas: 1})
+
+	if _, hit := cache.Get(fnName); !hit {
+		t.Fatal("want a hit before the fake clock advances past Expiry")
+	}
+
+	clock.now = clock.now.Add(time.Hour)
+
+	if _, hit := cache.Get(fnName); hit {
+		t.Fatal("want a miss once the fake clock has advanced past Expiry")
+	}
+}
+
 func Test_CacheFunctionNotExist(t *testing.T) {
 	fnName := "echo"
 	testName := "burt"
@@ -113,3 +137,38 @@ func Test_CacheFunctionNotExist(t *testing.T) {
 		t.Errorf("hit, want: %v, got %v", wantHit, hit)
 	}
 }
+
+func Test_FunctionCache_SaveAndLoadFromDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache-state.json")
+
+	cache := &FunctionCache{
+		Cache:  make(map[string]*FunctionMeta),
+		Expiry: time.Minute,
+	}
+	cache.Set("figlet", ServiceQueryResponse{AvailableReplicas: 3})
+
+	if err := cache.SaveToDisk(path); err != nil {
+		t.Fatalf("unexpected error saving cache: %s", err.Error())
+	}
+
+	loaded := &FunctionCache{Expiry: time.Minute}
+	if err := loaded.LoadFromDisk(path); err != nil {
+		t.Fatalf("unexpected error loading cache: %s", err.Error())
+	}
+
+	response, hit := loaded.Get("figlet")
+	if !hit {
+		t.Fatal("want a hit for figlet after reloading from disk")
+	}
+	if response.AvailableReplicas != 3 {
+		t.Fatalf("want 3 available replicas, got %d", response.AvailableReplicas)
+	}
+}
+
+func Test_FunctionCache_LoadFromDiskIgnoresAMissingFile(t *testing.T) {
+	cache := &FunctionCache{Expiry: time.Minute}
+
+	if err := cache.LoadFromDisk(filepath.Join(t.TempDir(), "does-not-exist.json")); err != nil {
+		t.Fatalf("want no error for a missing cache state file, got %s", err.Error())
+	}
+}
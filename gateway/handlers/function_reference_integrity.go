@@ -0,0 +1,207 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+const (
+	// TopicAnnotation lists NATS topics, comma-separated, a connector
+	// should invoke this function for - the same annotation key the
+	// NATS connector itself reads.
+	TopicAnnotation = "topic"
+
+	// ScheduleAnnotation is a cron expression the cron-connector should
+	// invoke this function on.
+	ScheduleAnnotation = "schedule"
+
+	// CallbackHostAnnotation is the default callback host a queued
+	// invocation's result is posted back to when the request itself sets
+	// no X-Callback-Url - see MakeQueuedProxy.
+	CallbackHostAnnotation = "com.openfaas.callback-host"
+)
+
+// MissingReference describes one secret or annotation-based dependency a
+// deploy/update request pointed at that doesn't actually exist or isn't
+// valid, so the caller can fix its request instead of finding out once the
+// function is already failing invocations.
+type MissingReference struct {
+	// Kind is "secret", "topic", "schedule" or "callback-host".
+	Kind string `json:"kind"`
+
+	// Reference is the secret name, topic name, cron expression or host
+	// that failed to resolve.
+	Reference string `json:"reference"`
+
+	// Reason explains why, e.g. "secret does not exist" or "not a valid
+	// cron expression".
+	Reason string `json:"reason"`
+}
+
+// SecretExistenceChecker reports which of the given secret names don't
+// exist, so a deploy referencing them can be rejected up front rather than
+// left to fail once the function can't mount them.
+type SecretExistenceChecker interface {
+	MissingSecrets(names []string) []string
+}
+
+// HTTPSecretExistenceChecker checks secret names against the connected
+// provider's GET /system/secrets endpoint, the same one `faas-cli secret
+// list` talks to. Not every provider implements it - faas-swarm and
+// faas-netes both do, but a bespoke provider might not - so a checker that
+// gets a 404 or can't be reached treats every name as unverifiable and
+// reports none missing, rather than rejecting every deploy a provider
+// without the endpoint would otherwise accept just fine.
+type HTTPSecretExistenceChecker struct {
+	Client               *http.Client
+	FunctionsProviderURL string
+}
+
+// MissingSecrets implements SecretExistenceChecker.
+func (h HTTPSecretExistenceChecker) MissingSecrets(names []string) []string {
+	if len(names) == 0 || h.Client == nil || len(h.FunctionsProviderURL) == 0 {
+		return nil
+	}
+
+	res, err := h.Client.Get(h.FunctionsProviderURL + "/system/secrets")
+	if err != nil || res.StatusCode != http.StatusOK {
+		if res != nil {
+			res.Body.Close()
+		}
+		return nil
+	}
+	defer res.Body.Close()
+
+	var existing []struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&existing); err != nil {
+		return nil
+	}
+
+	have := make(map[string]bool, len(existing))
+	for _, secret := range existing {
+		have[secret.Name] = true
+	}
+
+	var missing []string
+	for _, name := range names {
+		if !have[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// cronFieldPattern matches one field of a 5-field cron expression: a
+// comma-separated list of "*", a number, a range ("1-5") or a step
+// ("*/5"), optionally combined. This is a syntax check, not a range
+// check - "60" in the minutes field passes just as "5" does - since
+// rejecting every out-of-range value needs a real cron parser, and none
+// is vendored in this tree.
+var cronFieldPattern = regexp.MustCompile(`^(\*|[0-9]+)(-[0-9]+)?(/[0-9]+)?(,(\*|[0-9]+)(-[0-9]+)?(/[0-9]+)?)*$`)
+
+// validSchedule reports whether expr looks like a 5-field cron expression.
+func validSchedule(expr string) bool {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return false
+	}
+	for _, field := range fields {
+		if !cronFieldPattern.MatchString(field) {
+			return false
+		}
+	}
+	return true
+}
+
+// topicNamePattern matches a single NATS topic/subject token - letters,
+// digits, dots, dashes and underscores, the same character set this
+// gateway already accepts in a function name.
+var topicNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// CheckFunctionReferences validates req's secrets and annotation-based
+// dependencies, returning one MissingReference per problem found. A nil
+// secretChecker skips secret existence checking entirely (every reference
+// kind except secrets is checked by syntax alone, so this is the only
+// check that needs one).
+func CheckFunctionReferences(req requests.CreateFunctionRequest, secretChecker SecretExistenceChecker) []MissingReference {
+	var missing []MissingReference
+
+	if secretChecker != nil {
+		for _, name := range secretChecker.MissingSecrets(req.Secrets) {
+			missing = append(missing, MissingReference{Kind: "secret", Reference: name, Reason: "secret does not exist"})
+		}
+	}
+
+	if req.Annotations == nil {
+		return missing
+	}
+	annotations := *req.Annotations
+
+	for _, topic := range splitLabelList(annotations[TopicAnnotation]) {
+		if !topicNamePattern.MatchString(topic) {
+			missing = append(missing, MissingReference{Kind: "topic", Reference: topic, Reason: "not a valid topic name"})
+		}
+	}
+
+	if schedule, exists := annotations[ScheduleAnnotation]; exists {
+		if !validSchedule(schedule) {
+			missing = append(missing, MissingReference{Kind: "schedule", Reference: schedule, Reason: "not a valid cron expression"})
+		}
+	}
+
+	if callbackHost, exists := annotations[CallbackHostAnnotation]; exists {
+		parsed, err := url.Parse(callbackHost)
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			missing = append(missing, MissingReference{Kind: "callback-host", Reference: callbackHost, Reason: "not a valid http(s) URL"})
+		}
+	}
+
+	return missing
+}
+
+// MakeFunctionReferenceIntegrityHandler wraps next - typically
+// DeployFunction or UpdateFunction - rejecting a request whose secrets or
+// annotation-based dependencies (topics, schedules, callback host) don't
+// exist or aren't valid, with a structured list of what's wrong, instead
+// of forwarding it to the provider to fail at runtime once the function
+// can't find what it was promised.
+func MakeFunctionReferenceIntegrityHandler(next http.HandlerFunc, secretChecker SecretExistenceChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		var req requests.CreateFunctionRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			// Malformed JSON isn't this handler's concern - let next (or
+			// the provider behind it) reject it with its own error.
+			next(w, r)
+			return
+		}
+
+		if missing := CheckFunctionReferences(req, secretChecker); len(missing) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(missing)
+			return
+		}
+
+		next(w, r)
+	}
+}
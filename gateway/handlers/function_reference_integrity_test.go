@@ -0,0 +1,134 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+type fakeSecretChecker struct {
+	missing []string
+}
+
+func (f fakeSecretChecker) MissingSecrets(names []string) []string {
+	return f.missing
+}
+
+func Test_CheckFunctionReferences_MissingSecret(t *testing.T) {
+	req := requests.CreateFunctionRequest{Secrets: []string{"db-password"}}
+
+	missing := CheckFunctionReferences(req, fakeSecretChecker{missing: []string{"db-password"}})
+
+	if len(missing) != 1 || missing[0].Kind != "secret" || missing[0].Reference != "db-password" {
+		t.Fatalf("unexpected result: %+v", missing)
+	}
+}
+
+func Test_CheckFunctionReferences_NoSecretCheckerSkipsSecretCheck(t *testing.T) {
+	req := requests.CreateFunctionRequest{Secrets: []string{"anything"}}
+
+	if missing := CheckFunctionReferences(req, nil); len(missing) != 0 {
+		t.Fatalf("want no findings without a secret checker, got %+v", missing)
+	}
+}
+
+func Test_CheckFunctionReferences_InvalidTopic(t *testing.T) {
+	annotations := map[string]string{TopicAnnotation: "orders, in valid"}
+	req := requests.CreateFunctionRequest{Annotations: &annotations}
+
+	missing := CheckFunctionReferences(req, nil)
+
+	if len(missing) != 1 || missing[0].Kind != "topic" || missing[0].Reference != "in valid" {
+		t.Fatalf("unexpected result: %+v", missing)
+	}
+}
+
+func Test_CheckFunctionReferences_InvalidSchedule(t *testing.T) {
+	annotations := map[string]string{ScheduleAnnotation: "not a cron expression at all"}
+	req := requests.CreateFunctionRequest{Annotations: &annotations}
+
+	missing := CheckFunctionReferences(req, nil)
+
+	if len(missing) != 1 || missing[0].Kind != "schedule" {
+		t.Fatalf("unexpected result: %+v", missing)
+	}
+}
+
+func Test_CheckFunctionReferences_ValidSchedule(t *testing.T) {
+	annotations := map[string]string{ScheduleAnnotation: "*/5 * * * *"}
+	req := requests.CreateFunctionRequest{Annotations: &annotations}
+
+	if missing := CheckFunctionReferences(req, nil); len(missing) != 0 {
+		t.Fatalf("want no findings for a valid cron expression, got %+v", missing)
+	}
+}
+
+func Test_CheckFunctionReferences_InvalidCallbackHost(t *testing.T) {
+	annotations := map[string]string{CallbackHostAnnotation: "not-a-url"}
+	req := requests.CreateFunctionRequest{Annotations: &annotations}
+
+	missing := CheckFunctionReferences(req, nil)
+
+	if len(missing) != 1 || missing[0].Kind != "callback-host" {
+		t.Fatalf("unexpected result: %+v", missing)
+	}
+}
+
+func Test_MakeFunctionReferenceIntegrityHandler_RejectsMissingReferences(t *testing.T) {
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeFunctionReferenceIntegrityHandler(next, fakeSecretChecker{missing: []string{"db-password"}})
+
+	body, _ := json.Marshal(requests.CreateFunctionRequest{Secrets: []string{"db-password"}})
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if called {
+		t.Fatal("want next not to be called when a reference is missing")
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("want 400, got %d", rec.Code)
+	}
+
+	var missing []MissingReference
+	if err := json.Unmarshal(rec.Body.Bytes(), &missing); err != nil {
+		t.Fatalf("unexpected error decoding body: %s", err)
+	}
+	if len(missing) != 1 || missing[0].Kind != "secret" {
+		t.Fatalf("unexpected body: %+v", missing)
+	}
+}
+
+func Test_MakeFunctionReferenceIntegrityHandler_ForwardsValidRequest(t *testing.T) {
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		var req requests.CreateFunctionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Service != "figlet" {
+			t.Errorf("want body to still be readable by next, got %+v", req)
+		}
+	}
+
+	handler := MakeFunctionReferenceIntegrityHandler(next, fakeSecretChecker{})
+
+	body, _ := json.Marshal(requests.CreateFunctionRequest{Service: "figlet"})
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called for a valid request")
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func Test_FilterHeaders_AlwaysStripsHopByHopHeaders(t *testing.T) {
+	headers := http.Header{
+		"Connection": []string{"keep-alive"},
+		"X-Source":   []string{"unit-test"},
+		"Upgrade":    []string{"websocket"},
+	}
+
+	filtered := filterHeaders(headers, nil, nil)
+
+	if filtered.Get("Connection") != "" || filtered.Get("Upgrade") != "" {
+		t.Errorf("want hop-by-hop headers stripped, got %v", filtered)
+	}
+	if filtered.Get("X-Source") != "unit-test" {
+		t.Errorf("want application header kept, got %v", filtered)
+	}
+}
+
+func Test_FilterHeaders_AllowListRestrictsToNamedHeaders(t *testing.T) {
+	headers := http.Header{
+		"X-Source": []string{"unit-test"},
+		"X-Other":  []string{"dropped"},
+	}
+
+	filtered := filterHeaders(headers, []string{"X-Source"}, nil)
+
+	if filtered.Get("X-Source") != "unit-test" {
+		t.Errorf("want allow-listed header kept, got %v", filtered)
+	}
+	if filtered.Get("X-Other") != "" {
+		t.Errorf("want non-allow-listed header dropped, got %v", filtered)
+	}
+}
+
+func Test_FilterHeaders_DenyListStripsNamedHeaders(t *testing.T) {
+	headers := http.Header{
+		"X-Source": []string{"unit-test"},
+		"X-Secret": []string{"shh"},
+	}
+
+	filtered := filterHeaders(headers, nil, []string{"X-Secret"})
+
+	if filtered.Get("X-Source") != "unit-test" {
+		t.Errorf("want non-denied header kept, got %v", filtered)
+	}
+	if filtered.Get("X-Secret") != "" {
+		t.Errorf("want deny-listed header dropped, got %v", filtered)
+	}
+}
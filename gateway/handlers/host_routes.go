@@ -0,0 +1,142 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// HostRoute maps a vanity Host header onto a function, so a caller without
+// an ingress controller in front of the gateway can still expose a function
+// on its own domain.
+type HostRoute struct {
+	Host         string `json:"host"`
+	FunctionName string `json:"functionName"`
+}
+
+// HostRouteStore holds the Host-header-to-function mappings consulted by
+// MakeHostRoutingHandler. It is safe for concurrent use and changes take
+// effect on the next matching request - there is nothing to reload.
+type HostRouteStore struct {
+	mutex  sync.RWMutex
+	routes map[string]string
+}
+
+// NewHostRouteStore creates an empty HostRouteStore.
+func NewHostRouteStore() *HostRouteStore {
+	return &HostRouteStore{
+		routes: make(map[string]string),
+	}
+}
+
+// Set maps host onto functionName, replacing any existing mapping for host.
+func (s *HostRouteStore) Set(host, functionName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.routes[normalizeHost(host)] = functionName
+}
+
+// Resolve returns the function name mapped to host, or "" if host has no
+// mapping. host may include a port, which is ignored.
+func (s *HostRouteStore) Resolve(host string) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.routes[normalizeHost(host)]
+}
+
+// Delete removes the mapping for host, if any.
+func (s *HostRouteStore) Delete(host string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.routes, normalizeHost(host))
+}
+
+// List returns every configured HostRoute.
+func (s *HostRouteStore) List() []HostRoute {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	routes := make([]HostRoute, 0, len(s.routes))
+	for host, functionName := range s.routes {
+		routes = append(routes, HostRoute{Host: host, FunctionName: functionName})
+	}
+	return routes
+}
+
+// normalizeHost strips a port suffix and lower-cases host, so "API.Example.com:8080"
+// and "api.example.com" both resolve to the same mapping.
+func normalizeHost(host string) string {
+	if idx := strings.LastIndex(host, ":"); idx != -1 {
+		host = host[:idx]
+	}
+	return strings.ToLower(host)
+}
+
+// MakeHostRoutingHandler rewrites a request's path to target the function
+// mapped from its Host header via store, then delegates to next. Callers
+// should only invoke this handler for requests where store.Resolve(r.Host)
+// is already known to be non-empty, e.g. via a gorilla/mux MatcherFunc
+// registered ahead of the gateway's own /function/* routes.
+func MakeHostRoutingHandler(next http.HandlerFunc, store *HostRouteStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := store.Resolve(r.Host)
+		if functionName == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		r.URL.Path = "/function/" + functionName + r.URL.Path
+		next(w, r)
+	}
+}
+
+// MakeListHostRoutesHandler lists every configured host-to-function mapping.
+func MakeListHostRoutesHandler(store *HostRouteStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(store.List())
+	}
+}
+
+// MakeSetHostRouteHandler creates or replaces a host-to-function mapping.
+func MakeSetHostRouteHandler(store *HostRouteStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var route HostRoute
+		if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
+			http.Error(w, "unable to decode host route: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if route.Host == "" || route.FunctionName == "" {
+			http.Error(w, "host and functionName are required", http.StatusBadRequest)
+			return
+		}
+
+		store.Set(route.Host, route.FunctionName)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeDeleteHostRouteHandler removes the host-to-function mapping named by
+// the "host" mux variable.
+func MakeDeleteHostRouteHandler(store *HostRouteStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := mux.Vars(r)["host"]
+		if host == "" {
+			http.Error(w, "host is required", http.StatusBadRequest)
+			return
+		}
+
+		store.Delete(host)
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func Test_HostRouteStore_SetResolveDelete(t *testing.T) {
+	store := NewHostRouteStore()
+
+	store.Set("api.example.com", "env-prod/api")
+
+	if got := store.Resolve("api.example.com"); got != "env-prod/api" {
+		t.Errorf("want env-prod/api, got %s", got)
+	}
+
+	store.Delete("api.example.com")
+
+	if got := store.Resolve("api.example.com"); got != "" {
+		t.Errorf("want no mapping after delete, got %s", got)
+	}
+}
+
+func Test_HostRouteStore_ResolveIgnoresPortAndCase(t *testing.T) {
+	store := NewHostRouteStore()
+	store.Set("API.Example.com", "api")
+
+	if got := store.Resolve("api.example.com:8080"); got != "api" {
+		t.Errorf("want a case/port-insensitive match, got %s", got)
+	}
+}
+
+func Test_MakeHostRoutingHandler_RewritesPathForMappedHost(t *testing.T) {
+	store := NewHostRouteStore()
+	store.Set("api.example.com", "api")
+
+	var gotPath string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeHostRoutingHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/widgets", nil)
+	req.Host = "api.example.com"
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if gotPath != "/function/api/widgets" {
+		t.Errorf("want /function/api/widgets, got %s", gotPath)
+	}
+}
+
+func Test_MakeHostRoutingHandler_404sForUnmappedHost(t *testing.T) {
+	store := NewHostRouteStore()
+	handler := MakeHostRoutingHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called for an unmapped host")
+	}, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "unmapped.example.com"
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("want 404, got %d", rr.Code)
+	}
+}
+
+func Test_MakeSetHostRouteHandler_RejectsIncompleteRoute(t *testing.T) {
+	store := NewHostRouteStore()
+	handler := MakeSetHostRouteHandler(store)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/hostroutes", strings.NewReader(`{"host":"api.example.com"}`))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("want 400 for a route missing functionName, got %d", rr.Code)
+	}
+}
+
+func Test_MakeDeleteHostRouteHandler_RemovesMapping(t *testing.T) {
+	store := NewHostRouteStore()
+	store.Set("api.example.com", "api")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/hostroutes/{host}", MakeDeleteHostRouteHandler(store)).Methods(http.MethodDelete)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/hostroutes/api.example.com", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+	if got := store.Resolve("api.example.com"); got != "" {
+		t.Errorf("want mapping removed, got %s", got)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/types"
+)
+
+func Test_ForwardRequest_SlowButContinuousStreamSurvivesPastTotalTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		for i := 0; i < 5; i++ {
+			fmt.Fprintf(w, "chunk-%d", i)
+			flusher.Flush()
+			time.Sleep(time.Millisecond * 60)
+		}
+	}))
+	defer upstream.Close()
+
+	baseURL, _ := url.Parse(upstream.URL)
+	proxy := types.NewHTTPClientReverseProxy(baseURL, time.Millisecond*100, nil)
+	proxy.IdleStreamTimeout = time.Millisecond * 500
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	rr := httptest.NewRecorder()
+
+	statusCode, err := forwardRequestBuffered(rr, req, proxy, baseURL.String(), "/")
+
+	if err != nil {
+		t.Fatalf("want a continuously streaming response to survive past the total timeout, got err: %s", err.Error())
+	}
+	if statusCode != http.StatusOK {
+		t.Errorf("want status 200, got %d", statusCode)
+	}
+	if rr.Body.String() != "chunk-0chunk-1chunk-2chunk-3chunk-4" {
+		t.Errorf("want the full stream to be forwarded, got %q", rr.Body.String())
+	}
+}
+
+func Test_ForwardRequest_StalledStreamIsReapedByIdleTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		fmt.Fprint(w, "first-chunk")
+		flusher.Flush()
+		time.Sleep(time.Second) // longer than the test's idle timeout
+	}))
+	defer upstream.Close()
+
+	baseURL, _ := url.Parse(upstream.URL)
+	proxy := types.NewHTTPClientReverseProxy(baseURL, time.Second*5, nil)
+	proxy.IdleStreamTimeout = time.Millisecond * 100
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	rr := httptest.NewRecorder()
+
+	start := time.Now()
+	forwardRequestBuffered(rr, req, proxy, baseURL.String(), "/")
+	elapsed := time.Since(start)
+
+	if elapsed > time.Second {
+		t.Errorf("want the stalled upstream to be reaped around the idle timeout, took %s", elapsed)
+	}
+	if rr.Body.String() != "first-chunk" {
+		t.Errorf("want the bytes sent before the stall to still be forwarded, got %q", rr.Body.String())
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"log"
+	"time"
+)
+
+// idleReapInterval is how often an Idler checks for functions that have
+// gone idle, in the absence of an IdlerConfig.TickInterval.
+const idleReapInterval = time.Minute
+
+// IdlerConfig configures an opt-in scale-to-zero idler, standing in for
+// the separate faas-idler component for gateways that would rather not
+// run it.
+type IdlerConfig struct {
+	// DefaultIdleTimeout is how long a function can go without a recorded
+	// invocation before the idler scales it to zero. Zero disables the
+	// idler for any function not named in IdleTimeouts.
+	DefaultIdleTimeout time.Duration
+
+	// IdleTimeouts overrides DefaultIdleTimeout for specific function
+	// names, e.g. a function that is expensive to cold-start and should
+	// be given more headroom before being scaled down.
+	IdleTimeouts map[string]time.Duration
+
+	// Exclude lists function names the idler must never scale to zero,
+	// regardless of timeout, e.g. functions that poll an external source
+	// rather than being invoked directly.
+	Exclude map[string]bool
+
+	// TickInterval is how often the idler checks for idle functions.
+	// Defaults to idleReapInterval when unset.
+	TickInterval time.Duration
+
+	// History supplies each function's most recent invocation time.
+	History *InvocationHistory
+
+	// ServiceQuery is used to check whether a function is already scaled
+	// to zero, and to scale it down once idle.
+	ServiceQuery ServiceQuery
+
+	// Inflight, when set, is consulted immediately before scaling a
+	// function to zero. A function with at least one in-flight request
+	// is left alone for this tick even though its idle timeout has
+	// elapsed - closing the race where a request arrives and starts
+	// being handled just as the idler decides the function has gone
+	// quiet, and is then dropped when the replica disappears underneath
+	// it.
+	Inflight *InflightTracker
+
+	// Clock drives idle-window checks, defaulting to RealClock.
+	Clock Clock
+}
+
+// Idler is a ticker-driven background controller that scales a function
+// to zero once it has gone idle - no recorded invocation - for longer
+// than its configured timeout.
+type Idler struct {
+	config IdlerConfig
+}
+
+// NewIdler creates an Idler from config.
+func NewIdler(config IdlerConfig) *Idler {
+	return &Idler{config: config}
+}
+
+func (i *Idler) clock() Clock {
+	if i.config.Clock == nil {
+		return RealClock
+	}
+	return i.config.Clock
+}
+
+func (i *Idler) idleTimeout(functionName string) time.Duration {
+	if timeout, ok := i.config.IdleTimeouts[functionName]; ok {
+		return timeout
+	}
+	return i.config.DefaultIdleTimeout
+}
+
+// IdleOnce scales to zero every watched function that has gone idle for
+// longer than its configured timeout, returning the names it scaled
+// down.
+func (i *Idler) IdleOnce() []string {
+	now := i.clock().Now()
+
+	var idled []string
+	for _, functionName := range i.config.History.FunctionNames() {
+		if i.config.Exclude[functionName] {
+			continue
+		}
+
+		timeout := i.idleTimeout(functionName)
+		if timeout <= 0 {
+			continue
+		}
+
+		lastInvocation, ok := i.config.History.LastInvocation(functionName)
+		if !ok || now.Sub(lastInvocation) < timeout {
+			continue
+		}
+
+		if i.config.Inflight != nil && i.config.Inflight.Count(functionName) > 0 {
+			continue
+		}
+
+		response, err := i.config.ServiceQuery.GetReplicas(functionName)
+		if err != nil {
+			log.Printf("idler: unable to query replicas for %s: %s", functionName, err.Error())
+			continue
+		}
+		if response.AvailableReplicas == 0 {
+			continue
+		}
+
+		if err := i.config.ServiceQuery.SetReplicas(functionName, 0); err != nil {
+			log.Printf("idler: unable to scale %s to zero: %s", functionName, err.Error())
+			continue
+		}
+
+		idled = append(idled, functionName)
+	}
+	return idled
+}
+
+// Start runs IdleOnce every TickInterval until the returned func is
+// called.
+func (i *Idler) Start() (stop func()) {
+	interval := i.config.TickInterval
+	if interval <= 0 {
+		interval = idleReapInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if idled := i.IdleOnce(); len(idled) > 0 {
+					log.Printf("idler: scaled %d idle function(s) to zero: %v", len(idled), idled)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,172 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Idler_ScalesDownAnIdleFunction(t *testing.T) {
+	history := &InvocationHistory{}
+	history.Record("figlet", InvocationRecord{Timestamp: time.Unix(0, 0)})
+
+	query := &fakeServiceQuery{response: ServiceQueryResponse{AvailableReplicas: 1}}
+	clock := &fakeClock{now: time.Unix(0, 0).Add(time.Hour)}
+
+	idler := NewIdler(IdlerConfig{
+		DefaultIdleTimeout: time.Minute,
+		History:            history,
+		ServiceQuery:       query,
+		Clock:              clock,
+	})
+
+	idled := idler.IdleOnce()
+
+	if len(idled) != 1 || idled[0] != "figlet" {
+		t.Fatalf("want figlet to be idled, got %v", idled)
+	}
+	if !query.setCalled || query.replicas != 0 {
+		t.Fatalf("want figlet scaled to zero, got setCalled=%v replicas=%d", query.setCalled, query.replicas)
+	}
+}
+
+func Test_Idler_LeavesAFunctionInvokedWithinItsTimeoutAlone(t *testing.T) {
+	history := &InvocationHistory{}
+	history.Record("figlet", InvocationRecord{Timestamp: time.Unix(0, 0)})
+
+	query := &fakeServiceQuery{response: ServiceQueryResponse{AvailableReplicas: 1}}
+	clock := &fakeClock{now: time.Unix(0, 0).Add(time.Second)}
+
+	idler := NewIdler(IdlerConfig{
+		DefaultIdleTimeout: time.Minute,
+		History:            history,
+		ServiceQuery:       query,
+		Clock:              clock,
+	})
+
+	idled := idler.IdleOnce()
+
+	if len(idled) != 0 {
+		t.Fatalf("want nothing idled, got %v", idled)
+	}
+	if query.setCalled {
+		t.Error("want SetReplicas not called")
+	}
+}
+
+func Test_Idler_SkipsExcludedFunctions(t *testing.T) {
+	history := &InvocationHistory{}
+	history.Record("figlet", InvocationRecord{Timestamp: time.Unix(0, 0)})
+
+	query := &fakeServiceQuery{response: ServiceQueryResponse{AvailableReplicas: 1}}
+	clock := &fakeClock{now: time.Unix(0, 0).Add(time.Hour)}
+
+	idler := NewIdler(IdlerConfig{
+		DefaultIdleTimeout: time.Minute,
+		Exclude:            map[string]bool{"figlet": true},
+		History:            history,
+		ServiceQuery:       query,
+		Clock:              clock,
+	})
+
+	if idled := idler.IdleOnce(); len(idled) != 0 {
+		t.Fatalf("want excluded function left alone, got %v", idled)
+	}
+}
+
+func Test_Idler_UsesPerFunctionIdleTimeoutOverride(t *testing.T) {
+	history := &InvocationHistory{}
+	history.Record("slow-starter", InvocationRecord{Timestamp: time.Unix(0, 0)})
+
+	query := &fakeServiceQuery{response: ServiceQueryResponse{AvailableReplicas: 1}}
+	clock := &fakeClock{now: time.Unix(0, 0).Add(time.Minute)}
+
+	idler := NewIdler(IdlerConfig{
+		DefaultIdleTimeout: time.Minute,
+		IdleTimeouts:       map[string]time.Duration{"slow-starter": time.Hour},
+		History:            history,
+		ServiceQuery:       query,
+		Clock:              clock,
+	})
+
+	if idled := idler.IdleOnce(); len(idled) != 0 {
+		t.Fatalf("want the overridden timeout to keep slow-starter alive, got %v", idled)
+	}
+}
+
+func Test_Idler_SkipsAFunctionAlreadyAtZero(t *testing.T) {
+	history := &InvocationHistory{}
+	history.Record("figlet", InvocationRecord{Timestamp: time.Unix(0, 0)})
+
+	query := &fakeServiceQuery{response: ServiceQueryResponse{AvailableReplicas: 0}}
+	clock := &fakeClock{now: time.Unix(0, 0).Add(time.Hour)}
+
+	idler := NewIdler(IdlerConfig{
+		DefaultIdleTimeout: time.Minute,
+		History:            history,
+		ServiceQuery:       query,
+		Clock:              clock,
+	})
+
+	idled := idler.IdleOnce()
+	if len(idled) != 0 {
+		t.Fatalf("want nothing idled, got %v", idled)
+	}
+	if query.setCalled {
+		t.Error("want SetReplicas not called for a function already at zero")
+	}
+}
+
+func Test_Idler_LeavesAFunctionWithInflightRequestsAlone(t *testing.T) {
+	history := &InvocationHistory{}
+	history.Record("figlet", InvocationRecord{Timestamp: time.Unix(0, 0)})
+
+	inflight := NewInflightTracker()
+	inflight.Acquire("figlet", 0)
+
+	query := &fakeServiceQuery{response: ServiceQueryResponse{AvailableReplicas: 1}}
+	clock := &fakeClock{now: time.Unix(0, 0).Add(time.Hour)}
+
+	idler := NewIdler(IdlerConfig{
+		DefaultIdleTimeout: time.Minute,
+		History:            history,
+		ServiceQuery:       query,
+		Inflight:           inflight,
+		Clock:              clock,
+	})
+
+	idled := idler.IdleOnce()
+	if len(idled) != 0 {
+		t.Fatalf("want nothing idled while a request is in flight, got %v", idled)
+	}
+	if query.setCalled {
+		t.Error("want SetReplicas not called while a request is in flight")
+	}
+}
+
+func Test_Idler_ScalesDownOnceInflightRequestsFinish(t *testing.T) {
+	history := &InvocationHistory{}
+	history.Record("figlet", InvocationRecord{Timestamp: time.Unix(0, 0)})
+
+	inflight := NewInflightTracker()
+	inflight.Acquire("figlet", 0)
+	inflight.Release("figlet")
+
+	query := &fakeServiceQuery{response: ServiceQueryResponse{AvailableReplicas: 1}}
+	clock := &fakeClock{now: time.Unix(0, 0).Add(time.Hour)}
+
+	idler := NewIdler(IdlerConfig{
+		DefaultIdleTimeout: time.Minute,
+		History:            history,
+		ServiceQuery:       query,
+		Inflight:           inflight,
+		Clock:              clock,
+	})
+
+	idled := idler.IdleOnce()
+	if len(idled) != 1 || idled[0] != "figlet" {
+		t.Fatalf("want figlet idled once its in-flight requests finish, got %v", idled)
+	}
+}
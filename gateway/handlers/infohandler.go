@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"runtime"
+	"time"
 
 	"io/ioutil"
 	"net/http/httptest"
@@ -15,8 +17,11 @@ import (
 	"github.com/openfaas/faas/gateway/version"
 )
 
-// MakeInfoHandler is responsible for display component version information
-func MakeInfoHandler(h http.Handler) http.HandlerFunc {
+// MakeInfoHandler is responsible for display component version information.
+// features and backends are reported verbatim in the response, for
+// fingerprinting a deployment's configuration; startedAt is used to derive
+// UptimeSeconds.
+func MakeInfoHandler(h http.Handler, startedAt time.Time, features []string, backends types.BackendsInfo) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		responseRecorder := httptest.NewRecorder()
 		h.ServeHTTP(responseRecorder, r)
@@ -48,6 +53,12 @@ func MakeInfoHandler(h http.Handler) http.HandlerFunc {
 				Name:          provider["provider"].(string),
 				Orchestration: provider["orchestration"].(string),
 			},
+			Build: &types.BuildInfo{
+				GoVersion: runtime.Version(),
+			},
+			Features:      features,
+			Backends:      &backends,
+			UptimeSeconds: time.Since(startedAt).Seconds(),
 		}
 
 		jsonOut, marshalErr := json.Marshal(gatewayInfo)
@@ -0,0 +1,51 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/types"
+)
+
+func Test_MakeInfoHandler_ReportsBuildFeaturesBackendsAndUptime(t *testing.T) {
+	providerHandler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"provider":"mockprovider","orchestration":"mock","version":{"sha":"abc123","release":"1.0.0"}}`))
+	}
+
+	startedAt := time.Now().Add(-time.Hour)
+	backends := types.BackendsInfo{QueueType: "nats-streaming", AuthMode: "basic"}
+	handler := MakeInfoHandler(http.HandlerFunc(providerHandler), startedAt, []string{"scale_from_zero", "api_keys"}, backends)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/info", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+
+	var info types.GatewayInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+		t.Fatalf("unable to decode response: %s", err.Error())
+	}
+
+	if info.Build == nil || info.Build.GoVersion == "" {
+		t.Error("want a non-empty Build.GoVersion")
+	}
+	if len(info.Features) != 2 || info.Features[0] != "scale_from_zero" {
+		t.Errorf("want features reported verbatim, got %v", info.Features)
+	}
+	if info.Backends == nil || info.Backends.QueueType != "nats-streaming" || info.Backends.AuthMode != "basic" {
+		t.Errorf("want backends reported verbatim, got %+v", info.Backends)
+	}
+	if info.UptimeSeconds < 3599 {
+		t.Errorf("want uptime to reflect startedAt being an hour ago, got %f", info.UptimeSeconds)
+	}
+}
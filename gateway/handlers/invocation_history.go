@@ -0,0 +1,129 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// InvocationRecord is one entry in a function's invocation history.
+type InvocationRecord struct {
+	Timestamp      time.Time `json:"timestamp"`
+	StatusCode     int       `json:"statusCode"`
+	DurationMillis int64     `json:"durationMillis"`
+	CallID         string    `json:"callId"`
+}
+
+// InvocationHistory keeps a fixed-size, in-memory ring buffer of the most
+// recent invocations per function, for quick triage without a metrics
+// query.
+type InvocationHistory struct {
+	// Capacity is the maximum number of invocations retained per function.
+	// Defaults to 20 when unset.
+	Capacity int
+
+	lock    sync.Mutex
+	history map[string][]InvocationRecord
+}
+
+// Record appends an invocation to functionName's history, evicting the
+// oldest entry once Capacity is reached.
+func (h *InvocationHistory) Record(functionName string, record InvocationRecord) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.history == nil {
+		h.history = make(map[string][]InvocationRecord)
+	}
+
+	capacity := h.Capacity
+	if capacity <= 0 {
+		capacity = 20
+	}
+
+	entries := append(h.history[functionName], record)
+	if len(entries) > capacity {
+		entries = entries[len(entries)-capacity:]
+	}
+	h.history[functionName] = entries
+}
+
+// Get returns a copy of functionName's recorded invocations, oldest first.
+func (h *InvocationHistory) Get(functionName string) []InvocationRecord {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	entries := h.history[functionName]
+	out := make([]InvocationRecord, len(entries))
+	copy(out, entries)
+	return out
+}
+
+// LastInvocation returns the timestamp of functionName's most recent
+// recorded invocation, if any.
+func (h *InvocationHistory) LastInvocation(functionName string) (time.Time, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	entries := h.history[functionName]
+	if len(entries) == 0 {
+		return time.Time{}, false
+	}
+	return entries[len(entries)-1].Timestamp, true
+}
+
+// FunctionNames returns the names of every function with at least one
+// recorded invocation.
+func (h *InvocationHistory) FunctionNames() []string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	names := make([]string, 0, len(h.history))
+	for name := range h.history {
+		names = append(names, name)
+	}
+	return names
+}
+
+// MakeHistoryHandler wraps next so that each invocation's timestamp,
+// status, duration and call ID are recorded to history.
+func MakeHistoryHandler(next http.HandlerFunc, history *InvocationHistory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		recorder := httptest.NewRecorder()
+		next(recorder, r)
+
+		for k, v := range recorder.HeaderMap {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(recorder.Code)
+		w.Write(recorder.Body.Bytes())
+
+		history.Record(getServiceName(r.URL.Path), InvocationRecord{
+			Timestamp:      start,
+			StatusCode:     recorder.Code,
+			DurationMillis: time.Since(start).Milliseconds(),
+			CallID:         r.Header.Get("X-Call-Id"),
+		})
+	}
+}
+
+// MakeListInvocationsHandler creates a handler for GET
+// /system/functions/{name}/invocations, returning the function's recorded
+// invocation history.
+func MakeListInvocationsHandler(history *InvocationHistory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := mux.Vars(r)["name"]
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(history.Get(functionName))
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_InvocationHistory_EvictsOldestEntryPastCapacity(t *testing.T) {
+	history := &InvocationHistory{Capacity: 2}
+
+	history.Record("echo", InvocationRecord{StatusCode: 200})
+	history.Record("echo", InvocationRecord{StatusCode: 201})
+	history.Record("echo", InvocationRecord{StatusCode: 202})
+
+	entries := history.Get("echo")
+	if len(entries) != 2 {
+		t.Fatalf("want 2 retained entries, got %d", len(entries))
+	}
+	if entries[0].StatusCode != 201 || entries[1].StatusCode != 202 {
+		t.Errorf("want oldest entry evicted, got %+v", entries)
+	}
+}
+
+func Test_HistoryHandler_RecordsCompletedInvocation(t *testing.T) {
+	history := &InvocationHistory{Capacity: 10}
+
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("X-Call-Id", "call-123")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	handler := MakeHistoryHandler(upstream, history)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	entries := history.Get("echo")
+	if len(entries) != 1 {
+		t.Fatalf("want 1 recorded invocation, got %d", len(entries))
+	}
+	if entries[0].StatusCode != http.StatusOK || entries[0].CallID != "call-123" {
+		t.Errorf("want recorded invocation with status 200 and call id, got %+v", entries[0])
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/docker/distribution/uuid"
+	"github.com/openfaas/faas/gateway/queue"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// FunctionCatalog lists currently deployed functions, so a label selector
+// can be resolved to a concrete set of function names.
+type FunctionCatalog interface {
+	List() ([]requests.Function, error)
+}
+
+// HTTPFunctionCatalog lists functions by querying the provider's own
+// GET /system/functions endpoint directly, the same route faasHandlers.
+// ListFunctions proxies to.
+type HTTPFunctionCatalog struct {
+	Client               *http.Client
+	FunctionsProviderURL string
+}
+
+// List fetches the current function catalog from the provider.
+func (h HTTPFunctionCatalog) List() ([]requests.Function, error) {
+	res, err := h.Client.Get(strings.TrimSuffix(h.FunctionsProviderURL, "/") + "/system/functions")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("provider returned status %d listing functions", res.StatusCode)
+	}
+
+	var out []requests.Function
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ParseLabelSelector parses an equality-based label selector of the form
+// "key1=value1,key2=value2" - the same syntax Kubernetes uses for simple
+// selectors. Only equality terms are supported; there is no set-based
+// (in/notin) matching.
+func ParseLabelSelector(raw string) (map[string]string, error) {
+	selector := make(map[string]string)
+
+	raw = strings.TrimSpace(raw)
+	if len(raw) == 0 {
+		return selector, nil
+	}
+
+	for _, term := range strings.Split(raw, ",") {
+		term = strings.TrimSpace(term)
+		parts := strings.SplitN(term, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("invalid label selector term %q, want key=value", term)
+		}
+		selector[parts[0]] = parts[1]
+	}
+
+	return selector, nil
+}
+
+// matchesSelector reports whether labels satisfies every key=value term in
+// selector. An empty selector matches everything.
+func matchesSelector(labels *map[string]string, selector map[string]string) bool {
+	if len(selector) == 0 {
+		return true
+	}
+	if labels == nil {
+		return false
+	}
+
+	for key, value := range selector {
+		if (*labels)[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// InvokeSelectorRequest is the body of POST /system/invoke-selector.
+type InvokeSelectorRequest struct {
+	// Selector is an equality-based label selector, e.g. "topic=nightly-jobs".
+	Selector string `json:"selector"`
+
+	// Body is queued as-is for every matching function's invocation.
+	Body json.RawMessage `json:"body"`
+
+	// Method is the HTTP method each matching function is invoked with.
+	// Defaults to POST.
+	Method string `json:"method"`
+}
+
+// InvokeSelectorResult reports the call ID queued for one matching function.
+type InvokeSelectorResult struct {
+	FunctionName string `json:"functionName"`
+	CallID       string `json:"callId"`
+}
+
+// MakeInvokeSelectorHandler exposes POST /system/invoke-selector: it
+// resolves Selector against catalog, then queues an async invocation of
+// every matching function with Body, returning the call ID assigned to
+// each one - a built-in broadcast trigger for fleet-wide jobs (e.g. every
+// function tagged with a "topic" label) without a separate fan-out script.
+// Functions that fail to queue are logged and skipped rather than failing
+// the whole broadcast.
+func MakeInvokeSelectorHandler(catalog FunctionCatalog, canQueueRequests queue.CanQueueRequests, asyncCalls *AsyncCallStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req InvokeSelectorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		selector, err := ParseLabelSelector(req.Selector)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		functions, err := catalog.List()
+		if err != nil {
+			http.Error(w, "unable to list functions: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		method := req.Method
+		if len(method) == 0 {
+			method = http.MethodPost
+		}
+
+		results := make([]InvokeSelectorResult, 0)
+		for _, fn := range functions {
+			if !matchesSelector(fn.Labels, selector) {
+				continue
+			}
+
+			callID := uuid.Generate().String()
+
+			queueErr := canQueueRequests.Queue(&queue.Request{
+				Function: fn.Name,
+				Body:     []byte(req.Body),
+				Method:   method,
+				Header:   http.Header{"X-Call-Id": []string{callID}},
+			})
+			if queueErr != nil {
+				log.Printf("invoke-selector: unable to queue function=%s: %s", fn.Name, queueErr.Error())
+				continue
+			}
+
+			if asyncCalls != nil {
+				asyncCalls.Record(callID, AsyncCallQueued)
+			}
+
+			results = append(results, InvokeSelectorResult{FunctionName: fn.Name, CallID: callID})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(results)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+type fakeFunctionCatalog struct {
+	functions []requests.Function
+	err       error
+}
+
+func (f fakeFunctionCatalog) List() ([]requests.Function, error) {
+	return f.functions, f.err
+}
+
+func labels(pairs map[string]string) *map[string]string {
+	return &pairs
+}
+
+func Test_ParseLabelSelector(t *testing.T) {
+	scenarios := []struct {
+		name    string
+		raw     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty selector matches everything", raw: "", want: map[string]string{}},
+		{name: "single term", raw: "topic=nightly-jobs", want: map[string]string{"topic": "nightly-jobs"}},
+		{name: "multiple terms", raw: "topic=nightly-jobs,tier=batch", want: map[string]string{"topic": "nightly-jobs", "tier": "batch"}},
+		{name: "missing value is invalid", raw: "topic=", wantErr: true},
+		{name: "missing equals is invalid", raw: "topic", wantErr: true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			got, err := ParseLabelSelector(s.raw)
+			if s.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if len(got) != len(s.want) {
+				t.Fatalf("want %v, got %v", s.want, got)
+			}
+			for k, v := range s.want {
+				if got[k] != v {
+					t.Fatalf("want %v, got %v", s.want, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_MakeInvokeSelectorHandler_QueuesOnlyMatchingFunctions(t *testing.T) {
+	catalog := fakeFunctionCatalog{functions: []requests.Function{
+		{Name: "nightly-report", Labels: labels(map[string]string{"topic": "nightly-jobs"})},
+		{Name: "nightly-cleanup", Labels: labels(map[string]string{"topic": "nightly-jobs"})},
+		{Name: "webhook", Labels: labels(map[string]string{"topic": "events"})},
+		{Name: "no-labels"},
+	}}
+	rq := &recordingQueue{}
+	asyncCalls := NewAsyncCallStore()
+
+	handler := MakeInvokeSelectorHandler(catalog, rq, asyncCalls)
+
+	body := strings.NewReader(`{"selector":"topic=nightly-jobs","body":{"run":true}}`)
+	req := httptest.NewRequest(http.MethodPost, "/system/invoke-selector", body)
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rec.Code)
+	}
+
+	var results []InvokeSelectorResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("unable to decode response: %s", err.Error())
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("want 2 matching functions invoked, got %d: %+v", len(results), results)
+	}
+	if len(rq.queued) != 2 {
+		t.Fatalf("want 2 queued invocations, got %d", len(rq.queued))
+	}
+	for _, result := range results {
+		if len(result.CallID) == 0 {
+			t.Errorf("want a call id for %s", result.FunctionName)
+		}
+		if _, ok := asyncCalls.GetStatus(result.CallID); !ok {
+			t.Errorf("want call %s to be recorded", result.CallID)
+		}
+	}
+}
+
+func Test_MakeInvokeSelectorHandler_ReturnsBadGatewayWhenCatalogFails(t *testing.T) {
+	catalog := fakeFunctionCatalog{err: http.ErrServerClosed}
+	handler := MakeInvokeSelectorHandler(catalog, &recordingQueue{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/invoke-selector", strings.NewReader(`{}`))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("want 502 when the catalog can't be listed, got %d", rec.Code)
+	}
+}
+
+func Test_MakeInvokeSelectorHandler_RejectsAnInvalidSelector(t *testing.T) {
+	handler := MakeInvokeSelectorHandler(fakeFunctionCatalog{}, &recordingQueue{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/invoke-selector", strings.NewReader(`{"selector":"not-a-selector"}`))
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("want 400 for an invalid selector, got %d", rec.Code)
+	}
+}
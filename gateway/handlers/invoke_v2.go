@@ -0,0 +1,122 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// InvokeMode selects how an invocation made through the v2 API is executed.
+type InvokeMode string
+
+const (
+	// InvokeModeSync waits for the function to run and returns its response.
+	InvokeModeSync InvokeMode = "sync"
+
+	// InvokeModeAsync queues the invocation and returns immediately.
+	InvokeModeAsync InvokeMode = "async"
+)
+
+// InvokeV2Options carries the per-invocation options accepted by the v2
+// invoke API. parseInvokeV2Options fills Mode, IdempotencyKey and
+// CallbackURL from request headers; TimeoutSeconds and Priority are never
+// set from a request and stay zero.
+type InvokeV2Options struct {
+	Mode           InvokeMode `json:"mode,omitempty"`
+	TimeoutSeconds int        `json:"timeoutSeconds,omitempty"`
+	Priority       int        `json:"priority,omitempty"`
+	IdempotencyKey string     `json:"idempotencyKey,omitempty"`
+	CallbackURL    string     `json:"callbackUrl,omitempty"`
+}
+
+// InvokeV2Response is the structured invocation metadata returned by the v2
+// invoke API, alongside the function's own response body.
+type InvokeV2Response struct {
+	FunctionName   string     `json:"functionName"`
+	Mode           InvokeMode `json:"mode"`
+	StatusCode     int        `json:"statusCode"`
+	DurationMillis int64      `json:"durationMillis"`
+	IdempotencyKey string     `json:"idempotencyKey,omitempty"`
+	Body           string     `json:"body,omitempty"`
+}
+
+const (
+	invokeV2ModeHeader           = "X-Invoke-Mode"
+	invokeV2TimeoutHeader        = "X-Invoke-Timeout"
+	invokeV2PriorityHeader       = "X-Invoke-Priority"
+	invokeV2IdempotencyKeyHeader = "X-Invoke-Idempotency-Key"
+	invokeV2CallbackURLHeader    = "X-Callback-Url"
+)
+
+// parseInvokeV2Options reads Mode, IdempotencyKey and CallbackURL from the
+// X-Invoke-Mode, X-Invoke-Idempotency-Key and X-Callback-Url headers. The
+// request body is not parsed for options; it reaches the function as sent.
+func parseInvokeV2Options(r *http.Request) InvokeV2Options {
+	options := InvokeV2Options{Mode: InvokeModeSync}
+
+	if mode := r.Header.Get(invokeV2ModeHeader); len(mode) > 0 {
+		options.Mode = InvokeMode(mode)
+	}
+	if key := r.Header.Get(invokeV2IdempotencyKeyHeader); len(key) > 0 {
+		options.IdempotencyKey = key
+	}
+	if callback := r.Header.Get(invokeV2CallbackURLHeader); len(callback) > 0 {
+		options.CallbackURL = callback
+	}
+
+	return options
+}
+
+// MakeInvokeV2Handler wraps a legacy proxy handler for sync invocations and
+// an optional queued proxy handler for async ones, exposing a versioned
+// `POST /v2/invoke/{function}` API which accepts explicit sync/async
+// selection and returns a JSON envelope of invocation metadata. The legacy
+// `/function/` path is untouched, so existing clients keep working.
+func MakeInvokeV2Handler(syncProxy http.HandlerFunc, asyncProxy http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := mux.Vars(r)["name"]
+		if len(functionName) == 0 {
+			http.Error(w, "function name is required in the URL path", http.StatusBadRequest)
+			return
+		}
+
+		options := parseInvokeV2Options(r)
+
+		next := syncProxy
+		if options.Mode == InvokeModeAsync {
+			if asyncProxy == nil {
+				http.Error(w, "async invocations are not enabled on this gateway", http.StatusNotImplemented)
+				return
+			}
+			next = asyncProxy
+		}
+
+		start := time.Now()
+		recorder := httptest.NewRecorder()
+		next.ServeHTTP(recorder, r)
+		result := recorder.Result()
+		defer result.Body.Close()
+
+		envelope := InvokeV2Response{
+			FunctionName:   functionName,
+			Mode:           options.Mode,
+			StatusCode:     result.StatusCode,
+			DurationMillis: int64(time.Since(start) / time.Millisecond),
+			IdempotencyKey: options.IdempotencyKey,
+			Body:           recorder.Body.String(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(result.StatusCode)
+		if err := json.NewEncoder(w).Encode(envelope); err != nil {
+			fmt.Fprintf(w, `{"error": %q}`, err.Error())
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func routeToV2Handler(handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc("/v2/invoke/{name:[-a-zA-Z_0-9]+}", handler).Methods(http.MethodPost)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func Test_MakeInvokeV2Handler_Sync(t *testing.T) {
+	syncProxy := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("pong"))
+	}
+
+	handler := MakeInvokeV2Handler(syncProxy, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/v2/invoke/echo", nil)
+	rr := routeToV2Handler(handler, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status 200, got %d", rr.Code)
+	}
+
+	var envelope InvokeV2Response
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("unable to decode envelope: %s", err.Error())
+	}
+
+	if envelope.FunctionName != "echo" {
+		t.Errorf("want functionName echo, got %s", envelope.FunctionName)
+	}
+
+	if envelope.Body != "pong" {
+		t.Errorf("want body pong, got %s", envelope.Body)
+	}
+}
+
+func Test_MakeInvokeV2Handler_AsyncNotEnabled(t *testing.T) {
+	syncProxy := func(w http.ResponseWriter, r *http.Request) {}
+	handler := MakeInvokeV2Handler(syncProxy, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/v2/invoke/echo", nil)
+	req.Header.Set(invokeV2ModeHeader, "async")
+	rr := routeToV2Handler(handler, req)
+
+	if rr.Code != http.StatusNotImplemented {
+		t.Fatalf("want status 501, got %d", rr.Code)
+	}
+}
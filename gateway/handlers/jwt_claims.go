@@ -0,0 +1,108 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// JWTClaimsConfig controls MakeJWTClaimsHandler.
+type JWTClaimsConfig struct {
+	// ClaimHeaderMap maps a JWT claim name to the request header it's
+	// copied into, e.g. {"sub": "X-User-Id", "groups": "X-User-Groups"}.
+	// A claim absent from the token, or with no entry here, is left
+	// alone.
+	ClaimHeaderMap map[string]string
+}
+
+// jwtClaims decodes the claims segment of a bearer JWT found in
+// authHeader, without checking its signature or expiry. This repo vendors
+// no JWT/OIDC library, and this decoding does no verification of its own
+// (MakeOIDCAuthHandler is the verifying path, for RS256 tokens), so
+// MakeJWTClaimsHandler is metadata enrichment for a gateway sitting behind
+// something that already verified the token - not an auth check itself.
+func jwtClaims(authHeader string) (map[string]interface{}, bool) {
+	const prefix = "Bearer "
+	if !strings.HasPrefix(authHeader, prefix) {
+		return nil, false
+	}
+
+	segments := strings.Split(strings.TrimPrefix(authHeader, prefix), ".")
+	if len(segments) != 3 {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(segments[1])
+	if err != nil {
+		return nil, false
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// claimHeaderValue renders a decoded claim value as a single header value:
+// strings pass through, a string slice joins with a comma (e.g. a
+// "groups" claim), and other scalars are formatted as-is. Anything else -
+// nested objects, empty slices - has no sensible single-header rendering
+// and is skipped.
+func claimHeaderValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case []interface{}:
+		var parts []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				parts = append(parts, s)
+			}
+		}
+		if len(parts) == 0 {
+			return "", false
+		}
+		return strings.Join(parts, ","), true
+	case float64, bool:
+		return fmt.Sprintf("%v", v), true
+	default:
+		return "", false
+	}
+}
+
+// MakeJWTClaimsHandler wraps next, copying claims from an inbound bearer
+// JWT into request headers per config.ClaimHeaderMap before calling next.
+// A request with no bearer token, or one that doesn't decode as a JWT, is
+// passed through with headers untouched.
+func MakeJWTClaimsHandler(next http.HandlerFunc, config JWTClaimsConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(config.ClaimHeaderMap) == 0 {
+			next(w, r)
+			return
+		}
+
+		claims, ok := jwtClaims(r.Header.Get("Authorization"))
+		if !ok {
+			next(w, r)
+			return
+		}
+
+		for claim, header := range config.ClaimHeaderMap {
+			value, exists := claims[claim]
+			if !exists {
+				continue
+			}
+			if headerValue, ok := claimHeaderValue(value); ok {
+				r.Header.Set(header, headerValue)
+			}
+		}
+
+		next(w, r)
+	}
+}
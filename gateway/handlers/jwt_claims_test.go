@@ -0,0 +1,89 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func encodeTestJWT(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+
+	payloadBytes, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("unable to marshal claims: %s", err.Error())
+	}
+	payload := base64.RawURLEncoding.EncodeToString(payloadBytes)
+
+	return header + "." + payload + ".signature"
+}
+
+func Test_MakeJWTClaimsHandler_MapsClaimsToHeaders(t *testing.T) {
+	token := encodeTestJWT(t, map[string]interface{}{
+		"sub":    "alex",
+		"groups": []interface{}{"admins", "devs"},
+	})
+
+	var gotUserID, gotGroups string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotUserID = r.Header.Get("X-User-Id")
+		gotGroups = r.Header.Get("X-User-Groups")
+	}
+
+	handler := MakeJWTClaimsHandler(next, JWTClaimsConfig{
+		ClaimHeaderMap: map[string]string{"sub": "X-User-Id", "groups": "X-User-Groups"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	handler(httptest.NewRecorder(), req)
+
+	if gotUserID != "alex" {
+		t.Errorf("want X-User-Id: alex, got %q", gotUserID)
+	}
+	if gotGroups != "admins,devs" {
+		t.Errorf("want X-User-Groups: admins,devs, got %q", gotGroups)
+	}
+}
+
+func Test_MakeJWTClaimsHandler_PassesThroughWithoutBearerToken(t *testing.T) {
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeJWTClaimsHandler(next, JWTClaimsConfig{
+		ClaimHeaderMap: map[string]string{"sub": "X-User-Id"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	handler(httptest.NewRecorder(), req)
+
+	if !called {
+		t.Fatal("want next to be called even without a bearer token")
+	}
+}
+
+func Test_MakeJWTClaimsHandler_NoopWithoutConfiguredMapping(t *testing.T) {
+	token := encodeTestJWT(t, map[string]interface{}{"sub": "alex"})
+
+	var gotUserID string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotUserID = r.Header.Get("X-User-Id")
+	}
+
+	handler := MakeJWTClaimsHandler(next, JWTClaimsConfig{})
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	handler(httptest.NewRecorder(), req)
+
+	if len(gotUserID) > 0 {
+		t.Errorf("want no header set without a configured mapping, got %q", gotUserID)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"sync"
+)
+
+// LogBuffer is an io.Writer that keeps the last Capacity lines written to
+// it, so the gateway can hand a diagnostics bundle a recent slice of its
+// own log output without standing up a separate log-shipping pipeline.
+// Wire it in with log.SetOutput(io.MultiWriter(os.Stderr, logBuffer)) so
+// nothing about the gateway's existing logging behaviour changes.
+type LogBuffer struct {
+	// Capacity is the maximum number of lines retained. Zero disables
+	// retention - Write still succeeds, but Lines always returns empty.
+	Capacity int
+
+	lock  sync.Mutex
+	lines []string
+	next  []byte
+}
+
+// Write appends p to the buffer, splitting completed lines out of it and
+// keeping only the most recent Capacity of them. It never fails.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.next = append(b.next, p...)
+
+	for {
+		idx := bytes.IndexByte(b.next, '\n')
+		if idx < 0 {
+			break
+		}
+
+		line := string(b.next[:idx])
+		b.next = b.next[idx+1:]
+
+		if b.Capacity <= 0 {
+			continue
+		}
+
+		b.lines = append(b.lines, line)
+		if len(b.lines) > b.Capacity {
+			b.lines = b.lines[len(b.lines)-b.Capacity:]
+		}
+	}
+
+	return len(p), nil
+}
+
+// Lines returns a snapshot of the most recently retained lines, oldest
+// first.
+func (b *LogBuffer) Lines() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	lines := make([]string, len(b.lines))
+	copy(lines, b.lines)
+	return lines
+}
@@ -0,0 +1,50 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import "testing"
+
+func Test_LogBuffer_RetainsOnlyTheMostRecentLines(t *testing.T) {
+	buf := &LogBuffer{Capacity: 2}
+
+	buf.Write([]byte("one\n"))
+	buf.Write([]byte("two\n"))
+	buf.Write([]byte("three\n"))
+
+	lines := buf.Lines()
+	want := []string{"two", "three"}
+	if len(lines) != len(want) {
+		t.Fatalf("want %v, got %v", want, lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("want %v, got %v", want, lines)
+		}
+	}
+}
+
+func Test_LogBuffer_HoldsPartialLinesUntilTerminated(t *testing.T) {
+	buf := &LogBuffer{Capacity: 10}
+
+	buf.Write([]byte("partial "))
+	if len(buf.Lines()) != 0 {
+		t.Fatal("want no line retained before a newline arrives")
+	}
+
+	buf.Write([]byte("line\n"))
+	lines := buf.Lines()
+	if len(lines) != 1 || lines[0] != "partial line" {
+		t.Fatalf("want [\"partial line\"], got %v", lines)
+	}
+}
+
+func Test_LogBuffer_ZeroCapacityRetainsNothing(t *testing.T) {
+	buf := &LogBuffer{}
+
+	buf.Write([]byte("one\n"))
+
+	if len(buf.Lines()) != 0 {
+		t.Fatal("want a zero-capacity buffer to retain nothing")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import "time"
+
+// ObservabilityOptOutLabel lets a high-volume function trade away
+// per-invocation observability detail for lower overhead: an invocation of
+// a function carrying this label set to "true" is skipped by notifiers
+// wrapped in ObservabilityOptOutMode ObservabilityOptOutSkip, and has its
+// function name replaced by aggregateObservabilityLabel in notifiers
+// wrapped in ObservabilityOptOutAggregate - bounding log volume and metric
+// cardinality for that function without disabling either observability
+// mechanism gateway-wide.
+const ObservabilityOptOutLabel = "com.openfaas.observability.aggregate-only"
+
+// aggregateObservabilityLabel is the function name ObservabilityOptOutNotifier
+// substitutes, in ObservabilityOptOutAggregate mode, for an opted-out
+// function's own name - every opted-out function collapses onto this one
+// series rather than keeping its own.
+const aggregateObservabilityLabel = "_aggregate"
+
+// ObservabilityOptOutMode selects how ObservabilityOptOutNotifier reacts to
+// an invocation of a function carrying ObservabilityOptOutLabel.
+type ObservabilityOptOutMode int
+
+const (
+	// ObservabilityOptOutSkip drops the invocation without forwarding it
+	// to Next at all - the mode ExemplarLoggingNotifier is wrapped with in
+	// server.go, since its entire purpose is the per-call-ID log line
+	// opting out is meant to silence.
+	ObservabilityOptOutSkip ObservabilityOptOutMode = iota
+
+	// ObservabilityOptOutAggregate forwards the invocation to Next with
+	// its originalURL rewritten so it resolves to aggregateObservabilityLabel
+	// instead of its real function name - the mode PrometheusFunctionNotifier
+	// is wrapped with, so an opted-out function's observations still count
+	// towards totals without growing their own function_name series.
+	ObservabilityOptOutAggregate
+)
+
+// ObservabilityOptOutNotifier wraps another HTTPNotifier, consulting Specs
+// for the invoked function's ObservabilityOptOutLabel and reacting per Mode
+// when it's set. A function with no such label, or no recorded spec at all,
+// is forwarded to Next unchanged.
+type ObservabilityOptOutNotifier struct {
+	Next  HTTPNotifier
+	Specs *FunctionSpecStore
+	Mode  ObservabilityOptOutMode
+}
+
+// Notify implements HTTPNotifier.
+func (n ObservabilityOptOutNotifier) Notify(method string, URL string, originalURL string, statusCode int, duration time.Duration, callID string) {
+	if n.optedOut(originalURL) {
+		switch n.Mode {
+		case ObservabilityOptOutSkip:
+			return
+		case ObservabilityOptOutAggregate:
+			originalURL = "/function/" + aggregateObservabilityLabel
+		}
+	}
+
+	n.Next.Notify(method, URL, originalURL, statusCode, duration, callID)
+}
+
+func (n ObservabilityOptOutNotifier) optedOut(originalURL string) bool {
+	if n.Specs == nil {
+		return false
+	}
+
+	spec, exists := n.Specs.Get(getServiceName(originalURL))
+	if !exists || spec.Labels == nil {
+		return false
+	}
+
+	return (*spec.Labels)[ObservabilityOptOutLabel] == "true"
+}
@@ -0,0 +1,84 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+type recordingNotifier struct {
+	calls []string
+}
+
+func (r *recordingNotifier) Notify(method string, URL string, originalURL string, statusCode int, duration time.Duration, callID string) {
+	r.calls = append(r.calls, originalURL)
+}
+
+func Test_ObservabilityOptOutNotifier_SkipModeDropsOptedOutInvocations(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	specs.Set("figlet", requests.CreateFunctionRequest{
+		Labels: &map[string]string{ObservabilityOptOutLabel: "true"},
+	})
+
+	next := &recordingNotifier{}
+	notifier := ObservabilityOptOutNotifier{Next: next, Specs: specs, Mode: ObservabilityOptOutSkip}
+
+	notifier.Notify("GET", "/function/figlet", "/function/figlet", 200, time.Millisecond, "call-1")
+
+	if len(next.calls) != 0 {
+		t.Errorf("want the opted-out invocation dropped, got %v", next.calls)
+	}
+}
+
+func Test_ObservabilityOptOutNotifier_SkipModeForwardsUnoptedOutInvocations(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	specs.Set("figlet", requests.CreateFunctionRequest{})
+
+	next := &recordingNotifier{}
+	notifier := ObservabilityOptOutNotifier{Next: next, Specs: specs, Mode: ObservabilityOptOutSkip}
+
+	notifier.Notify("GET", "/function/figlet", "/function/figlet", 200, time.Millisecond, "call-1")
+
+	if len(next.calls) != 1 {
+		t.Fatalf("want the invocation forwarded, got %v", next.calls)
+	}
+	if next.calls[0] != "/function/figlet" {
+		t.Errorf("want originalURL left unchanged, got %s", next.calls[0])
+	}
+}
+
+func Test_ObservabilityOptOutNotifier_AggregateModeRewritesFunctionName(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	specs.Set("figlet", requests.CreateFunctionRequest{
+		Labels: &map[string]string{ObservabilityOptOutLabel: "true"},
+	})
+
+	next := &recordingNotifier{}
+	notifier := ObservabilityOptOutNotifier{Next: next, Specs: specs, Mode: ObservabilityOptOutAggregate}
+
+	notifier.Notify("GET", "/function/figlet", "/function/figlet", 200, time.Millisecond, "call-1")
+
+	if len(next.calls) != 1 {
+		t.Fatalf("want the invocation forwarded, got %v", next.calls)
+	}
+	if got := getServiceName(next.calls[0]); got != aggregateObservabilityLabel {
+		t.Errorf("want the function name collapsed to %q, got %q", aggregateObservabilityLabel, got)
+	}
+}
+
+func Test_ObservabilityOptOutNotifier_NoSpecLeavesInvocationUnchanged(t *testing.T) {
+	specs := NewFunctionSpecStore()
+
+	next := &recordingNotifier{}
+	notifier := ObservabilityOptOutNotifier{Next: next, Specs: specs, Mode: ObservabilityOptOutAggregate}
+
+	notifier.Notify("GET", "/function/figlet", "/function/figlet", 200, time.Millisecond, "call-1")
+
+	if len(next.calls) != 1 || next.calls[0] != "/function/figlet" {
+		t.Errorf("want the invocation forwarded unchanged, got %v", next.calls)
+	}
+}
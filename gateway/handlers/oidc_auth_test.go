@@ -0,0 +1,324 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-provider/auth"
+)
+
+func generateTestRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate RSA key: %s", err)
+	}
+	return key
+}
+
+func base64URLEncode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, kid string, alg string, claims map[string]interface{}) string {
+	header := map[string]interface{}{"alg": alg, "typ": "JWT", "kid": kid}
+	headerBytes, _ := json.Marshal(header)
+	claimsBytes, _ := json.Marshal(claims)
+
+	signingInput := base64URLEncode(headerBytes) + "." + base64URLEncode(claimsBytes)
+
+	if alg != "RS256" {
+		return signingInput + "." + base64URLEncode([]byte("not-a-real-signature"))
+	}
+
+	hashed := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatalf("unable to sign test JWT: %s", err)
+	}
+
+	return signingInput + "." + base64URLEncode(signature)
+}
+
+func testJWKSServer(t *testing.T, kid string, key *rsa.PrivateKey) *httptest.Server {
+	jwk := jsonWebKey{
+		Kid: kid,
+		Kty: "RSA",
+		Alg: "RS256",
+		N:   base64URLEncode(key.PublicKey.N.Bytes()),
+		E:   base64URLEncode(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+	}
+	jwks := jsonWebKeySet{Keys: []jsonWebKey{jwk}}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwks)
+	}))
+}
+
+func Test_JWKSCache_FetchesAndReturnsKeyForKid(t *testing.T) {
+	key := generateTestRSAKey(t)
+	server := testJWKSServer(t, "key-1", key)
+	defer server.Close()
+
+	cache := NewJWKSCache(server.URL, time.Minute, nil)
+	got, err := cache.Key("key-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got.N.Cmp(key.PublicKey.N) != 0 {
+		t.Fatal("want the fetched key's modulus to match the source key")
+	}
+}
+
+func Test_JWKSCache_UnknownKidReturnsError(t *testing.T) {
+	key := generateTestRSAKey(t)
+	server := testJWKSServer(t, "key-1", key)
+	defer server.Close()
+
+	cache := NewJWKSCache(server.URL, time.Minute, nil)
+	if _, err := cache.Key("missing-kid"); err == nil {
+		t.Fatal("want an error for an unknown kid")
+	}
+}
+
+func Test_MakeOIDCAuthHandler_AcceptsValidTokenAndExposesClaims(t *testing.T) {
+	key := generateTestRSAKey(t)
+	server := testJWKSServer(t, "key-1", key)
+	defer server.Close()
+
+	cache := NewJWKSCache(server.URL, time.Minute, nil)
+	config := OIDCConfig{Issuer: "https://issuer.example.com", Audience: "gateway"}
+
+	var sawClaims map[string]interface{}
+	next := func(w http.ResponseWriter, r *http.Request) {
+		sawClaims, _ = OIDCClaimsFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeOIDCAuthHandler(next, config, cache)
+
+	token := signTestJWT(t, key, "key-1", "RS256", map[string]interface{}{
+		"iss": "https://issuer.example.com",
+		"aud": "gateway",
+		"exp": float64(time.Now().Add(time.Hour).Unix()),
+		"sub": "user-1",
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if sawClaims["sub"] != "user-1" {
+		t.Fatalf("want claims exposed to next, got %v", sawClaims)
+	}
+}
+
+func Test_MakeOIDCAuthHandler_RejectsMissingToken(t *testing.T) {
+	cache := NewJWKSCache("http://unused.example.com", time.Minute, nil)
+	handler := MakeOIDCAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called")
+	}, OIDCConfig{}, cache)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401, got %d", rec.Code)
+	}
+}
+
+func Test_MakeOIDCAuthHandler_RejectsBadSignature(t *testing.T) {
+	key := generateTestRSAKey(t)
+	otherKey := generateTestRSAKey(t)
+	server := testJWKSServer(t, "key-1", key)
+	defer server.Close()
+
+	cache := NewJWKSCache(server.URL, time.Minute, nil)
+	handler := MakeOIDCAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called")
+	}, OIDCConfig{}, cache)
+
+	token := signTestJWT(t, otherKey, "key-1", "RS256", map[string]interface{}{
+		"exp": float64(time.Now().Add(time.Hour).Unix()),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401 for a bad signature, got %d", rec.Code)
+	}
+}
+
+func Test_MakeOIDCAuthHandler_RejectsWrongIssuer(t *testing.T) {
+	key := generateTestRSAKey(t)
+	server := testJWKSServer(t, "key-1", key)
+	defer server.Close()
+
+	cache := NewJWKSCache(server.URL, time.Minute, nil)
+	config := OIDCConfig{Issuer: "https://issuer.example.com"}
+	handler := MakeOIDCAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called")
+	}, config, cache)
+
+	token := signTestJWT(t, key, "key-1", "RS256", map[string]interface{}{
+		"iss": "https://someone-else.example.com",
+		"exp": float64(time.Now().Add(time.Hour).Unix()),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401 for a wrong issuer, got %d", rec.Code)
+	}
+}
+
+func Test_MakeOIDCAuthHandler_RejectsExpiredToken(t *testing.T) {
+	key := generateTestRSAKey(t)
+	server := testJWKSServer(t, "key-1", key)
+	defer server.Close()
+
+	cache := NewJWKSCache(server.URL, time.Minute, nil)
+	handler := MakeOIDCAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called")
+	}, OIDCConfig{}, cache)
+
+	token := signTestJWT(t, key, "key-1", "RS256", map[string]interface{}{
+		"exp": float64(time.Now().Add(-time.Hour).Unix()),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401 for an expired token, got %d", rec.Code)
+	}
+}
+
+func Test_MakeOIDCAuthHandler_RejectsUnsupportedAlgorithm(t *testing.T) {
+	key := generateTestRSAKey(t)
+	server := testJWKSServer(t, "key-1", key)
+	defer server.Close()
+
+	cache := NewJWKSCache(server.URL, time.Minute, nil)
+	handler := MakeOIDCAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called")
+	}, OIDCConfig{}, cache)
+
+	token := signTestJWT(t, key, "key-1", "HS256", map[string]interface{}{
+		"exp": float64(time.Now().Add(time.Hour).Unix()),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401 for an unsupported algorithm, got %d", rec.Code)
+	}
+}
+
+func Test_MakeOIDCOrBasicAuthHandler_AllowsValidBasicAuthWithoutAttemptingOIDC(t *testing.T) {
+	cache := NewJWKSCache("http://should-not-be-called.example.com", time.Minute, nil)
+	credentials := &auth.BasicAuthCredentials{User: "admin", Password: "password"}
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeOIDCOrBasicAuthHandler(next, OIDCConfig{}, cache, credentials)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	req.SetBasicAuth("admin", "password")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatalf("want next called for valid basic auth, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func Test_MakeOIDCOrBasicAuthHandler_RejectsWrongBasicAuthWithoutFallingBackToOIDC(t *testing.T) {
+	cache := NewJWKSCache("http://should-not-be-called.example.com", time.Minute, nil)
+	credentials := &auth.BasicAuthCredentials{User: "admin", Password: "password"}
+
+	handler := MakeOIDCOrBasicAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called")
+	}, OIDCConfig{}, cache, credentials)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	req.SetBasicAuth("admin", "wrong-password")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401 for a wrong password, got %d", rec.Code)
+	}
+}
+
+func Test_MakeOIDCOrBasicAuthHandler_AllowsValidBearerWhenNoBasicAuthPresented(t *testing.T) {
+	key := generateTestRSAKey(t)
+	server := testJWKSServer(t, "key-1", key)
+	defer server.Close()
+
+	cache := NewJWKSCache(server.URL, time.Minute, nil)
+	credentials := &auth.BasicAuthCredentials{User: "admin", Password: "password"}
+	config := OIDCConfig{Issuer: "https://issuer.example.com"}
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeOIDCOrBasicAuthHandler(next, config, cache, credentials)
+
+	token := signTestJWT(t, key, "key-1", "RS256", map[string]interface{}{
+		"iss": "https://issuer.example.com",
+		"exp": float64(time.Now().Add(time.Hour).Unix()),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatalf("want next called for a valid bearer token, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func Test_MakeOIDCOrBasicAuthHandler_RejectsRequestWithNeitherCredential(t *testing.T) {
+	cache := NewJWKSCache("http://unused.example.com", time.Minute, nil)
+	credentials := &auth.BasicAuthCredentials{User: "admin", Password: "password"}
+	config := OIDCConfig{Issuer: "https://issuer.example.com"}
+
+	handler := MakeOIDCOrBasicAuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called")
+	}, config, cache, credentials)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/functions", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401 with no credentials at all, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// Owner identifies who deployed a function: an individual identity and,
+// optionally, a team it belongs to. Either may be used to satisfy
+// ownership checks, so a team member can manage a function deployed by a
+// teammate.
+type Owner struct {
+	Identity string `json:"identity"`
+	Team     string `json:"team,omitempty"`
+}
+
+// OwnershipStore records which identity/team deployed each function. It
+// is a coarse, single-gateway device for self-service safety on a shared
+// gateway - it does not provide the isolation of separate namespaces or
+// clusters, and like the gateway's other in-memory stores, it does not
+// survive a restart. A function with no recorded owner (e.g. one
+// deployed before this feature existed, or without an X-Deployer-Identity
+// header) is left unrestricted, preserving prior behaviour.
+type OwnershipStore struct {
+	lock   sync.RWMutex
+	owners map[string]Owner
+}
+
+// NewOwnershipStore creates an empty OwnershipStore.
+func NewOwnershipStore() *OwnershipStore {
+	return &OwnershipStore{
+		owners: make(map[string]Owner),
+	}
+}
+
+// SetOwner records owner as functionName's owner, replacing any existing
+// record. Called with a zero-value Owner, it is a no-op, since an empty
+// identity cannot satisfy an ownership check.
+func (s *OwnershipStore) SetOwner(functionName string, owner Owner) {
+	if len(owner.Identity) == 0 {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.owners[functionName] = owner
+}
+
+// Owner returns functionName's recorded owner, if any.
+func (s *OwnershipStore) Owner(functionName string) (Owner, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	owner, exists := s.owners[functionName]
+	return owner, exists
+}
+
+// Remove forgets functionName's recorded owner, e.g. once it is deleted.
+func (s *OwnershipStore) Remove(functionName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.owners, functionName)
+}
+
+// callerIdentity derives the identity a deployer-identity-gated check
+// (ownership, change freeze) should trust, preferring a verified source
+// over the caller-supplied X-Deployer-Identity header whenever one is
+// available. With OIDC configured, that's the "sub" claim
+// MakeOIDCAuthHandler/MakeOIDCOrBasicAuthHandler already verified and
+// attached to the request context; with an API key, it's the key's own ID
+// (set as X-Caller-Identity by MakeAPIKeyAuthHandler once the key has been
+// validated). Either way the caller's own X-Deployer-Identity is ignored
+// in favour of it, since trusting it once a verified identity exists would
+// let that caller claim to be anyone.
+//
+// When neither is configured, the second return value is false and
+// X-Deployer-Identity is returned as-is: it is the only signal available,
+// but it is advisory only, not access control - any caller able to reach
+// this endpoint (a shared basic-auth password grants no per-caller
+// identity of its own) can set it to claim someone else's identity.
+func callerIdentity(r *http.Request) (identity string, verified bool) {
+	if claims, ok := OIDCClaimsFromContext(r.Context()); ok {
+		if sub, ok := claims["sub"].(string); ok && len(sub) > 0 {
+			return sub, true
+		}
+	}
+	if keyID := r.Header.Get("X-Caller-Identity"); len(keyID) > 0 {
+		return keyID, true
+	}
+	return r.Header.Get("X-Deployer-Identity"), false
+}
+
+// canManage reports whether the caller identified by identity/team may
+// manage a function owned by owner. A function with no recorded owner is
+// unrestricted.
+func canManage(owner Owner, exists bool, identity, team string) bool {
+	if !exists {
+		return true
+	}
+	if len(identity) > 0 && identity == owner.Identity {
+		return true
+	}
+	if len(team) > 0 && team == owner.Team {
+		return true
+	}
+	return false
+}
+
+// MakeRecordOwnerHandler wraps a deploy handler (POST /system/functions)
+// so that, once next has accepted the request, the deploying caller is
+// recorded as the new function's owner - its verified identity (see
+// callerIdentity) when one is available, or its X-Deployer-Identity header
+// otherwise. X-Deployer-Team is always taken from the header as-is: there
+// is no verified equivalent, so team-based delegation remains advisory
+// regardless.
+func MakeRecordOwnerHandler(next http.HandlerFunc, store *OwnershipStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if r.Body != nil {
+			body, _ = ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		identity, _ := callerIdentity(r)
+		team := r.Header.Get("X-Deployer-Team")
+
+		recorder := &statusCapturingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(recorder, r)
+		if recorder.statusCode >= http.StatusMultipleChoices {
+			return
+		}
+
+		var createReq requests.CreateFunctionRequest
+		if err := json.Unmarshal(body, &createReq); err != nil || len(createReq.Service) == 0 {
+			return
+		}
+
+		store.SetOwner(createReq.Service, Owner{
+			Identity: identity,
+			Team:     team,
+		})
+	}
+}
+
+// MakeOwnershipHandler wraps a mutating function-management handler
+// (update, delete or scale) so that it is only reached by the function's
+// recorded owner, a member of its owning team, or a caller against an
+// unowned function. functionName extracts the target function's name
+// from the request, since each of those routes carries it differently
+// (a JSON body vs. a URL variable).
+//
+// Once the caller's identity is verified (see callerIdentity), only an
+// exact identity match passes - the caller-supplied X-Deployer-Team header
+// is ignored, since trusting it too would let a verified-but-unrelated
+// caller claim the owning team to reach someone else's function. Team
+// membership only ever satisfies this check in the unverified, advisory
+// case, same as identity does there.
+func MakeOwnershipHandler(next http.HandlerFunc, store *OwnershipStore, functionName func(r *http.Request) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := functionName(r)
+		if len(name) == 0 {
+			next(w, r)
+			return
+		}
+
+		owner, exists := store.Owner(name)
+		identity, verified := callerIdentity(r)
+		team := ""
+		if !verified {
+			team = r.Header.Get("X-Deployer-Team")
+		}
+		if !canManage(owner, exists, identity, team) {
+			http.Error(w, "this function may only be managed by its owner or owning team", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// MakeForgetOwnerOnDeleteHandler wraps a delete handler so that, once
+// next has accepted the request, the deleted function's ownership
+// record is forgotten along with it.
+func MakeForgetOwnerOnDeleteHandler(next http.HandlerFunc, store *OwnershipStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := FunctionNameFromDeleteBody(r)
+
+		recorder := &statusCapturingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(recorder, r)
+
+		if len(name) > 0 && recorder.statusCode < http.StatusMultipleChoices {
+			store.Remove(name)
+		}
+	}
+}
+
+// FunctionNameFromDeleteBody extracts the target function name from a
+// DeleteFunctionRequest body, for use as a MakeOwnershipHandler
+// functionName extractor.
+func FunctionNameFromDeleteBody(r *http.Request) string {
+	var deleteReq requests.DeleteFunctionRequest
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if err := json.Unmarshal(body, &deleteReq); err != nil {
+		return ""
+	}
+	return deleteReq.FunctionName
+}
+
+// FunctionNameFromUpdateBody extracts the target function name from a
+// CreateFunctionRequest body (also used for updates), for use as a
+// MakeOwnershipHandler functionName extractor.
+func FunctionNameFromUpdateBody(r *http.Request) string {
+	var updateReq requests.CreateFunctionRequest
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if err := json.Unmarshal(body, &updateReq); err != nil {
+		return ""
+	}
+	return updateReq.Service
+}
+
+// FunctionNameFromScaleVars extracts the target function name from the
+// {name} route variable used by /system/scale-function/{name}, for use
+// as a MakeOwnershipHandler functionName extractor.
+func FunctionNameFromScaleVars(r *http.Request) string {
+	return mux.Vars(r)["name"]
+}
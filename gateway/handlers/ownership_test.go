@@ -0,0 +1,172 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_MakeRecordOwnerHandler_RecordsOwnerOnDeploy(t *testing.T) {
+	store := NewOwnershipStore()
+	next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	handler := MakeRecordOwnerHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", bytes.NewBufferString(`{"service":"figlet","image":"figlet:latest"}`))
+	req.Header.Set("X-Deployer-Identity", "alice")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	owner, exists := store.Owner("figlet")
+	if !exists || owner.Identity != "alice" {
+		t.Fatalf("want owner alice recorded for figlet, got %v (exists=%v)", owner, exists)
+	}
+}
+
+func Test_MakeOwnershipHandler_RejectsNonOwner(t *testing.T) {
+	store := NewOwnershipStore()
+	store.SetOwner("figlet", Owner{Identity: "alice"})
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeOwnershipHandler(next, store, FunctionNameFromUpdateBody)
+
+	req := httptest.NewRequest(http.MethodPut, "/system/functions", bytes.NewBufferString(`{"service":"figlet","image":"figlet:v2"}`))
+	req.Header.Set("X-Deployer-Identity", "bob")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("want 403, got %d", rec.Code)
+	}
+	if called {
+		t.Fatal("want next not to be called for a non-owner")
+	}
+}
+
+func Test_MakeOwnershipHandler_AllowsOwner(t *testing.T) {
+	store := NewOwnershipStore()
+	store.SetOwner("figlet", Owner{Identity: "alice"})
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeOwnershipHandler(next, store, FunctionNameFromUpdateBody)
+
+	req := httptest.NewRequest(http.MethodPut, "/system/functions", bytes.NewBufferString(`{"service":"figlet","image":"figlet:v2"}`))
+	req.Header.Set("X-Deployer-Identity", "alice")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called for the owner")
+	}
+}
+
+func Test_MakeOwnershipHandler_AllowsTeamMember(t *testing.T) {
+	store := NewOwnershipStore()
+	store.SetOwner("figlet", Owner{Identity: "alice", Team: "platform"})
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeOwnershipHandler(next, store, FunctionNameFromUpdateBody)
+
+	req := httptest.NewRequest(http.MethodPut, "/system/functions", bytes.NewBufferString(`{"service":"figlet","image":"figlet:v2"}`))
+	req.Header.Set("X-Deployer-Team", "platform")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called for a member of the owning team")
+	}
+}
+
+func Test_MakeOwnershipHandler_AllowsUnownedFunction(t *testing.T) {
+	store := NewOwnershipStore()
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeOwnershipHandler(next, store, FunctionNameFromUpdateBody)
+
+	req := httptest.NewRequest(http.MethodPut, "/system/functions", bytes.NewBufferString(`{"service":"unowned-fn","image":"x:latest"}`))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called for a function with no recorded owner")
+	}
+}
+
+// Test_MakeOwnershipHandler_VerifiedIdentityIgnoresSpoofedHeader guards
+// the fix for the ownership check trusting a caller-supplied
+// X-Deployer-Identity outright: once a verified OIDC subject claim is
+// attached to the request context, that - not the header - is what's
+// checked against the recorded owner.
+func Test_MakeOwnershipHandler_VerifiedIdentityIgnoresSpoofedHeader(t *testing.T) {
+	store := NewOwnershipStore()
+	store.SetOwner("figlet", Owner{Identity: "alice"})
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeOwnershipHandler(next, store, FunctionNameFromUpdateBody)
+
+	req := httptest.NewRequest(http.MethodPut, "/system/functions", bytes.NewBufferString(`{"service":"figlet","image":"figlet:v2"}`))
+	req.Header.Set("X-Deployer-Identity", "alice")
+	ctx := context.WithValue(req.Context(), oidcClaimsContextKey{}, map[string]interface{}{"sub": "bob"})
+	rec := httptest.NewRecorder()
+	handler(rec, req.WithContext(ctx))
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("want 403 for a verified identity that isn't the owner, even with a matching spoofed header, got %d", rec.Code)
+	}
+	if called {
+		t.Fatal("want next not to be called")
+	}
+}
+
+// Test_MakeOwnershipHandler_VerifiedIdentityIgnoresSpoofedTeam guards the
+// same fix for team-based delegation: a verified caller can't claim the
+// owning team via X-Deployer-Team either, since team membership has no
+// verified equivalent and is only ever honoured in the advisory,
+// unverified case.
+func Test_MakeOwnershipHandler_VerifiedIdentityIgnoresSpoofedTeam(t *testing.T) {
+	store := NewOwnershipStore()
+	store.SetOwner("figlet", Owner{Identity: "alice", Team: "platform"})
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	handler := MakeOwnershipHandler(next, store, FunctionNameFromUpdateBody)
+
+	req := httptest.NewRequest(http.MethodPut, "/system/functions", bytes.NewBufferString(`{"service":"figlet","image":"figlet:v2"}`))
+	req.Header.Set("X-Deployer-Team", "platform")
+	ctx := context.WithValue(req.Context(), oidcClaimsContextKey{}, map[string]interface{}{"sub": "mallory"})
+	rec := httptest.NewRecorder()
+	handler(rec, req.WithContext(ctx))
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("want 403 for a verified non-member claiming the owning team via a header, got %d", rec.Code)
+	}
+	if called {
+		t.Fatal("want next not to be called")
+	}
+}
+
+func Test_MakeForgetOwnerOnDeleteHandler_ForgetsOwner(t *testing.T) {
+	store := NewOwnershipStore()
+	store.SetOwner("figlet", Owner{Identity: "alice"})
+	next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	handler := MakeForgetOwnerOnDeleteHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/functions", bytes.NewBufferString(`{"functionName":"figlet"}`))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if _, exists := store.Owner("figlet"); exists {
+		t.Fatal("want the ownership record to be forgotten after delete")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DrainStore tracks functions a provider has told the gateway are
+// currently draining a replica (via a pre-stop hook), so in-flight
+// requests affected by that replica disappearing can be retried instead
+// of surfaced as errors. In direct-functions mode the gateway has no
+// per-replica endpoint list of its own - DNS/service load balancing
+// picks the backend - so this can't remove a single endpoint outright;
+// it widens the window during which a failed connection to the function
+// is treated as transient and retried, on the assumption that DNS will
+// route the retry to a different, still-live replica.
+type DrainStore struct {
+	lock     sync.RWMutex
+	draining map[string]time.Time // functionName -> drain deadline
+}
+
+// NewDrainStore creates an empty DrainStore.
+func NewDrainStore() *DrainStore {
+	return &DrainStore{draining: make(map[string]time.Time)}
+}
+
+// MarkDraining records that functionName has a replica draining, for
+// window from now.
+func (s *DrainStore) MarkDraining(functionName string, window time.Duration, now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.draining[functionName] = now.Add(window)
+}
+
+// IsDraining reports whether functionName was marked draining and that
+// window hasn't yet elapsed.
+func (s *DrainStore) IsDraining(functionName string, now time.Time) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	deadline, exists := s.draining[functionName]
+	return exists && now.Before(deadline)
+}
+
+// MakePreStopHandler registers a provider's pre-stop notification for a
+// function, POST /system/functions/{name}/pre-stop. window bounds how
+// long the gateway treats connection failures to the function as
+// transient afterwards.
+func MakePreStopHandler(drain *DrainStore, window time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := mux.Vars(r)["name"]
+		if len(functionName) == 0 {
+			http.Error(w, "a function name is required", http.StatusBadRequest)
+			return
+		}
+
+		drain.MarkDraining(functionName, window, time.Now())
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// idempotentMethods are the HTTP methods MakeDirectFunctionsRetryHandler
+// will retry; a retried POST/PATCH could double up a side effect on the
+// replica that serves the retry, so those are left to surface their
+// original error instead.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+// directRetryBufferCap mirrors coldStartRetryBufferCap: forwardRequest's
+// failure responses are small, single Write JSON bodies.
+const directRetryBufferCap = 8 * 1024
+
+// directRetryStatusCodes are the status codes forwardRequest writes when
+// it fails to reach a function at all (see classifyUpstreamError).
+var directRetryStatusCodes = map[int]bool{
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// MakeDirectFunctionsRetryHandler wraps next (the direct-functions mode
+// proxy) so that an idempotent request failing with a connection-level
+// status while drain reports the target function as draining is retried
+// once, giving DNS/service load balancing a chance to land the retry on
+// a replica that isn't mid-shutdown. Non-idempotent requests, and
+// requests to a function that isn't currently draining, are left
+// untouched.
+func MakeDirectFunctionsRetryHandler(next http.HandlerFunc, drain *DrainStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !idempotentMethods[r.Method] {
+			next(w, r)
+			return
+		}
+
+		functionName := getServiceName(r.URL.String())
+		if !drain.IsDraining(functionName, time.Now()) {
+			next(w, r)
+			return
+		}
+
+		interceptor := newRetryableStatusWriter(w, directRetryBufferCap, func(statusCode int) bool {
+			return directRetryStatusCodes[statusCode]
+		})
+		next(interceptor, r)
+
+		if !interceptor.buffering {
+			return
+		}
+
+		interceptor.discard()
+		next(interceptor, r)
+		interceptor.flush()
+	}
+}
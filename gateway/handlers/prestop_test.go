@@ -0,0 +1,130 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func Test_MakePreStopHandler_MarksFunctionDraining(t *testing.T) {
+	drain := NewDrainStore()
+
+	r := mux.NewRouter()
+	r.HandleFunc("/system/functions/{name}/pre-stop", MakePreStopHandler(drain, time.Minute)).Methods(http.MethodPost)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions/figlet/pre-stop", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("want status 202, got %d", rr.Code)
+	}
+	if !drain.IsDraining("figlet", time.Now()) {
+		t.Error("want figlet to be marked as draining")
+	}
+	if drain.IsDraining("other-fn", time.Now()) {
+		t.Error("want an unrelated function to not be marked as draining")
+	}
+}
+
+func Test_DrainStore_IsDrainingExpiresAfterWindow(t *testing.T) {
+	drain := NewDrainStore()
+	now := time.Now()
+
+	drain.MarkDraining("figlet", time.Minute, now)
+
+	if !drain.IsDraining("figlet", now.Add(time.Second)) {
+		t.Error("want figlet to still be draining inside the window")
+	}
+	if drain.IsDraining("figlet", now.Add(2*time.Minute)) {
+		t.Error("want figlet to no longer be draining once the window has elapsed")
+	}
+}
+
+func Test_MakeDirectFunctionsRetryHandler_RetriesIdempotentRequestWhileDraining(t *testing.T) {
+	drain := NewDrainStore()
+	drain.MarkDraining("figlet", time.Minute, time.Now())
+
+	attempts := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("unreachable"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("pong"))
+	}
+
+	handler := MakeDirectFunctionsRetryHandler(next, drain)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if attempts != 2 {
+		t.Fatalf("want next to be called twice (original + retry), got %d", attempts)
+	}
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want the client to see the retried 200, got %d", rr.Code)
+	}
+	if rr.Body.String() != "pong" {
+		t.Errorf("want the retried body forwarded to the client, got %q", rr.Body.String())
+	}
+}
+
+func Test_MakeDirectFunctionsRetryHandler_DoesNotRetryNonIdempotentRequest(t *testing.T) {
+	drain := NewDrainStore()
+	drain.MarkDraining("figlet", time.Minute, time.Now())
+
+	attempts := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("unreachable"))
+	}
+
+	handler := MakeDirectFunctionsRetryHandler(next, drain)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if attempts != 1 {
+		t.Fatalf("want no retry for a non-idempotent request, got %d attempts", attempts)
+	}
+	if rr.Code != http.StatusBadGateway {
+		t.Fatalf("want the original 502 forwarded, got %d", rr.Code)
+	}
+}
+
+func Test_MakeDirectFunctionsRetryHandler_DoesNotRetryWhenNotDraining(t *testing.T) {
+	drain := NewDrainStore()
+
+	attempts := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("unreachable"))
+	}
+
+	handler := MakeDirectFunctionsRetryHandler(next, drain)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if attempts != 1 {
+		t.Fatalf("want no retry when the function isn't draining, got %d attempts", attempts)
+	}
+	if rr.Code != http.StatusBadGateway {
+		t.Fatalf("want the original 502 forwarded, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/openfaas/faas/gateway/types"
+)
+
+// ConnectionPrewarmer opens, then immediately discards, an HTTP request
+// against a function's resolved upstream endpoint right after a
+// scale-from-zero completes. It reuses Proxy's own http.Client, so any
+// connection it leaves idle (DNS already resolved, TCP and, for an https
+// BaseURL, TLS already established) is the same one the request that
+// triggered the scale-up can pick up from the pool, instead of paying for
+// that setup on the hot path.
+type ConnectionPrewarmer struct {
+	// Proxy supplies the http.Client whose connection pool is warmed.
+	// Must be the same proxy used to forward the real request afterwards,
+	// or the warmed connection sits in the wrong pool and goes to waste.
+	Proxy *types.HTTPClientReverseProxy
+
+	// Resolver resolves a request to the upstream base URL to prewarm,
+	// matching whatever BaseURLResolver the forwarding handler downstream
+	// uses for the same request.
+	Resolver BaseURLResolver
+}
+
+// Prewarm resolves r's upstream endpoint and makes a cheap HEAD request
+// against it, discarding the response. Errors are logged, not returned -
+// prewarming is a latency optimisation for the request that follows, not
+// something that request's own success should depend on.
+func (p ConnectionPrewarmer) Prewarm(r *http.Request) {
+	if p.Proxy == nil || p.Proxy.Client == nil || p.Resolver == nil {
+		return
+	}
+
+	baseURL := p.Resolver.Resolve(r)
+
+	req, err := http.NewRequest(http.MethodHead, baseURL+"/", nil)
+	if err != nil {
+		return
+	}
+
+	start := time.Now()
+	res, err := p.Proxy.Client.Do(req)
+	if err != nil {
+		log.Printf("[Prewarm] unable to warm connection to %s: %s", baseURL, err.Error())
+		return
+	}
+	res.Body.Close()
+
+	log.Printf("[Prewarm] connection to %s warmed in %f seconds", baseURL, time.Since(start).Seconds())
+}
@@ -0,0 +1,45 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/types"
+)
+
+func Test_ConnectionPrewarmer_RequestsTheResolvedBaseURL(t *testing.T) {
+	var gotPath string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+	proxy := types.NewHTTPClientReverseProxy(upstreamURL, time.Second, nil)
+
+	prewarmer := ConnectionPrewarmer{
+		Proxy:    proxy,
+		Resolver: SingleHostBaseURLResolver{BaseURL: upstream.URL},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	prewarmer.Prewarm(req)
+
+	if gotPath != "/" {
+		t.Fatalf("want the prewarm request to hit \"/\", got %q", gotPath)
+	}
+}
+
+func Test_ConnectionPrewarmer_IsANoOpWithoutAProxyOrResolver(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+
+	ConnectionPrewarmer{}.Prewarm(req)
+	ConnectionPrewarmer{Resolver: SingleHostBaseURLResolver{BaseURL: "http://127.0.0.1:1"}}.Prewarm(req)
+}
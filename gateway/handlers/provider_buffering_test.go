@@ -0,0 +1,76 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/metrics"
+	"github.com/openfaas/faas/gateway/types"
+)
+
+func Test_ForwardRequestBuffered_RetriesUntilProviderIsBackUp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close() // provider "down" - nothing listening yet
+
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	}()
+
+	baseURL, _ := url.Parse("http://" + addr)
+
+	metricsOptions := metrics.BuildMetricsOptions()
+	proxy := types.NewHTTPClientReverseProxy(baseURL, time.Millisecond*200, nil)
+	proxy.BufferWindow = time.Second * 2
+	proxy.BufferRetryInterval = time.Millisecond * 50
+	proxy.Metrics = &metricsOptions
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	rr := httptest.NewRecorder()
+
+	statusCode, err := forwardRequestBuffered(rr, req, proxy, baseURL.String(), "/")
+
+	if err != nil {
+		t.Fatalf("expected buffering to succeed once the provider is back, got err: %s", err.Error())
+	}
+
+	if statusCode != http.StatusOK {
+		t.Errorf("want status 200, got %d", statusCode)
+	}
+}
+
+func Test_ForwardRequestBuffered_DropsAfterWindowExpires(t *testing.T) {
+	baseURL, _ := url.Parse("http://127.0.0.1:1") // nothing listening
+
+	metricsOptions := metrics.BuildMetricsOptions()
+	proxy := types.NewHTTPClientReverseProxy(baseURL, time.Millisecond*100, nil)
+	proxy.BufferWindow = time.Millisecond * 150
+	proxy.BufferRetryInterval = time.Millisecond * 50
+	proxy.Metrics = &metricsOptions
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	rr := httptest.NewRecorder()
+
+	_, err := forwardRequestBuffered(rr, req, proxy, baseURL.String(), "/")
+
+	if err == nil {
+		t.Fatal("expected an error once the buffering window has been exhausted")
+	}
+}
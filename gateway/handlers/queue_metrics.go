@@ -0,0 +1,36 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"time"
+
+	"github.com/openfaas/faas/gateway/metrics"
+	"github.com/openfaas/faas/gateway/queue"
+)
+
+// InstrumentedQueueProvider wraps another queue.CanQueueRequests, timing
+// every call to Queue and counting its failures against Metrics, so
+// publish latency and error rate show up in Prometheus regardless of
+// which provider (NATS Streaming, Kafka, JetStream) is actually in use.
+type InstrumentedQueueProvider struct {
+	Next    queue.CanQueueRequests
+	Metrics metrics.MetricOptions
+}
+
+// Queue calls Next.Queue, recording its duration and, on error, counting
+// the failure - both labelled by req.Function.
+func (p InstrumentedQueueProvider) Queue(req *queue.Request) error {
+	start := time.Now()
+	err := p.Next.Queue(req)
+
+	if p.Metrics.QueuePublishHistogram != nil {
+		p.Metrics.QueuePublishHistogram.WithLabelValues(req.Function).Observe(time.Since(start).Seconds())
+	}
+	if err != nil && p.Metrics.QueuePublishErrors != nil {
+		p.Metrics.QueuePublishErrors.WithLabelValues(req.Function).Inc()
+	}
+
+	return err
+}
@@ -0,0 +1,49 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/metrics"
+	"github.com/openfaas/faas/gateway/queue"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_InstrumentedQueueProvider_RecordsLatencyOnSuccess(t *testing.T) {
+	provider := &fakeQueueProvider{}
+	metricsOptions := metrics.BuildMetricsOptions()
+	instrumented := InstrumentedQueueProvider{Next: provider, Metrics: metricsOptions}
+
+	if err := instrumented.Queue(&queue.Request{Function: "figlet"}); err != nil {
+		t.Fatalf("want no error, got %s", err.Error())
+	}
+
+	m := &dto.Metric{}
+	metricsOptions.QueuePublishHistogram.WithLabelValues("figlet").(interface {
+		Write(*dto.Metric) error
+	}).Write(m)
+
+	if got := m.GetHistogram().GetSampleCount(); got != 1 {
+		t.Fatalf("want one observation recorded, got %d", got)
+	}
+}
+
+func Test_InstrumentedQueueProvider_CountsPublishErrors(t *testing.T) {
+	provider := &fakeQueueProvider{err: errors.New("queue is down")}
+	metricsOptions := metrics.BuildMetricsOptions()
+	instrumented := InstrumentedQueueProvider{Next: provider, Metrics: metricsOptions}
+
+	if err := instrumented.Queue(&queue.Request{Function: "figlet"}); err == nil {
+		t.Fatal("want the underlying error to be returned")
+	}
+
+	m := &dto.Metric{}
+	metricsOptions.QueuePublishErrors.WithLabelValues("figlet").Write(m)
+
+	if got := m.GetCounter().GetValue(); got != 1 {
+		t.Fatalf("want one error recorded, got %f", got)
+	}
+}
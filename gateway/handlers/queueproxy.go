@@ -4,6 +4,8 @@
 package handlers
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -12,10 +14,65 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/openfaas/faas/gateway/metrics"
 	"github.com/openfaas/faas/gateway/queue"
+	"github.com/openfaas/faas/gateway/tracing"
 )
 
-// MakeQueuedProxy accepts work onto a queue
-func MakeQueuedProxy(metrics metrics.MetricOptions, wildcard bool, canQueueRequests queue.CanQueueRequests, pathTransformer URLPathTransformer) http.HandlerFunc {
+// QueueProxyConfig bounds and optionally compresses the payloads
+// MakeQueuedProxy accepts onto the queue.
+type QueueProxyConfig struct {
+	// MaxPayloadBytes rejects a submission larger than this with 413
+	// Payload Too Large before it ever reaches the queue. Zero (the
+	// default) applies no limit, leaving an oversized payload to fail
+	// later, and less clearly, against the queue provider's own message
+	// size limit (1MB by default for NATS Streaming).
+	MaxPayloadBytes int
+
+	// CompressionThresholdBytes gzip-compresses a payload at or above
+	// this size before it's queued, setting Content-Encoding: gzip on
+	// the queued request so a payload close to the queue provider's
+	// message size limit has a better chance of fitting. Zero (the
+	// default) never compresses.
+	//
+	// This only helps if whatever dequeues the request decompresses it
+	// again before invoking the function - the NATS Streaming
+	// queue-worker vendored in this tree does not, so enabling this
+	// requires a queue-worker build that does.
+	CompressionThresholdBytes int
+
+	// Tracer, when set, wraps each publish in a "queue_publish" span and
+	// injects its traceparent into the queued request's headers, so a
+	// queue-worker that also understands W3C Trace Context can continue
+	// the same trace. Nil leaves publishing untraced and the queued
+	// request's headers unchanged, as before this existed.
+	Tracer *tracing.Tracer
+}
+
+func (c QueueProxyConfig) exceedsMaxPayload(size int) bool {
+	return c.MaxPayloadBytes > 0 && size > c.MaxPayloadBytes
+}
+
+func (c QueueProxyConfig) shouldCompress(size int) bool {
+	return c.CompressionThresholdBytes > 0 && size >= c.CompressionThresholdBytes
+}
+
+func gzipCompress(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MakeQueuedProxy accepts work onto a queue. asyncCalls, if non-nil, has
+// each accepted call's X-Call-Id recorded as queued so it can later be
+// looked up or cancelled through the /system/async/{callId} API. pending,
+// if non-nil, additionally retains a copy of the request under its
+// X-Call-Id so a RetryCoordinator can republish or dead-letter it later.
+func MakeQueuedProxy(metrics metrics.MetricOptions, wildcard bool, canQueueRequests queue.CanQueueRequests, pathTransformer URLPathTransformer, asyncCalls *AsyncCallStore, queueConfig QueueProxyConfig, pending *PendingAsyncRequests) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		defer r.Body.Close()
 
@@ -27,6 +84,39 @@ func MakeQueuedProxy(metrics metrics.MetricOptions, wildcard bool, canQueueReque
 			return
 		}
 
+		if queueConfig.exceedsMaxPayload(len(body)) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write([]byte(fmt.Sprintf("payload of %d bytes exceeds the maximum of %d bytes", len(body), queueConfig.MaxPayloadBytes)))
+			return
+		}
+
+		header := r.Header
+		headerCloned := false
+		if queueConfig.shouldCompress(len(body)) {
+			compressed, compressErr := gzipCompress(body)
+			if compressErr != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(compressErr.Error()))
+				return
+			}
+
+			header = header.Clone()
+			headerCloned = true
+			header.Set("Content-Encoding", "gzip")
+			body = compressed
+		}
+
+		// Inject a traceparent so a queue-worker consuming this message can
+		// continue the caller's trace, generating a fresh one if the
+		// caller didn't send one. header must be cloned first if it still
+		// aliases r.Header, so this doesn't rewrite headers on the
+		// caller's own *http.Request.
+		traceParent := tracing.FromHeaderOrNew(r.Header.Get(tracing.TraceParentHeader))
+		if !headerCloned {
+			header = header.Clone()
+		}
+		header.Set(tracing.TraceParentHeader, traceParent.String())
+
 		vars := mux.Vars(r)
 		name := vars["name"]
 
@@ -50,13 +140,22 @@ func MakeQueuedProxy(metrics metrics.MetricOptions, wildcard bool, canQueueReque
 			Method:      r.Method,
 			QueryString: r.URL.RawQuery,
 			Path:        pathTransformer.Transform(r),
-			Header:      r.Header,
+			Header:      header,
 			Host:        r.Host,
 			CallbackURL: callbackURL,
 		}
 
+		var publishSpan *tracing.Span
+		if queueConfig.Tracer != nil {
+			publishSpan = queueConfig.Tracer.StartSpan("queue_publish", traceParent, name)
+		}
+
 		err = canQueueRequests.Queue(req)
 
+		if publishSpan != nil {
+			publishSpan.End(queueConfig.Tracer.Exporter)
+		}
+
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Write([]byte(err.Error()))
@@ -64,6 +163,14 @@ func MakeQueuedProxy(metrics metrics.MetricOptions, wildcard bool, canQueueReque
 			return
 		}
 
+		if asyncCalls != nil {
+			asyncCalls.Record(r.Header.Get("X-Call-Id"), AsyncCallQueued)
+		}
+
+		if pending != nil {
+			pending.Track(r.Header.Get("X-Call-Id"), req)
+		}
+
 		w.WriteHeader(http.StatusAccepted)
 	}
 }
@@ -0,0 +1,121 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas/gateway/metrics"
+	"github.com/openfaas/faas/gateway/queue"
+)
+
+type fakeQueueProvider struct {
+	queued []*queue.Request
+	err    error
+}
+
+func (f *fakeQueueProvider) Queue(req *queue.Request) error {
+	f.queued = append(f.queued, req)
+	return f.err
+}
+
+func routeToQueuedProxy(handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc("/async-function/{name:[-a-zA-Z_0-9]+}", handler)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func Test_MakeQueuedProxy_RejectsPayloadOverMaxPayloadBytes(t *testing.T) {
+	provider := &fakeQueueProvider{}
+	handler := MakeQueuedProxy(metrics.MetricOptions{}, true, provider, TransparentURLPathTransformer{}, nil, QueueProxyConfig{MaxPayloadBytes: 4}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/async-function/echo", strings.NewReader("too big"))
+	rr := routeToQueuedProxy(handler, req)
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want status 413, got %d", rr.Code)
+	}
+	if len(provider.queued) != 0 {
+		t.Fatalf("want the oversized payload never to reach the queue, got %d queued", len(provider.queued))
+	}
+}
+
+func Test_MakeQueuedProxy_AllowsPayloadWithinMaxPayloadBytes(t *testing.T) {
+	provider := &fakeQueueProvider{}
+	handler := MakeQueuedProxy(metrics.MetricOptions{}, true, provider, TransparentURLPathTransformer{}, nil, QueueProxyConfig{MaxPayloadBytes: 1024}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/async-function/echo", strings.NewReader("fits fine"))
+	rr := routeToQueuedProxy(handler, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("want status 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(provider.queued) != 1 {
+		t.Fatalf("want the payload to reach the queue, got %d queued", len(provider.queued))
+	}
+}
+
+func Test_MakeQueuedProxy_CompressesPayloadAtOrAboveCompressionThreshold(t *testing.T) {
+	provider := &fakeQueueProvider{}
+	handler := MakeQueuedProxy(metrics.MetricOptions{}, true, provider, TransparentURLPathTransformer{}, nil, QueueProxyConfig{CompressionThresholdBytes: 4}, nil)
+
+	body := "this payload is over the threshold"
+	req := httptest.NewRequest(http.MethodPost, "/async-function/echo", strings.NewReader(body))
+	rr := routeToQueuedProxy(handler, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("want status 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(provider.queued) != 1 {
+		t.Fatalf("want exactly one queued request, got %d", len(provider.queued))
+	}
+
+	queued := provider.queued[0]
+	if queued.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("want Content-Encoding: gzip to be set on the queued request")
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(queued.Body))
+	if err != nil {
+		t.Fatalf("want the queued body to be valid gzip, got error: %s", err.Error())
+	}
+	decompressed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("unable to decompress queued body: %s", err.Error())
+	}
+	if string(decompressed) != body {
+		t.Fatalf("want decompressed body %q, got %q", body, string(decompressed))
+	}
+}
+
+func Test_MakeQueuedProxy_LeavesPayloadBelowCompressionThresholdUncompressed(t *testing.T) {
+	provider := &fakeQueueProvider{}
+	handler := MakeQueuedProxy(metrics.MetricOptions{}, true, provider, TransparentURLPathTransformer{}, nil, QueueProxyConfig{CompressionThresholdBytes: 1024}, nil)
+
+	body := "small"
+	req := httptest.NewRequest(http.MethodPost, "/async-function/echo", strings.NewReader(body))
+	rr := routeToQueuedProxy(handler, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("want status 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	queued := provider.queued[0]
+	if queued.Header.Get("Content-Encoding") == "gzip" {
+		t.Fatalf("want no Content-Encoding header below the compression threshold")
+	}
+	if string(queued.Body) != body {
+		t.Fatalf("want uncompressed body %q, got %q", body, string(queued.Body))
+	}
+}
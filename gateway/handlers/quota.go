@@ -0,0 +1,264 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// QuotaWindow is the cumulative period a Quota's Limit applies over.
+type QuotaWindow string
+
+const (
+	// QuotaWindowDaily resets a quota's counter at UTC midnight.
+	QuotaWindowDaily QuotaWindow = "daily"
+
+	// QuotaWindowMonthly resets a quota's counter on the first of the
+	// month, UTC.
+	QuotaWindowMonthly QuotaWindow = "monthly"
+)
+
+// QuotaScope names what a Quota's Identity identifies.
+type QuotaScope string
+
+const (
+	// QuotaScopeKey quotas a single caller identity, as derived by
+	// clientIdentity - typically an API key.
+	QuotaScopeKey QuotaScope = "key"
+
+	// QuotaScopeNamespace quotas every invocation of the function named by
+	// Identity, regardless of caller. Identity is compared with the function
+	// name as it appears in the invocation path (e.g. "foo" or
+	// "foo.staging"); functions are not grouped by namespace, so one quota
+	// covers one such name.
+	QuotaScopeNamespace QuotaScope = "namespace"
+)
+
+// Quota caps cumulative invocations for one identity within one scope over
+// one Window, independently of any instantaneous rate limit.
+type Quota struct {
+	Scope    QuotaScope  `json:"scope"`
+	Identity string      `json:"identity"`
+	Window   QuotaWindow `json:"window"`
+	Limit    int64       `json:"limit"`
+}
+
+func quotaKey(scope QuotaScope, identity string) string {
+	return string(scope) + ":" + identity
+}
+
+// QuotaStore holds the configured Quota for each identity. It is safe for
+// concurrent use.
+type QuotaStore struct {
+	mutex  sync.RWMutex
+	quotas map[string]Quota
+}
+
+// NewQuotaStore creates an empty QuotaStore.
+func NewQuotaStore() *QuotaStore {
+	return &QuotaStore{
+		quotas: make(map[string]Quota),
+	}
+}
+
+// Set stores quota, replacing any existing quota for the same scope and
+// identity.
+func (s *QuotaStore) Set(quota Quota) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.quotas[quotaKey(quota.Scope, quota.Identity)] = quota
+}
+
+// Get returns the quota configured for scope and identity, and whether one
+// exists.
+func (s *QuotaStore) Get(scope QuotaScope, identity string) (Quota, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	quota, ok := s.quotas[quotaKey(scope, identity)]
+	return quota, ok
+}
+
+// Delete removes the quota configured for scope and identity, if any.
+func (s *QuotaStore) Delete(scope QuotaScope, identity string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.quotas, quotaKey(scope, identity))
+}
+
+// List returns every configured Quota.
+func (s *QuotaStore) List() []Quota {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	quotas := make([]Quota, 0, len(s.quotas))
+	for _, quota := range s.quotas {
+		quotas = append(quotas, quota)
+	}
+	return quotas
+}
+
+// QuotaCounterStore accumulates invocation counts per period key (e.g.
+// "key:abc123:2026-08" for a monthly key quota), so quota enforcement can
+// be backed by something other than this process's memory - a Redis
+// INCR-backed implementation, for instance, shares counts across gateway
+// replicas instead of each one enforcing its own.
+type QuotaCounterStore interface {
+	// Increment adds one to the counter for periodKey and returns its new
+	// value.
+	Increment(periodKey string) int64
+}
+
+// InMemoryQuotaCounterStore is the default QuotaCounterStore, holding
+// counts in process memory. A period key that's never incremented again
+// (the window has rolled over) just sits unused - counters are not
+// actively evicted, matching the low cardinality expected of a quota
+// configuration (one entry per configured identity per window they're
+// currently in).
+type InMemoryQuotaCounterStore struct {
+	mutex  sync.Mutex
+	counts map[string]int64
+}
+
+// NewInMemoryQuotaCounterStore creates an empty InMemoryQuotaCounterStore.
+func NewInMemoryQuotaCounterStore() *InMemoryQuotaCounterStore {
+	return &InMemoryQuotaCounterStore{
+		counts: make(map[string]int64),
+	}
+}
+
+// Increment implements QuotaCounterStore.
+func (s *InMemoryQuotaCounterStore) Increment(periodKey string) int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.counts[periodKey]++
+	return s.counts[periodKey]
+}
+
+// quotaPeriodKey identifies which daily or monthly window now falls in, so
+// a counter naturally starts back at zero once the window rolls over.
+func quotaPeriodKey(window QuotaWindow, now time.Time) string {
+	now = now.UTC()
+	if window == QuotaWindowMonthly {
+		return now.Format("2006-01")
+	}
+	return now.Format("2006-01-02")
+}
+
+// quotaResetsAt returns the start of the window after the one now falls in.
+func quotaResetsAt(window QuotaWindow, now time.Time) time.Time {
+	now = now.UTC()
+	if window == QuotaWindowMonthly {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+}
+
+// MakeQuotaHandler wraps next, rejecting a request with 429 once either the
+// caller's QuotaScopeKey quota or the target function's QuotaScopeNamespace
+// quota has been exhausted for the current window. quotas with no
+// configured entry for an identity are not enforced at all. rejections,
+// when non-nil, counts rejected requests for alerting.
+func MakeQuotaHandler(next http.HandlerFunc, quotas *QuotaStore, counters QuotaCounterStore, clock Clock, rejections prometheus.Counter) http.HandlerFunc {
+	if clock == nil {
+		clock = RealClock
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := clock.Now()
+
+		identities := []struct {
+			scope    QuotaScope
+			identity string
+		}{
+			{QuotaScopeKey, clientIdentity(r)},
+			{QuotaScopeNamespace, getServiceName(r.URL.Path)},
+		}
+
+		for _, candidate := range identities {
+			quota, ok := quotas.Get(candidate.scope, candidate.identity)
+			if !ok {
+				continue
+			}
+
+			periodKey := quotaKey(candidate.scope, candidate.identity) + ":" + quotaPeriodKey(quota.Window, now)
+			count := counters.Increment(periodKey)
+
+			remaining := quota.Limit - count
+			if remaining < 0 {
+				remaining = 0
+			}
+			resetsAt := quotaResetsAt(quota.Window, now)
+
+			w.Header().Set("X-Quota-Limit", fmt.Sprintf("%d", quota.Limit))
+			w.Header().Set("X-Quota-Remaining", fmt.Sprintf("%d", remaining))
+			w.Header().Set("X-Quota-Reset", fmt.Sprintf("%d", resetsAt.Unix()))
+
+			if count > quota.Limit {
+				if rejections != nil {
+					rejections.Inc()
+				}
+				http.Error(w, fmt.Sprintf("%s quota exceeded for %s", quota.Window, candidate.identity), http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// MakeListQuotasHandler lists every configured Quota.
+func MakeListQuotasHandler(store *QuotaStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(store.List())
+	}
+}
+
+// MakeSetQuotaHandler creates or replaces a Quota.
+func MakeSetQuotaHandler(store *QuotaStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var quota Quota
+		if err := json.NewDecoder(r.Body).Decode(&quota); err != nil {
+			http.Error(w, "unable to decode quota: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if quota.Identity == "" || quota.Limit <= 0 {
+			http.Error(w, "identity and a positive limit are required", http.StatusBadRequest)
+			return
+		}
+		if quota.Scope != QuotaScopeKey && quota.Scope != QuotaScopeNamespace {
+			http.Error(w, "scope must be \"key\" or \"namespace\"", http.StatusBadRequest)
+			return
+		}
+		if quota.Window != QuotaWindowDaily && quota.Window != QuotaWindowMonthly {
+			http.Error(w, "window must be \"daily\" or \"monthly\"", http.StatusBadRequest)
+			return
+		}
+
+		store.Set(quota)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeDeleteQuotaHandler removes the quota named by the "scope" and
+// "identity" mux variables.
+func MakeDeleteQuotaHandler(store *QuotaStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		store.Delete(QuotaScope(vars["scope"]), vars["identity"])
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixedQuotaClock struct {
+	now time.Time
+}
+
+func (c fixedQuotaClock) Now() time.Time        { return c.now }
+func (c fixedQuotaClock) Sleep(d time.Duration) {}
+
+func Test_MakeQuotaHandler_AllowsUnderLimit(t *testing.T) {
+	quotas := NewQuotaStore()
+	quotas.Set(Quota{Scope: QuotaScopeKey, Identity: "caller-a", Window: QuotaWindowDaily, Limit: 2})
+	counters := NewInMemoryQuotaCounterStore()
+
+	called := 0
+	next := func(w http.ResponseWriter, r *http.Request) { called++ }
+	handler := MakeQuotaHandler(next, quotas, counters, fixedQuotaClock{now: time.Now()}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	req.Header.Set("X-Caller-Identity", "caller-a")
+
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("want 200 on request %d, got %d", i+1, rr.Code)
+		}
+	}
+
+	if called != 2 {
+		t.Errorf("want next called twice, got %d", called)
+	}
+}
+
+func Test_MakeQuotaHandler_RejectsOverLimit(t *testing.T) {
+	quotas := NewQuotaStore()
+	quotas.Set(Quota{Scope: QuotaScopeKey, Identity: "caller-a", Window: QuotaWindowDaily, Limit: 1})
+	counters := NewInMemoryQuotaCounterStore()
+
+	next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	handler := MakeQuotaHandler(next, quotas, counters, fixedQuotaClock{now: time.Now()}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	req.Header.Set("X-Caller-Identity", "caller-a")
+
+	handler(httptest.NewRecorder(), req)
+
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("want 429 once the quota is exhausted, got %d", rr.Code)
+	}
+	if rr.Header().Get("X-Quota-Remaining") != "0" {
+		t.Errorf("want X-Quota-Remaining 0, got %s", rr.Header().Get("X-Quota-Remaining"))
+	}
+}
+
+func Test_MakeQuotaHandler_EnforcesNamespaceScopeIndependentlyOfKeyScope(t *testing.T) {
+	quotas := NewQuotaStore()
+	quotas.Set(Quota{Scope: QuotaScopeNamespace, Identity: "foo", Window: QuotaWindowDaily, Limit: 1})
+	counters := NewInMemoryQuotaCounterStore()
+
+	next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	handler := MakeQuotaHandler(next, quotas, counters, fixedQuotaClock{now: time.Now()}, nil)
+
+	req1 := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	req1.Header.Set("X-Caller-Identity", "caller-a")
+	handler(httptest.NewRecorder(), req1)
+
+	req2 := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	req2.Header.Set("X-Caller-Identity", "caller-b")
+	rr := httptest.NewRecorder()
+	handler(rr, req2)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("want a different caller hitting the same namespace quota to be rejected too, got %d", rr.Code)
+	}
+}
+
+func Test_MakeQuotaHandler_ResetsOnNewWindow(t *testing.T) {
+	quotas := NewQuotaStore()
+	quotas.Set(Quota{Scope: QuotaScopeKey, Identity: "caller-a", Window: QuotaWindowDaily, Limit: 1})
+	counters := NewInMemoryQuotaCounterStore()
+
+	day1 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	day2 := time.Date(2026, 1, 2, 0, 0, 1, 0, time.UTC)
+
+	next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	req.Header.Set("X-Caller-Identity", "caller-a")
+
+	handler := MakeQuotaHandler(next, quotas, counters, fixedQuotaClock{now: day1}, nil)
+	handler(httptest.NewRecorder(), req)
+
+	handler = MakeQuotaHandler(next, quotas, counters, fixedQuotaClock{now: day2}, nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("want the quota to have reset on a new day, got %d", rr.Code)
+	}
+}
+
+func Test_MakeSetQuotaHandler_RejectsInvalidScopeOrWindow(t *testing.T) {
+	store := NewQuotaStore()
+	handler := MakeSetQuotaHandler(store)
+
+	cases := []string{
+		`{"identity":"caller-a","scope":"bogus","window":"daily","limit":1}`,
+		`{"identity":"caller-a","scope":"key","window":"bogus","limit":1}`,
+		`{"identity":"caller-a","scope":"key","window":"daily","limit":0}`,
+	}
+
+	for _, body := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/system/quotas", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("want 400 for %s, got %d", body, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+)
+
+// MakeRangeHandler wraps next so that a caller's single-range Range request
+// is honoured even when the function behind it ignores Range and always
+// returns its full body. next's response is buffered, then, if the caller
+// asked for a range and next answered with a full 200 OK, the requested
+// slice is served as 206 Partial Content with a matching Content-Range; a
+// function that already understands Range (and returns 206/416 itself) is
+// passed through untouched.
+//
+// Only a single "bytes=start-end" range is supported, matching the common
+// case of a resumable download client; a multipart (comma-separated) range
+// request is passed through to the function unmodified.
+func MakeRangeHandler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rangeHeader := r.Header.Get("Range")
+		if len(rangeHeader) == 0 || strings.Contains(rangeHeader, ",") {
+			next(w, r)
+			return
+		}
+
+		recorder := httptest.NewRecorder()
+		next(recorder, r)
+
+		if recorder.Code != http.StatusOK {
+			copyHeaders(w.Header(), &recorder.HeaderMap)
+			w.WriteHeader(recorder.Code)
+			w.Write(recorder.Body.Bytes())
+			return
+		}
+
+		body := recorder.Body.Bytes()
+		start, end, ok := parseByteRange(rangeHeader, int64(len(body)))
+		if !ok {
+			copyHeaders(w.Header(), &recorder.HeaderMap)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(body)))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+
+		copyHeaders(w.Header(), &recorder.HeaderMap)
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(body)))
+		w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(body[start : end+1])
+	}
+}
+
+// parseByteRange parses a "bytes=start-end" Range header value against a
+// body of size bytes, returning the inclusive [start, end] byte offsets to
+// serve. An open-ended range ("bytes=500-") runs to the end of the body; a
+// suffix range ("bytes=-500") returns the last 500 bytes.
+func parseByteRange(rangeHeader string, size int64) (start int64, end int64, ok bool) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(rangeHeader, prefix) || size == 0 {
+		return 0, 0, false
+	}
+
+	spec := strings.TrimPrefix(rangeHeader, prefix)
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	if len(parts[0]) == 0 {
+		suffixLength, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || suffixLength <= 0 {
+			return 0, 0, false
+		}
+		if suffixLength > size {
+			suffixLength = size
+		}
+		return size - suffixLength, size - 1, true
+	}
+
+	start, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+
+	if len(parts[1]) == 0 {
+		return start, size - 1, true
+	}
+
+	end, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+	if end >= size {
+		end = size - 1
+	}
+
+	return start, end, true
+}
@@ -0,0 +1,116 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_MakeRangeHandler_ServesPartialContentWhenFunctionIgnoresRange(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}
+
+	handler := MakeRangeHandler(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/reports/big.csv", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("want 206, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != "2345" {
+		t.Errorf("want body 2345, got %s", got)
+	}
+	if got := rec.Header().Get("Content-Range"); got != "bytes 2-5/10" {
+		t.Errorf("want Content-Range bytes 2-5/10, got %s", got)
+	}
+}
+
+func Test_MakeRangeHandler_SuffixRange(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}
+
+	handler := MakeRangeHandler(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/reports/big.csv", nil)
+	req.Header.Set("Range", "bytes=-3")
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("want 206, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != "789" {
+		t.Errorf("want body 789, got %s", got)
+	}
+}
+
+func Test_MakeRangeHandler_UnsatisfiableRangeReturns416(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}
+
+	handler := MakeRangeHandler(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/reports/big.csv", nil)
+	req.Header.Set("Range", "bytes=100-200")
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("want 416, got %d", rec.Code)
+	}
+}
+
+func Test_MakeRangeHandler_PassesThroughWhenFunctionAlreadyHandlesRange(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "bytes 0-3/10")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte("0123"))
+	}
+
+	handler := MakeRangeHandler(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/reports/big.csv", nil)
+	req.Header.Set("Range", "bytes=0-3")
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("want 206, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != "0123" {
+		t.Errorf("want body 0123, got %s", got)
+	}
+}
+
+func Test_MakeRangeHandler_NoRangeHeaderPassesThrough(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}
+
+	handler := MakeRangeHandler(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/reports/big.csv", nil)
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != "0123456789" {
+		t.Errorf("want full body, got %s", got)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// Priority distinguishes calls the gateway makes to the provider API so
+// a TokenBucketLimiter can keep serving invocation-critical traffic
+// (scaling a function a client is waiting on) after background/admin
+// traffic has used up its share.
+type Priority int
+
+const (
+	// PriorityHigh is for calls on the invocation path - a client is
+	// blocked waiting on the result, e.g. the scale-from-zero poll loop.
+	PriorityHigh Priority = iota
+
+	// PriorityLow is for everything else - status queries, the admin
+	// API, the function catalog used by /system/invoke-selector.
+	PriorityLow
+)
+
+// TokenBucketLimiter throttles calls the gateway itself makes to the
+// provider API. A traffic spike that fans out into many concurrent
+// scale-from-zero cold-starts can otherwise turn into a burst of
+// provider/Kubernetes API calls large enough to overload it.
+//
+// PriorityLow callers are held back once Reserve tokens are left in the
+// bucket, so that headroom stays available for PriorityHigh callers
+// during a spike, rather than every caller being throttled equally.
+type TokenBucketLimiter struct {
+	// Capacity is the bucket's maximum number of tokens.
+	Capacity float64
+
+	// RefillPerSecond is how many tokens are added back per second.
+	RefillPerSecond float64
+
+	// Reserve is how many tokens PriorityLow callers must leave in the
+	// bucket. Zero means PriorityLow and PriorityHigh are throttled
+	// identically.
+	Reserve float64
+
+	// Clock provides the current time. When nil, RealClock is used.
+	Clock Clock
+
+	lock       sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+	started    bool
+}
+
+func (l *TokenBucketLimiter) clock() Clock {
+	if l.Clock == nil {
+		return RealClock
+	}
+	return l.Clock
+}
+
+func (l *TokenBucketLimiter) refill(now time.Time) {
+	if !l.started {
+		l.tokens = l.Capacity
+		l.lastRefill = now
+		l.started = true
+		return
+	}
+
+	elapsed := now.Sub(l.lastRefill).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	l.tokens += elapsed * l.RefillPerSecond
+	if l.tokens > l.Capacity {
+		l.tokens = l.Capacity
+	}
+	l.lastRefill = now
+}
+
+// Allow reports whether a call of the given priority may proceed right
+// now, consuming a token if so.
+func (l *TokenBucketLimiter) Allow(priority Priority) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.refill(l.clock().Now())
+
+	reserve := 0.0
+	if priority == PriorityLow {
+		reserve = l.Reserve
+	}
+
+	if l.tokens-reserve < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// errRateLimited is wrapped with ErrProviderUnavailable so a caller
+// blocked by the limiter is handled exactly like a provider the gateway
+// couldn't reach - e.g. statusCodeForScalingError reports it as a 502.
+var errRateLimited = fmt.Errorf("gateway rate limit exceeded calling provider: %w", ErrProviderUnavailable)
+
+// RateLimitedServiceQuery wraps a ServiceQuery so every call is admitted
+// through limiter first, at the given priority.
+type RateLimitedServiceQuery struct {
+	ServiceQuery ServiceQuery
+	Limiter      *TokenBucketLimiter
+	Priority     Priority
+}
+
+// GetReplicas admits the call through the limiter before delegating.
+func (q RateLimitedServiceQuery) GetReplicas(service string) (ServiceQueryResponse, error) {
+	if !q.Limiter.Allow(q.Priority) {
+		return ServiceQueryResponse{}, errRateLimited
+	}
+	return q.ServiceQuery.GetReplicas(service)
+}
+
+// SetReplicas admits the call through the limiter before delegating.
+func (q RateLimitedServiceQuery) SetReplicas(service string, count uint64) error {
+	if !q.Limiter.Allow(q.Priority) {
+		return errRateLimited
+	}
+	return q.ServiceQuery.SetReplicas(service, count)
+}
+
+// RateLimitedFunctionCatalog wraps a FunctionCatalog so List is admitted
+// through limiter first, at the given priority.
+type RateLimitedFunctionCatalog struct {
+	FunctionCatalog FunctionCatalog
+	Limiter         *TokenBucketLimiter
+	Priority        Priority
+}
+
+// List admits the call through the limiter before delegating.
+func (c RateLimitedFunctionCatalog) List() ([]requests.Function, error) {
+	if !c.Limiter.Allow(c.Priority) {
+		return nil, errRateLimited
+	}
+	return c.FunctionCatalog.List()
+}
@@ -0,0 +1,110 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_TokenBucketLimiter_AllowsUpToCapacityThenThrottles(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := &TokenBucketLimiter{Capacity: 2, RefillPerSecond: 1, Clock: clock}
+
+	if !limiter.Allow(PriorityHigh) {
+		t.Fatal("want the first call admitted")
+	}
+	if !limiter.Allow(PriorityHigh) {
+		t.Fatal("want the second call admitted")
+	}
+	if limiter.Allow(PriorityHigh) {
+		t.Fatal("want the third call throttled once capacity is spent")
+	}
+}
+
+func Test_TokenBucketLimiter_RefillsOverTime(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := &TokenBucketLimiter{Capacity: 1, RefillPerSecond: 1, Clock: clock}
+
+	if !limiter.Allow(PriorityHigh) {
+		t.Fatal("want the first call admitted")
+	}
+	if limiter.Allow(PriorityHigh) {
+		t.Fatal("want the bucket empty immediately after")
+	}
+
+	clock.now = clock.now.Add(time.Second)
+
+	if !limiter.Allow(PriorityHigh) {
+		t.Fatal("want a token back after refilling for a second")
+	}
+}
+
+func Test_TokenBucketLimiter_ReservesHeadroomForHighPriority(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := &TokenBucketLimiter{Capacity: 3, RefillPerSecond: 0, Reserve: 1, Clock: clock}
+
+	if !limiter.Allow(PriorityLow) {
+		t.Fatal("want the first low priority call admitted (2 tokens left)")
+	}
+	if !limiter.Allow(PriorityLow) {
+		t.Fatal("want the second low priority call admitted (1 token left, at the reserve boundary)")
+	}
+	if limiter.Allow(PriorityLow) {
+		t.Fatal("want low priority throttled once only the reserve is left")
+	}
+	if !limiter.Allow(PriorityHigh) {
+		t.Fatal("want high priority still admitted, spending the reserved token")
+	}
+	if limiter.Allow(PriorityHigh) {
+		t.Fatal("want high priority throttled once the bucket is truly empty")
+	}
+}
+
+func Test_RateLimitedServiceQuery_ThrottlesCallsOverCapacity(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := &TokenBucketLimiter{Capacity: 1, RefillPerSecond: 0, Clock: clock}
+
+	query := RateLimitedServiceQuery{
+		ServiceQuery: &fakeServiceQuery{response: ServiceQueryResponse{AvailableReplicas: 1}},
+		Limiter:      limiter,
+		Priority:     PriorityHigh,
+	}
+
+	if _, err := query.GetReplicas("figlet"); err != nil {
+		t.Fatalf("want the first call to succeed, got %s", err.Error())
+	}
+	if _, err := query.GetReplicas("figlet"); err == nil {
+		t.Fatal("want the second call to be rate limited")
+	}
+}
+
+type fakeFunctionCatalogForRateLimit struct {
+	calls int
+}
+
+func (f *fakeFunctionCatalogForRateLimit) List() ([]requests.Function, error) {
+	f.calls++
+	return nil, nil
+}
+
+func Test_RateLimitedFunctionCatalog_ThrottlesCallsOverCapacity(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := &TokenBucketLimiter{Capacity: 1, RefillPerSecond: 0, Clock: clock}
+	catalog := &fakeFunctionCatalogForRateLimit{}
+
+	limited := RateLimitedFunctionCatalog{FunctionCatalog: catalog, Limiter: limiter, Priority: PriorityLow}
+
+	if _, err := limited.List(); err != nil {
+		t.Fatalf("want the first call to succeed, got %s", err.Error())
+	}
+	if _, err := limited.List(); err == nil {
+		t.Fatal("want the second call to be rate limited")
+	}
+	if catalog.calls != 1 {
+		t.Fatalf("want the underlying catalog only called once, got %d", catalog.calls)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+)
+
+// FunctionReplica describes a single running instance of a function.
+type FunctionReplica struct {
+	ID       string `json:"id"`
+	NodeID   string `json:"nodeID,omitempty"`
+	Ready    bool   `json:"ready"`
+	Restarts uint64 `json:"restarts"`
+	Age      string `json:"age,omitempty"`
+}
+
+// MakeListReplicasHandler creates a handler for GET
+// /system/function/{name}/replicas. It forwards to the equivalent
+// endpoint on the configured provider so that providers which expose
+// per-replica node/restart/age data can return it as-is. A provider that
+// doesn't implement the endpoint (a non-200 response) falls back to one
+// synthetic, minimal entry per available replica reported by
+// serviceQuery, since that count is all a generic provider guarantees.
+func MakeListReplicasHandler(next http.HandlerFunc, serviceQuery ServiceQuery) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder := httptest.NewRecorder()
+		next(recorder, r)
+
+		if recorder.Code == http.StatusOK {
+			for k, v := range recorder.HeaderMap {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(recorder.Code)
+			w.Write(recorder.Body.Bytes())
+			return
+		}
+
+		functionName := mux.Vars(r)["name"]
+		queryResponse, err := serviceQuery.GetReplicas(functionName)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error finding function %s: %s", functionName, err.Error()), http.StatusNotFound)
+			return
+		}
+
+		replicas := make([]FunctionReplica, queryResponse.AvailableReplicas)
+		for i := range replicas {
+			replicas[i] = FunctionReplica{
+				ID:    fmt.Sprintf("%s-%d", functionName, i),
+				Ready: true,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(replicas)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func routeToReplicasHandler(handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc("/system/function/{name:[-a-zA-Z_0-9]+}/replicas", handler).Methods(http.MethodGet)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func Test_ListReplicasHandler_PassesThroughProviderResponse(t *testing.T) {
+	providerReplicas := []FunctionReplica{{ID: "task-1", NodeID: "node-a", Ready: true}}
+	provider := func(w http.ResponseWriter, r *http.Request) {
+		bytesOut, _ := json.Marshal(providerReplicas)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(bytesOut)
+	}
+
+	query := &fakeServiceQuery{}
+	handler := MakeListReplicasHandler(provider, query)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/echo/replicas", nil)
+	rr := routeToReplicasHandler(handler, req)
+
+	var got []FunctionReplica
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].NodeID != "node-a" {
+		t.Errorf("want provider response passed through, got %v", got)
+	}
+}
+
+func Test_ListReplicasHandler_FallsBackWhenProviderDoesNotSupportIt(t *testing.T) {
+	provider := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}
+
+	query := &fakeServiceQuery{response: ServiceQueryResponse{AvailableReplicas: 2}}
+	handler := MakeListReplicasHandler(provider, query)
+
+	req := httptest.NewRequest(http.MethodGet, "/system/function/echo/replicas", nil)
+	rr := routeToReplicasHandler(handler, req)
+
+	var got []FunctionReplica
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("want 2 synthetic replicas, got %d", len(got))
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_MakeRequestPreconditionsHandler_RejectsMissingRequiredHeader(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{RequiredHeadersLabel: "X-Api-Key"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeRequestPreconditionsHandler(next, specs, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Fatal("want next not to be called when a required header is missing")
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("want 400, got %d", rec.Code)
+	}
+}
+
+func Test_MakeRequestPreconditionsHandler_AllowsWhenRequiredHeaderPresent(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{RequiredHeadersLabel: "X-Api-Key"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeRequestPreconditionsHandler(next, specs, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	req.Header.Set("X-Api-Key", "secret")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called once the required header is present")
+	}
+}
+
+func Test_MakeRequestPreconditionsHandler_RejectsUnacceptedContentType(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{AcceptedContentTypesLabel: "application/json"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeRequestPreconditionsHandler(next, specs, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", strings.NewReader("<xml/>"))
+	req.Header.Set("Content-Type", "application/xml")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Fatal("want next not to be called for an unaccepted content type")
+	}
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("want 415, got %d", rec.Code)
+	}
+}
+
+func Test_MakeRequestPreconditionsHandler_AllowsAcceptedContentTypeWithParams(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{AcceptedContentTypesLabel: "application/json"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeRequestPreconditionsHandler(next, specs, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called for an accepted content type")
+	}
+}
+
+func Test_MakeRequestPreconditionsHandler_RejectsOversizedDeclaredBody(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{MaxBodyBytesLabel: "4"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeRequestPreconditionsHandler(next, specs, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", strings.NewReader("way too long"))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Fatal("want next not to be called for a body exceeding the declared limit")
+	}
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want 413, got %d", rec.Code)
+	}
+}
+
+func Test_MakeRequestPreconditionsHandler_AllowsEverythingWithoutLabels(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet"})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeRequestPreconditionsHandler(next, specs, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", strings.NewReader("anything"))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called when the function declares no preconditions")
+	}
+}
+
+func Test_MakeRequestPreconditionsHandler_RejectsOverDefaultMaxBodyBytes(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet"})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeRequestPreconditionsHandler(next, specs, 4)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", strings.NewReader("way too long"))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Fatal("want next not to be called for a body exceeding the global default limit")
+	}
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want 413, got %d", rec.Code)
+	}
+}
+
+func Test_MakeRequestPreconditionsHandler_LabelOverridesDefaultMaxBodyBytes(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	labels := map[string]string{MaxBodyBytesLabel: "1024"}
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Labels: &labels})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeRequestPreconditionsHandler(next, specs, 4)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", strings.NewReader("way too long"))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want the function's own, larger limit to take precedence over the global default")
+	}
+}
+
+func Test_MakeMaxBodySizeHandler_RejectsOverDefaultMaxBodyBytes(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet"})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeMaxBodySizeHandler(next, specs, 4)
+
+	req := httptest.NewRequest(http.MethodPost, "/async-function/figlet", strings.NewReader("way too long"))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Fatal("want next not to be called for a body exceeding the global default limit")
+	}
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want 413, got %d", rec.Code)
+	}
+}
+
+func Test_MakeMaxBodySizeHandler_AllowsWithinLimit(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet"})
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeMaxBodySizeHandler(next, specs, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/async-function/figlet", strings.NewReader("anything"))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called when no limit is in effect")
+	}
+}
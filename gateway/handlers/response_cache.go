@@ -0,0 +1,218 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"container/list"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ResponseCacheTTLLabel opts a function into GET response caching and sets
+// how long a cached response is served before it's treated as stale. A
+// function with no value, or a value MakeResponseCacheHandler can't parse
+// as a duration (e.g. "30s"), is never cached.
+const ResponseCacheTTLLabel = "com.openfaas.cache.ttl"
+
+// ResponseCacheEntry is one cached response.
+type ResponseCacheEntry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Expiry     time.Time
+}
+
+// expired reports whether entry is past its Expiry as of now.
+func (entry ResponseCacheEntry) expired(now time.Time) bool {
+	return now.After(entry.Expiry)
+}
+
+// ResponseCacheStore holds cached GET responses, keyed by request method
+// and URL. InvalidateCache implements CacheInvalidator, so
+// /system/cache/invalidate can drop a function's cached responses the same
+// way it drops its replica or catalog cache entries.
+type ResponseCacheStore interface {
+	Get(key string) (ResponseCacheEntry, bool)
+	Set(key, functionName string, entry ResponseCacheEntry)
+	InvalidateCache(functionName string)
+}
+
+// InMemoryResponseCache is the default ResponseCacheStore - an in-memory
+// LRU bounded by Capacity entries, each additionally expiring after its
+// own TTL. A Redis-backed ResponseCacheStore can share cached responses
+// across gateway replicas the same way, but isn't implemented in this
+// tree yet.
+type InMemoryResponseCache struct {
+	// Capacity is the maximum number of entries retained before the
+	// least-recently-used one is evicted to make room for a new one.
+	Capacity int
+
+	// Clock provides the current time for expiry checks. When nil,
+	// RealClock is used.
+	Clock Clock
+
+	mutex       sync.Mutex
+	entries     map[string]*list.Element
+	evictionLRU *list.List
+}
+
+type responseCacheElement struct {
+	key          string
+	functionName string
+	entry        ResponseCacheEntry
+}
+
+// NewInMemoryResponseCache creates an InMemoryResponseCache holding at most
+// capacity entries.
+func NewInMemoryResponseCache(capacity int) *InMemoryResponseCache {
+	return &InMemoryResponseCache{
+		Capacity:    capacity,
+		entries:     make(map[string]*list.Element),
+		evictionLRU: list.New(),
+	}
+}
+
+func (c *InMemoryResponseCache) clock() Clock {
+	if c.Clock == nil {
+		return RealClock
+	}
+	return c.Clock
+}
+
+// Get implements ResponseCacheStore.
+func (c *InMemoryResponseCache) Get(key string) (ResponseCacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		return ResponseCacheEntry{}, false
+	}
+
+	cached := element.Value.(*responseCacheElement)
+	if cached.entry.expired(c.clock().Now()) {
+		c.evictionLRU.Remove(element)
+		delete(c.entries, key)
+		return ResponseCacheEntry{}, false
+	}
+
+	c.evictionLRU.MoveToFront(element)
+	return cached.entry, true
+}
+
+// Set implements ResponseCacheStore.
+func (c *InMemoryResponseCache) Set(key, functionName string, entry ResponseCacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		element.Value.(*responseCacheElement).entry = entry
+		c.evictionLRU.MoveToFront(element)
+		return
+	}
+
+	element := c.evictionLRU.PushFront(&responseCacheElement{key: key, functionName: functionName, entry: entry})
+	c.entries[key] = element
+
+	if c.Capacity > 0 {
+		for len(c.entries) > c.Capacity {
+			oldest := c.evictionLRU.Back()
+			if oldest == nil {
+				break
+			}
+			c.evictionLRU.Remove(oldest)
+			delete(c.entries, oldest.Value.(*responseCacheElement).key)
+		}
+	}
+}
+
+// InvalidateCache implements CacheInvalidator. An empty functionName clears
+// every cached response.
+func (c *InMemoryResponseCache) InvalidateCache(functionName string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if functionName == "" {
+		c.entries = make(map[string]*list.Element)
+		c.evictionLRU = list.New()
+		return
+	}
+
+	for key, element := range c.entries {
+		if element.Value.(*responseCacheElement).functionName == functionName {
+			c.evictionLRU.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// MakeResponseCacheHandler wraps next, serving a cached response for a GET
+// request to a function that declares ResponseCacheTTLLabel, and
+// populating the cache from next's response otherwise. Non-GET requests,
+// and responses other than 200, are never cached. hits, when non-nil,
+// counts responses served from cache.
+func MakeResponseCacheHandler(next http.HandlerFunc, store ResponseCacheStore, specs *FunctionSpecStore, hits prometheus.Counter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next(w, r)
+			return
+		}
+
+		functionName := getServiceName(r.URL.String())
+		spec, exists := specs.Get(functionName)
+		if !exists || spec.Labels == nil {
+			next(w, r)
+			return
+		}
+
+		ttl, err := time.ParseDuration((*spec.Labels)[ResponseCacheTTLLabel])
+		if err != nil || ttl <= 0 {
+			next(w, r)
+			return
+		}
+
+		key := r.Method + " " + r.URL.String()
+
+		if cached, hit := store.Get(key); hit {
+			if hits != nil {
+				hits.Inc()
+			}
+			for name, values := range cached.Header {
+				for _, value := range values {
+					w.Header().Add(name, value)
+				}
+			}
+			w.Header().Set("Age", strconv.Itoa(int(time.Since(cached.Expiry.Add(-ttl)).Seconds())))
+			w.WriteHeader(cached.StatusCode)
+			w.Write(cached.Body)
+			return
+		}
+
+		recorder := httptest.NewRecorder()
+		next(recorder, r)
+		result := recorder.Result()
+
+		if result.StatusCode == http.StatusOK {
+			store.Set(key, functionName, ResponseCacheEntry{
+				StatusCode: result.StatusCode,
+				Header:     result.Header,
+				Body:       recorder.Body.Bytes(),
+				Expiry:     time.Now().Add(ttl),
+			})
+		}
+
+		for name, values := range result.Header {
+			for _, value := range values {
+				w.Header().Add(name, value)
+			}
+		}
+		w.WriteHeader(result.StatusCode)
+		w.Write(recorder.Body.Bytes())
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func cachingSpecStore(t *testing.T, functionName, ttl string) *FunctionSpecStore {
+	t.Helper()
+	store := NewFunctionSpecStore()
+	labels := map[string]string{ResponseCacheTTLLabel: ttl}
+	store.Set(functionName, requests.CreateFunctionRequest{Service: functionName, Labels: &labels})
+	return store
+}
+
+func Test_MakeResponseCacheHandler_CachesSecondIdenticalGET(t *testing.T) {
+	specs := cachingSpecStore(t, "foo", "1m")
+	store := NewInMemoryResponseCache(10)
+
+	calls := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("response"))
+	}
+	handler := MakeResponseCacheHandler(next, store, specs, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	handler(httptest.NewRecorder(), req)
+
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if calls != 1 {
+		t.Errorf("want next called once, got %d", calls)
+	}
+	if rr.Body.String() != "response" {
+		t.Errorf("want the cached body, got %q", rr.Body.String())
+	}
+}
+
+func Test_MakeResponseCacheHandler_SkipsUnconfiguredFunctions(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	store := NewInMemoryResponseCache(10)
+
+	calls := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("response"))
+	}
+	handler := MakeResponseCacheHandler(next, store, specs, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	handler(httptest.NewRecorder(), req)
+	handler(httptest.NewRecorder(), req)
+
+	if calls != 2 {
+		t.Errorf("want every request to pass through without caching, got %d calls", calls)
+	}
+}
+
+func Test_MakeResponseCacheHandler_SkipsNonGETRequests(t *testing.T) {
+	specs := cachingSpecStore(t, "foo", "1m")
+	store := NewInMemoryResponseCache(10)
+
+	calls := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("response"))
+	}
+	handler := MakeResponseCacheHandler(next, store, specs, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/function/foo", nil)
+	handler(httptest.NewRecorder(), req)
+	handler(httptest.NewRecorder(), req)
+
+	if calls != 2 {
+		t.Errorf("want POST requests never cached, got %d calls", calls)
+	}
+}
+
+func Test_InMemoryResponseCache_EvictsLeastRecentlyUsedOverCapacity(t *testing.T) {
+	cache := NewInMemoryResponseCache(1)
+
+	cache.Set("a", "foo", ResponseCacheEntry{StatusCode: 200, Expiry: time.Now().Add(time.Minute)})
+	cache.Set("b", "foo", ResponseCacheEntry{StatusCode: 200, Expiry: time.Now().Add(time.Minute)})
+
+	if _, hit := cache.Get("a"); hit {
+		t.Error("want the least-recently-used entry evicted")
+	}
+	if _, hit := cache.Get("b"); !hit {
+		t.Error("want the most recent entry retained")
+	}
+}
+
+func Test_InMemoryResponseCache_ExpiresEntriesPastTTL(t *testing.T) {
+	cache := NewInMemoryResponseCache(10)
+	cache.Set("a", "foo", ResponseCacheEntry{StatusCode: 200, Expiry: time.Now().Add(-time.Second)})
+
+	if _, hit := cache.Get("a"); hit {
+		t.Error("want an expired entry to miss")
+	}
+}
+
+func Test_InMemoryResponseCache_InvalidateCacheByFunctionName(t *testing.T) {
+	cache := NewInMemoryResponseCache(10)
+	cache.Set("a", "foo", ResponseCacheEntry{StatusCode: 200, Expiry: time.Now().Add(time.Minute)})
+	cache.Set("b", "bar", ResponseCacheEntry{StatusCode: 200, Expiry: time.Now().Add(time.Minute)})
+
+	cache.InvalidateCache("foo")
+
+	if _, hit := cache.Get("a"); hit {
+		t.Error("want foo's cached response invalidated")
+	}
+	if _, hit := cache.Get("b"); !hit {
+		t.Error("want bar's cached response left alone")
+	}
+}
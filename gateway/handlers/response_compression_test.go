@@ -0,0 +1,100 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_MakeResponseCompressionHandler_CompressesLargePlainResponse(t *testing.T) {
+	body := strings.Repeat("x", 2000)
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(body))
+	}
+	handler := MakeResponseCompressionHandler(next, 860)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("want gzip encoding, got headers %v", rr.Header())
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(rr.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("unable to open gzip reader: %s", err.Error())
+	}
+	decoded, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("unable to decompress body: %s", err.Error())
+	}
+	if string(decoded) != body {
+		t.Error("want the decompressed body to round-trip")
+	}
+}
+
+func Test_MakeResponseCompressionHandler_SkipsWithoutAcceptEncoding(t *testing.T) {
+	body := strings.Repeat("x", 2000)
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}
+	handler := MakeResponseCompressionHandler(next, 860)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Header().Get("Content-Encoding") == "gzip" {
+		t.Error("want no compression without an Accept-Encoding: gzip request header")
+	}
+	if rr.Body.String() != body {
+		t.Error("want the body unchanged")
+	}
+}
+
+func Test_MakeResponseCompressionHandler_SkipsSmallResponses(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tiny"))
+	}
+	handler := MakeResponseCompressionHandler(next, 860)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Header().Get("Content-Encoding") == "gzip" {
+		t.Error("want a response below the threshold left uncompressed")
+	}
+	if rr.Body.String() != "tiny" {
+		t.Error("want the body unchanged")
+	}
+}
+
+func Test_MakeResponseCompressionHandler_SkipsAlreadyCompressedContentTypes(t *testing.T) {
+	body := strings.Repeat("x", 2000)
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(body))
+	}
+	handler := MakeResponseCompressionHandler(next, 860)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Header().Get("Content-Encoding") == "gzip" {
+		t.Error("want an already-compressed content type left alone")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// retryProxyDefaultStatusCodes are treated as retryable when
+// RetryProxyConfig.StatusCodes is empty - the same connection-level
+// failures classifyUpstreamError maps forwardRequest's own errors to.
+var retryProxyDefaultStatusCodes = map[int]bool{
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// retryProxyBufferCap mirrors directRetryBufferCap: the failure responses
+// being retried are small, single Write JSON bodies.
+const retryProxyBufferCap = 8 * 1024
+
+// RetryProxyConfig controls MakeRetryingProxyHandler.
+type RetryProxyConfig struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// One or zero disables retrying.
+	MaxAttempts int
+
+	// StatusCodes lists the upstream status codes worth retrying. Empty
+	// falls back to retryProxyDefaultStatusCodes.
+	StatusCodes []int
+
+	// BaseDelay is the delay passed to Backoff.NextDelay. Defaults to
+	// 100ms.
+	BaseDelay time.Duration
+
+	// Backoff computes the delay between attempts. Defaults to
+	// FixedBackoff.
+	Backoff BackoffStrategy
+
+	sleep func(time.Duration) // overridden in tests
+}
+
+func (c RetryProxyConfig) maxAttempts() int {
+	if c.MaxAttempts > 0 {
+		return c.MaxAttempts
+	}
+	return 1
+}
+
+func (c RetryProxyConfig) baseDelay() time.Duration {
+	if c.BaseDelay > 0 {
+		return c.BaseDelay
+	}
+	return 100 * time.Millisecond
+}
+
+func (c RetryProxyConfig) backoff() BackoffStrategy {
+	if c.Backoff != nil {
+		return c.Backoff
+	}
+	return FixedBackoff{}
+}
+
+func (c RetryProxyConfig) retryable(statusCode int) bool {
+	if len(c.StatusCodes) == 0 {
+		return retryProxyDefaultStatusCodes[statusCode]
+	}
+	for _, code := range c.StatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (c RetryProxyConfig) sleepFunc() func(time.Duration) {
+	if c.sleep != nil {
+		return c.sleep
+	}
+	return time.Sleep
+}
+
+// isRetryEligible reports whether r may safely be retried: either its
+// method is inherently idempotent (see idempotentMethods), or the caller
+// has explicitly taken responsibility for a retry being safe via
+// X-Idempotency-Key.
+func isRetryEligible(r *http.Request) bool {
+	return idempotentMethods[r.Method] || len(r.Header.Get("X-Idempotency-Key")) > 0
+}
+
+// MakeRetryingProxyHandler wraps next so that a retryable failure - see
+// RetryProxyConfig.StatusCodes - is retried until config.MaxAttempts
+// attempts in all, the first included, have been made, with
+// config.Backoff between attempts, but only for requests
+// isRetryEligible allows. This is a general-purpose counterpart to
+// MakeDirectFunctionsRetryHandler, which retries exactly once and only
+// while a replica is known to be draining.
+func MakeRetryingProxyHandler(next http.HandlerFunc, config RetryProxyConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if config.maxAttempts() <= 1 || !isRetryEligible(r) {
+			next(w, r)
+			return
+		}
+
+		// Read the body once up-front so it can be replayed on a retry;
+		// a consumed Reader from the first attempt would otherwise send
+		// an empty body on the next one.
+		var bodyBytes []byte
+		if r.Body != nil {
+			bodyBytes, _ = ioutil.ReadAll(r.Body)
+			r.Body.Close()
+		}
+
+		interceptor := newRetryableStatusWriter(w, retryProxyBufferCap, config.retryable)
+
+		for attempt := 1; ; attempt++ {
+			if bodyBytes != nil {
+				r.Body = ioutil.NopCloser(bytes.NewReader(bodyBytes))
+			}
+
+			next(interceptor, r)
+
+			if !interceptor.buffering || attempt >= config.maxAttempts() {
+				break
+			}
+
+			config.sleepFunc()(config.backoff().NextDelay(attempt-1, config.baseDelay()))
+			interceptor.discard()
+		}
+
+		interceptor.flush()
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func noopSleep(time.Duration) {}
+
+func Test_MakeRetryingProxyHandler_RetriesIdempotentRequestOnRetryableStatus(t *testing.T) {
+	attempts := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("pong"))
+	}
+
+	handler := MakeRetryingProxyHandler(next, RetryProxyConfig{MaxAttempts: 3, sleep: noopSleep})
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if attempts != 3 {
+		t.Fatalf("want 3 attempts, got %d", attempts)
+	}
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want the eventual 200 forwarded, got %d", rr.Code)
+	}
+	if rr.Body.String() != "pong" {
+		t.Errorf("want the successful body forwarded, got %q", rr.Body.String())
+	}
+}
+
+func Test_MakeRetryingProxyHandler_GivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	handler := MakeRetryingProxyHandler(next, RetryProxyConfig{MaxAttempts: 2, sleep: noopSleep})
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if attempts != 2 {
+		t.Fatalf("want exactly MaxAttempts attempts, got %d", attempts)
+	}
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("want the final 503 forwarded once attempts are exhausted, got %d", rr.Code)
+	}
+}
+
+func Test_MakeRetryingProxyHandler_DoesNotRetryNonIdempotentRequestWithoutIdempotencyKey(t *testing.T) {
+	attempts := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	handler := MakeRetryingProxyHandler(next, RetryProxyConfig{MaxAttempts: 3, sleep: noopSleep})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", strings.NewReader("body"))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if attempts != 1 {
+		t.Fatalf("want no retry for a non-idempotent request, got %d attempts", attempts)
+	}
+}
+
+func Test_MakeRetryingProxyHandler_RetriesNonIdempotentRequestWithIdempotencyKey(t *testing.T) {
+	attempts := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	handler := MakeRetryingProxyHandler(next, RetryProxyConfig{MaxAttempts: 2, sleep: noopSleep})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", strings.NewReader("body"))
+	req.Header.Set("X-Idempotency-Key", "abc-123")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if attempts != 2 {
+		t.Fatalf("want a retry when X-Idempotency-Key is set, got %d attempts", attempts)
+	}
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want the retried 200 forwarded, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// retryableStatusWriter intercepts the first WriteHeader call so a caller
+// can decide, once the handler underneath has finished, whether to
+// transparently retry the whole request before anything reaches the real
+// client. A status code accepted by retryable is held back, along with
+// whatever body follows, up to bufferCap bytes; any other status, or a
+// body that grows past bufferCap, is passed straight through immediately
+// and interception switches off for the remainder of the response - so a
+// normal or streaming response pays no buffering cost.
+type retryableStatusWriter struct {
+	http.ResponseWriter
+	retryable     func(statusCode int) bool
+	bufferCap     int
+	statusCode    int
+	headerWritten bool
+	buffering     bool
+	buffer        bytes.Buffer
+}
+
+func newRetryableStatusWriter(w http.ResponseWriter, bufferCap int, retryable func(statusCode int) bool) *retryableStatusWriter {
+	return &retryableStatusWriter{ResponseWriter: w, bufferCap: bufferCap, retryable: retryable}
+}
+
+func (c *retryableStatusWriter) WriteHeader(statusCode int) {
+	if c.headerWritten {
+		return
+	}
+	c.headerWritten = true
+	c.statusCode = statusCode
+
+	if c.retryable(statusCode) {
+		c.buffering = true
+		return
+	}
+
+	c.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (c *retryableStatusWriter) Write(b []byte) (int, error) {
+	if !c.headerWritten {
+		c.WriteHeader(http.StatusOK)
+	}
+
+	if c.buffering {
+		if c.buffer.Len()+len(b) > c.bufferCap {
+			c.flush()
+			return c.ResponseWriter.Write(b)
+		}
+		return c.buffer.Write(b)
+	}
+
+	return c.ResponseWriter.Write(b)
+}
+
+// flush sends whatever was buffered to the real client and disables
+// interception for the rest of the response.
+func (c *retryableStatusWriter) flush() {
+	if !c.buffering {
+		return
+	}
+	c.buffering = false
+	c.ResponseWriter.WriteHeader(c.statusCode)
+	c.ResponseWriter.Write(c.buffer.Bytes())
+	c.buffer.Reset()
+}
+
+// discard drops whatever was buffered so a retry can start from a clean
+// state.
+func (c *retryableStatusWriter) discard() {
+	c.headerWritten = false
+	c.buffering = false
+	c.statusCode = 0
+	c.buffer.Reset()
+}
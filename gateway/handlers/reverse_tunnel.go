@@ -0,0 +1,261 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tunnelRequest and tunnelResponse are the minimal HTTP envelope framed as
+// JSON text over the tunnel's WebSocket connection.
+type tunnelRequest struct {
+	Method string              `json:"method"`
+	URL    string              `json:"url"`
+	Header map[string][]string `json:"header"`
+	Body   []byte              `json:"body"`
+}
+
+type tunnelResponse struct {
+	StatusCode int                 `json:"statusCode"`
+	Header     map[string][]string `json:"header"`
+	Body       []byte              `json:"body"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// Tunnel is a persistent connection from an edge provider back to the
+// gateway, established by dialing /system/tunnel/register. Requests are
+// proxied to the edge node over it.
+//
+// A single TCP connection only carries one request at a time: without a
+// true stream multiplexer such as yamux (not vendored in this tree) there
+// is no safe way to interleave concurrent requests on it, so callRequestLock
+// serializes access. An edge node wanting concurrency should register
+// multiple tunnels under the same node ID; Next round-robins across them.
+type Tunnel struct {
+	conn            net.Conn
+	bufrw           *bufio.ReadWriter
+	callRequestLock sync.Mutex
+}
+
+// TunnelRegistry holds the live tunnels for each registered edge node ID.
+type TunnelRegistry struct {
+	lock    sync.Mutex
+	tunnels map[string][]*Tunnel
+	next    map[string]int
+}
+
+// NewTunnelRegistry creates an empty TunnelRegistry.
+func NewTunnelRegistry() *TunnelRegistry {
+	return &TunnelRegistry{
+		tunnels: make(map[string][]*Tunnel),
+		next:    make(map[string]int),
+	}
+}
+
+// RegisterNode adds tunnel under nodeID.
+func (tr *TunnelRegistry) RegisterNode(nodeID string, tunnel *Tunnel) {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+	tr.tunnels[nodeID] = append(tr.tunnels[nodeID], tunnel)
+}
+
+// UnregisterNode removes tunnel from nodeID, e.g. once its connection
+// closes.
+func (tr *TunnelRegistry) UnregisterNode(nodeID string, tunnel *Tunnel) {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+
+	tunnels := tr.tunnels[nodeID]
+	for i, t := range tunnels {
+		if t == tunnel {
+			tr.tunnels[nodeID] = append(tunnels[:i], tunnels[i+1:]...)
+			break
+		}
+	}
+}
+
+// Next returns the next tunnel registered for nodeID, round-robining across
+// however many are registered, or false if none are.
+func (tr *TunnelRegistry) Next(nodeID string) (*Tunnel, bool) {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+
+	tunnels := tr.tunnels[nodeID]
+	if len(tunnels) == 0 {
+		return nil, false
+	}
+
+	i := tr.next[nodeID] % len(tunnels)
+	tr.next[nodeID] = i + 1
+
+	return tunnels[i], true
+}
+
+// Nodes returns the IDs of every node with at least one tunnel currently
+// registered.
+func (tr *TunnelRegistry) Nodes() []string {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+
+	nodes := make([]string, 0, len(tr.tunnels))
+	for nodeID, tunnels := range tr.tunnels {
+		if len(tunnels) > 0 {
+			nodes = append(nodes, nodeID)
+		}
+	}
+	return nodes
+}
+
+// MakeTunnelRegisterHandler upgrades GET /system/tunnel/register?node=ID to
+// a WebSocket-framed connection and registers it in registry for the
+// lifetime of the connection, so invocations can be proxied down to the
+// edge node that dialed in. A non-empty secret is additionally required as
+// a bearer token on the upgrade request, so registering under a given node
+// ID - and thereby receiving that node's forwarded traffic - takes more
+// than just being able to reach the gateway; see
+// types.GatewayConfig.TunnelRegisterSecret.
+func MakeTunnelRegisterHandler(registry *TunnelRegistry, affinity *NodeAffinity, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nodeID := r.URL.Query().Get("node")
+		region := r.URL.Query().Get("region")
+		key := r.Header.Get("Sec-WebSocket-Key")
+		if len(nodeID) == 0 || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || len(key) == 0 {
+			http.Error(w, "expected a node query parameter and a WebSocket upgrade request", http.StatusBadRequest)
+			return
+		}
+
+		if len(secret) > 0 && !tunnelRegisterTokenMatches(r, secret) {
+			http.Error(w, "invalid or missing tunnel register token", http.StatusUnauthorized)
+			return
+		}
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "websockets are not supported by this connection", http.StatusInternalServerError)
+			return
+		}
+
+		conn, bufrw, err := hijacker.Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+
+		accept := websocketAccept(key)
+		bufrw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+		bufrw.WriteString("Upgrade: websocket\r\n")
+		bufrw.WriteString("Connection: Upgrade\r\n")
+		bufrw.WriteString("Sec-WebSocket-Accept: " + accept + "\r\n\r\n")
+		if err := bufrw.Flush(); err != nil {
+			return
+		}
+
+		tunnel := &Tunnel{conn: conn, bufrw: bufrw}
+		registry.RegisterNode(nodeID, tunnel)
+		defer registry.UnregisterNode(nodeID, tunnel)
+
+		if affinity != nil && len(region) > 0 {
+			affinity.SetRegion(nodeID, region)
+		}
+
+		// Block here for as long as the edge node stays connected; frames
+		// sent down this connection are read out by callOverTunnel.
+		for {
+			if _, _, err := readWebsocketFrame(bufrw.Reader); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// tunnelRegisterTokenMatches checks r's "Authorization: Bearer <token>"
+// header against secret in constant time.
+func tunnelRegisterTokenMatches(r *http.Request, secret string) bool {
+	const prefix = "Bearer "
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, prefix) {
+		return false
+	}
+	token := strings.TrimPrefix(authHeader, prefix)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+// callOverTunnel proxies r to tunnel's edge node and copies the response
+// back to w.
+func callOverTunnel(w http.ResponseWriter, r *http.Request, tunnel *Tunnel, timeout time.Duration) error {
+	bodyBytes, _ := ioutil.ReadAll(r.Body)
+
+	payload, err := json.Marshal(tunnelRequest{
+		Method: r.Method,
+		URL:    r.URL.String(),
+		Header: r.Header,
+		Body:   bodyBytes,
+	})
+	if err != nil {
+		return err
+	}
+
+	tunnel.callRequestLock.Lock()
+	defer tunnel.callRequestLock.Unlock()
+
+	tunnel.conn.SetDeadline(time.Now().Add(timeout))
+	defer tunnel.conn.SetDeadline(time.Time{})
+
+	if err := writeWebsocketFrame(tunnel.bufrw.Writer, wsOpText, payload); err != nil {
+		return err
+	}
+
+	_, respPayload, err := readWebsocketFrame(tunnel.bufrw.Reader)
+	if err != nil {
+		return err
+	}
+
+	var tunnelResp tunnelResponse
+	if err := json.Unmarshal(respPayload, &tunnelResp); err != nil {
+		return err
+	}
+
+	if len(tunnelResp.Error) > 0 {
+		return fmt.Errorf("edge node error: %s", tunnelResp.Error)
+	}
+
+	for name, values := range tunnelResp.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.WriteHeader(tunnelResp.StatusCode)
+	w.Write(tunnelResp.Body)
+
+	return nil
+}
+
+// MakeTunnelForwardingHandler proxies requests for the {node} edge node
+// over its registered tunnel, falling back to a 502 when no tunnel for that
+// node is currently registered.
+func MakeTunnelForwardingHandler(registry *TunnelRegistry, nodeIDResolver func(r *http.Request) string, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nodeID := nodeIDResolver(r)
+
+		tunnel, exists := registry.Next(nodeID)
+		if !exists {
+			http.Error(w, fmt.Sprintf("no tunnel registered for node %q", nodeID), http.StatusBadGateway)
+			return
+		}
+
+		if err := callOverTunnel(w, r, tunnel, timeout); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestTunnel(t *testing.T) (*Tunnel, net.Conn) {
+	gatewaySide, edgeSide := net.Pipe()
+
+	tunnel := &Tunnel{
+		conn:  gatewaySide,
+		bufrw: bufio.NewReadWriter(bufio.NewReader(gatewaySide), bufio.NewWriter(gatewaySide)),
+	}
+
+	return tunnel, edgeSide
+}
+
+// serveOneTunnelRequest plays the edge node side of the tunnel: it reads one
+// framed request and replies with a fixed response.
+func serveOneTunnelRequest(t *testing.T, edgeSide net.Conn, statusCode int, body string) {
+	reader := bufio.NewReader(edgeSide)
+	writer := bufio.NewWriter(edgeSide)
+
+	_, _, err := readWebsocketFrame(reader)
+	if err != nil {
+		t.Errorf("edge node: unable to read request frame: %s", err.Error())
+		return
+	}
+
+	payload, err := json.Marshal(tunnelResponse{
+		StatusCode: statusCode,
+		Header:     map[string][]string{"X-Edge-Node": {"node-1"}},
+		Body:       []byte(body),
+	})
+	if err != nil {
+		t.Errorf("edge node: unable to marshal response: %s", err.Error())
+		return
+	}
+
+	if err := writeWebsocketFrame(writer, wsOpText, payload); err != nil {
+		t.Errorf("edge node: unable to write response frame: %s", err.Error())
+	}
+}
+
+func Test_CallOverTunnel_ProxiesRequestAndResponse(t *testing.T) {
+	tunnel, edgeSide := newTestTunnel(t)
+	defer edgeSide.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serveOneTunnelRequest(t, edgeSide, http.StatusOK, "hello from the edge")
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	rr := httptest.NewRecorder()
+
+	if err := callOverTunnel(rr, req, tunnel, time.Second*5); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	<-done
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("want 200, got %d", rr.Code)
+	}
+	if rr.Body.String() != "hello from the edge" {
+		t.Errorf("want body from edge node, got %s", rr.Body.String())
+	}
+	if rr.Header().Get("X-Edge-Node") != "node-1" {
+		t.Errorf("want edge node header copied through, got %v", rr.Header())
+	}
+}
+
+func Test_TunnelForwardingHandler_502sWhenNoTunnelRegistered(t *testing.T) {
+	registry := NewTunnelRegistry()
+
+	handler := MakeTunnelForwardingHandler(registry, func(r *http.Request) string {
+		return "missing-node"
+	}, time.Second)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/echo", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("want 502, got %d", rr.Code)
+	}
+}
+
+func Test_TunnelRegistry_NextRoundRobinsAcrossRegisteredTunnels(t *testing.T) {
+	registry := NewTunnelRegistry()
+	first := &Tunnel{}
+	second := &Tunnel{}
+
+	registry.RegisterNode("node-1", first)
+	registry.RegisterNode("node-1", second)
+
+	a, _ := registry.Next("node-1")
+	b, _ := registry.Next("node-1")
+	c, _ := registry.Next("node-1")
+
+	if a != first || b != second || c != first {
+		t.Errorf("want round-robin across registered tunnels")
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// RoutingRule redirects an invocation of FunctionName to TargetFunctionName
+// when the request matches every condition set on it - HeaderName/HeaderValue,
+// PathPrefix and/or QueryParam/QueryValue. A condition left empty is not
+// checked, so a rule with none of them set matches every request to
+// FunctionName.
+type RoutingRule struct {
+	TargetFunctionName string `json:"targetFunctionName"`
+
+	// HeaderName/HeaderValue, when HeaderName is set, require the request
+	// to carry that header with exactly that value, e.g. HeaderName
+	// "X-Api-Version" and HeaderValue "2".
+	HeaderName  string `json:"headerName"`
+	HeaderValue string `json:"headerValue"`
+
+	// PathPrefix, when set, requires the request's path to start with it,
+	// e.g. "/function/foo/admin".
+	PathPrefix string `json:"pathPrefix"`
+
+	// QueryParam/QueryValue, when QueryParam is set, require the request's
+	// query string to carry that parameter with exactly that value.
+	QueryParam string `json:"queryParam"`
+	QueryValue string `json:"queryValue"`
+}
+
+// matches reports whether r satisfies every condition rule has set.
+func (rule RoutingRule) matches(r *http.Request) bool {
+	if rule.HeaderName != "" && r.Header.Get(rule.HeaderName) != rule.HeaderValue {
+		return false
+	}
+	if rule.PathPrefix != "" && !strings.HasPrefix(r.URL.Path, rule.PathPrefix) {
+		return false
+	}
+	if rule.QueryParam != "" && r.URL.Query().Get(rule.QueryParam) != rule.QueryValue {
+		return false
+	}
+	return true
+}
+
+// RoutingRuleSet is the list of RoutingRule evaluated, in order, for
+// invocations of FunctionName - the first rule whose conditions all match
+// wins.
+type RoutingRuleSet struct {
+	FunctionName string        `json:"functionName"`
+	Rules        []RoutingRule `json:"rules"`
+}
+
+// RoutingRuleStore holds the ordered RoutingRules configured per function,
+// keyed by FunctionName.
+type RoutingRuleStore struct {
+	lock sync.RWMutex
+	sets map[string][]RoutingRule
+}
+
+// NewRoutingRuleStore creates an empty RoutingRuleStore.
+func NewRoutingRuleStore() *RoutingRuleStore {
+	return &RoutingRuleStore{
+		sets: make(map[string][]RoutingRule),
+	}
+}
+
+// Set replaces every rule configured for functionName.
+func (s *RoutingRuleStore) Set(functionName string, rules []RoutingRule) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sets[functionName] = rules
+}
+
+// Get returns the ordered rules configured for functionName, if any.
+func (s *RoutingRuleStore) Get(functionName string) []RoutingRule {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.sets[functionName]
+}
+
+// Delete removes every rule configured for functionName.
+func (s *RoutingRuleStore) Delete(functionName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.sets, functionName)
+}
+
+// List returns every configured RoutingRuleSet.
+func (s *RoutingRuleStore) List() []RoutingRuleSet {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	sets := make([]RoutingRuleSet, 0, len(s.sets))
+	for functionName, rules := range s.sets {
+		sets = append(sets, RoutingRuleSet{FunctionName: functionName, Rules: rules})
+	}
+	return sets
+}
+
+// MakeRoutingRulesHandler wraps next, rewriting the invoked function name
+// to the first matching rule's TargetFunctionName. A function with no
+// configured rules, or whose rules all fail to match, is forwarded
+// unchanged.
+func MakeRoutingRulesHandler(next http.HandlerFunc, store *RoutingRuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := getServiceName(r.URL.Path)
+
+		for _, rule := range store.Get(functionName) {
+			if rule.matches(r) {
+				r.URL.Path = rewriteFunctionName(r.URL.Path, functionName, rule.TargetFunctionName)
+				break
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// MakeListRoutingRulesHandler returns every configured RoutingRuleSet as
+// JSON.
+func MakeListRoutingRulesHandler(store *RoutingRuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bytesOut, err := json.Marshal(store.List())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(bytesOut)
+	}
+}
+
+// MakeSetRoutingRulesHandler decodes a RoutingRuleSet from the request body
+// and registers it against its FunctionName, replacing any rules
+// previously configured for that function.
+func MakeSetRoutingRulesHandler(store *RoutingRuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var set RoutingRuleSet
+		if err := json.NewDecoder(r.Body).Decode(&set); err != nil {
+			http.Error(w, "invalid routing rule set", http.StatusBadRequest)
+			return
+		}
+
+		if len(set.FunctionName) == 0 || len(set.Rules) == 0 {
+			http.Error(w, "functionName and rules are required", http.StatusBadRequest)
+			return
+		}
+
+		for _, rule := range set.Rules {
+			if len(rule.TargetFunctionName) == 0 {
+				http.Error(w, "every rule requires a targetFunctionName", http.StatusBadRequest)
+				return
+			}
+		}
+
+		store.Set(set.FunctionName, set.Rules)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeDeleteRoutingRulesHandler removes every rule configured for the
+// {name} function.
+func MakeDeleteRoutingRulesHandler(store *RoutingRuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := mux.Vars(r)["name"]
+		store.Delete(functionName)
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func Test_MakeRoutingRulesHandler_PassesThroughWithoutRules(t *testing.T) {
+	store := NewRoutingRuleStore()
+
+	var gotPath string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeRoutingRulesHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if gotPath != "/function/foo" {
+		t.Errorf("want the request untouched, got %s", gotPath)
+	}
+}
+
+func Test_MakeRoutingRulesHandler_RoutesOnHeaderMatch(t *testing.T) {
+	store := NewRoutingRuleStore()
+	store.Set("foo", []RoutingRule{
+		{TargetFunctionName: "foo-v2", HeaderName: "X-Api-Version", HeaderValue: "2"},
+	})
+
+	var gotPath string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeRoutingRulesHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	req.Header.Set("X-Api-Version", "2")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if gotPath != "/function/foo-v2" {
+		t.Errorf("want routed to foo-v2, got %s", gotPath)
+	}
+}
+
+func Test_MakeRoutingRulesHandler_FirstMatchWins(t *testing.T) {
+	store := NewRoutingRuleStore()
+	store.Set("foo", []RoutingRule{
+		{TargetFunctionName: "foo-admin", PathPrefix: "/function/foo/admin"},
+		{TargetFunctionName: "foo-v2", HeaderName: "X-Api-Version", HeaderValue: "2"},
+	})
+
+	var gotPath string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeRoutingRulesHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo/admin", nil)
+	req.Header.Set("X-Api-Version", "2")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if gotPath != "/function/foo-admin/admin" {
+		t.Errorf("want the first matching rule to win, got %s", gotPath)
+	}
+}
+
+func Test_MakeRoutingRulesHandler_NoMatchFallsThroughUnchanged(t *testing.T) {
+	store := NewRoutingRuleStore()
+	store.Set("foo", []RoutingRule{
+		{TargetFunctionName: "foo-v2", HeaderName: "X-Api-Version", HeaderValue: "2"},
+	})
+
+	var gotPath string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeRoutingRulesHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if gotPath != "/function/foo" {
+		t.Errorf("want no match to leave the request unchanged, got %s", gotPath)
+	}
+}
+
+func Test_MakeRoutingRulesHandler_RoutesOnQueryParamMatch(t *testing.T) {
+	store := NewRoutingRuleStore()
+	store.Set("foo", []RoutingRule{
+		{TargetFunctionName: "foo-beta", QueryParam: "cohort", QueryValue: "beta"},
+	})
+
+	var gotPath string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}
+	handler := MakeRoutingRulesHandler(next, store)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/foo?cohort=beta", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if gotPath != "/function/foo-beta" {
+		t.Errorf("want routed to foo-beta, got %s", gotPath)
+	}
+}
+
+func Test_MakeSetRoutingRulesHandler_RejectsRuleWithoutTarget(t *testing.T) {
+	store := NewRoutingRuleStore()
+	handler := MakeSetRoutingRulesHandler(store)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/routes", strings.NewReader(`{"functionName":"foo","rules":[{"headerName":"X-Api-Version","headerValue":"2"}]}`))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("want 400 for a rule missing targetFunctionName, got %d", rr.Code)
+	}
+}
+
+func Test_MakeDeleteRoutingRulesHandler_RemovesRules(t *testing.T) {
+	store := NewRoutingRuleStore()
+	store.Set("foo", []RoutingRule{{TargetFunctionName: "foo-v2"}})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/routes/{name}", MakeDeleteRoutingRulesHandler(store)).Methods(http.MethodDelete)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/routes/foo", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+	if rules := store.Get("foo"); len(rules) != 0 {
+		t.Errorf("want rules removed, got %v", rules)
+	}
+}
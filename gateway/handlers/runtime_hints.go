@@ -0,0 +1,218 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RuntimeHint is what a function, or its template, has told the gateway
+// about its own runtime behaviour, so the gateway can tune itself rather
+// than relying on gateway-wide defaults that may not suit that function.
+type RuntimeHint struct {
+	FunctionName string `json:"functionName"`
+
+	// ExpectedColdStartDuration is how long this function's template
+	// expects a scale-from-zero cycle to take. When set, it widens or
+	// narrows the scale-from-zero poll interval/count derived in
+	// PollIntervalForHint/MaxPollCountForHint, instead of every function
+	// being polled against the same gateway-wide assumption regardless
+	// of how slow or fast its own cold start actually is.
+	ExpectedColdStartDuration time.Duration `json:"expectedColdStartDuration"`
+
+	// SupportsStreaming reports whether this function handles chunked
+	// request/response bodies, rather than requiring the gateway to
+	// buffer a request fully before forwarding it.
+	SupportsStreaming bool `json:"supportsStreaming"`
+
+	// IdempotentMethods lists the HTTP methods this function can safely
+	// have retried or served from cache, e.g. ["GET", "HEAD"].
+	IdempotentMethods []string `json:"idempotentMethods"`
+}
+
+// IsIdempotent reports whether method is listed in h.IdempotentMethods.
+func (h RuntimeHint) IsIdempotent(method string) bool {
+	for _, idempotentMethod := range h.IdempotentMethods {
+		if idempotentMethod == method {
+			return true
+		}
+	}
+	return false
+}
+
+// minPollInterval is the floor PollIntervalForHint will ever suggest,
+// regardless of how short ExpectedColdStartDuration is, so a
+// mis-configured hint can't turn scale-from-zero polling into a busy loop.
+const minPollInterval = time.Millisecond
+
+// pollSamples is how many polls PollIntervalForHint/MaxPollCountForHint
+// aim to spread across ExpectedColdStartDuration.
+const pollSamples = 20
+
+// PollIntervalForHint derives a scale-from-zero poll interval from a
+// function's expected cold-start duration, aiming for roughly pollSamples
+// polls across that duration. Returns zero - meaning "no override" - when
+// the hint gives no duration to work from.
+func PollIntervalForHint(hint RuntimeHint) time.Duration {
+	if hint.ExpectedColdStartDuration <= 0 {
+		return 0
+	}
+
+	interval := hint.ExpectedColdStartDuration / pollSamples
+	if interval < minPollInterval {
+		interval = minPollInterval
+	}
+	return interval
+}
+
+// MaxPollCountForHint derives a scale-from-zero poll count from a
+// function's expected cold-start duration and the interval
+// PollIntervalForHint would derive for it, with headroom so a cold start
+// that overruns its expectation still has a chance to succeed rather than
+// timing out right at the expected duration. Returns zero - meaning "no
+// override" - when the hint gives no duration to work from.
+func MaxPollCountForHint(hint RuntimeHint) uint {
+	interval := PollIntervalForHint(hint)
+	if interval <= 0 {
+		return 0
+	}
+
+	return uint(2*hint.ExpectedColdStartDuration/interval) + 1
+}
+
+// RuntimeHintStore holds the runtime hints registered per function.
+type RuntimeHintStore struct {
+	lock  sync.RWMutex
+	hints map[string]RuntimeHint
+}
+
+// NewRuntimeHintStore creates an empty RuntimeHintStore.
+func NewRuntimeHintStore() *RuntimeHintStore {
+	return &RuntimeHintStore{
+		hints: make(map[string]RuntimeHint),
+	}
+}
+
+// Set registers or replaces the runtime hint for its FunctionName.
+func (s *RuntimeHintStore) Set(hint RuntimeHint) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.hints[hint.FunctionName] = hint
+}
+
+// Get returns the runtime hint registered for functionName, if any.
+func (s *RuntimeHintStore) Get(functionName string) (RuntimeHint, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	hint, exists := s.hints[functionName]
+	return hint, exists
+}
+
+// Delete removes the runtime hint registered for functionName, if any.
+func (s *RuntimeHintStore) Delete(functionName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.hints, functionName)
+}
+
+// List returns every registered runtime hint.
+func (s *RuntimeHintStore) List() []RuntimeHint {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	hints := make([]RuntimeHint, 0, len(s.hints))
+	for _, hint := range s.hints {
+		hints = append(hints, hint)
+	}
+	return hints
+}
+
+// MakeListRuntimeHintsHandler returns every registered runtime hint as JSON.
+func MakeListRuntimeHintsHandler(store *RuntimeHintStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bytesOut, err := json.Marshal(store.List())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(bytesOut)
+	}
+}
+
+// MakeSetRuntimeHintHandler decodes a RuntimeHint from the request body and
+// registers it against its FunctionName, so a function or its template can
+// call this once at start-up to tell the gateway how to treat it.
+func MakeSetRuntimeHintHandler(store *RuntimeHintStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var hint RuntimeHint
+		if err := json.NewDecoder(r.Body).Decode(&hint); err != nil {
+			http.Error(w, "invalid runtime hint", http.StatusBadRequest)
+			return
+		}
+
+		if len(hint.FunctionName) == 0 {
+			http.Error(w, "functionName is required", http.StatusBadRequest)
+			return
+		}
+
+		store.Set(hint)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeDeleteRuntimeHintHandler removes the runtime hint registered against
+// the {name} function.
+func MakeDeleteRuntimeHintHandler(store *RuntimeHintStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := mux.Vars(r)["name"]
+		store.Delete(functionName)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// HintedServiceQuery wraps a ServiceQuery, overriding PollInterval and
+// MaxPollCount on its GetReplicas responses from whatever runtime hint is
+// registered for the function in Hints - taking the same effect as the
+// PollIntervalLabel/MaxPollCountLabel overrides already supported by
+// plugin.ExternalServiceQuery, but driven by a hint the function itself
+// registered rather than a label the function's owner set on it.
+// A provider-sourced override, when present, always wins over the hint.
+type HintedServiceQuery struct {
+	Next  ServiceQuery
+	Hints *RuntimeHintStore
+}
+
+// GetReplicas implements ServiceQuery.
+func (q HintedServiceQuery) GetReplicas(service string) (ServiceQueryResponse, error) {
+	response, err := q.Next.GetReplicas(service)
+	if err != nil {
+		return response, err
+	}
+
+	hint, exists := q.Hints.Get(service)
+	if !exists {
+		return response, nil
+	}
+
+	if response.PollInterval == 0 {
+		response.PollInterval = PollIntervalForHint(hint)
+	}
+	if response.MaxPollCount == 0 {
+		response.MaxPollCount = MaxPollCountForHint(hint)
+	}
+
+	return response, nil
+}
+
+// SetReplicas implements ServiceQuery.
+func (q HintedServiceQuery) SetReplicas(service string, count uint64) error {
+	return q.Next.SetReplicas(service, count)
+}
@@ -0,0 +1,178 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func Test_RuntimeHintStore_SetGetDelete(t *testing.T) {
+	store := NewRuntimeHintStore()
+
+	if _, exists := store.Get("figlet"); exists {
+		t.Fatal("want no hint registered yet")
+	}
+
+	store.Set(RuntimeHint{FunctionName: "figlet", SupportsStreaming: true})
+
+	hint, exists := store.Get("figlet")
+	if !exists || !hint.SupportsStreaming {
+		t.Fatalf("want registered hint with SupportsStreaming, got %+v, exists=%v", hint, exists)
+	}
+
+	store.Delete("figlet")
+	if _, exists := store.Get("figlet"); exists {
+		t.Fatal("want hint removed after Delete")
+	}
+}
+
+func Test_RuntimeHint_IsIdempotent(t *testing.T) {
+	hint := RuntimeHint{IdempotentMethods: []string{"GET", "HEAD"}}
+
+	if !hint.IsIdempotent("GET") {
+		t.Error("want GET to be idempotent")
+	}
+	if hint.IsIdempotent("POST") {
+		t.Error("want POST to not be idempotent")
+	}
+}
+
+func Test_PollIntervalAndMaxPollCountForHint_NoOverrideWithoutDuration(t *testing.T) {
+	hint := RuntimeHint{}
+
+	if got := PollIntervalForHint(hint); got != 0 {
+		t.Errorf("want zero poll interval without a duration hint, got %s", got)
+	}
+	if got := MaxPollCountForHint(hint); got != 0 {
+		t.Errorf("want zero max poll count without a duration hint, got %d", got)
+	}
+}
+
+func Test_PollIntervalForHint_NeverBelowFloor(t *testing.T) {
+	hint := RuntimeHint{ExpectedColdStartDuration: time.Microsecond}
+
+	if got := PollIntervalForHint(hint); got < minPollInterval {
+		t.Errorf("want poll interval floored at %s, got %s", minPollInterval, got)
+	}
+}
+
+func Test_MakeSetRuntimeHintHandler_RejectsMissingFunctionName(t *testing.T) {
+	handler := MakeSetRuntimeHintHandler(NewRuntimeHintStore())
+
+	req := httptest.NewRequest(http.MethodPost, "/system/runtime-hints", bytes.NewReader([]byte(`{}`)))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("want 400 for a missing functionName, got %d", rr.Code)
+	}
+}
+
+func Test_MakeSetRuntimeHintHandler_RegistersHint(t *testing.T) {
+	store := NewRuntimeHintStore()
+	handler := MakeSetRuntimeHintHandler(store)
+
+	body := []byte(`{"functionName":"figlet","expectedColdStartDuration":2000000000,"supportsStreaming":true}`)
+	req := httptest.NewRequest(http.MethodPost, "/system/runtime-hints", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+
+	hint, exists := store.Get("figlet")
+	if !exists || hint.ExpectedColdStartDuration != 2*time.Second {
+		t.Fatalf("want registered hint with a 2s expected cold start, got %+v, exists=%v", hint, exists)
+	}
+}
+
+func Test_MakeDeleteRuntimeHintHandler_RemovesHint(t *testing.T) {
+	store := NewRuntimeHintStore()
+	store.Set(RuntimeHint{FunctionName: "figlet"})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/runtime-hints/{name}", MakeDeleteRuntimeHintHandler(store)).Methods(http.MethodDelete)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/runtime-hints/figlet", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+	if _, exists := store.Get("figlet"); exists {
+		t.Error("want hint removed")
+	}
+}
+
+type stubServiceQueryForHints struct {
+	response ServiceQueryResponse
+	err      error
+}
+
+func (s stubServiceQueryForHints) GetReplicas(service string) (ServiceQueryResponse, error) {
+	return s.response, s.err
+}
+
+func (s stubServiceQueryForHints) SetReplicas(service string, count uint64) error {
+	return nil
+}
+
+func Test_HintedServiceQuery_OverridesPollingFromHintWhenProviderDidNot(t *testing.T) {
+	hints := NewRuntimeHintStore()
+	hints.Set(RuntimeHint{FunctionName: "figlet", ExpectedColdStartDuration: 2 * time.Second})
+
+	query := HintedServiceQuery{
+		Next:  stubServiceQueryForHints{response: ServiceQueryResponse{AvailableReplicas: 0}},
+		Hints: hints,
+	}
+
+	response, err := query.GetReplicas("figlet")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if response.PollInterval == 0 || response.MaxPollCount == 0 {
+		t.Fatalf("want poll interval/count derived from the hint, got %+v", response)
+	}
+}
+
+func Test_HintedServiceQuery_ProviderOverrideWinsOverHint(t *testing.T) {
+	hints := NewRuntimeHintStore()
+	hints.Set(RuntimeHint{FunctionName: "figlet", ExpectedColdStartDuration: 2 * time.Second})
+
+	query := HintedServiceQuery{
+		Next: stubServiceQueryForHints{response: ServiceQueryResponse{
+			PollInterval: time.Millisecond,
+			MaxPollCount: 7,
+		}},
+		Hints: hints,
+	}
+
+	response, err := query.GetReplicas("figlet")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if response.PollInterval != time.Millisecond || response.MaxPollCount != 7 {
+		t.Fatalf("want the provider's own override preserved, got %+v", response)
+	}
+}
+
+func Test_HintedServiceQuery_PropagatesUnderlyingError(t *testing.T) {
+	query := HintedServiceQuery{
+		Next:  stubServiceQueryForHints{err: errors.New("boom")},
+		Hints: NewRuntimeHintStore(),
+	}
+
+	if _, err := query.GetReplicas("figlet"); err == nil {
+		t.Fatal("want the underlying error propagated")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxAbsoluteReplicas caps the replica count accepted through the scale
+// API regardless of a function's own min/max scale labels, to reject
+// clearly-mistaken values rather than silently clamping them.
+const maxAbsoluteReplicas = 1000
+
+// ScaleFunctionRequest is the body accepted by the scale-function API.
+type ScaleFunctionRequest struct {
+	ServiceName string `json:"serviceName"`
+	Replicas    uint64 `json:"replicas"`
+}
+
+// ScaleFunctionResponse reports the outcome of a scale request.
+type ScaleFunctionResponse struct {
+	Replicas          uint64 `json:"replicas"`
+	AvailableReplicas uint64 `json:"availableReplicas"`
+	MinReplicas       uint64 `json:"minReplicas"`
+	MaxReplicas       uint64 `json:"maxReplicas"`
+}
+
+// MakeScaleFunctionHandler creates a handler for POST
+// /system/scale-function/{name}. The requested replica count is clamped to
+// the function's min/max scale labels and rejected outright if it exceeds
+// maxAbsoluteReplicas. Passing ?wait=true blocks the response until the
+// new replicas are available, or config.MaxPollCount attempts are used up,
+// reusing the same poll cadence as scaling from zero.
+func MakeScaleFunctionHandler(serviceQuery ServiceQuery, config ScalingConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := mux.Vars(r)["name"]
+
+		var scaleReq ScaleFunctionRequest
+		if err := json.NewDecoder(r.Body).Decode(&scaleReq); err != nil {
+			http.Error(w, fmt.Sprintf("invalid scale request: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		if scaleReq.Replicas > maxAbsoluteReplicas {
+			http.Error(w, fmt.Sprintf("requested replicas %d exceeds the maximum of %d", scaleReq.Replicas, maxAbsoluteReplicas), http.StatusBadRequest)
+			return
+		}
+
+		current, err := serviceQuery.GetReplicas(functionName)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error finding function %s: %s", functionName, err.Error()), http.StatusNotFound)
+			return
+		}
+
+		replicas := scaleReq.Replicas
+		if replicas < current.MinReplicas {
+			replicas = current.MinReplicas
+		}
+		if current.MaxReplicas > 0 && replicas > current.MaxReplicas {
+			replicas = current.MaxReplicas
+		}
+
+		if err := serviceQuery.SetReplicas(functionName, replicas); err != nil {
+			http.Error(w, fmt.Sprintf("error scaling function %s: %s", functionName, err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		available := replicas
+		if r.URL.Query().Get("wait") == "true" {
+			available = waitForReplicas(serviceQuery, functionName, replicas, config)
+		}
+
+		res := ScaleFunctionResponse{
+			Replicas:          replicas,
+			AvailableReplicas: available,
+			MinReplicas:       current.MinReplicas,
+			MaxReplicas:       current.MaxReplicas,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+// waitForReplicas polls serviceQuery until at least target replicas are
+// available, or config.MaxPollCount attempts have been made.
+func waitForReplicas(serviceQuery ServiceQuery, functionName string, target uint64, config ScalingConfig) uint64 {
+	var available uint64
+	for i := uint(0); i < config.MaxPollCount; i++ {
+		response, err := serviceQuery.GetReplicas(functionName)
+		if err == nil {
+			available = response.AvailableReplicas
+			if available >= target {
+				return available
+			}
+		}
+		time.Sleep(config.FunctionPollInterval)
+	}
+	return available
+}
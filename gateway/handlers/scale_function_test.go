@@ -0,0 +1,98 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type fakeServiceQuery struct {
+	response  ServiceQueryResponse
+	getErr    error
+	replicas  uint64
+	setCalled bool
+}
+
+func (f *fakeServiceQuery) GetReplicas(service string) (ServiceQueryResponse, error) {
+	if f.setCalled {
+		f.response.AvailableReplicas = f.replicas
+	}
+	return f.response, f.getErr
+}
+
+func (f *fakeServiceQuery) SetReplicas(service string, count uint64) error {
+	f.replicas = count
+	f.setCalled = true
+	return nil
+}
+
+func routeToScaleHandler(handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc("/system/scale-function/{name:[-a-zA-Z_0-9]+}", handler).Methods(http.MethodPost)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func Test_ScaleFunctionHandler_ClampsToMaxReplicas(t *testing.T) {
+	query := &fakeServiceQuery{response: ServiceQueryResponse{MinReplicas: 1, MaxReplicas: 5}}
+	handler := MakeScaleFunctionHandler(query, ScalingConfig{MaxPollCount: 1, FunctionPollInterval: time.Millisecond})
+
+	body, _ := json.Marshal(ScaleFunctionRequest{Replicas: 50})
+	req := httptest.NewRequest(http.MethodPost, "/system/scale-function/echo", bytes.NewReader(body))
+
+	rr := routeToScaleHandler(handler, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var res ScaleFunctionResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Replicas != 5 {
+		t.Errorf("want replicas clamped to 5, got %d", res.Replicas)
+	}
+}
+
+func Test_ScaleFunctionHandler_RejectsAbsurdReplicaCount(t *testing.T) {
+	query := &fakeServiceQuery{response: ServiceQueryResponse{MinReplicas: 1, MaxReplicas: 5}}
+	handler := MakeScaleFunctionHandler(query, ScalingConfig{})
+
+	body, _ := json.Marshal(ScaleFunctionRequest{Replicas: 999999})
+	req := httptest.NewRequest(http.MethodPost, "/system/scale-function/echo", bytes.NewReader(body))
+
+	rr := routeToScaleHandler(handler, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("want status 400 for an absurd replica count, got %d", rr.Code)
+	}
+}
+
+func Test_ScaleFunctionHandler_WaitBlocksUntilReplicasAvailable(t *testing.T) {
+	query := &fakeServiceQuery{response: ServiceQueryResponse{MinReplicas: 1, MaxReplicas: 5}}
+	handler := MakeScaleFunctionHandler(query, ScalingConfig{MaxPollCount: 5, FunctionPollInterval: time.Millisecond})
+
+	body, _ := json.Marshal(ScaleFunctionRequest{Replicas: 3})
+	req := httptest.NewRequest(http.MethodPost, "/system/scale-function/echo?wait=true", bytes.NewReader(body))
+
+	rr := routeToScaleHandler(handler, req)
+
+	var res ScaleFunctionResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.AvailableReplicas != 3 {
+		t.Errorf("want available replicas to reach 3 after waiting, got %d", res.AvailableReplicas)
+	}
+}
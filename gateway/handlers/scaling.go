@@ -4,12 +4,55 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io/ioutil"
 	"net/http"
 	"time"
+
+	"github.com/openfaas/faas/gateway/logging"
+	"github.com/openfaas/faas/gateway/metrics"
+	"github.com/openfaas/faas/gateway/tracing"
+)
+
+// Sentinel errors returned by the scaling handler's dependencies
+// (ServiceQuery implementations, ExternalScaler) so callers - including
+// code outside this package that embeds the gateway - can branch with
+// errors.Is instead of matching on error message text. Wrap these with
+// fmt.Errorf("...: %w", Err...) rather than returning them bare, so the
+// underlying cause is preserved alongside the sentinel.
+var (
+	// ErrFunctionNotFound is returned when the provider has no record of
+	// the requested function.
+	ErrFunctionNotFound = errors.New("function not found")
+
+	// ErrProviderUnavailable is returned when the provider could not be
+	// reached at all, as distinct from it responding with a not-found.
+	ErrProviderUnavailable = errors.New("function provider unavailable")
+
+	// ErrScaleTimeout is returned when a function was scaled up but did
+	// not report any available replicas within MaxPollCount attempts.
+	ErrScaleTimeout = errors.New("timed out waiting for function to scale up")
 )
 
+// statusCodeForScalingError maps a scaling error to the HTTP status the
+// handler should return to the client, using errors.Is so wrapped errors
+// are matched by their sentinel cause rather than by message text.
+func statusCodeForScalingError(err error) int {
+	switch {
+	case errors.Is(err, ErrFunctionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrProviderUnavailable):
+		return http.StatusBadGateway
+	case errors.Is(err, ErrScaleTimeout):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // ScalingConfig for scaling behaviours
 type ScalingConfig struct {
 	// MaxPollCount attempts to query a function before giving up
@@ -23,6 +66,114 @@ type ScalingConfig struct {
 
 	// ServiceQuery queries available/ready replicas for function
 	ServiceQuery ServiceQuery
+
+	// ExternalScaler optionally overrides the desired replica count when
+	// scaling up from zero, allowing custom scaling logic. When nil the
+	// function's configured MinReplicas is used.
+	ExternalScaler ExternalScaler
+
+	// Schedules optionally combines with reactive scaling as a floor/ceiling
+	// during configured time windows, e.g. business hours.
+	Schedules *ScalingScheduleStore
+
+	// Cache holds cached replica counts. When nil a private cache is created.
+	// Providing one allows it to be shared with, and flushed by, the admin API.
+	Cache *FunctionCache
+
+	// Clock provides the current time and drives polling/back-off delays.
+	// When nil, RealClock is used - a test can inject a fake to make
+	// scale-from-zero polling and cold-start retries deterministic and
+	// instantaneous instead of racing real sleeps.
+	Clock Clock
+
+	// BackoffStrategy decides how long to wait between scale-from-zero
+	// poll attempts, given FunctionPollInterval as the base interval.
+	// When nil, FixedBackoff is used - every attempt waits
+	// FunctionPollInterval, matching this handler's original behaviour.
+	BackoffStrategy BackoffStrategy
+
+	// Coalesce deduplicates concurrent scale-from-zero requests for the
+	// same function, so that a burst of requests arriving while a
+	// function is at zero replicas triggers a single SetReplicas/poll
+	// cycle instead of one per request. When nil a private group is
+	// created, scoped to this handler only.
+	Coalesce *SingleflightGroup
+
+	// ShutdownContext is cancelled when the gateway is shutting down, so
+	// a request parked waiting for a cold-start to finish gives up
+	// rather than holding a response open against a process that's on
+	// its way out. When nil, context.Background is used and only the
+	// request's own context.Context governs cancellation.
+	ShutdownContext context.Context
+
+	// Prewarmer, when set, is called once a scale-from-zero cycle
+	// succeeds, warming the upstream connection before next is invoked so
+	// that call doesn't also pay for a cold dial. When nil, no
+	// prewarming happens.
+	Prewarmer *ConnectionPrewarmer
+
+	// Tracer, when set, wraps each scale-from-zero cycle in a
+	// "scale_from_zero" span, parented to the triggering request's W3C
+	// traceparent header if it sent one. Nil leaves scaling untraced, as
+	// before this existed.
+	Tracer *tracing.Tracer
+
+	// Logger, when set, receives this handler's structured scaling
+	// events (see the logging package) instead of them going to log.Printf
+	// with a "[Scale] ..." prefix. When nil, a console-format Logger
+	// writing to os.Stdout is used, so this handler's log output is
+	// unchanged by default.
+	Logger logging.Logger
+
+	// Metrics, when set, records each scale-from-zero cycle's duration
+	// in ColdStartDurationHistogram, labelled by outcome. When nil, no
+	// cold-start metrics are recorded.
+	Metrics *metrics.MetricOptions
+}
+
+// recordColdStart observes a scale-from-zero cycle's duration against
+// ColdStartDurationHistogram, if metrics are configured.
+func (c ScalingConfig) recordColdStart(functionName, outcome string, duration time.Duration) {
+	if c.Metrics == nil || c.Metrics.ColdStartDurationHistogram == nil {
+		return
+	}
+	c.Metrics.ColdStartDurationHistogram.WithLabelValues(functionName, outcome).Observe(duration.Seconds())
+}
+
+func (c ScalingConfig) logger() logging.Logger {
+	if c.Logger == nil {
+		return logging.New("console")
+	}
+	return c.Logger
+}
+
+func (c ScalingConfig) clock() Clock {
+	if c.Clock == nil {
+		return RealClock
+	}
+	return c.Clock
+}
+
+func (c ScalingConfig) backoffStrategy() BackoffStrategy {
+	if c.BackoffStrategy == nil {
+		return FixedBackoff{}
+	}
+	return c.BackoffStrategy
+}
+
+func (c ScalingConfig) shutdownContext() context.Context {
+	if c.ShutdownContext == nil {
+		return context.Background()
+	}
+	return c.ShutdownContext
+}
+
+// scaleFromZeroResult carries a coalesced scale-from-zero cycle's outcome
+// from the goroutine that ran it back to whichever request is still
+// waiting on it.
+type scaleFromZeroResult struct {
+	err    error
+	shared bool
 }
 
 // MakeScalingHandler creates handler which can scale a function from
@@ -31,9 +182,18 @@ type ScalingConfig struct {
 // amount of attempts / queries then next will not be invoked and a status
 // will be returned to the client.
 func MakeScalingHandler(next http.HandlerFunc, config ScalingConfig) http.HandlerFunc {
-	cache := FunctionCache{
-		Cache:  make(map[string]*FunctionMeta),
-		Expiry: config.CacheExpiry,
+	cache := config.Cache
+	if cache == nil {
+		cache = &FunctionCache{
+			Cache:  make(map[string]*FunctionMeta),
+			Expiry: config.CacheExpiry,
+			Clock:  config.clock(),
+		}
+	}
+
+	coalesce := config.Coalesce
+	if coalesce == nil {
+		coalesce = &SingleflightGroup{}
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -41,18 +201,17 @@ func MakeScalingHandler(next http.HandlerFunc, config ScalingConfig) http.Handle
 		functionName := getServiceName(r.URL.String())
 
 		if serviceQueryResponse, hit := cache.Get(functionName); hit && serviceQueryResponse.AvailableReplicas > 0 {
-			next.ServeHTTP(w, r)
+			serveWithColdStartRetry(next, config, cache, functionName, w, r)
 			return
 		}
 
 		queryResponse, err := config.ServiceQuery.GetReplicas(functionName)
 
 		if err != nil {
-			var errStr string
-			errStr = fmt.Sprintf("error finding function %s: %s", functionName, err.Error())
+			errStr := fmt.Sprintf("error finding function %s: %s", functionName, err.Error())
 
-			log.Printf(errStr)
-			w.WriteHeader(http.StatusNotFound)
+			config.logger().Log("scale_query_failed", r.Header.Get("X-Call-Id"), logging.Fields{"function_name": functionName, "error": err.Error()})
+			w.WriteHeader(statusCodeForScalingError(err))
 			w.Write([]byte(errStr))
 			return
 		}
@@ -60,47 +219,205 @@ func MakeScalingHandler(next http.HandlerFunc, config ScalingConfig) http.Handle
 		cache.Set(functionName, queryResponse)
 
 		if queryResponse.AvailableReplicas == 0 {
+			r.Header.Set("X-Cold-Start", "true")
+
 			minReplicas := uint64(1)
 			if queryResponse.MinReplicas > 0 {
 				minReplicas = queryResponse.MinReplicas
 			}
 
-			log.Printf("[Scale] function=%s 0 => %d requested", functionName, minReplicas)
-			scalingStartTime := time.Now()
+			if config.ExternalScaler != nil {
+				desired, scalerErr := config.ExternalScaler.GetDesiredReplicas(functionName, queryResponse)
+				if scalerErr != nil {
+					config.logger().Log("external_scaler_error", r.Header.Get("X-Call-Id"), logging.Fields{"function_name": functionName, "error": scalerErr.Error()})
+				} else if desired > 0 {
+					minReplicas = desired
+				}
+			}
 
-			err := config.ServiceQuery.SetReplicas(functionName, minReplicas)
-			if err != nil {
-				errStr := fmt.Errorf("unable to scale function [%s], err: %s", functionName, err)
-				log.Printf(errStr.Error())
+			if config.Schedules != nil {
+				if window, active := config.Schedules.ActiveWindow(functionName, config.clock().Now()); active {
+					minReplicas = window.clamp(minReplicas)
+				}
+			}
 
-				w.WriteHeader(http.StatusInternalServerError)
-				w.Write([]byte(errStr.Error()))
-				return
+			callID := r.Header.Get("X-Call-Id")
+			config.logger().Log("scale_from_zero_requested", callID, logging.Fields{"function_name": functionName, "desired_replicas": minReplicas})
+			scalingStartTime := config.clock().Now()
+
+			var scaleSpan *tracing.Span
+			if config.Tracer != nil {
+				traceParent := tracing.FromHeaderOrNew(r.Header.Get(tracing.TraceParentHeader))
+				scaleSpan = config.Tracer.StartSpan("scale_from_zero", traceParent, functionName)
+			}
+
+			// maxPollCount and pollInterval default to the gateway-wide
+			// ScalingConfig values, but a function can override either via
+			// MaxPollCountLabel/PollIntervalLabel - useful for a function
+			// whose container is known to start much slower or faster than
+			// the gateway-wide default assumes.
+			maxPollCount := config.MaxPollCount
+			if queryResponse.MaxPollCount > 0 {
+				maxPollCount = queryResponse.MaxPollCount
 			}
 
-			for i := 0; i < int(config.MaxPollCount); i++ {
-				queryResponse, err := config.ServiceQuery.GetReplicas(functionName)
-				cache.Set(functionName, queryResponse)
+			pollInterval := config.FunctionPollInterval
+			if queryResponse.PollInterval > 0 {
+				pollInterval = queryResponse.PollInterval
+			}
 
-				if err != nil {
-					errStr := fmt.Sprintf("error: %s", err.Error())
-					log.Printf(errStr)
+			done := make(chan scaleFromZeroResult, 1)
+			go func() {
+				_, err, shared := coalesce.Do(functionName, func() (interface{}, error) {
+					if err := config.ServiceQuery.SetReplicas(functionName, minReplicas); err != nil {
+						config.recordColdStart(functionName, "error", config.clock().Now().Sub(scalingStartTime))
+						return nil, fmt.Errorf("unable to scale function [%s]: %w", functionName, ErrProviderUnavailable)
+					}
 
-					w.WriteHeader(http.StatusInternalServerError)
-					w.Write([]byte(errStr))
-					return
+					for i := 0; i < int(maxPollCount); i++ {
+						queryResponse, err := config.ServiceQuery.GetReplicas(functionName)
+						if err != nil {
+							config.recordColdStart(functionName, "error", config.clock().Now().Sub(scalingStartTime))
+							return nil, err
+						}
+
+						cache.Set(functionName, queryResponse)
+
+						if queryResponse.AvailableReplicas > 0 {
+							scalingDuration := config.clock().Now().Sub(scalingStartTime)
+							config.logger().Log("scale_from_zero_succeeded", callID, logging.Fields{"function_name": functionName, "available_replicas": queryResponse.AvailableReplicas, "duration_seconds": scalingDuration.Seconds()})
+							config.recordColdStart(functionName, "ready", scalingDuration)
+
+							if config.Prewarmer != nil {
+								config.Prewarmer.Prewarm(r)
+							}
+
+							return nil, nil
+						}
+
+						config.clock().Sleep(config.backoffStrategy().NextDelay(i, pollInterval))
+					}
+
+					config.recordColdStart(functionName, "timeout", config.clock().Now().Sub(scalingStartTime))
+					return nil, fmt.Errorf("function %s: %w", functionName, ErrScaleTimeout)
+				})
+				done <- scaleFromZeroResult{err: err, shared: shared}
+			}()
+
+			// The SetReplicas/poll cycle above runs in its own goroutine,
+			// shared via coalesce across every request waiting on
+			// functionName, so that this request giving up doesn't cut the
+			// cycle short for any of the others still waiting on it. This
+			// request itself stops waiting as soon as its own context or
+			// the gateway's shutdown context is done, instead of holding
+			// the response open - and the provider calls - until
+			// MaxPollCount is exhausted for a client that's already gone.
+			select {
+			case result := <-done:
+				if scaleSpan != nil {
+					scaleSpan.End(config.Tracer.Exporter)
 				}
 
-				if queryResponse.AvailableReplicas > 0 {
-					scalingDuration := time.Since(scalingStartTime)
-					log.Printf("[Scale] function=%s 0 => %d successful - %f seconds", functionName, queryResponse.AvailableReplicas, scalingDuration.Seconds())
-					break
+				if result.shared {
+					config.logger().Log("scale_from_zero_shared", callID, logging.Fields{"function_name": functionName})
 				}
 
-				time.Sleep(config.FunctionPollInterval)
+				if result.err != nil {
+					config.logger().Log("scale_from_zero_failed", callID, logging.Fields{"function_name": functionName, "error": result.err.Error()})
+
+					w.WriteHeader(statusCodeForScalingError(result.err))
+					w.Write([]byte(result.err.Error()))
+					return
+				}
+			case <-r.Context().Done():
+				config.logger().Log("scale_from_zero_client_disconnected", callID, logging.Fields{"function_name": functionName})
+				return
+			case <-config.shutdownContext().Done():
+				config.logger().Log("scale_from_zero_gateway_shutdown", callID, logging.Fields{"function_name": functionName})
+				return
 			}
 		}
 
-		next.ServeHTTP(w, r)
+		serveWithColdStartRetry(next, config, cache, functionName, w, r)
 	}
 }
+
+// coldStartStatusCodes are the status codes forwardRequest writes when it
+// fails to reach a function at all (see classifyUpstreamError) - the
+// signature of the replica a request was scaled/cached for having
+// terminated in the window between that check and the request actually
+// reaching it.
+var coldStartStatusCodes = map[int]bool{
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// coldStartRetryBufferCap bounds how much of a response
+// serveWithColdStartRetry will hold back while deciding whether to
+// retry. forwardRequest's own failure responses are small, single Write
+// JSON bodies, so this is generous headroom rather than a tight limit.
+const coldStartRetryBufferCap = 8 * 1024
+
+// serveWithColdStartRetry calls next and, if it fails with a status code
+// that looks like the target replica had just terminated, re-scales the
+// function from zero and retries once before giving up. The response is
+// only held back from the real client while it looks like a small,
+// single-shot error body; anything larger, or any other status, streams
+// straight through exactly as before, so the common case pays no
+// buffering cost.
+func serveWithColdStartRetry(next http.HandlerFunc, config ScalingConfig, cache *FunctionCache, functionName string, w http.ResponseWriter, r *http.Request) {
+	var bodyBytes []byte
+	if r.Body != nil {
+		bodyBytes, _ = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(bodyBytes))
+	}
+
+	interceptor := newRetryableStatusWriter(w, coldStartRetryBufferCap, func(statusCode int) bool {
+		return coldStartStatusCodes[statusCode]
+	})
+	next.ServeHTTP(interceptor, r)
+
+	if !interceptor.buffering {
+		return
+	}
+
+	queryResponse, err := config.ServiceQuery.GetReplicas(functionName)
+	if err != nil || queryResponse.AvailableReplicas > 0 {
+		interceptor.flush()
+		return
+	}
+
+	minReplicas := uint64(1)
+	if queryResponse.MinReplicas > 0 {
+		minReplicas = queryResponse.MinReplicas
+	}
+
+	config.logger().Log("cold_start_race_detected", r.Header.Get("X-Call-Id"), logging.Fields{"function_name": functionName, "status_code": interceptor.statusCode})
+
+	if err := config.ServiceQuery.SetReplicas(functionName, minReplicas); err != nil {
+		interceptor.flush()
+		return
+	}
+
+	for i := 0; i < int(config.MaxPollCount); i++ {
+		queryResponse, err := config.ServiceQuery.GetReplicas(functionName)
+		if err == nil {
+			cache.Set(functionName, queryResponse)
+			if queryResponse.AvailableReplicas > 0 {
+				break
+			}
+		}
+
+		config.clock().Sleep(config.FunctionPollInterval)
+	}
+
+	interceptor.discard()
+	if bodyBytes != nil {
+		r.Body = ioutil.NopCloser(bytes.NewReader(bodyBytes))
+	}
+
+	next.ServeHTTP(interceptor, r)
+	interceptor.flush()
+}
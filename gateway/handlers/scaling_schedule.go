@@ -0,0 +1,96 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// ScalingWindow describes a recurring time window (e.g. business hours) during
+// which a function should have a minimum and maximum replica floor/ceiling.
+type ScalingWindow struct {
+	// DaysOfWeek this window applies to. An empty slice matches every day.
+	DaysOfWeek []time.Weekday
+
+	// StartHour and EndHour bound the window using a 24-hour clock in the
+	// gateway's local time. EndHour is exclusive.
+	StartHour int
+	EndHour   int
+
+	// MinReplicas is the floor applied to reactive scaling decisions while
+	// this window is active.
+	MinReplicas uint64
+
+	// MaxReplicas is the ceiling applied to reactive scaling decisions while
+	// this window is active. Zero means no ceiling.
+	MaxReplicas uint64
+}
+
+// matches reports whether now falls within the window.
+func (s ScalingWindow) matches(now time.Time) bool {
+	if len(s.DaysOfWeek) > 0 {
+		dayMatches := false
+		for _, day := range s.DaysOfWeek {
+			if day == now.Weekday() {
+				dayMatches = true
+				break
+			}
+		}
+		if !dayMatches {
+			return false
+		}
+	}
+
+	hour := now.Hour()
+	return hour >= s.StartHour && hour < s.EndHour
+}
+
+// ScalingScheduleStore holds per-function scaling schedules and evaluates
+// which window, if any, is currently active for a function.
+type ScalingScheduleStore struct {
+	sync.RWMutex
+	schedules map[string][]ScalingWindow
+}
+
+// NewScalingScheduleStore creates an empty ScalingScheduleStore.
+func NewScalingScheduleStore() *ScalingScheduleStore {
+	return &ScalingScheduleStore{
+		schedules: make(map[string][]ScalingWindow),
+	}
+}
+
+// Set replaces the scaling windows configured for functionName.
+func (s *ScalingScheduleStore) Set(functionName string, windows []ScalingWindow) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.schedules[functionName] = windows
+}
+
+// ActiveWindow returns the first window matching now for functionName, if any.
+func (s *ScalingScheduleStore) ActiveWindow(functionName string, now time.Time) (ScalingWindow, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	for _, window := range s.schedules[functionName] {
+		if window.matches(now) {
+			return window, true
+		}
+	}
+
+	return ScalingWindow{}, false
+}
+
+// clamp applies the window's MinReplicas floor and MaxReplicas ceiling to a
+// reactively-computed replica count.
+func (s ScalingWindow) clamp(replicas uint64) uint64 {
+	if replicas < s.MinReplicas {
+		replicas = s.MinReplicas
+	}
+	if s.MaxReplicas > 0 && replicas > s.MaxReplicas {
+		replicas = s.MaxReplicas
+	}
+	return replicas
+}
@@ -0,0 +1,48 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ActiveWindow_MatchesHourAndDay(t *testing.T) {
+	store := NewScalingScheduleStore()
+	store.Set("echo", []ScalingWindow{
+		{
+			DaysOfWeek:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			StartHour:   9,
+			EndHour:     17,
+			MinReplicas: 3,
+			MaxReplicas: 10,
+		},
+	})
+
+	businessHours := time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC) // a Monday
+	if _, active := store.ActiveWindow("echo", businessHours); !active {
+		t.Errorf("expected window to be active during business hours")
+	}
+
+	weekend := time.Date(2020, time.January, 4, 10, 0, 0, 0, time.UTC) // a Saturday
+	if _, active := store.ActiveWindow("echo", weekend); active {
+		t.Errorf("expected window not to be active at the weekend")
+	}
+}
+
+func Test_ScalingWindow_Clamp(t *testing.T) {
+	window := ScalingWindow{MinReplicas: 3, MaxReplicas: 10}
+
+	if got := window.clamp(0); got != 3 {
+		t.Errorf("want floor of 3, got %d", got)
+	}
+
+	if got := window.clamp(20); got != 10 {
+		t.Errorf("want ceiling of 10, got %d", got)
+	}
+
+	if got := window.clamp(5); got != 5 {
+		t.Errorf("want unchanged value of 5, got %d", got)
+	}
+}
@@ -0,0 +1,587 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/metrics"
+	"github.com/openfaas/faas/gateway/types"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_MakeScalingHandler_RetriesOnColdStartRace(t *testing.T) {
+	query := &fakeServiceQuery{response: ServiceQueryResponse{MinReplicas: 1}}
+	cache := &FunctionCache{Cache: make(map[string]*FunctionMeta), Expiry: time.Minute}
+	cache.Set("figlet", ServiceQueryResponse{AvailableReplicas: 1})
+
+	attempts := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"code":"FUNCTION_UNREACHABLE"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("pong"))
+	}
+
+	handler := MakeScalingHandler(next, ScalingConfig{
+		ServiceQuery:         query,
+		Cache:                cache,
+		MaxPollCount:         1,
+		FunctionPollInterval: time.Millisecond,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if attempts != 2 {
+		t.Fatalf("want next to be called twice (original + retry), got %d", attempts)
+	}
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want the client to see the retried 200, got %d", rr.Code)
+	}
+	if rr.Body.String() != "pong" {
+		t.Errorf("want the retried body forwarded to the client, got %q", rr.Body.String())
+	}
+	if !query.setCalled {
+		t.Error("want the function to be re-scaled before the retry")
+	}
+}
+
+func Test_MakeScalingHandler_PassesThroughNonColdStartStatus(t *testing.T) {
+	query := &fakeServiceQuery{response: ServiceQueryResponse{MinReplicas: 1}}
+	cache := &FunctionCache{Cache: make(map[string]*FunctionMeta), Expiry: time.Minute}
+	cache.Set("figlet", ServiceQueryResponse{AvailableReplicas: 1})
+
+	attempts := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}
+
+	handler := MakeScalingHandler(next, ScalingConfig{
+		ServiceQuery:         query,
+		Cache:                cache,
+		MaxPollCount:         1,
+		FunctionPollInterval: time.Millisecond,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if attempts != 1 {
+		t.Fatalf("want no retry for a non-cold-start status, got %d attempts", attempts)
+	}
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("want the original 404 forwarded, got %d", rr.Code)
+	}
+	if query.setCalled {
+		t.Error("want no re-scale attempt for a non-cold-start status")
+	}
+}
+
+func Test_MakeScalingHandler_MapsGetReplicasErrorsToStatusCodesViaErrorsIs(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"not found", fmt.Errorf("figlet: %w", ErrFunctionNotFound), http.StatusNotFound},
+		{"provider unavailable", fmt.Errorf("dial failed: %w", ErrProviderUnavailable), http.StatusBadGateway},
+		{"unrecognised", fmt.Errorf("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			query := &fakeServiceQuery{getErr: tc.err}
+			handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+				t.Fatal("want next not to be called when GetReplicas fails")
+			}, ScalingConfig{ServiceQuery: query, CacheExpiry: time.Minute})
+
+			req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("want status %d, got %d", tc.wantStatus, rr.Code)
+			}
+		})
+	}
+}
+
+type neverScalesServiceQuery struct{}
+
+func (neverScalesServiceQuery) GetReplicas(service string) (ServiceQueryResponse, error) {
+	return ServiceQueryResponse{MinReplicas: 1, AvailableReplicas: 0}, nil
+}
+
+func (neverScalesServiceQuery) SetReplicas(service string, count uint64) error {
+	return nil
+}
+
+func Test_MakeScalingHandler_ReturnsScaleTimeoutWhenReplicasNeverBecomeAvailable(t *testing.T) {
+	query := neverScalesServiceQuery{}
+
+	handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("want next not to be called when the function never scales up")
+	}, ScalingConfig{
+		ServiceQuery:         query,
+		CacheExpiry:          time.Minute,
+		MaxPollCount:         2,
+		FunctionPollInterval: time.Millisecond,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("want a 503 for ErrScaleTimeout, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), ErrScaleTimeout.Error()) {
+		t.Errorf("want the response body to mention the scale timeout, got %q", rr.Body.String())
+	}
+}
+
+func Test_MakeScalingHandler_StopsWaitingWhenRequestContextIsCancelled(t *testing.T) {
+	query := neverScalesServiceQuery{}
+
+	handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("want next not to be called once the client has already gone")
+	}, ScalingConfig{
+		ServiceQuery:         query,
+		CacheExpiry:          time.Minute,
+		MaxPollCount:         1000,
+		FunctionPollInterval: time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		handler(rr, req)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("want the handler to return promptly once the client's context is done")
+	}
+
+	if rr.Body.Len() != 0 {
+		t.Errorf("want no response body written for an already-cancelled client, got %q", rr.Body.String())
+	}
+}
+
+func Test_MakeScalingHandler_StopsWaitingWhenShutdownContextIsCancelled(t *testing.T) {
+	query := neverScalesServiceQuery{}
+
+	shutdownCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("want next not to be called once the gateway is shutting down")
+	}, ScalingConfig{
+		ServiceQuery:         query,
+		CacheExpiry:          time.Minute,
+		MaxPollCount:         1000,
+		FunctionPollInterval: time.Millisecond,
+		ShutdownContext:      shutdownCtx,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		handler(rr, req)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("want the handler to return promptly once the shutdown context is done")
+	}
+}
+
+// neverScalesWithPollOverride is neverScalesServiceQuery, but its
+// GetReplicas response carries per-function poll overrides, so a test can
+// assert they take effect over the gateway-wide ScalingConfig values.
+type neverScalesWithPollOverride struct {
+	maxPollCount uint
+	pollInterval time.Duration
+}
+
+func (q neverScalesWithPollOverride) GetReplicas(service string) (ServiceQueryResponse, error) {
+	return ServiceQueryResponse{
+		MinReplicas:       1,
+		AvailableReplicas: 0,
+		MaxPollCount:      q.maxPollCount,
+		PollInterval:      q.pollInterval,
+	}, nil
+}
+
+func (q neverScalesWithPollOverride) SetReplicas(service string, count uint64) error {
+	return nil
+}
+
+func Test_MakeScalingHandler_PerFunctionPollOverrideTakesPrecedence(t *testing.T) {
+	query := neverScalesWithPollOverride{maxPollCount: 1, pollInterval: time.Millisecond}
+
+	handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("want next not to be called when the function never scales up")
+	}, ScalingConfig{
+		ServiceQuery: query,
+		CacheExpiry:  time.Minute,
+		// Deliberately large gateway-wide values - if the per-function
+		// override in the GetReplicas response weren't honoured, this
+		// test would hang rather than fail fast.
+		MaxPollCount:         1000,
+		FunctionPollInterval: time.Second,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		handler(rr, req)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("want the per-function MaxPollCount/PollInterval override to make this give up quickly")
+	}
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("want a 503 for ErrScaleTimeout, got %d", rr.Code)
+	}
+}
+
+// delayedScaleServiceQuery reports no available replicas for the first
+// pollsBeforeReady GetReplicas calls after SetReplicas is called, so tests
+// can exercise the scaling handler's poll loop for a known number of
+// iterations rather than resolving on the very first poll.
+type delayedScaleServiceQuery struct {
+	pollsBeforeReady int
+	polls            int
+}
+
+func (q *delayedScaleServiceQuery) GetReplicas(service string) (ServiceQueryResponse, error) {
+	q.polls++
+	if q.polls > q.pollsBeforeReady {
+		return ServiceQueryResponse{MinReplicas: 1, AvailableReplicas: 1}, nil
+	}
+	return ServiceQueryResponse{MinReplicas: 1, AvailableReplicas: 0}, nil
+}
+
+func (q *delayedScaleServiceQuery) SetReplicas(service string, count uint64) error {
+	return nil
+}
+
+func Test_MakeScalingHandler_PrewarmsConnectionAfterSuccessfulScaleFromZero(t *testing.T) {
+	var prewarmRequests int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&prewarmRequests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+	proxy := types.NewHTTPClientReverseProxy(upstreamURL, time.Second, nil)
+
+	query := &delayedScaleServiceQuery{pollsBeforeReady: 1}
+
+	handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, ScalingConfig{
+		ServiceQuery:         query,
+		CacheExpiry:          time.Minute,
+		MaxPollCount:         3,
+		FunctionPollInterval: time.Millisecond,
+		Prewarmer: &ConnectionPrewarmer{
+			Proxy:    proxy,
+			Resolver: SingleHostBaseURLResolver{BaseURL: upstream.URL},
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200 once the function is scaled up, got %d", rr.Code)
+	}
+	if atomic.LoadInt32(&prewarmRequests) != 1 {
+		t.Fatalf("want exactly one prewarm request against the resolved upstream, got %d", prewarmRequests)
+	}
+}
+
+func Test_MakeScalingHandler_UsesInjectedClockForPolling(t *testing.T) {
+	query := &delayedScaleServiceQuery{pollsBeforeReady: 2}
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, ScalingConfig{
+		ServiceQuery: query,
+		CacheExpiry:  time.Minute,
+		// A poll interval an actual test run could never survive without
+		// Sleep being faked out - proving polling is driven by the
+		// injected Clock rather than the real one.
+		MaxPollCount:         3,
+		FunctionPollInterval: time.Hour,
+		Clock:                clock,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200 once the fake service query reports scaled, got %d", rr.Code)
+	}
+	if len(clock.slept) == 0 {
+		t.Fatal("want the scaling handler to have slept via the injected clock at least once")
+	}
+	for _, d := range clock.slept {
+		if d != time.Hour {
+			t.Errorf("want every sleep to use FunctionPollInterval (1h), got %s", d)
+		}
+	}
+}
+
+func Test_MakeScalingHandler_UsesConfiguredBackoffStrategy(t *testing.T) {
+	query := &delayedScaleServiceQuery{pollsBeforeReady: 3}
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, ScalingConfig{
+		ServiceQuery:         query,
+		CacheExpiry:          time.Minute,
+		MaxPollCount:         3,
+		FunctionPollInterval: time.Second,
+		Clock:                clock,
+		BackoffStrategy:      LinearBackoff{},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+	if len(clock.slept) != 2 {
+		t.Fatalf("want 2 polls before ready, got %d sleeps: %v", len(clock.slept), clock.slept)
+	}
+	if clock.slept[0] != time.Second || clock.slept[1] != 2*time.Second {
+		t.Fatalf("want linearly growing delays [1s, 2s], got %v", clock.slept)
+	}
+}
+
+func Test_MakeScalingHandler_StreamsLargeResponsesThroughImmediately(t *testing.T) {
+	query := &fakeServiceQuery{response: ServiceQueryResponse{MinReplicas: 1}}
+	cache := &FunctionCache{Cache: make(map[string]*FunctionMeta), Expiry: time.Minute}
+	cache.Set("figlet", ServiceQueryResponse{AvailableReplicas: 1})
+
+	large := strings.Repeat("x", coldStartRetryBufferCap*2)
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(large))
+	}
+
+	handler := MakeScalingHandler(next, ScalingConfig{
+		ServiceQuery:         query,
+		Cache:                cache,
+		MaxPollCount:         1,
+		FunctionPollInterval: time.Millisecond,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusBadGateway {
+		t.Fatalf("want the original 502 forwarded once the body outgrows the buffer cap, got %d", rr.Code)
+	}
+	if rr.Body.String() != large {
+		t.Error("want the full body forwarded once interception is disabled")
+	}
+	if query.setCalled {
+		t.Error("want no re-scale attempt once the response was already streamed through")
+	}
+}
+
+// concurrentScaleServiceQuery holds every pre-scale-up GetReplicas call at
+// a barrier until arrived has received one send per caller, so a test can
+// be sure every concurrent request actually observed the function as cold
+// before any of them is allowed to proceed into MakeScalingHandler's
+// scale-from-zero path.
+type concurrentScaleServiceQuery struct {
+	lock     sync.Mutex
+	setCalls int
+	scaled   bool
+	arrived  chan struct{}
+	release  chan struct{}
+}
+
+func (q *concurrentScaleServiceQuery) GetReplicas(service string) (ServiceQueryResponse, error) {
+	q.lock.Lock()
+	scaled := q.scaled
+	q.lock.Unlock()
+
+	if scaled {
+		return ServiceQueryResponse{MinReplicas: 1, AvailableReplicas: 1}, nil
+	}
+
+	q.arrived <- struct{}{}
+	<-q.release
+	return ServiceQueryResponse{MinReplicas: 1, AvailableReplicas: 0}, nil
+}
+
+func (q *concurrentScaleServiceQuery) SetReplicas(service string, count uint64) error {
+	q.lock.Lock()
+	q.setCalls++
+	q.lock.Unlock()
+
+	// Give every other caller blocked on the arrived/release barrier time
+	// to reach SingleflightGroup.Do and queue up behind this call before
+	// it completes, so the test can rely on them sharing this call's
+	// result rather than each racing to become their own leader.
+	time.Sleep(50 * time.Millisecond)
+
+	q.lock.Lock()
+	q.scaled = true
+	q.lock.Unlock()
+	return nil
+}
+
+func Test_MakeScalingHandler_CoalescesConcurrentScaleFromZeroRequests(t *testing.T) {
+	const callers = 10
+	query := &concurrentScaleServiceQuery{
+		arrived: make(chan struct{}, callers),
+		release: make(chan struct{}),
+	}
+
+	handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, ScalingConfig{
+		ServiceQuery:         query,
+		CacheExpiry:          time.Minute,
+		MaxPollCount:         3,
+		FunctionPollInterval: time.Millisecond,
+	})
+
+	codes := make(chan int, callers)
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+			codes <- rr.Code
+		}()
+	}
+
+	for i := 0; i < callers; i++ {
+		<-query.arrived
+	}
+	close(query.release)
+
+	wg.Wait()
+	close(codes)
+
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("want every caller to see 200 once scaling completes, got %d", code)
+		}
+	}
+
+	if query.setCalls != 1 {
+		t.Errorf("want SetReplicas called once across all concurrent requests, got %d", query.setCalls)
+	}
+}
+
+func Test_MakeScalingHandler_RecordsColdStartDurationOnSuccess(t *testing.T) {
+	query := &delayedScaleServiceQuery{pollsBeforeReady: 1}
+	metricsOptions := metrics.BuildMetricsOptions()
+
+	handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, ScalingConfig{
+		ServiceQuery:         query,
+		CacheExpiry:          time.Minute,
+		MaxPollCount:         3,
+		FunctionPollInterval: time.Millisecond,
+		Metrics:              &metricsOptions,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	m := &dto.Metric{}
+	metricsOptions.ColdStartDurationHistogram.WithLabelValues("figlet", "ready").(interface {
+		Write(*dto.Metric) error
+	}).Write(m)
+
+	if got := m.GetHistogram().GetSampleCount(); got != 1 {
+		t.Fatalf("want one ready observation recorded, got %d", got)
+	}
+}
+
+func Test_MakeScalingHandler_RecordsColdStartDurationOnTimeout(t *testing.T) {
+	query := neverScalesServiceQuery{}
+	metricsOptions := metrics.BuildMetricsOptions()
+
+	handler := MakeScalingHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("want next not to be called when the function never scales up")
+	}, ScalingConfig{
+		ServiceQuery:         query,
+		CacheExpiry:          time.Minute,
+		MaxPollCount:         2,
+		FunctionPollInterval: time.Millisecond,
+		Metrics:              &metricsOptions,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/function/figlet", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	m := &dto.Metric{}
+	metricsOptions.ColdStartDurationHistogram.WithLabelValues("figlet", "timeout").(interface {
+		Write(*dto.Metric) error
+	}).Write(m)
+
+	if got := m.GetHistogram().GetSampleCount(); got != 1 {
+		t.Fatalf("want one timeout observation recorded, got %d", got)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerTimingRecorder accumulates named phase durations for a single
+// request, for rendering as a Server-Timing response header (see
+// MakeServerTimingHandler). Each recorded duration covers the time spent
+// in that phase's handler and everything nested inside it, so phases are
+// expected to overlap rather than sum to the "total" entry - the same way
+// nested spans work in the tracing package.
+type ServerTimingRecorder struct {
+	mutex  sync.Mutex
+	phases []serverTimingPhase
+}
+
+type serverTimingPhase struct {
+	name     string
+	duration time.Duration
+}
+
+// NewServerTimingRecorder creates an empty ServerTimingRecorder.
+func NewServerTimingRecorder() *ServerTimingRecorder {
+	return &ServerTimingRecorder{}
+}
+
+// Record appends a phase's duration. Safe for concurrent use, though in
+// practice phases are recorded sequentially as a request unwinds back up
+// through the handlers that wrap it.
+func (rec *ServerTimingRecorder) Record(name string, duration time.Duration) {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	rec.phases = append(rec.phases, serverTimingPhase{name: name, duration: duration})
+}
+
+// Header renders the recorded phases as a Server-Timing header value, e.g.
+// "auth;dur=1.2, scale;dur=420.8, total;dur=512.3".
+func (rec *ServerTimingRecorder) Header() string {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
+	entries := make([]string, 0, len(rec.phases))
+	for _, phase := range rec.phases {
+		entries = append(entries, fmt.Sprintf("%s;dur=%.1f", phase.name, float64(phase.duration)/float64(time.Millisecond)))
+	}
+	return strings.Join(entries, ", ")
+}
+
+type serverTimingContextKey struct{}
+
+func withServerTimingRecorder(ctx context.Context, rec *ServerTimingRecorder) context.Context {
+	return context.WithValue(ctx, serverTimingContextKey{}, rec)
+}
+
+// serverTimingRecorderFromContext returns the ServerTimingRecorder attached
+// to ctx by MakeServerTimingHandler, if Server-Timing is enabled for this
+// request. Phase-reporting handlers use this to become a no-op, with no
+// overhead beyond the context lookup, when it's disabled.
+func serverTimingRecorderFromContext(ctx context.Context) (*ServerTimingRecorder, bool) {
+	rec, ok := ctx.Value(serverTimingContextKey{}).(*ServerTimingRecorder)
+	return rec, ok
+}
+
+// MakeTimedHandler wraps next, recording how long it - and everything
+// nested inside it - took against phase on the request's
+// ServerTimingRecorder, if one is present. A request with Server-Timing
+// disabled carries no recorder, so this degrades to calling next directly.
+func MakeTimedHandler(next http.HandlerFunc, phase string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder, ok := serverTimingRecorderFromContext(r.Context())
+		if !ok {
+			next(w, r)
+			return
+		}
+
+		start := time.Now()
+		next(w, r)
+		recorder.Record(phase, time.Since(start))
+	}
+}
+
+// MakeServerTimingHandler wraps next with a ServerTimingRecorder attached
+// to the request's context, and adds the recorded phases - plus an
+// overall "total" - as a Server-Timing response header once next returns.
+// It buffers the response with httptest.NewRecorder so the header can
+// still be added after next has already written to it, the same approach
+// MakeResponseCompressionHandler and MakeResponseCacheHandler use.
+// enabled gates the whole feature off with no overhead when false,
+// mirroring config.ResponseCompression.
+func MakeServerTimingHandler(next http.HandlerFunc, enabled bool) http.HandlerFunc {
+	if !enabled {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		recorder := NewServerTimingRecorder()
+		r = r.WithContext(withServerTimingRecorder(r.Context(), recorder))
+
+		start := time.Now()
+		result := httptest.NewRecorder()
+		next(result, r)
+		recorder.Record("total", time.Since(start))
+
+		copyHeaders(w.Header(), &result.HeaderMap)
+		w.Header().Set("Server-Timing", recorder.Header())
+		w.WriteHeader(result.Code)
+		w.Write(result.Body.Bytes())
+	}
+}
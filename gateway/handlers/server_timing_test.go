@@ -0,0 +1,72 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_MakeServerTimingHandler_AddsHeaderWithPhasesAndTotal(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		MakeTimedHandler(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		}, "scale")(w, r)
+	}
+	handler := MakeServerTimingHandler(next, true)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	header := rec.Header().Get("Server-Timing")
+	if !strings.Contains(header, "scale;dur=") {
+		t.Fatalf("want a scale entry in the Server-Timing header, got %q", header)
+	}
+	if !strings.Contains(header, "total;dur=") {
+		t.Fatalf("want a total entry in the Server-Timing header, got %q", header)
+	}
+}
+
+func Test_MakeServerTimingHandler_DisabledIsNoop(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	handler := MakeServerTimingHandler(next, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Header().Get("Server-Timing") != "" {
+		t.Fatal("want no Server-Timing header when the feature is disabled")
+	}
+}
+
+func Test_MakeTimedHandler_NoopWithoutRecorderInContext(t *testing.T) {
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeTimedHandler(next, "auth")
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("want next to be called even with no ServerTimingRecorder in context")
+	}
+}
+
+func Test_ServerTimingRecorder_HeaderFormatsEachPhase(t *testing.T) {
+	rec := NewServerTimingRecorder()
+	rec.Record("auth", 2*time.Millisecond)
+	rec.Record("total", 12*time.Millisecond)
+
+	header := rec.Header()
+	if header != "auth;dur=2.0, total;dur=12.0" {
+		t.Fatalf("want a comma-separated Server-Timing value, got %q", header)
+	}
+}
@@ -3,6 +3,8 @@
 
 package handlers
 
+import "time"
+
 // ServiceQuery provides interface for replica querying/setting
 type ServiceQuery interface {
 	GetReplicas(service string) (response ServiceQueryResponse, err error)
@@ -16,4 +18,12 @@ type ServiceQueryResponse struct {
 	MinReplicas       uint64
 	ScalingFactor     uint64
 	AvailableReplicas uint64
+
+	// PollInterval, when non-zero, overrides ScalingConfig.FunctionPollInterval
+	// for this function only, sourced from PollIntervalLabel.
+	PollInterval time.Duration
+
+	// MaxPollCount, when non-zero, overrides ScalingConfig.MaxPollCount
+	// for this function only, sourced from MaxPollCountLabel.
+	MaxPollCount uint
 }
@@ -0,0 +1,146 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// LoadDebugSamples reads the newline-delimited JSON DebugSample archive
+// written by FileDebugSink at path, in the order they were recorded.
+func LoadDebugSamples(path string) ([]DebugSample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var samples []DebugSample
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var sample DebugSample
+		if err := json.Unmarshal(line, &sample); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, scanner.Err()
+}
+
+// ReplayResult summarizes a shadow replay run.
+type ReplayResult struct {
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
+// ReplaySamples re-issues samples, in order, against targetBaseURL - e.g. a
+// shadow/staging environment's gateway - preserving the inter-arrival gaps
+// they were originally recorded with, scaled by speed (2 replays twice as
+// fast as the recording; 0 or negative is treated as realtime). Any
+// redaction applied when the samples were written (see SamplingConfig.Redact)
+// is already baked into RequestHeaders/RequestBody, so nothing further is
+// stripped here - a ReplaySamples caller gets exactly what's in the
+// archive. clock, when nil, defaults to RealClock.
+func ReplaySamples(samples []DebugSample, targetBaseURL string, speed float64, client *http.Client, clock Clock) ReplayResult {
+	if speed <= 0 {
+		speed = 1
+	}
+	if clock == nil {
+		clock = RealClock
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var result ReplayResult
+	var previousTimestamp time.Time
+
+	for i, sample := range samples {
+		if i > 0 {
+			if gap := sample.Timestamp.Sub(previousTimestamp); gap > 0 {
+				clock.Sleep(time.Duration(float64(gap) / speed))
+			}
+		}
+		previousTimestamp = sample.Timestamp
+
+		url := strings.TrimRight(targetBaseURL, "/") + sample.URL
+		req, err := http.NewRequest(sample.Method, url, strings.NewReader(sample.RequestBody))
+		if err != nil {
+			result.Failed++
+			continue
+		}
+		req.Header = sample.RequestHeaders.Clone()
+
+		resp, err := client.Do(req)
+		if err != nil {
+			result.Failed++
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			result.Failed++
+			continue
+		}
+		result.Replayed++
+	}
+
+	return result
+}
+
+// ReplayRequest is the /system/shadow/replay request body.
+type ReplayRequest struct {
+	// ArchivePath is the DebugSample archive written by FileDebugSink to
+	// replay from.
+	ArchivePath string `json:"archivePath"`
+
+	// TargetBaseURL is the shadow environment's gateway to replay
+	// against, e.g. "http://gateway.staging:8080".
+	TargetBaseURL string `json:"targetBaseUrl"`
+
+	// Speed scales playback relative to how the traffic was originally
+	// recorded. Defaults to 1 (realtime) when zero or negative.
+	Speed float64 `json:"speed"`
+}
+
+// MakeShadowReplayHandler decodes a ReplayRequest and runs ReplaySamples
+// against it in the background, responding 202 Accepted immediately - a
+// replay run can take as long as the recording it's replaying, scaled by
+// Speed, so this does not block on it.
+func MakeShadowReplayHandler(client *http.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request ReplayRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "unable to decode replay request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if request.ArchivePath == "" || request.TargetBaseURL == "" {
+			http.Error(w, "archivePath and targetBaseUrl are required", http.StatusBadRequest)
+			return
+		}
+
+		samples, err := LoadDebugSamples(request.ArchivePath)
+		if err != nil {
+			http.Error(w, "unable to load archive: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		go ReplaySamples(samples, request.TargetBaseURL, request.Speed, client, RealClock)
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
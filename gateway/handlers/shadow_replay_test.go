@@ -0,0 +1,113 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeArchive(t *testing.T, samples []DebugSample) string {
+	t.Helper()
+
+	file, err := ioutil.TempFile("", "shadow-replay-*.jsonl")
+	if err != nil {
+		t.Fatalf("unable to create temp file: %s", err.Error())
+	}
+	defer file.Close()
+
+	sink := &FileDebugSink{Path: file.Name()}
+	for _, sample := range samples {
+		if err := sink.Write(sample); err != nil {
+			t.Fatalf("unable to write sample: %s", err.Error())
+		}
+	}
+
+	t.Cleanup(func() { os.Remove(file.Name()) })
+	return file.Name()
+}
+
+func Test_LoadDebugSamples_RoundTripsArchive(t *testing.T) {
+	path := writeArchive(t, []DebugSample{
+		{Method: http.MethodGet, URL: "/function/foo", RequestBody: "a"},
+		{Method: http.MethodPost, URL: "/function/bar", RequestBody: "b"},
+	})
+
+	samples, err := LoadDebugSamples(path)
+	if err != nil {
+		t.Fatalf("unable to load archive: %s", err.Error())
+	}
+	if len(samples) != 2 || samples[0].URL != "/function/foo" || samples[1].URL != "/function/bar" {
+		t.Fatalf("want both samples back in order, got %+v", samples)
+	}
+}
+
+func Test_ReplaySamples_ReissuesEachSampleAgainstTarget(t *testing.T) {
+	var gotPaths []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPaths = append(gotPaths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	base := time.Unix(0, 0)
+	samples := []DebugSample{
+		{Method: http.MethodGet, URL: "/function/foo", Timestamp: base},
+		{Method: http.MethodGet, URL: "/function/bar", Timestamp: base.Add(time.Millisecond)},
+	}
+
+	result := ReplaySamples(samples, target.URL, 1000, target.Client(), nil)
+
+	if result.Replayed != 2 || result.Failed != 0 {
+		t.Fatalf("want both samples replayed successfully, got %+v", result)
+	}
+	if len(gotPaths) != 2 || gotPaths[0] != "/function/foo" || gotPaths[1] != "/function/bar" {
+		t.Fatalf("want both paths replayed in order, got %v", gotPaths)
+	}
+}
+
+func Test_ReplaySamples_CountsUpstreamFailures(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer target.Close()
+
+	samples := []DebugSample{{Method: http.MethodGet, URL: "/function/foo"}}
+	result := ReplaySamples(samples, target.URL, 1, target.Client(), nil)
+
+	if result.Failed != 1 || result.Replayed != 0 {
+		t.Fatalf("want the 500 counted as a failure, got %+v", result)
+	}
+}
+
+func Test_MakeShadowReplayHandler_RejectsIncompleteRequest(t *testing.T) {
+	handler := MakeShadowReplayHandler(http.DefaultClient)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/shadow/replay", strings.NewReader(`{"archivePath":"x"}`))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("want 400 for a request missing targetBaseUrl, got %d", rr.Code)
+	}
+}
+
+func Test_MakeShadowReplayHandler_AcceptsAndStartsReplay(t *testing.T) {
+	path := writeArchive(t, []DebugSample{{Method: http.MethodGet, URL: "/function/foo"}})
+	handler := MakeShadowReplayHandler(http.DefaultClient)
+
+	body := `{"archivePath":"` + path + `","targetBaseUrl":"http://127.0.0.1:0"}`
+	req := httptest.NewRequest(http.MethodPost, "/system/shadow/replay", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("want 202, got %d", rr.Code)
+	}
+}
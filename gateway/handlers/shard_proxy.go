@@ -0,0 +1,57 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas/gateway/types"
+)
+
+// MakeShardingProxyHandler wraps next so that a request for a function not
+// owned by this gateway instance - per ring and localGatewayURL - is
+// forwarded on to whichever backend gateway the ring assigns it to,
+// instead of being handled locally. This lets a fleet of gateways behind a
+// single front door split function-owning state (in-flight replica
+// counts, scale-from-zero locks, breaker/trash stores) by function name
+// for horizontal scale-out, with no client-visible change to the request -
+// unlike MakeFederatedProxyHandler, which requires the caller to name a
+// cluster explicitly with an "@cluster" suffix.
+func MakeShardingProxyHandler(next http.HandlerFunc, ring *ShardRing, localGatewayURL string, client *http.Client, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		functionName := getServiceName(r.URL.String())
+
+		backend, exists := ring.Resolve(functionName)
+		if !exists || backend == localGatewayURL {
+			next(w, r)
+			return
+		}
+
+		baseURL := strings.TrimSuffix(backend, "/")
+
+		upstreamReq := buildUpstreamRequest(r, baseURL, r.URL.Path, types.HeaderFilterConfig{}, ForwardedHeaderAppend)
+		if upstreamReq.Body != nil {
+			defer upstreamReq.Body.Close()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		res, err := client.Do(upstreamReq.WithContext(ctx))
+		if err != nil {
+			statusCode, errorCode := classifyUpstreamError(err)
+			writeUpstreamError(w, statusCode, errorCode, err)
+			return
+		}
+		defer res.Body.Close()
+
+		copyHeaders(w.Header(), &res.Header)
+		w.WriteHeader(res.StatusCode)
+		io.CopyBuffer(w, res.Body, nil)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_MakeShardingProxyHandler_HandlesLocallyOwnedFunction(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("want the remote backend not to be called for a locally owned function")
+	}))
+	defer backend.Close()
+
+	ring := NewShardRing([]string{"local"}, 0)
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeShardingProxyHandler(next, ring, "local", http.DefaultClient, time.Second)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	handler(httptest.NewRecorder(), req)
+
+	if !called {
+		t.Fatal("want next to be called for a function owned by this gateway")
+	}
+}
+
+func Test_MakeShardingProxyHandler_ForwardsToOwningBackend(t *testing.T) {
+	var gotPath string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	ring := NewShardRing([]string{backend.URL}, 0)
+
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+	handler := MakeShardingProxyHandler(next, ring, "local", http.DefaultClient, time.Second)
+
+	req := httptest.NewRequest(http.MethodGet, "/function/figlet", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Fatal("want next not to be called for a function owned by a remote backend")
+	}
+	if gotPath != "/function/figlet" {
+		t.Fatalf("want the request forwarded to the owning backend, got path %q", gotPath)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want the backend's response forwarded, got %d", rec.Code)
+	}
+}
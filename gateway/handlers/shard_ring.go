@@ -0,0 +1,64 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// ShardRing assigns a function name to one of a fixed set of backend
+// gateway addresses using consistent hashing, so that adding or removing a
+// backend only reshuffles the functions nearest to it on the ring rather
+// than redistributing every function, the way a plain hash-mod-N split
+// would.
+type ShardRing struct {
+	points     []uint32
+	pointOwner map[uint32]string
+}
+
+// NewShardRing builds a ring over backends, each represented by
+// virtualNodesPerBackend points scattered across the ring to smooth out an
+// otherwise uneven distribution. virtualNodesPerBackend defaults to 64
+// when zero or negative.
+func NewShardRing(backends []string, virtualNodesPerBackend int) *ShardRing {
+	if virtualNodesPerBackend <= 0 {
+		virtualNodesPerBackend = 64
+	}
+
+	ring := &ShardRing{pointOwner: make(map[uint32]string)}
+
+	for _, backend := range backends {
+		for i := 0; i < virtualNodesPerBackend; i++ {
+			point := ringHash(fmt.Sprintf("%s#%d", backend, i))
+			ring.points = append(ring.points, point)
+			ring.pointOwner[point] = backend
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+
+	return ring
+}
+
+func ringHash(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// Resolve returns the backend address owning key, or false if the ring has
+// no backends.
+func (r *ShardRing) Resolve(key string) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+
+	point := ringHash(key)
+	idx := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= point })
+	if idx == len(r.points) {
+		idx = 0
+	}
+	return r.pointOwner[r.points[idx]], true
+}
@@ -0,0 +1,46 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import "testing"
+
+func Test_ShardRing_IsStableForTheSameKey(t *testing.T) {
+	ring := NewShardRing([]string{"gw-a:8080", "gw-b:8080", "gw-c:8080"}, 0)
+
+	first, exists := ring.Resolve("figlet")
+	if !exists {
+		t.Fatal("want a backend for a non-empty ring")
+	}
+
+	for i := 0; i < 10; i++ {
+		got, _ := ring.Resolve("figlet")
+		if got != first {
+			t.Fatalf("want the same backend on every lookup of the same key, got %q then %q", first, got)
+		}
+	}
+}
+
+func Test_ShardRing_DistributesAcrossBackends(t *testing.T) {
+	backends := []string{"gw-a:8080", "gw-b:8080", "gw-c:8080"}
+	ring := NewShardRing(backends, 0)
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		name := "function-" + string(rune('a'+i%26)) + string(rune('0'+i%10))
+		backend, _ := ring.Resolve(name)
+		seen[backend] = true
+	}
+
+	if len(seen) < 2 {
+		t.Fatalf("want function names spread across more than one backend, got only %v", seen)
+	}
+}
+
+func Test_ShardRing_EmptyRingHasNoOwner(t *testing.T) {
+	ring := NewShardRing(nil, 0)
+
+	if _, exists := ring.Resolve("figlet"); exists {
+		t.Fatal("want no backend resolved from an empty ring")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import "sync"
+
+// SingleflightGroup coalesces concurrent callers keyed by a string so that
+// only one of them actually runs fn; the rest block and share its result.
+//
+// This mirrors golang.org/x/sync/singleflight.Group's Do method - that
+// package is not vendored in this tree, so this is a small hand-rolled
+// equivalent scoped to what MakeScalingHandler needs.
+type SingleflightGroup struct {
+	mutex sync.Mutex
+	calls map[string]*singleflightCall
+}
+
+type singleflightCall struct {
+	wg  sync.WaitGroup
+	val interface{}
+	err error
+}
+
+// Do executes fn, making sure only one execution is in-flight for a given
+// key at a time. If a duplicate call comes in while the original is still
+// in flight, it waits for the original to complete and receives the same
+// result. shared reports whether v and err came from a call made on behalf
+// of another caller.
+func (g *SingleflightGroup) Do(key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*singleflightCall)
+	}
+
+	if call, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+		call.wg.Wait()
+		return call.val, call.err, true
+	}
+
+	call := &singleflightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mutex.Unlock()
+
+	call.val, call.err = fn()
+	call.wg.Done()
+
+	g.mutex.Lock()
+	delete(g.calls, key)
+	g.mutex.Unlock()
+
+	return call.val, call.err, false
+}
@@ -0,0 +1,123 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func Test_SingleflightGroup_CoalescesConcurrentCallers(t *testing.T) {
+	group := &SingleflightGroup{}
+
+	var calls int
+	var mutex sync.Mutex
+	release := make(chan struct{})
+
+	fn := func() (interface{}, error) {
+		mutex.Lock()
+		calls++
+		mutex.Unlock()
+		<-release
+		return "result", nil
+	}
+
+	const callers = 10
+	arrived := make(chan struct{}, callers)
+	results := make(chan string, callers)
+	shares := make(chan bool, callers)
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			arrived <- struct{}{}
+			v, err, shared := group.Do("figlet", fn)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err.Error())
+			}
+			results <- v.(string)
+			shares <- shared
+		}()
+	}
+
+	// Wait for every caller to have reached Do before releasing fn, so
+	// none of them arrive late enough to find the in-flight call already
+	// gone and start a fresh one of their own.
+	for i := 0; i < callers; i++ {
+		<-arrived
+	}
+	close(release)
+	wg.Wait()
+	close(results)
+	close(shares)
+
+	if calls != 1 {
+		t.Fatalf("want fn invoked once, got %d", calls)
+	}
+
+	sharedCount := 0
+	for i := 0; i < callers; i++ {
+		if v := <-results; v != "result" {
+			t.Errorf("want every caller to see the shared result, got %q", v)
+		}
+		if <-shares {
+			sharedCount++
+		}
+	}
+	if sharedCount == 0 {
+		t.Error("want at least one caller to report a shared result")
+	}
+}
+
+func Test_SingleflightGroup_RunsAgainAfterPreviousCallCompletes(t *testing.T) {
+	group := &SingleflightGroup{}
+
+	var calls int
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	if v, _, _ := group.Do("figlet", fn); v.(int) != 1 {
+		t.Fatalf("want 1, got %v", v)
+	}
+	if v, _, _ := group.Do("figlet", fn); v.(int) != 2 {
+		t.Fatalf("want the key reusable once the first call completes, got %v", v)
+	}
+}
+
+func Test_SingleflightGroup_PropagatesErrorsToEveryCaller(t *testing.T) {
+	group := &SingleflightGroup{}
+	wantErr := errors.New("boom")
+
+	release := make(chan struct{})
+	fn := func() (interface{}, error) {
+		<-release
+		return nil, wantErr
+	}
+
+	const callers = 5
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			_, err, _ := group.Do("figlet", fn)
+			errs <- err
+		}()
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != wantErr {
+			t.Errorf("want every caller to see the shared error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// websocketGUID is the fixed GUID defined by RFC 6455 used to compute the
+// Sec-WebSocket-Accept handshake response header.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+)
+
+// MakeSystemWebsocketHandler upgrades GET /system/ws to a WebSocket
+// connection and streams events from bus as JSON text frames. A
+// comma-separated "types" query parameter subscribes to only those event
+// types, and a "function" query parameter further filters to one function.
+func MakeSystemWebsocketHandler(bus *EventBus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Sec-WebSocket-Key")
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || len(key) == 0 {
+			http.Error(w, "expected a WebSocket upgrade request", http.StatusBadRequest)
+			return
+		}
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "websockets are not supported by this connection", http.StatusInternalServerError)
+			return
+		}
+
+		conn, bufrw, err := hijacker.Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+
+		accept := websocketAccept(key)
+		bufrw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+		bufrw.WriteString("Upgrade: websocket\r\n")
+		bufrw.WriteString("Connection: Upgrade\r\n")
+		bufrw.WriteString("Sec-WebSocket-Accept: " + accept + "\r\n\r\n")
+		if err := bufrw.Flush(); err != nil {
+			return
+		}
+
+		filter := subscriptionFilter(r.URL.Query().Get("types"), r.URL.Query().Get("function"))
+		id, events := bus.Subscribe(filter)
+		defer bus.Unsubscribe(id)
+
+		// Drain client frames (pings/close) in the background so the TCP
+		// connection is noticed as closed promptly.
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := readWebsocketFrame(bufrw.Reader); err != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case event, open := <-events:
+				if !open {
+					return
+				}
+				payload, err := json.Marshal(event)
+				if err != nil {
+					log.Printf("system websocket: unable to marshal event: %s", err.Error())
+					continue
+				}
+				if err := writeWebsocketFrame(bufrw.Writer, wsOpText, payload); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func subscriptionFilter(typesParam string, functionParam string) func(Event) bool {
+	if len(typesParam) == 0 && len(functionParam) == 0 {
+		return nil
+	}
+
+	var types map[string]bool
+	if len(typesParam) > 0 {
+		types = make(map[string]bool)
+		for _, t := range strings.Split(typesParam, ",") {
+			types[strings.TrimSpace(t)] = true
+		}
+	}
+
+	return func(e Event) bool {
+		if types != nil && !types[e.Type] {
+			return false
+		}
+		if len(functionParam) > 0 && e.FunctionName != functionParam {
+			return false
+		}
+		return true
+	}
+}
+
+func websocketAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// writeWebsocketFrame writes a single, unfragmented, unmasked server frame.
+func writeWebsocketFrame(w *bufio.Writer, opcode byte, payload []byte) error {
+	if err := w.WriteByte(0x80 | opcode); err != nil { // FIN + opcode
+		return err
+	}
+
+	length := len(payload)
+	switch {
+	case length <= 125:
+		if err := w.WriteByte(byte(length)); err != nil {
+			return err
+		}
+	case length <= 0xFFFF:
+		if err := w.WriteByte(126); err != nil {
+			return err
+		}
+		if err := w.WriteByte(byte(length >> 8)); err != nil {
+			return err
+		}
+		if err := w.WriteByte(byte(length)); err != nil {
+			return err
+		}
+	default:
+		if err := w.WriteByte(127); err != nil {
+			return err
+		}
+		for i := 7; i >= 0; i-- {
+			if err := w.WriteByte(byte(length >> uint(i*8))); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// readWebsocketFrame reads a single frame, returning its opcode and its
+// payload with any mask removed. Callers use it to detect when the client
+// has closed the connection, and callOverTunnel to read a tunnel response.
+func readWebsocketFrame(r *bufio.Reader) (opcode byte, payload []byte, err error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	opcode = header & 0x0F
+
+	lengthByte, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	masked := lengthByte&0x80 != 0
+	length := int(lengthByte & 0x7F)
+
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(r, ext); err != nil {
+			return 0, nil, err
+		}
+		length = int(ext[0])<<8 | int(ext[1])
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(r, ext); err != nil {
+			return 0, nil, err
+		}
+		length = 0
+		for _, b := range ext {
+			length = length<<8 | int(b)
+		}
+	}
+
+	var maskKey [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, maskKey[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= maskKey[i%4]
+		}
+	}
+
+	if opcode == wsOpClose {
+		return opcode, payload, errClosed
+	}
+
+	return opcode, payload, nil
+}
+
+var errClosed = errWebsocketClosed{}
+
+type errWebsocketClosed struct{}
+
+func (errWebsocketClosed) Error() string { return "websocket connection closed" }
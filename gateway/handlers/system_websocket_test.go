@@ -0,0 +1,70 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bufio"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_SystemWebsocketHandler_HandshakeAndPublish(t *testing.T) {
+	bus := NewEventBus()
+	server := httptest.NewServer(MakeSystemWebsocketHandler(bus))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("unable to dial test server: %s", err.Error())
+	}
+	defer conn.Close()
+
+	req := "GET /system/ws HTTP/1.1\r\n" +
+		"Host: " + addr + "\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatalf("unable to write handshake: %s", err.Error())
+	}
+
+	reader := bufio.NewReader(conn)
+	statusLine, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("unable to read status line: %s", err.Error())
+	}
+	if !strings.Contains(statusLine, "101") {
+		t.Fatalf("want 101 Switching Protocols, got: %s", statusLine)
+	}
+
+	// Drain the remaining headers.
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("unable to read headers: %s", err.Error())
+		}
+		if line == "\r\n" {
+			break
+		}
+	}
+
+	// Give the handler a moment to register its subscription before publishing.
+	time.Sleep(time.Millisecond * 50)
+	bus.Publish(Event{Type: "deployment", FunctionName: "echo"})
+
+	conn.SetReadDeadline(time.Now().Add(time.Second * 2))
+	opcodeByte, err := reader.ReadByte()
+	if err != nil {
+		t.Fatalf("unable to read frame header: %s", err.Error())
+	}
+	if opcodeByte&0x0F != wsOpText {
+		t.Fatalf("want text frame opcode, got %x", opcodeByte)
+	}
+}
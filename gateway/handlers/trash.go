@@ -0,0 +1,286 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// trashReapInterval is how often a TrashReaper checks for functions whose
+// retention window has passed.
+const trashReapInterval = time.Minute
+
+// FunctionSpecStore remembers the most recently deployed spec for each
+// function, so that a soft-deleted function's spec can be restored
+// without the caller having to resubmit it.
+type FunctionSpecStore struct {
+	lock  sync.RWMutex
+	specs map[string]requests.CreateFunctionRequest
+}
+
+// NewFunctionSpecStore creates an empty FunctionSpecStore.
+func NewFunctionSpecStore() *FunctionSpecStore {
+	return &FunctionSpecStore{
+		specs: make(map[string]requests.CreateFunctionRequest),
+	}
+}
+
+// Set records spec as functionName's latest known spec.
+func (s *FunctionSpecStore) Set(functionName string, spec requests.CreateFunctionRequest) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.specs[functionName] = spec
+}
+
+// Get returns functionName's latest known spec, if any.
+func (s *FunctionSpecStore) Get(functionName string) (requests.CreateFunctionRequest, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	spec, exists := s.specs[functionName]
+	return spec, exists
+}
+
+// Delete forgets functionName's latest known spec.
+func (s *FunctionSpecStore) Delete(functionName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.specs, functionName)
+}
+
+// TrashedFunction is a soft-deleted function's retained spec, scheduled
+// for permanent removal once its retention window passes.
+type TrashedFunction struct {
+	Spec      requests.CreateFunctionRequest `json:"spec"`
+	DeletedAt time.Time                      `json:"deletedAt"`
+}
+
+// TrashStore holds soft-deleted function specs pending restoration or
+// permanent removal. Like the gateway's other in-memory stores, it does
+// not survive a restart, so a restart during a retention window loses
+// the ability to restore.
+type TrashStore struct {
+	lock    sync.RWMutex
+	trashed map[string]TrashedFunction
+}
+
+// NewTrashStore creates an empty TrashStore.
+func NewTrashStore() *TrashStore {
+	return &TrashStore{
+		trashed: make(map[string]TrashedFunction),
+	}
+}
+
+// Trash records functionName as soft-deleted at deletedAt, retaining spec
+// for later restoration.
+func (s *TrashStore) Trash(functionName string, spec requests.CreateFunctionRequest, deletedAt time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.trashed[functionName] = TrashedFunction{Spec: spec, DeletedAt: deletedAt}
+}
+
+// Get returns functionName's trashed entry, if any.
+func (s *TrashStore) Get(functionName string) (TrashedFunction, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	trashed, exists := s.trashed[functionName]
+	return trashed, exists
+}
+
+// Remove forgets functionName's trashed entry, e.g. once restored or
+// permanently purged.
+func (s *TrashStore) Remove(functionName string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.trashed, functionName)
+}
+
+// Expired returns the names of every trashed function whose retention
+// window has passed as of now.
+func (s *TrashStore) Expired(retention time.Duration, now time.Time) []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var expired []string
+	for name, trashed := range s.trashed {
+		if now.Sub(trashed.DeletedAt) >= retention {
+			expired = append(expired, name)
+		}
+	}
+	return expired
+}
+
+// MakeRecordSpecHandler wraps a deploy or update handler so that, once
+// next has accepted the request, the submitted spec is recorded in
+// specs, keeping it available for a later soft-delete/restore cycle.
+func MakeRecordSpecHandler(next http.HandlerFunc, specs *FunctionSpecStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if r.Body != nil {
+			body, _ = ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		recorder := &statusCapturingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(recorder, r)
+		if recorder.statusCode >= http.StatusMultipleChoices {
+			return
+		}
+
+		var spec requests.CreateFunctionRequest
+		if err := json.Unmarshal(body, &spec); err != nil || len(spec.Service) == 0 {
+			return
+		}
+		specs.Set(spec.Service, spec)
+	}
+}
+
+// MakeSoftDeleteHandler wraps a delete route (POST body is a
+// requests.DeleteFunctionRequest) so that, instead of deleting the
+// function outright, it is scaled to zero and its spec is moved into
+// trash, where it remains restorable until a TrashReaper purges it.
+func MakeSoftDeleteHandler(scaleToZero func(functionName string) error, specs *FunctionSpecStore, trash *TrashStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var deleteReq requests.DeleteFunctionRequest
+		if err := json.NewDecoder(r.Body).Decode(&deleteReq); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		spec, exists := specs.Get(deleteReq.FunctionName)
+		if !exists {
+			http.Error(w, "function not found", http.StatusNotFound)
+			return
+		}
+
+		if err := scaleToZero(deleteReq.FunctionName); err != nil {
+			http.Error(w, "unable to scale function to zero: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		trash.Trash(deleteReq.FunctionName, spec, time.Now())
+		specs.Delete(deleteReq.FunctionName)
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeRestoreFunctionHandler handles POST
+// /system/functions/{name}/restore, redeploying a soft-deleted function
+// from its retained spec via deploy.
+func MakeRestoreFunctionHandler(deploy http.HandlerFunc, specs *FunctionSpecStore, trash *TrashStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+
+		trashed, exists := trash.Get(name)
+		if !exists {
+			http.Error(w, "no trashed function found with that name", http.StatusNotFound)
+			return
+		}
+
+		bodyBytes, err := json.Marshal(trashed.Spec)
+		if err != nil {
+			http.Error(w, "unable to encode retained spec: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		deployReq, err := http.NewRequest(http.MethodPost, r.URL.String(), bytes.NewReader(bodyBytes))
+		if err != nil {
+			http.Error(w, "unable to build restore request: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		deployReq.Header.Set("Content-Type", "application/json")
+
+		deploy(w, deployReq)
+
+		specs.Set(name, trashed.Spec)
+		trash.Remove(name)
+	}
+}
+
+// TrashReaper permanently deletes, directly against the functions
+// provider, any trashed function whose retention window has passed. It
+// bypasses the gateway's own soft-delete route, since going through it
+// again would just re-trash the function.
+type TrashReaper struct {
+	ProviderURL string
+	Trash       *TrashStore
+	Retention   time.Duration
+
+	Client *http.Client
+}
+
+// ReapOnce purges every trashed function past its retention window,
+// returning how many were purged.
+func (t *TrashReaper) ReapOnce() int {
+	purged := 0
+	for _, name := range t.Trash.Expired(t.Retention, time.Now()) {
+		if err := t.purge(name); err != nil {
+			log.Printf("trash reaper: unable to purge %s: %s", name, err.Error())
+			continue
+		}
+		t.Trash.Remove(name)
+		purged++
+	}
+	return purged
+}
+
+func (t *TrashReaper) purge(functionName string) error {
+	bodyBytes, err := json.Marshal(requests.DeleteFunctionRequest{FunctionName: functionName})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, strings.TrimSuffix(t.ProviderURL, "/")+"/system/functions", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := t.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	ioutil.ReadAll(res.Body)
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("provider returned %d", res.StatusCode)
+	}
+	return nil
+}
+
+// Start runs ReapOnce every trashReapInterval until the returned func is
+// called.
+func (t *TrashReaper) Start() (stop func()) {
+	ticker := time.NewTicker(trashReapInterval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if purged := t.ReapOnce(); purged > 0 {
+					log.Printf("trash reaper: permanently purged %d function(s)", purged)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
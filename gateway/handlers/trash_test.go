@@ -0,0 +1,169 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_MakeRecordSpecHandler_RecordsSpecOnDeploy(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	next := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	handler := MakeRecordSpecHandler(next, specs)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions", bytes.NewBufferString(`{"service":"figlet","image":"figlet:latest"}`))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	spec, exists := specs.Get("figlet")
+	if !exists || spec.Image != "figlet:latest" {
+		t.Fatalf("want figlet's spec recorded, got %v (exists=%v)", spec, exists)
+	}
+}
+
+func Test_MakeSoftDeleteHandler_TrashesKnownFunction(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	specs.Set("figlet", requests.CreateFunctionRequest{Service: "figlet", Image: "figlet:latest"})
+	trash := NewTrashStore()
+
+	scaledTo := uint64(1)
+	handler := MakeSoftDeleteHandler(func(functionName string) error {
+		scaledTo = 0
+		return nil
+	}, specs, trash)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/functions", bytes.NewBufferString(`{"functionName":"figlet"}`))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rec.Code)
+	}
+	if scaledTo != 0 {
+		t.Fatal("want the function to be scaled to zero")
+	}
+	if _, exists := specs.Get("figlet"); exists {
+		t.Fatal("want the spec to be removed from the live spec store")
+	}
+	if _, exists := trash.Get("figlet"); !exists {
+		t.Fatal("want the spec to be moved into trash")
+	}
+}
+
+func Test_MakeSoftDeleteHandler_404sOnUnknownFunction(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	trash := NewTrashStore()
+
+	handler := MakeSoftDeleteHandler(func(functionName string) error { return nil }, specs, trash)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/functions", bytes.NewBufferString(`{"functionName":"missing"}`))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("want 404, got %d", rec.Code)
+	}
+}
+
+func Test_MakeRestoreFunctionHandler_RedeploysFromTrash(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	trash := NewTrashStore()
+	trash.Trash("figlet", requests.CreateFunctionRequest{Service: "figlet", Image: "figlet:latest"}, time.Now())
+
+	var deployedImage string
+	deploy := func(w http.ResponseWriter, r *http.Request) {
+		var spec requests.CreateFunctionRequest
+		json.NewDecoder(r.Body).Decode(&spec)
+		deployedImage = spec.Image
+		w.WriteHeader(http.StatusOK)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/functions/{name}/restore", MakeRestoreFunctionHandler(deploy, specs, trash)).Methods(http.MethodPost)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions/figlet/restore", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rec.Code)
+	}
+	if deployedImage != "figlet:latest" {
+		t.Errorf("want the retained spec redeployed, got image %q", deployedImage)
+	}
+	if _, exists := trash.Get("figlet"); exists {
+		t.Fatal("want the trashed entry to be removed once restored")
+	}
+	if _, exists := specs.Get("figlet"); !exists {
+		t.Fatal("want the spec to be re-recorded as live once restored")
+	}
+}
+
+func Test_MakeRestoreFunctionHandler_404sOnUnknownFunction(t *testing.T) {
+	specs := NewFunctionSpecStore()
+	trash := NewTrashStore()
+	deploy := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/functions/{name}/restore", MakeRestoreFunctionHandler(deploy, specs, trash)).Methods(http.MethodPost)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/functions/missing/restore", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("want 404, got %d", rec.Code)
+	}
+}
+
+func Test_TrashStore_Expired(t *testing.T) {
+	trash := NewTrashStore()
+	now := time.Now()
+	trash.Trash("old", requests.CreateFunctionRequest{Service: "old"}, now.Add(-2*time.Hour))
+	trash.Trash("fresh", requests.CreateFunctionRequest{Service: "fresh"}, now)
+
+	expired := trash.Expired(time.Hour, now)
+	if len(expired) != 1 || expired[0] != "old" {
+		t.Fatalf("want only 'old' to be expired, got %v", expired)
+	}
+}
+
+func Test_TrashReaper_ReapOnce_PurgesExpiredFunctions(t *testing.T) {
+	var gotMethod, gotPath string
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer provider.Close()
+
+	trash := NewTrashStore()
+	trash.Trash("old", requests.CreateFunctionRequest{Service: "old"}, time.Now().Add(-2*time.Hour))
+
+	reaper := &TrashReaper{
+		ProviderURL: provider.URL,
+		Trash:       trash,
+		Retention:   time.Hour,
+		Client:      provider.Client(),
+	}
+
+	if purged := reaper.ReapOnce(); purged != 1 {
+		t.Fatalf("want 1 function purged, got %d", purged)
+	}
+	if gotMethod != http.MethodDelete || gotPath != "/system/functions" {
+		t.Errorf("want a DELETE to /system/functions, got %s %s", gotMethod, gotPath)
+	}
+	if _, exists := trash.Get("old"); exists {
+		t.Fatal("want the purged function to be removed from trash")
+	}
+}
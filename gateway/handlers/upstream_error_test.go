@@ -0,0 +1,47 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func Test_ClassifyUpstreamError_DNSFailureMapsToResolutionFailed(t *testing.T) {
+	err := &net.DNSError{Err: "no such host", Name: "does-not-exist.invalid"}
+
+	statusCode, code := classifyUpstreamError(err)
+
+	if statusCode != http.StatusBadGateway || code != "RESOLUTION_FAILED" {
+		t.Errorf("want 502/RESOLUTION_FAILED, got %d/%s", statusCode, code)
+	}
+}
+
+func Test_ClassifyUpstreamError_TimeoutMapsToFunctionTimeout(t *testing.T) {
+	err := timeoutError{}
+
+	statusCode, code := classifyUpstreamError(err)
+
+	if statusCode != http.StatusGatewayTimeout || code != "FUNCTION_TIMEOUT" {
+		t.Errorf("want 504/FUNCTION_TIMEOUT, got %d/%s", statusCode, code)
+	}
+}
+
+func Test_ClassifyUpstreamError_ConnectionRefusedMapsToFunctionUnavailable(t *testing.T) {
+	err := errors.New("dial tcp 127.0.0.1:1: connect: connection refused")
+
+	statusCode, code := classifyUpstreamError(err)
+
+	if statusCode != http.StatusServiceUnavailable || code != "FUNCTION_UNAVAILABLE" {
+		t.Errorf("want 503/FUNCTION_UNAVAILABLE, got %d/%s", statusCode, code)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
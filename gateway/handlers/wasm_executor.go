@@ -0,0 +1,156 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// WASMModuleStore holds uploaded WASM module bytes by name, keyed
+// independently of the function catalog - a WASM module is invoked directly
+// by name via MakeWASMInvokeHandler rather than scaled and proxied like a
+// container-backed function. It is safe for concurrent use.
+type WASMModuleStore struct {
+	mutex   sync.RWMutex
+	modules map[string][]byte
+}
+
+// NewWASMModuleStore creates an empty WASMModuleStore.
+func NewWASMModuleStore() *WASMModuleStore {
+	return &WASMModuleStore{
+		modules: make(map[string][]byte),
+	}
+}
+
+// Set uploads or replaces the module named name.
+func (s *WASMModuleStore) Set(name string, module []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.modules[name] = module
+}
+
+// Get returns the module named name, and whether it was found.
+func (s *WASMModuleStore) Get(name string) ([]byte, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	module, ok := s.modules[name]
+	return module, ok
+}
+
+// Delete removes the module named name, if any.
+func (s *WASMModuleStore) Delete(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.modules, name)
+}
+
+// List returns the names of every uploaded module.
+func (s *WASMModuleStore) List() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	names := make([]string, 0, len(s.modules))
+	for name := range s.modules {
+		names = append(names, name)
+	}
+	return names
+}
+
+// WASMExecutor runs an uploaded WASM module against input and returns its
+// output, bypassing the usual container cold start for small,
+// latency-sensitive transformations and routing logic.
+type WASMExecutor interface {
+	Execute(module []byte, input []byte) ([]byte, error)
+}
+
+// UnavailableWASMExecutor is the WASMExecutor used when this gateway binary
+// was built without a WASM runtime vendored. faas's vendor tree carries no
+// WASM engine (e.g. wasmer-go or wasmtime-go) today, so in-process
+// execution is not yet possible here - Execute always fails, rather than
+// this feature silently pretending to run modules it can't.
+type UnavailableWASMExecutor struct{}
+
+// Execute implements WASMExecutor by always failing.
+func (UnavailableWASMExecutor) Execute(module []byte, input []byte) ([]byte, error) {
+	return nil, errWASMRuntimeUnavailable
+}
+
+var errWASMRuntimeUnavailable = &wasmRuntimeUnavailableError{}
+
+type wasmRuntimeUnavailableError struct{}
+
+func (*wasmRuntimeUnavailableError) Error() string {
+	return "no WASM runtime is vendored in this gateway build"
+}
+
+// MakeWASMUploadHandler stores the request body, verbatim, as the WASM
+// module named by the "name" mux variable.
+func MakeWASMUploadHandler(store *WASMModuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+
+		module, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "unable to read module body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		store.Set(name, module)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeListWASMModulesHandler lists the names of every uploaded WASM module.
+func MakeListWASMModulesHandler(store *WASMModuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(store.List())
+	}
+}
+
+// MakeDeleteWASMModuleHandler removes the WASM module named by the "name"
+// mux variable.
+func MakeDeleteWASMModuleHandler(store *WASMModuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store.Delete(mux.Vars(r)["name"])
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeWASMInvokeHandler runs the WASM module named by the "name" mux
+// variable against the request body via executor, and writes its output as
+// the response body.
+func MakeWASMInvokeHandler(store *WASMModuleStore, executor WASMExecutor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+
+		module, ok := store.Get(name)
+		if !ok {
+			http.Error(w, "wasm module not found: "+name, http.StatusNotFound)
+			return
+		}
+
+		input, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "unable to read request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		output, err := executor.Execute(module, input)
+		if err != nil {
+			http.Error(w, "wasm execution failed: "+err.Error(), http.StatusNotImplemented)
+			return
+		}
+
+		w.Write(output)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func Test_WASMModuleStore_SetGetDelete(t *testing.T) {
+	store := NewWASMModuleStore()
+	store.Set("transform", []byte("fake-wasm-bytes"))
+
+	module, ok := store.Get("transform")
+	if !ok || string(module) != "fake-wasm-bytes" {
+		t.Fatalf("want the uploaded module back, got %q, ok=%v", module, ok)
+	}
+
+	store.Delete("transform")
+	if _, ok := store.Get("transform"); ok {
+		t.Error("want the module removed after delete")
+	}
+}
+
+func Test_MakeWASMUploadHandler_StoresRequestBody(t *testing.T) {
+	store := NewWASMModuleStore()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/wasm/{name}", MakeWASMUploadHandler(store)).Methods(http.MethodPost)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/wasm/transform", strings.NewReader("fake-wasm-bytes"))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+
+	module, ok := store.Get("transform")
+	if !ok || string(module) != "fake-wasm-bytes" {
+		t.Errorf("want the module stored, got %q, ok=%v", module, ok)
+	}
+}
+
+func Test_MakeDeleteWASMModuleHandler_RemovesModule(t *testing.T) {
+	store := NewWASMModuleStore()
+	store.Set("transform", []byte("fake-wasm-bytes"))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/system/wasm/{name}", MakeDeleteWASMModuleHandler(store)).Methods(http.MethodDelete)
+
+	req := httptest.NewRequest(http.MethodDelete, "/system/wasm/transform", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+	if _, ok := store.Get("transform"); ok {
+		t.Error("want the module removed")
+	}
+}
+
+func Test_MakeWASMInvokeHandler_404sForMissingModule(t *testing.T) {
+	store := NewWASMModuleStore()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/wasm/{name}", MakeWASMInvokeHandler(store, UnavailableWASMExecutor{})).Methods(http.MethodPost)
+
+	req := httptest.NewRequest(http.MethodPost, "/wasm/transform", strings.NewReader("input"))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("want 404 for an unknown module, got %d", rr.Code)
+	}
+}
+
+func Test_MakeWASMInvokeHandler_501sWhenNoRuntimeIsVendored(t *testing.T) {
+	store := NewWASMModuleStore()
+	store.Set("transform", []byte("fake-wasm-bytes"))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/wasm/{name}", MakeWASMInvokeHandler(store, UnavailableWASMExecutor{})).Methods(http.MethodPost)
+
+	req := httptest.NewRequest(http.MethodPost, "/wasm/transform", strings.NewReader("input"))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("want 501 since no WASM runtime is vendored, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import "testing"
+
+type fakeIdleConnectionRecycler struct {
+	closed int
+}
+
+func (f *fakeIdleConnectionRecycler) CloseIdleConnections() {
+	f.closed++
+}
+
+func Test_Watchdog_RecyclesTransportOnceThresholdExceeded(t *testing.T) {
+	transport := &fakeIdleConnectionRecycler{}
+	watchdog := NewWatchdog(WatchdogConfig{
+		GoroutineThreshold: 100,
+		Transport:          transport,
+		Sample:             func() int { return 150 },
+	})
+
+	if !watchdog.CheckOnce() {
+		t.Fatal("want CheckOnce to report it recycled the transport")
+	}
+	if transport.closed != 1 {
+		t.Fatalf("want CloseIdleConnections called once, got %d", transport.closed)
+	}
+}
+
+func Test_Watchdog_LeavesTransportAloneUnderThreshold(t *testing.T) {
+	transport := &fakeIdleConnectionRecycler{}
+	watchdog := NewWatchdog(WatchdogConfig{
+		GoroutineThreshold: 100,
+		Transport:          transport,
+		Sample:             func() int { return 10 },
+	})
+
+	if watchdog.CheckOnce() {
+		t.Fatal("want CheckOnce to report no action taken")
+	}
+	if transport.closed != 0 {
+		t.Fatalf("want CloseIdleConnections not called, got %d", transport.closed)
+	}
+}
+
+func Test_Watchdog_DisabledWhenThresholdIsZero(t *testing.T) {
+	transport := &fakeIdleConnectionRecycler{}
+	watchdog := NewWatchdog(WatchdogConfig{
+		Transport: transport,
+		Sample:    func() int { return 1000000 },
+	})
+
+	if watchdog.CheckOnce() {
+		t.Fatal("want a zero threshold to disable the watchdog")
+	}
+	if transport.closed != 0 {
+		t.Fatalf("want CloseIdleConnections not called, got %d", transport.closed)
+	}
+}
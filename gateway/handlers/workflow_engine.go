@@ -0,0 +1,330 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/uuid"
+)
+
+var (
+	errWorkflowNotFound = errors.New("workflow definition not found")
+	errWorkflowEmpty    = errors.New("workflow definition has no steps")
+)
+
+// WorkflowStep is one step of a WorkflowDefinition: it invokes Function
+// with the previous step's response body, then moves on to OnSuccess (or
+// the next step in sequence, if OnSuccess is empty) or OnFailure. A
+// workflow with no OnSuccess/OnFailure set on any step simply runs its
+// Steps in order, which covers the common "sequence" case; naming a step
+// out of sequence as OnSuccess/OnFailure is what gives branching.
+type WorkflowStep struct {
+	Name      string `json:"name"`
+	Function  string `json:"function"`
+	OnSuccess string `json:"onSuccess,omitempty"`
+	OnFailure string `json:"onFailure,omitempty"`
+
+	// Retries is how many additional attempts are made if Function
+	// returns a status of 400 or above or a transport error, before the step is
+	// considered failed.
+	Retries int `json:"retries,omitempty"`
+}
+
+// WorkflowDefinition is a named, multi-step pipeline of function calls.
+type WorkflowDefinition struct {
+	Name  string         `json:"name"`
+	Steps []WorkflowStep `json:"steps"`
+}
+
+// WorkflowStepResult records what happened running one step of an
+// execution.
+type WorkflowStepResult struct {
+	Step       string `json:"step"`
+	StatusCode int    `json:"statusCode"`
+	Body       string `json:"body,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Attempts   int    `json:"attempts"`
+}
+
+// WorkflowExecutionStatus is the lifecycle state of one WorkflowExecution.
+type WorkflowExecutionStatus string
+
+const (
+	WorkflowRunning   WorkflowExecutionStatus = "running"
+	WorkflowCompleted WorkflowExecutionStatus = "completed"
+	WorkflowFailed    WorkflowExecutionStatus = "failed"
+	WorkflowCancelled WorkflowExecutionStatus = "cancelled"
+)
+
+// WorkflowExecution is a point-in-time snapshot of one run of a
+// WorkflowDefinition, as returned by WorkflowEngine.GetExecution.
+type WorkflowExecution struct {
+	ID             string                  `json:"id"`
+	DefinitionName string                  `json:"definitionName"`
+	Status         WorkflowExecutionStatus `json:"status"`
+	Steps          []WorkflowStepResult    `json:"steps"`
+}
+
+// workflowRun is the engine's mutable, in-flight bookkeeping for one
+// execution; WorkflowExecution is the immutable snapshot taken from it.
+type workflowRun struct {
+	id             string
+	definitionName string
+
+	lock   sync.Mutex
+	status WorkflowExecutionStatus
+	steps  []WorkflowStepResult
+	cancel context.CancelFunc
+}
+
+func (run *workflowRun) appendResult(result WorkflowStepResult) {
+	run.lock.Lock()
+	defer run.lock.Unlock()
+	run.steps = append(run.steps, result)
+}
+
+func (run *workflowRun) setStatus(status WorkflowExecutionStatus) {
+	run.lock.Lock()
+	defer run.lock.Unlock()
+	run.status = status
+}
+
+func (run *workflowRun) snapshot() WorkflowExecution {
+	run.lock.Lock()
+	defer run.lock.Unlock()
+	stepsCopy := make([]WorkflowStepResult, len(run.steps))
+	copy(stepsCopy, run.steps)
+	return WorkflowExecution{
+		ID:             run.id,
+		DefinitionName: run.definitionName,
+		Status:         run.status,
+		Steps:          stepsCopy,
+	}
+}
+
+// WorkflowEngine is a lightweight, in-memory durable-enough workflow
+// runner: it executes each step by invoking a function through this
+// gateway's own /function/ route, so no separate orchestrator or function
+// client is required. Executions do not survive a gateway restart - there
+// is no external state store wired into this codebase to persist them to -
+// so this trades true durability for covering the common case of simple,
+// short-lived pipelines without standing up extra infrastructure.
+type WorkflowEngine struct {
+	lock        sync.RWMutex
+	definitions map[string]WorkflowDefinition
+	executions  map[string]*workflowRun
+
+	gatewayURL string
+	client     *http.Client
+}
+
+// NewWorkflowEngine creates an engine which invokes workflow steps against
+// gatewayURL (this gateway's own address) using client.
+func NewWorkflowEngine(gatewayURL string, client *http.Client) *WorkflowEngine {
+	return &WorkflowEngine{
+		definitions: make(map[string]WorkflowDefinition),
+		executions:  make(map[string]*workflowRun),
+		gatewayURL:  strings.TrimSuffix(gatewayURL, "/"),
+		client:      client,
+	}
+}
+
+// DefineWorkflow registers or replaces a workflow definition by name.
+func (e *WorkflowEngine) DefineWorkflow(def WorkflowDefinition) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.definitions[def.Name] = def
+}
+
+// GetWorkflow returns the definition named name, if one is registered.
+func (e *WorkflowEngine) GetWorkflow(name string) (WorkflowDefinition, bool) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	def, exists := e.definitions[name]
+	return def, exists
+}
+
+// GetExecution returns a snapshot of the execution named id, if one exists.
+func (e *WorkflowEngine) GetExecution(id string) (WorkflowExecution, bool) {
+	e.lock.RLock()
+	exec, exists := e.executions[id]
+	e.lock.RUnlock()
+	if !exists {
+		return WorkflowExecution{}, false
+	}
+	return exec.snapshot(), true
+}
+
+// Cancel stops a running execution: the request of a step already in
+// flight is cancelled with it, and no further step is started. Returns
+// false if id is unknown or already finished.
+func (e *WorkflowEngine) Cancel(id string) bool {
+	e.lock.RLock()
+	exec, exists := e.executions[id]
+	e.lock.RUnlock()
+	if !exists {
+		return false
+	}
+
+	exec.lock.Lock()
+	running := exec.status == WorkflowRunning
+	exec.lock.Unlock()
+	if !running {
+		return false
+	}
+
+	exec.cancel()
+	return true
+}
+
+// Start begins a new execution of the workflow named definitionName, with
+// input as the body passed to its first step. The execution runs
+// asynchronously; its ID is returned immediately so callers can poll
+// GetExecution.
+func (e *WorkflowEngine) Start(definitionName string, input []byte) (string, error) {
+	def, exists := e.GetWorkflow(definitionName)
+	if !exists {
+		return "", errWorkflowNotFound
+	}
+	if len(def.Steps) == 0 {
+		return "", errWorkflowEmpty
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	exec := &workflowRun{
+		id:             uuid.Generate().String(),
+		definitionName: definitionName,
+		status:         WorkflowRunning,
+		cancel:         cancel,
+	}
+
+	e.lock.Lock()
+	e.executions[exec.id] = exec
+	e.lock.Unlock()
+
+	go e.run(ctx, def, exec, input)
+
+	return exec.id, nil
+}
+
+func (e *WorkflowEngine) run(ctx context.Context, def WorkflowDefinition, exec *workflowRun, input []byte) {
+	byName := make(map[string]WorkflowStep, len(def.Steps))
+	for _, step := range def.Steps {
+		byName[step.Name] = step
+	}
+
+	current := def.Steps[0]
+	body := input
+
+	for {
+		if ctx.Err() != nil {
+			exec.setStatus(WorkflowCancelled)
+			return
+		}
+
+		statusCode, respBody, attempts, err := e.invokeWithRetry(ctx, current, body)
+
+		if ctx.Err() != nil {
+			exec.setStatus(WorkflowCancelled)
+			return
+		}
+
+		result := WorkflowStepResult{Step: current.Name, StatusCode: statusCode, Attempts: attempts}
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Body = string(respBody)
+			body = respBody
+		}
+		exec.appendResult(result)
+
+		failed := err != nil || statusCode >= 400
+
+		if failed {
+			if len(current.OnFailure) == 0 {
+				exec.setStatus(WorkflowFailed)
+				return
+			}
+			nextStep, exists := byName[current.OnFailure]
+			if !exists {
+				exec.setStatus(WorkflowFailed)
+				return
+			}
+			current = nextStep
+			continue
+		}
+
+		if len(current.OnSuccess) > 0 {
+			nextStep, exists := byName[current.OnSuccess]
+			if !exists {
+				exec.setStatus(WorkflowFailed)
+				return
+			}
+			current = nextStep
+			continue
+		}
+
+		// No branch configured: fall through to the next step in
+		// sequence, or finish if this was the last one.
+		idx := indexOfStep(def.Steps, current.Name)
+		if idx == -1 || idx == len(def.Steps)-1 {
+			exec.setStatus(WorkflowCompleted)
+			return
+		}
+		current = def.Steps[idx+1]
+	}
+}
+
+func indexOfStep(steps []WorkflowStep, name string) int {
+	for i, step := range steps {
+		if step.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// invokeWithRetry calls step.Function through the gateway, retrying up to
+// step.Retries additional times on a transport error or a status of 400 or
+// above.
+func (e *WorkflowEngine) invokeWithRetry(ctx context.Context, step WorkflowStep, body []byte) (statusCode int, respBody []byte, attempts int, err error) {
+	for attempts = 1; ; attempts++ {
+		statusCode, respBody, err = e.invokeStep(ctx, step, body)
+		if err == nil && statusCode < 400 {
+			return statusCode, respBody, attempts, nil
+		}
+		if attempts > step.Retries {
+			return statusCode, respBody, attempts, err
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+func (e *WorkflowEngine) invokeStep(ctx context.Context, step WorkflowStep, body []byte) (int, []byte, error) {
+	req, err := http.NewRequest(http.MethodPost, e.gatewayURL+"/function/"+step.Function, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res, err := e.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	respBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, nil, err
+	}
+
+	return res.StatusCode, respBody, nil
+}
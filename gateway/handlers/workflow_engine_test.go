@@ -0,0 +1,161 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitForExecution(t *testing.T, engine *WorkflowEngine, id string, want WorkflowExecutionStatus) WorkflowExecution {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		exec, exists := engine.GetExecution(id)
+		if exists && exec.Status != WorkflowRunning {
+			if exec.Status != want {
+				t.Fatalf("want status %s, got %s (%+v)", want, exec.Status, exec.Steps)
+			}
+			return exec
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("execution %s did not reach status %s in time", id, want)
+	return WorkflowExecution{}
+}
+
+func Test_WorkflowEngine_RunsStepsInSequence(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.HasSuffix(r.URL.Path, "/upper") {
+			w.Write([]byte(strings.ToUpper(string(body))))
+			return
+		}
+		w.Write(append(body, []byte("-done")...))
+	}))
+	defer gateway.Close()
+
+	engine := NewWorkflowEngine(gateway.URL, http.DefaultClient)
+	engine.DefineWorkflow(WorkflowDefinition{
+		Name: "pipeline",
+		Steps: []WorkflowStep{
+			{Name: "step1", Function: "upper"},
+			{Name: "step2", Function: "finish"},
+		},
+	})
+
+	id, err := engine.Start("pipeline", []byte("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	exec := waitForExecution(t, engine, id, WorkflowCompleted)
+	if len(exec.Steps) != 2 {
+		t.Fatalf("want 2 step results, got %d", len(exec.Steps))
+	}
+	if exec.Steps[1].Body != "HELLO-done" {
+		t.Errorf("want HELLO-done, got %s", exec.Steps[1].Body)
+	}
+}
+
+func Test_WorkflowEngine_BranchesToOnFailure(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/risky") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("recovered"))
+	}))
+	defer gateway.Close()
+
+	engine := NewWorkflowEngine(gateway.URL, http.DefaultClient)
+	engine.DefineWorkflow(WorkflowDefinition{
+		Name: "with-fallback",
+		Steps: []WorkflowStep{
+			{Name: "try", Function: "risky", OnFailure: "fallback"},
+			{Name: "fallback", Function: "safe"},
+		},
+	})
+
+	id, _ := engine.Start("with-fallback", nil)
+
+	exec := waitForExecution(t, engine, id, WorkflowCompleted)
+	if len(exec.Steps) != 2 {
+		t.Fatalf("want 2 step results (try, fallback), got %d", len(exec.Steps))
+	}
+	if exec.Steps[1].Step != "fallback" {
+		t.Errorf("want second step to be fallback, got %s", exec.Steps[1].Step)
+	}
+}
+
+func Test_WorkflowEngine_RetriesBeforeFailing(t *testing.T) {
+	var attempts int32
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer gateway.Close()
+
+	engine := NewWorkflowEngine(gateway.URL, http.DefaultClient)
+	engine.DefineWorkflow(WorkflowDefinition{
+		Name: "flaky",
+		Steps: []WorkflowStep{
+			{Name: "step1", Function: "unstable", Retries: 2},
+		},
+	})
+
+	id, _ := engine.Start("flaky", nil)
+
+	exec := waitForExecution(t, engine, id, WorkflowFailed)
+	if exec.Steps[0].Attempts != 3 {
+		t.Errorf("want 3 attempts (1 + 2 retries), got %d", exec.Steps[0].Attempts)
+	}
+	if atomic.LoadInt32(&attempts) != 3 {
+		t.Errorf("want 3 calls to the function, got %d", attempts)
+	}
+}
+
+func Test_WorkflowEngine_CancelStopsExecution(t *testing.T) {
+	block := make(chan struct{})
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+		w.Write([]byte("late"))
+	}))
+	defer gateway.Close()
+
+	engine := NewWorkflowEngine(gateway.URL, http.DefaultClient)
+	engine.DefineWorkflow(WorkflowDefinition{
+		Name: "slow",
+		Steps: []WorkflowStep{
+			{Name: "step1", Function: "slow-fn", OnSuccess: "step2"},
+			{Name: "step2", Function: "slow-fn"},
+		},
+	})
+
+	id, _ := engine.Start("slow", nil)
+
+	if !engine.Cancel(id) {
+		t.Fatal("want Cancel to succeed on a running execution")
+	}
+	close(block)
+
+	time.Sleep(50 * time.Millisecond)
+	exec, _ := engine.GetExecution(id)
+	if exec.Status != WorkflowCancelled {
+		t.Errorf("want status cancelled, got %s", exec.Status)
+	}
+}
+
+func Test_WorkflowEngine_StartUnknownWorkflowFails(t *testing.T) {
+	engine := NewWorkflowEngine("http://gateway.local", http.DefaultClient)
+
+	if _, err := engine.Start("missing", nil); err == nil {
+		t.Fatal("want an error starting an undefined workflow")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// MakeDefineWorkflowHandler registers or replaces a workflow definition
+// POSTed as JSON to /system/workflows.
+func MakeDefineWorkflowHandler(engine *WorkflowEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		var def WorkflowDefinition
+		if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+			http.Error(w, "invalid workflow definition: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(def.Name) == 0 {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		if len(def.Steps) == 0 {
+			http.Error(w, "steps must list at least one step", http.StatusBadRequest)
+			return
+		}
+
+		engine.DefineWorkflow(def)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// MakeStartWorkflowHandler starts an execution of the workflow named in
+// the URL path, POST /system/workflows/{name}/start, with the request
+// body as the first step's input.
+func MakeStartWorkflowHandler(engine *WorkflowEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		name := mux.Vars(r)["name"]
+		input, _ := ioutil.ReadAll(r.Body)
+
+		id, err := engine.Start(name, input)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"id": id})
+	}
+}
+
+// MakeGetWorkflowExecutionHandler reports an execution's current status
+// and per-step results, GET /system/workflows/executions/{id}.
+func MakeGetWorkflowExecutionHandler(engine *WorkflowEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		exec, exists := engine.GetExecution(id)
+		if !exists {
+			http.Error(w, "execution not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(exec)
+	}
+}
+
+// MakeCancelWorkflowExecutionHandler stops a running execution,
+// DELETE /system/workflows/executions/{id}.
+func MakeCancelWorkflowExecutionHandler(engine *WorkflowEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		if !engine.Cancel(id) {
+			http.Error(w, "execution not found or already finished", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package logging provides a structured alternative to this codebase's
+// prevailing log.Printf("[Tag] message", args...) style, for handlers
+// that want a request's correlation ID (its X-Call-Id, see
+// handlers.MakeCallIDMiddleware) attached to every line it logs rather
+// than folded into a formatted string.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fields is a set of structured key/value pairs attached to a log line,
+// in addition to its event and call ID.
+type Fields map[string]interface{}
+
+// Logger writes one structured log line per call. event is a short,
+// stable, snake_case identifier (e.g. "scale_from_zero_requested"),
+// distinct from the free-form messages log.Printf call sites elsewhere in
+// this codebase use, so a line can be matched on regardless of log
+// format. callID is typically a request's X-Call-Id and may be empty.
+type Logger interface {
+	Log(event string, callID string, fields Fields)
+}
+
+// consoleLogger renders a line in the same single-line, human-readable
+// shape this codebase's existing log.Printf calls already produce,
+// appending fields as key=value pairs so nothing not already shown some
+// other way is lost.
+type consoleLogger struct {
+	out io.Writer
+}
+
+// Log implements Logger.
+func (c consoleLogger) Log(event string, callID string, fields Fields) {
+	var b strings.Builder
+	b.WriteString(event)
+
+	if callID != "" {
+		fmt.Fprintf(&b, " call_id=%s", callID)
+	}
+
+	for _, key := range sortedKeys(fields) {
+		fmt.Fprintf(&b, " %s=%v", key, fields[key])
+	}
+
+	fmt.Fprintf(c.out, "%s %s\n", time.Now().UTC().Format(time.RFC3339), b.String())
+}
+
+// jsonLine is what jsonLogger.Log marshals - event, call_id and every
+// field as sibling top-level keys, rather than a nested "fields" object,
+// so a log shipper's field extraction doesn't need to know this
+// package's shape to pull out e.g. "function_name".
+type jsonLine struct {
+	Time   string                 `json:"time"`
+	Event  string                 `json:"event"`
+	CallID string                 `json:"call_id,omitempty"`
+	Fields map[string]interface{} `json:"-"`
+}
+
+// jsonLogger renders each line as a single JSON object, for log
+// aggregators (ELK, Loki, CloudWatch Logs Insights) that parse structured
+// fields out of JSON far more reliably than out of consoleLogger's
+// key=value text.
+type jsonLogger struct {
+	out io.Writer
+}
+
+// Log implements Logger.
+func (j jsonLogger) Log(event string, callID string, fields Fields) {
+	line := map[string]interface{}{
+		"time":  time.Now().UTC().Format(time.RFC3339),
+		"event": event,
+	}
+	if callID != "" {
+		line["call_id"] = callID
+	}
+	for key, value := range fields {
+		line[key] = value
+	}
+
+	encoded, err := json.Marshal(line)
+	if err != nil {
+		// A field value that can't be marshalled (e.g. a channel) would
+		// otherwise drop the line entirely - fall back to just the part
+		// that's always safe to encode instead of losing it.
+		encoded, _ = json.Marshal(jsonLine{Time: line["time"].(string), Event: event, CallID: callID})
+	}
+	fmt.Fprintln(j.out, string(encoded))
+}
+
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// New builds a Logger writing to os.Stdout. format of "json" selects
+// structured JSON lines; anything else (including the empty string)
+// keeps this codebase's existing console-style output, so deployments
+// that don't set log_format see no change in their logs.
+func New(format string) Logger {
+	if format == "json" {
+		return jsonLogger{out: os.Stdout}
+	}
+	return consoleLogger{out: os.Stdout}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_ConsoleLogger_WritesEventCallIDAndFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := consoleLogger{out: &buf}
+
+	logger.Log("scale_from_zero_requested", "call-1", Fields{"function_name": "figlet"})
+
+	out := buf.String()
+	if !strings.Contains(out, "scale_from_zero_requested") || !strings.Contains(out, "call_id=call-1") || !strings.Contains(out, "function_name=figlet") {
+		t.Fatalf("unexpected console output: %s", out)
+	}
+}
+
+func Test_JSONLogger_EncodesFieldsAsTopLevelKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := jsonLogger{out: &buf}
+
+	logger.Log("scale_from_zero_requested", "call-1", Fields{"function_name": "figlet"})
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("unexpected error decoding output: %s", err)
+	}
+
+	if decoded["event"] != "scale_from_zero_requested" {
+		t.Errorf("want event field, got %+v", decoded)
+	}
+	if decoded["call_id"] != "call-1" {
+		t.Errorf("want call_id field, got %+v", decoded)
+	}
+	if decoded["function_name"] != "figlet" {
+		t.Errorf("want function_name promoted to a top-level field, got %+v", decoded)
+	}
+}
+
+func Test_New_SelectsImplementationByFormat(t *testing.T) {
+	if _, ok := New("json").(jsonLogger); !ok {
+		t.Error("want New(\"json\") to return a jsonLogger")
+	}
+	if _, ok := New("console").(consoleLogger); !ok {
+		t.Error("want New(\"console\") to return a consoleLogger")
+	}
+	if _, ok := New("").(consoleLogger); !ok {
+		t.Error("want New(\"\") to default to a consoleLogger")
+	}
+}
+
+func Test_ConsoleLogger_OmitsEmptyCallID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := consoleLogger{out: &buf}
+
+	logger.Log("scale_query_failed", "", Fields{"function_name": "figlet"})
+
+	if strings.Contains(buf.String(), "call_id=") {
+		t.Errorf("want no call_id field for an empty call ID, got: %s", buf.String())
+	}
+}
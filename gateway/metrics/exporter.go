@@ -40,6 +40,11 @@ func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	e.metricOptions.GatewayFunctionInvocation.Describe(ch)
 	e.metricOptions.GatewayFunctionsHistogram.Describe(ch)
 	e.metricOptions.ServiceReplicasGauge.Describe(ch)
+	e.metricOptions.GatewayBufferedRequests.Describe(ch)
+	e.metricOptions.GatewayDroppedRequests.Describe(ch)
+	e.metricOptions.ExperimentAssignments.Describe(ch)
+	e.metricOptions.APIKeyUsage.Describe(ch)
+	e.metricOptions.AsyncStateReclaimed.Describe(ch)
 }
 
 // Collect collects data to be consumed by prometheus
@@ -54,6 +59,12 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 			Set(float64(service.Replicas))
 	}
 	e.metricOptions.ServiceReplicasGauge.Collect(ch)
+
+	e.metricOptions.GatewayBufferedRequests.Collect(ch)
+	e.metricOptions.GatewayDroppedRequests.Collect(ch)
+	e.metricOptions.ExperimentAssignments.Collect(ch)
+	e.metricOptions.APIKeyUsage.Collect(ch)
+	e.metricOptions.AsyncStateReclaimed.Collect(ch)
 }
 
 // StartServiceWatcher starts a ticker and collects service replica counts to expose to prometheus
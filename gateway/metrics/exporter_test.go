@@ -58,6 +58,36 @@ func Test_Describe_DescribesThePrometheusMetrics(t *testing.T) {
 	if expectedServiceReplicasGaugeDesc != actualServiceReplicasGaugeDesc {
 		t.Errorf("Want %s, got: %s", expectedServiceReplicasGaugeDesc, actualServiceReplicasGaugeDesc)
 	}
+	d = (<-ch)
+	expectedGatewayBufferedRequestsDesc := `Desc{fqName: "gateway_provider_buffered_requests_total", help: "Requests held and retried while the provider was briefly unavailable", constLabels: {}, variableLabels: []}`
+	actualGatewayBufferedRequestsDesc := d.String()
+	if expectedGatewayBufferedRequestsDesc != actualGatewayBufferedRequestsDesc {
+		t.Errorf("Want %s, got: %s", expectedGatewayBufferedRequestsDesc, actualGatewayBufferedRequestsDesc)
+	}
+	d = (<-ch)
+	expectedGatewayDroppedRequestsDesc := `Desc{fqName: "gateway_provider_dropped_requests_total", help: "Requests given up on after the provider stayed unavailable past the buffering window", constLabels: {}, variableLabels: []}`
+	actualGatewayDroppedRequestsDesc := d.String()
+	if expectedGatewayDroppedRequestsDesc != actualGatewayDroppedRequestsDesc {
+		t.Errorf("Want %s, got: %s", expectedGatewayDroppedRequestsDesc, actualGatewayDroppedRequestsDesc)
+	}
+	d = (<-ch)
+	expectedExperimentAssignmentsDesc := `Desc{fqName: "gateway_experiment_assignments_total", help: "Invocations assigned to each variant of a running A/B experiment", constLabels: {}, variableLabels: [experiment_name function_name variant]}`
+	actualExperimentAssignmentsDesc := d.String()
+	if expectedExperimentAssignmentsDesc != actualExperimentAssignmentsDesc {
+		t.Errorf("Want %s, got: %s", expectedExperimentAssignmentsDesc, actualExperimentAssignmentsDesc)
+	}
+	d = (<-ch)
+	expectedAPIKeyUsageDesc := `Desc{fqName: "gateway_api_key_usage_total", help: "Authenticated invocations per managed API key", constLabels: {}, variableLabels: [api_key_id]}`
+	actualAPIKeyUsageDesc := d.String()
+	if expectedAPIKeyUsageDesc != actualAPIKeyUsageDesc {
+		t.Errorf("Want %s, got: %s", expectedAPIKeyUsageDesc, actualAPIKeyUsageDesc)
+	}
+	d = (<-ch)
+	expectedAsyncStateReclaimedDesc := `Desc{fqName: "gateway_async_state_reclaimed_total", help: "Orphaned async state reclaimed by the async state janitor, per store", constLabels: {}, variableLabels: [store]}`
+	actualAsyncStateReclaimedDesc := d.String()
+	if expectedAsyncStateReclaimedDesc != actualAsyncStateReclaimedDesc {
+		t.Errorf("Want %s, got: %s", expectedAsyncStateReclaimedDesc, actualAsyncStateReclaimedDesc)
+	}
 }
 
 func Test_Collect_CollectsTheNumberOfReplicasOfAService(t *testing.T) {
@@ -85,4 +115,8 @@ func Test_Collect_CollectsTheNumberOfReplicasOfAService(t *testing.T) {
 	if expectedReplicas != result.value {
 		t.Errorf("Want %f, got %f", expectedReplicas, result.value)
 	}
+
+	// Drain the buffered/dropped request counters that follow the gauge.
+	<-ch
+	<-ch
 }
@@ -14,6 +14,75 @@ type MetricOptions struct {
 	GatewayFunctionInvocation *prometheus.CounterVec
 	GatewayFunctionsHistogram *prometheus.HistogramVec
 	ServiceReplicasGauge      *prometheus.GaugeVec
+
+	// GatewayBufferedRequests counts requests held while the provider was
+	// briefly unavailable and later retried successfully.
+	GatewayBufferedRequests prometheus.Counter
+
+	// GatewayDroppedRequests counts requests given up on because the
+	// provider stayed unavailable past the configured buffering window.
+	GatewayDroppedRequests prometheus.Counter
+
+	// ExperimentAssignments counts how many invocations were assigned to
+	// each variant of each running A/B experiment.
+	ExperimentAssignments *prometheus.CounterVec
+
+	// APIKeyUsage counts authenticated invocations per managed API key,
+	// for per-tenant usage metering.
+	APIKeyUsage *prometheus.CounterVec
+
+	// QueuePublishHistogram times how long the gateway's own call to the
+	// async queue provider's Queue method took, per function.
+	QueuePublishHistogram *prometheus.HistogramVec
+
+	// QueuePublishErrors counts failed calls to the queue provider's
+	// Queue method, per function.
+	QueuePublishErrors *prometheus.CounterVec
+
+	// QueueConnectionState is 1 while the gateway believes its queue
+	// provider connection is up, 0 otherwise. It is only ever set once,
+	// right after the initial connection attempt - the NATS Streaming
+	// client vendored in this tree (nats-queue-worker/handler.NatsQueue)
+	// doesn't expose its underlying connection or a reconnect/disconnect
+	// hook, so this gauge can't track state changes after that, and
+	// there is deliberately no reconnect-count or consumer-lag metric
+	// here: neither is observable from gateway's side of that interface,
+	// and consumer lag in particular is the queue-worker process's own
+	// state, not the gateway's.
+	QueueConnectionState prometheus.Gauge
+
+	// CircuitBreakerState reports each function's circuit breaker state -
+	// 0 closed, 1 open, 2 half-open, matching handlers.CircuitBreakerState.
+	CircuitBreakerState *prometheus.GaugeVec
+
+	// AsyncStateReclaimed counts orphaned async state reclaimed by
+	// handlers.AsyncStateJanitor, per store it was reclaimed from
+	// ("call_status", "pending" or "dead_letter").
+	AsyncStateReclaimed *prometheus.CounterVec
+
+	// ColdStartDurationHistogram times scale-from-zero cycles, labelled
+	// by function name and outcome ("ready", "timeout" or "error"), so
+	// operators can alert on cold-start regressions separately from
+	// gateway_functions_seconds, which only covers requests that already
+	// found a warm replica.
+	ColdStartDurationHistogram *prometheus.HistogramVec
+
+	// ClientRateLimitRejections counts requests rejected by
+	// handlers.MakeClientRateLimitHandler. Not labelled by client
+	// identity, which is unbounded cardinality - this is a single
+	// counter for alerting on sustained throttling, not a per-client
+	// breakdown.
+	ClientRateLimitRejections prometheus.Counter
+
+	// QuotaRejections counts requests rejected by
+	// handlers.MakeQuotaHandler for exceeding a daily or monthly
+	// invocation quota, distinct from ClientRateLimitRejections which
+	// only tracks instantaneous throttling.
+	QuotaRejections prometheus.Counter
+
+	// ResponseCacheHits counts GET requests served from
+	// handlers.ResponseCacheStore instead of reaching the function.
+	ResponseCacheHits prometheus.Counter
 }
 
 // PrometheusHandler Bootstraps prometheus for metrics collection
@@ -44,16 +113,109 @@ func BuildMetricsOptions() MetricOptions {
 		[]string{"function_name"},
 	)
 
+	gatewayBufferedRequests := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_provider_buffered_requests_total",
+		Help: "Requests held and retried while the provider was briefly unavailable",
+	})
+
+	gatewayDroppedRequests := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_provider_dropped_requests_total",
+		Help: "Requests given up on after the provider stayed unavailable past the buffering window",
+	})
+
+	experimentAssignments := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_experiment_assignments_total",
+			Help: "Invocations assigned to each variant of a running A/B experiment",
+		},
+		[]string{"experiment_name", "function_name", "variant"},
+	)
+
+	apiKeyUsage := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_api_key_usage_total",
+			Help: "Authenticated invocations per managed API key",
+		},
+		[]string{"api_key_id"},
+	)
+
+	queuePublishHistogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "gateway_queue_publish_seconds",
+		Help: "Time taken by the gateway to publish an async invocation to the queue provider",
+	}, []string{"function_name"})
+
+	queuePublishErrors := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_queue_publish_errors_total",
+			Help: "Failed calls to the queue provider's Queue method",
+		},
+		[]string{"function_name"},
+	)
+
+	queueConnectionState := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_queue_connection_state",
+		Help: "1 if the gateway's queue provider connection was established, 0 otherwise",
+	})
+
+	circuitBreakerState := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gateway_function_circuit_breaker_state",
+			Help: "Per-function circuit breaker state: 0 closed, 1 open, 2 half-open",
+		},
+		[]string{"function_name"},
+	)
+
+	asyncStateReclaimed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_async_state_reclaimed_total",
+			Help: "Orphaned async state reclaimed by the async state janitor, per store",
+		},
+		[]string{"store"},
+	)
+
+	coldStartDurationHistogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "gateway_function_cold_start_duration_seconds",
+		Help: "Time taken for a scale-from-zero cycle to finish, by outcome",
+	}, []string{"function_name", "outcome"})
+
+	clientRateLimitRejections := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_client_rate_limit_rejections_total",
+		Help: "Requests rejected by the per-client rate limiter",
+	})
+
+	quotaRejections := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_quota_rejections_total",
+		Help: "Requests rejected for exceeding a daily or monthly invocation quota",
+	})
+
+	responseCacheHits := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_response_cache_hits_total",
+		Help: "GET requests served from the response cache instead of reaching the function",
+	})
+
 	metricsOptions := MetricOptions{
-		GatewayFunctionsHistogram: gatewayFunctionsHistogram,
-		GatewayFunctionInvocation: gatewayFunctionInvocation,
-		ServiceReplicasGauge:      serviceReplicas,
+		GatewayFunctionsHistogram:  gatewayFunctionsHistogram,
+		GatewayFunctionInvocation:  gatewayFunctionInvocation,
+		ServiceReplicasGauge:       serviceReplicas,
+		GatewayBufferedRequests:    gatewayBufferedRequests,
+		GatewayDroppedRequests:     gatewayDroppedRequests,
+		ExperimentAssignments:      experimentAssignments,
+		APIKeyUsage:                apiKeyUsage,
+		QueuePublishHistogram:      queuePublishHistogram,
+		QueuePublishErrors:         queuePublishErrors,
+		QueueConnectionState:       queueConnectionState,
+		CircuitBreakerState:        circuitBreakerState,
+		AsyncStateReclaimed:        asyncStateReclaimed,
+		ColdStartDurationHistogram: coldStartDurationHistogram,
+		ClientRateLimitRejections:  clientRateLimitRejections,
+		QuotaRejections:            quotaRejections,
+		ResponseCacheHits:          responseCacheHits,
 	}
 
 	return metricsOptions
 }
 
-//RegisterMetrics registers with Prometheus for tracking
+// RegisterMetrics registers with Prometheus for tracking
 func RegisterExporter(exporter *Exporter) {
 	prometheus.MustRegister(exporter)
 }
@@ -0,0 +1,46 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package mockprovider
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/server"
+	"github.com/openfaas/faas/gateway/types"
+)
+
+// StartGateway builds a full gateway (server.New) in-process, pointed at
+// provider, and returns an httptest.Server for it plus the Provider so the
+// caller can seed/inspect function state. The returned servers are closed
+// automatically via t.Cleanup.
+func StartGateway(t *testing.T, provider *Provider, opts ...server.Option) *httptest.Server {
+	t.Helper()
+
+	providerServer := provider.NewServer()
+	t.Cleanup(providerServer.Close)
+
+	providerURL, err := url.Parse(providerServer.URL + "/")
+	if err != nil {
+		t.Fatalf("unable to parse mock provider URL: %s", err.Error())
+	}
+
+	config := types.GatewayConfig{
+		FunctionsProviderURL: providerURL,
+		UpstreamTimeout:      time.Second * 5,
+		ScaleFromZero:        true,
+	}
+
+	gatewayServer, err := server.New(config, nil, opts...)
+	if err != nil {
+		t.Fatalf("unable to build gateway: %s", err.Error())
+	}
+
+	ts := httptest.NewServer(gatewayServer.Router)
+	t.Cleanup(ts.Close)
+
+	return ts
+}
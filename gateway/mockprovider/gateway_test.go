@@ -0,0 +1,26 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package mockprovider
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_StartGateway_ListsASeededFunctionThroughTheRealRouter(t *testing.T) {
+	provider := NewProvider()
+	provider.Seed(requests.Function{Name: "figlet", Image: "functions/figlet:latest", AvailableReplicas: 1})
+
+	gateway := StartGateway(t, provider)
+
+	res, err := http.Get(gateway.URL + "/system/function/figlet")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 from the gateway's query-function route, got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package mockprovider is an in-memory implementation of the subset of the
+// faas-provider REST contract the gateway depends on (list/deploy/update/
+// delete/scale), plus a handlers.ServiceQuery backed by the same state
+// without going over HTTP. It lets tests exercise gateway middleware,
+// policies and scaling behaviour against a real provider without
+// Kubernetes, Docker Swarm or any other orchestrator.
+package mockprovider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/openfaas/faas/gateway/handlers"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// Provider is an in-memory store of deployed functions, addressable either
+// as an http.Handler implementing the provider REST contract, or directly
+// as a handlers.ServiceQuery via ServiceQuery().
+type Provider struct {
+	lock      sync.Mutex
+	functions map[string]requests.Function
+}
+
+// NewProvider creates an empty Provider.
+func NewProvider() *Provider {
+	return &Provider{functions: make(map[string]requests.Function)}
+}
+
+// Seed registers a function directly, bypassing the deploy endpoint - for
+// setting up fixture state before a test starts.
+func (p *Provider) Seed(fn requests.Function) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.functions[fn.Name] = fn
+}
+
+// SetAvailableReplicas updates a seeded/deployed function's available
+// replica count, e.g. to simulate a function finishing a scale-from-zero
+// poll.
+func (p *Provider) SetAvailableReplicas(name string, availableReplicas uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	fn, exists := p.functions[name]
+	if !exists {
+		return
+	}
+	fn.AvailableReplicas = availableReplicas
+	p.functions[name] = fn
+}
+
+// NewServer starts the Provider as an httptest.Server implementing the
+// faas-provider REST contract. Callers must Close it.
+func (p *Provider) NewServer() *httptest.Server {
+	return httptest.NewServer(p.router())
+}
+
+// ServiceQuery returns a handlers.ServiceQuery backed directly by this
+// Provider's in-memory state, for tests that want to exercise scaling logic
+// without an HTTP round-trip.
+func (p *Provider) ServiceQuery() handlers.ServiceQuery {
+	return providerServiceQuery{provider: p}
+}
+
+func (p *Provider) router() *mux.Router {
+	r := mux.NewRouter()
+
+	r.HandleFunc("/system/functions", p.listFunctions).Methods(http.MethodGet)
+	r.HandleFunc("/system/functions", p.deployFunction).Methods(http.MethodPost)
+	r.HandleFunc("/system/functions", p.updateFunction).Methods(http.MethodPut)
+	r.HandleFunc("/system/functions", p.deleteFunction).Methods(http.MethodDelete)
+	r.HandleFunc("/system/function/{name}", p.getFunction).Methods(http.MethodGet)
+	r.HandleFunc("/system/scale-function/{name}", p.scaleFunction).Methods(http.MethodPost)
+
+	return r
+}
+
+func (p *Provider) listFunctions(w http.ResponseWriter, r *http.Request) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	out := make([]requests.Function, 0, len(p.functions))
+	for _, fn := range p.functions {
+		out = append(out, fn)
+	}
+
+	json.NewEncoder(w).Encode(out)
+}
+
+func (p *Provider) getFunction(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	p.lock.Lock()
+	fn, exists := p.functions[name]
+	p.lock.Unlock()
+
+	if !exists {
+		http.Error(w, "function not found: "+name, http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(fn)
+}
+
+func (p *Provider) deployFunction(w http.ResponseWriter, r *http.Request) {
+	var req requests.CreateFunctionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.lock.Lock()
+	p.functions[req.Service] = requests.Function{
+		Name:              req.Service,
+		Image:             req.Image,
+		EnvProcess:        req.EnvProcess,
+		Replicas:          1,
+		AvailableReplicas: 1,
+		Labels:            req.Labels,
+		Annotations:       req.Annotations,
+	}
+	p.lock.Unlock()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (p *Provider) updateFunction(w http.ResponseWriter, r *http.Request) {
+	var req requests.CreateFunctionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.lock.Lock()
+	fn, exists := p.functions[req.Service]
+	p.lock.Unlock()
+
+	if !exists {
+		http.Error(w, "function not found: "+req.Service, http.StatusNotFound)
+		return
+	}
+
+	fn.Image = req.Image
+	fn.EnvProcess = req.EnvProcess
+	fn.Labels = req.Labels
+	fn.Annotations = req.Annotations
+
+	p.lock.Lock()
+	p.functions[req.Service] = fn
+	p.lock.Unlock()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (p *Provider) deleteFunction(w http.ResponseWriter, r *http.Request) {
+	var req requests.DeleteFunctionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.lock.Lock()
+	_, exists := p.functions[req.FunctionName]
+	delete(p.functions, req.FunctionName)
+	p.lock.Unlock()
+
+	if !exists {
+		http.Error(w, "function not found: "+req.FunctionName, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// scaleServiceRequest mirrors plugin.ScaleServiceRequest's JSON shape -
+// duplicated rather than imported to avoid a dependency from this test
+// helper package back onto the plugin package it's meant to stand in for.
+type scaleServiceRequest struct {
+	ServiceName string `json:"serviceName"`
+	Replicas    uint64 `json:"replicas"`
+}
+
+func (p *Provider) scaleFunction(w http.ResponseWriter, r *http.Request) {
+	var req scaleServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+
+	p.lock.Lock()
+	fn, exists := p.functions[name]
+	if exists {
+		fn.Replicas = req.Replicas
+		fn.AvailableReplicas = req.Replicas
+		p.functions[name] = fn
+	}
+	p.lock.Unlock()
+
+	if !exists {
+		http.Error(w, "function not found: "+name, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// providerServiceQuery adapts Provider to handlers.ServiceQuery directly
+// against its in-memory map, bypassing HTTP.
+type providerServiceQuery struct {
+	provider *Provider
+}
+
+func (q providerServiceQuery) GetReplicas(service string) (handlers.ServiceQueryResponse, error) {
+	q.provider.lock.Lock()
+	fn, exists := q.provider.functions[service]
+	q.provider.lock.Unlock()
+
+	if !exists {
+		return handlers.ServiceQueryResponse{}, handlers.ErrFunctionNotFound
+	}
+
+	return handlers.ServiceQueryResponse{
+		Replicas:          fn.Replicas,
+		MaxReplicas:       handlers.DefaultMaxReplicas,
+		MinReplicas:       handlers.DefaultMinReplicas,
+		ScalingFactor:     handlers.DefaultScalingFactor,
+		AvailableReplicas: fn.AvailableReplicas,
+	}, nil
+}
+
+func (q providerServiceQuery) SetReplicas(service string, count uint64) error {
+	q.provider.lock.Lock()
+	defer q.provider.lock.Unlock()
+
+	fn, exists := q.provider.functions[service]
+	if !exists {
+		return handlers.ErrFunctionNotFound
+	}
+
+	fn.Replicas = count
+	fn.AvailableReplicas = count
+	q.provider.functions[service] = fn
+
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package mockprovider
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_Provider_DeployListAndDeleteOverHTTP(t *testing.T) {
+	provider := NewProvider()
+	ts := provider.NewServer()
+	defer ts.Close()
+
+	createBody, _ := json.Marshal(requests.CreateFunctionRequest{Service: "figlet", Image: "functions/figlet:latest"})
+	res, err := http.Post(ts.URL+"/system/functions", "application/json", bytes.NewReader(createBody))
+	if err != nil {
+		t.Fatalf("unexpected error deploying: %s", err.Error())
+	}
+	if res.StatusCode != http.StatusAccepted {
+		t.Fatalf("want 202 deploying, got %d", res.StatusCode)
+	}
+
+	listRes, err := http.Get(ts.URL + "/system/functions")
+	if err != nil {
+		t.Fatalf("unexpected error listing: %s", err.Error())
+	}
+	var listed []requests.Function
+	body, _ := ioutil.ReadAll(listRes.Body)
+	if err := json.Unmarshal(body, &listed); err != nil {
+		t.Fatalf("unable to decode function list: %s", err.Error())
+	}
+	if len(listed) != 1 || listed[0].Name != "figlet" {
+		t.Fatalf("want figlet in the function list, got %+v", listed)
+	}
+
+	deleteBody, _ := json.Marshal(requests.DeleteFunctionRequest{FunctionName: "figlet"})
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/system/functions", bytes.NewReader(deleteBody))
+	deleteRes, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error deleting: %s", err.Error())
+	}
+	if deleteRes.StatusCode != http.StatusAccepted {
+		t.Fatalf("want 202 deleting, got %d", deleteRes.StatusCode)
+	}
+
+	getRes, _ := http.Get(ts.URL + "/system/function/figlet")
+	if getRes.StatusCode != http.StatusNotFound {
+		t.Fatalf("want 404 for a deleted function, got %d", getRes.StatusCode)
+	}
+}
+
+func Test_Provider_ServiceQueryReflectsScaling(t *testing.T) {
+	provider := NewProvider()
+	provider.Seed(requests.Function{Name: "figlet", AvailableReplicas: 0})
+
+	query := provider.ServiceQuery()
+
+	resp, err := query.GetReplicas("figlet")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if resp.AvailableReplicas != 0 {
+		t.Fatalf("want 0 available replicas before scaling, got %d", resp.AvailableReplicas)
+	}
+
+	if err := query.SetReplicas("figlet", 3); err != nil {
+		t.Fatalf("unexpected error scaling: %s", err.Error())
+	}
+
+	resp, err = query.GetReplicas("figlet")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if resp.AvailableReplicas != 3 {
+		t.Fatalf("want 3 available replicas after scaling, got %d", resp.AvailableReplicas)
+	}
+}
+
+func Test_Provider_ServiceQueryReturnsNotFoundForUnknownFunction(t *testing.T) {
+	provider := NewProvider()
+	query := provider.ServiceQuery()
+
+	if _, err := query.GetReplicas("missing"); err == nil {
+		t.Fatal("want an error for an unknown function")
+	}
+}
@@ -62,7 +62,6 @@ type ScaleServiceRequest struct {
 
 // GetReplicas replica count for function
 func (s ExternalServiceQuery) GetReplicas(serviceName string) (handlers.ServiceQueryResponse, error) {
-	var err error
 	var emptyServiceQueryResponse handlers.ServiceQueryResponse
 
 	function := requests.Function{}
@@ -79,27 +78,33 @@ func (s ExternalServiceQuery) GetReplicas(serviceName string) (handlers.ServiceQ
 
 	if err != nil {
 		log.Println(urlPath, err)
-	} else {
+		return emptyServiceQueryResponse, fmt.Errorf("%s: %w", err.Error(), handlers.ErrProviderUnavailable)
+	}
 
-		if res.Body != nil {
-			defer res.Body.Close()
-		}
+	if res.Body != nil {
+		defer res.Body.Close()
+	}
 
-		if res.StatusCode == http.StatusOK {
-			bytesOut, _ := ioutil.ReadAll(res.Body)
-			err = json.Unmarshal(bytesOut, &function)
-			if err != nil {
-				log.Println(urlPath, err)
-			}
-		} else {
-			return emptyServiceQueryResponse, fmt.Errorf("server returned non-200 status code (%d) for function, %s", res.StatusCode, serviceName)
-		}
+	if res.StatusCode == http.StatusNotFound {
+		return emptyServiceQueryResponse, fmt.Errorf("%s: %w", serviceName, handlers.ErrFunctionNotFound)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return emptyServiceQueryResponse, fmt.Errorf("server returned non-200 status code (%d) for function, %s: %w", res.StatusCode, serviceName, handlers.ErrProviderUnavailable)
+	}
+
+	bytesOut, _ := ioutil.ReadAll(res.Body)
+	if err := json.Unmarshal(bytesOut, &function); err != nil {
+		log.Println(urlPath, err)
+		return emptyServiceQueryResponse, fmt.Errorf("%s: %w", err.Error(), handlers.ErrProviderUnavailable)
 	}
 
 	minReplicas := uint64(handlers.DefaultMinReplicas)
 	maxReplicas := uint64(handlers.DefaultMaxReplicas)
 	scalingFactor := uint64(handlers.DefaultScalingFactor)
 	availableReplicas := function.AvailableReplicas
+	var pollInterval time.Duration
+	var maxPollCount uint
 
 	if function.Labels != nil {
 		labels := *function.Labels
@@ -113,6 +118,9 @@ func (s ExternalServiceQuery) GetReplicas(serviceName string) (handlers.ServiceQ
 		} else {
 			log.Printf("Bad Scaling Factor: %d, is not in range of [0 - 100]. Will fallback to %d", extractedScalingFactor, scalingFactor)
 		}
+
+		pollInterval = extractPollInterval(labels[handlers.PollIntervalLabel])
+		maxPollCount = uint(extractLabelValue(labels[handlers.MaxPollCountLabel], 0))
 	}
 
 	return handlers.ServiceQueryResponse{
@@ -121,7 +129,9 @@ func (s ExternalServiceQuery) GetReplicas(serviceName string) (handlers.ServiceQ
 		MinReplicas:       minReplicas,
 		ScalingFactor:     scalingFactor,
 		AvailableReplicas: availableReplicas,
-	}, err
+		PollInterval:      pollInterval,
+		MaxPollCount:      maxPollCount,
+	}, nil
 }
 
 // SetReplicas update the replica count
@@ -150,19 +160,41 @@ func (s ExternalServiceQuery) SetReplicas(serviceName string, count uint64) erro
 
 	if err != nil {
 		log.Println(urlPath, err)
-	} else {
-		if res.Body != nil {
-			defer res.Body.Close()
-		}
+		return fmt.Errorf("%s: %w", err.Error(), handlers.ErrProviderUnavailable)
+	}
+
+	if res.Body != nil {
+		defer res.Body.Close()
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", serviceName, handlers.ErrFunctionNotFound)
 	}
 
 	if !(res.StatusCode == http.StatusOK || res.StatusCode == http.StatusAccepted) {
-		err = fmt.Errorf("error scaling HTTP code %d, %s", res.StatusCode, urlPath)
+		err = fmt.Errorf("error scaling HTTP code %d, %s: %w", res.StatusCode, urlPath, handlers.ErrProviderUnavailable)
 	}
 
 	return err
 }
 
+// extractPollInterval parses rawLabelValue (e.g. "5ms", "2s") as a
+// time.Duration, returning zero - meaning "no override" - if it's absent
+// or malformed.
+func extractPollInterval(rawLabelValue string) time.Duration {
+	if len(rawLabelValue) == 0 {
+		return 0
+	}
+
+	value, err := time.ParseDuration(rawLabelValue)
+	if err != nil {
+		log.Printf("Provided label value %s should be a duration, e.g. 5ms", rawLabelValue)
+		return 0
+	}
+
+	return value
+}
+
 // extractLabelValue will parse the provided raw label value and if it fails
 // it will return the provided fallback value and log an message
 func extractLabelValue(rawLabelValue string, fallback uint64) uint64 {
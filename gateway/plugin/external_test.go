@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/openfaas/faas-provider/auth"
 	"github.com/openfaas/faas/gateway/handlers"
@@ -96,6 +97,71 @@ func TestGetReplicasExistentFn(t *testing.T) {
 	}
 }
 
+func TestExtractPollIntervalEmpty(t *testing.T) {
+	extractedValue := extractPollInterval("")
+
+	if extractedValue != 0 {
+		t.Logf("Expected extractedValue to be 0, got: %v", extractedValue)
+		t.Fail()
+	}
+}
+
+func TestExtractPollIntervalValid(t *testing.T) {
+	extractedValue := extractPollInterval("5ms")
+
+	if extractedValue != 5*time.Millisecond {
+		t.Logf("Expected extractedValue to be 5ms, got: %v", extractedValue)
+		t.Fail()
+	}
+}
+
+func TestExtractPollIntervalInvalid(t *testing.T) {
+	extractedValue := extractPollInterval("not-a-duration")
+
+	if extractedValue != 0 {
+		t.Logf("Expected extractedValue to be 0, got: %v", extractedValue)
+		t.Fail()
+	}
+}
+
+func TestGetReplicasAppliesPerFunctionPollOverrides(t *testing.T) {
+
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(http.StatusOK)
+			res.Write([]byte(`{
+				"labels": {
+					"com.openfaas.scale.poll-interval": "5ms",
+					"com.openfaas.scale.max-poll-count": "3"
+				}
+			}`))
+		}))
+	defer testServer.Close()
+
+	var creds auth.BasicAuthCredentials
+
+	url, _ := url.Parse(testServer.URL + "/")
+
+	esq := NewExternalServiceQuery(*url, &creds)
+
+	svcQryResp, err := esq.GetReplicas("burt")
+
+	if err != nil {
+		t.Logf("Expected err to be nil got: %s ", err.Error())
+		t.Fail()
+	}
+
+	if svcQryResp.PollInterval != 5*time.Millisecond {
+		t.Logf("Expected PollInterval to be 5ms, got: %v", svcQryResp.PollInterval)
+		t.Fail()
+	}
+
+	if svcQryResp.MaxPollCount != 3 {
+		t.Logf("Expected MaxPollCount to be 3, got: %v", svcQryResp.MaxPollCount)
+		t.Fail()
+	}
+}
+
 func TestSetReplicasNonExistentFn(t *testing.T) {
 
 	testServer := httptest.NewServer(
@@ -0,0 +1,90 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrJetStreamUnavailable is returned by every JetStreamProvider.Queue
+// call. This tree only vendors github.com/nats-io/go-nats and
+// github.com/nats-io/go-nats-streaming, both of which predate JetStream -
+// there is no vendored client capable of opening a JetStream context. A
+// real implementation would call nc.JetStream(), ensure JetStreamConfig's
+// stream exists (nats.StreamConfig{Name: ...}), and js.Publish into it
+// under a durable consumer with the configured ack wait. Until that client
+// is vendored, JetStreamProvider fails loudly instead of silently
+// dropping async invocations.
+var ErrJetStreamUnavailable = errors.New("NATS JetStream client is not vendored in this build")
+
+// JetStreamConfig configures a JetStreamProvider.
+type JetStreamConfig struct {
+	// NATSAddress and NATSPort locate the NATS server to connect to.
+	NATSAddress string
+	NATSPort    int
+
+	// StreamName is the JetStream stream async invocation requests are
+	// published into. Defaults to "faas-request" when empty.
+	StreamName string
+
+	// Durable names the durable consumer queue-workers subscribe with, so
+	// a worker restart resumes from its last acknowledged message
+	// instead of replaying or losing the backlog. Defaults to
+	// "faas-workers" when empty.
+	Durable string
+
+	// AckWait bounds how long JetStream waits for a consumer to ack a
+	// message before redelivering it. Defaults to 30s when zero.
+	AckWait time.Duration
+}
+
+func (c JetStreamConfig) streamName() string {
+	if len(c.StreamName) == 0 {
+		return "faas-request"
+	}
+	return c.StreamName
+}
+
+func (c JetStreamConfig) durable() string {
+	if len(c.Durable) == 0 {
+		return "faas-workers"
+	}
+	return c.Durable
+}
+
+func (c JetStreamConfig) ackWait() time.Duration {
+	if c.AckWait <= 0 {
+		return 30 * time.Second
+	}
+	return c.AckWait
+}
+
+// JetStreamProvider is a Provider backed by a NATS JetStream stream, with
+// a durable consumer so queue-workers survive restarts without losing or
+// replaying already-acknowledged messages. See ErrJetStreamUnavailable:
+// Queue always fails in this build, since no JetStream-capable client is
+// vendored here. Construction never fails, so a JetStreamProvider can
+// still be wired up as DualProvider.Secondary ahead of that client being
+// vendored, with the migration only actually moving traffic once Queue
+// stops returning ErrJetStreamUnavailable.
+type JetStreamProvider struct {
+	config JetStreamConfig
+}
+
+// NewJetStreamProvider returns a JetStreamProvider for config, applying
+// StreamName/Durable/AckWait defaults where config leaves them unset.
+func NewJetStreamProvider(config JetStreamConfig) *JetStreamProvider {
+	config.StreamName = config.streamName()
+	config.Durable = config.durable()
+	config.AckWait = config.ackWait()
+	return &JetStreamProvider{config: config}
+}
+
+// Queue always fails with ErrJetStreamUnavailable.
+func (p *JetStreamProvider) Queue(req *Request) error {
+	return fmt.Errorf("unable to queue request for %s via JetStream stream %s (durable consumer %s): %w",
+		req.Function, p.config.StreamName, p.config.Durable, ErrJetStreamUnavailable)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package queue
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrKafkaUnavailable is returned by every KafkaProvider.Queue call. No
+// Kafka client (e.g. github.com/Shopify/sarama) is vendored in this tree.
+// A real implementation would produce req, keyed by req.Function so every
+// invocation of the same function lands on the same partition and is
+// processed in order by one consumer, onto TopicPrefix+req.Function. Until
+// that client is vendored, KafkaProvider fails loudly instead of silently
+// dropping async invocations.
+var ErrKafkaUnavailable = errors.New("Kafka client is not vendored in this build")
+
+// KafkaConfig configures a KafkaProvider.
+type KafkaConfig struct {
+	// Brokers lists the Kafka bootstrap brokers, e.g.
+	// []string{"kafka:9092"}.
+	Brokers []string
+
+	// TopicPrefix is prepended to the function name to form the topic an
+	// invocation is produced to, e.g. prefix "faas." and function
+	// "figlet" produce to topic "faas.figlet". Defaults to "faas." when
+	// empty.
+	TopicPrefix string
+}
+
+func (c KafkaConfig) topicPrefix() string {
+	if len(c.TopicPrefix) == 0 {
+		return "faas."
+	}
+	return c.TopicPrefix
+}
+
+// KafkaProvider is a Provider backed by Kafka, partitioning by function
+// name (via the message key) so all invocations of one function are
+// processed in order by a single consumer. See ErrKafkaUnavailable: Queue
+// always fails in this build, since no Kafka client is vendored here.
+// Construction never fails, so a KafkaProvider can still be selected via
+// queue_provider=kafka ahead of that client being vendored.
+type KafkaProvider struct {
+	config KafkaConfig
+}
+
+// NewKafkaProvider returns a KafkaProvider for config, applying the
+// TopicPrefix default where config leaves it unset.
+func NewKafkaProvider(config KafkaConfig) *KafkaProvider {
+	config.TopicPrefix = config.topicPrefix()
+	return &KafkaProvider{config: config}
+}
+
+// Queue always fails with ErrKafkaUnavailable.
+func (p *KafkaProvider) Queue(req *Request) error {
+	return fmt.Errorf("unable to queue request for %s via Kafka topic %s%s: %w",
+		req.Function, p.config.TopicPrefix, req.Function, ErrKafkaUnavailable)
+}
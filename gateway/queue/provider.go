@@ -0,0 +1,43 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package queue
+
+import "log"
+
+// Provider is implemented by a specific async invocation queue backend -
+// NATS Streaming, NATS JetStream, Kafka, and so on. It's the same contract
+// as CanQueueRequests; Provider is the name used when there's a choice of
+// interchangeable backend, as there is during a migration from one to
+// another.
+type Provider interface {
+	CanQueueRequests
+}
+
+// DualProvider publishes every request to Primary and, when Secondary is
+// set, also to Secondary - so an operator migrating from one queue
+// backend to another (e.g. NATS Streaming to NATS JetStream) can run both
+// side-by-side: existing queue-workers keep consuming from Primary while
+// new ones are brought up against Secondary, before anything is cut over.
+// Only Primary's result is returned; Secondary failing doesn't fail the
+// request, since that queue's delivery is a migration aid, not something
+// the caller is relying on yet.
+type DualProvider struct {
+	Primary   Provider
+	Secondary Provider
+}
+
+// Queue publishes req to Primary, then - best-effort - to Secondary.
+func (d DualProvider) Queue(req *Request) error {
+	if err := d.Primary.Queue(req); err != nil {
+		return err
+	}
+
+	if d.Secondary != nil {
+		if err := d.Secondary.Queue(req); err != nil {
+			log.Printf("[Queue] secondary provider failed to queue request for %s: %s", req.Function, err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package queue
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeProvider struct {
+	err   error
+	calls []*Request
+}
+
+func (f *fakeProvider) Queue(req *Request) error {
+	f.calls = append(f.calls, req)
+	return f.err
+}
+
+func Test_DualProvider_PublishesToBothOnSuccess(t *testing.T) {
+	primary := &fakeProvider{}
+	secondary := &fakeProvider{}
+	provider := DualProvider{Primary: primary, Secondary: secondary}
+
+	req := &Request{Function: "figlet"}
+	if err := provider.Queue(req); err != nil {
+		t.Fatalf("want no error, got %s", err.Error())
+	}
+
+	if len(primary.calls) != 1 {
+		t.Fatalf("want primary to be called once, got %d", len(primary.calls))
+	}
+	if len(secondary.calls) != 1 {
+		t.Fatalf("want secondary to be called once, got %d", len(secondary.calls))
+	}
+}
+
+func Test_DualProvider_PrimaryFailurePropagatesAndSkipsSecondary(t *testing.T) {
+	wantErr := errors.New("primary is down")
+	primary := &fakeProvider{err: wantErr}
+	secondary := &fakeProvider{}
+	provider := DualProvider{Primary: primary, Secondary: secondary}
+
+	if err := provider.Queue(&Request{Function: "figlet"}); err != wantErr {
+		t.Fatalf("want %s, got %v", wantErr.Error(), err)
+	}
+
+	if len(secondary.calls) != 0 {
+		t.Fatalf("want secondary not to be called when primary fails, got %d calls", len(secondary.calls))
+	}
+}
+
+func Test_DualProvider_SecondaryFailureIsLoggedNotPropagated(t *testing.T) {
+	primary := &fakeProvider{}
+	secondary := &fakeProvider{err: errors.New("secondary is down")}
+	provider := DualProvider{Primary: primary, Secondary: secondary}
+
+	if err := provider.Queue(&Request{Function: "figlet"}); err != nil {
+		t.Fatalf("want secondary failure to be swallowed, got %s", err.Error())
+	}
+}
+
+func Test_DualProvider_NilSecondaryIsANoOp(t *testing.T) {
+	primary := &fakeProvider{}
+	provider := DualProvider{Primary: primary}
+
+	if err := provider.Queue(&Request{Function: "figlet"}); err != nil {
+		t.Fatalf("want no error, got %s", err.Error())
+	}
+}
+
+func Test_KafkaProvider_QueueReturnsErrKafkaUnavailable(t *testing.T) {
+	provider := NewKafkaProvider(KafkaConfig{})
+
+	err := provider.Queue(&Request{Function: "figlet"})
+	if err == nil {
+		t.Fatal("want an error, got nil")
+	}
+	if !errors.Is(err, ErrKafkaUnavailable) {
+		t.Fatalf("want error to wrap ErrKafkaUnavailable, got %s", err.Error())
+	}
+}
+
+func Test_KafkaConfig_DefaultsTopicPrefix(t *testing.T) {
+	provider := NewKafkaProvider(KafkaConfig{})
+
+	err := provider.Queue(&Request{Function: "figlet"})
+	wantMessage := "unable to queue request for figlet via Kafka topic faas.figlet"
+	if got := err.Error(); len(got) < len(wantMessage) || got[:len(wantMessage)] != wantMessage {
+		t.Fatalf("want error to start with %q, got %q", wantMessage, got)
+	}
+}
+
+func Test_JetStreamProvider_QueueReturnsErrJetStreamUnavailable(t *testing.T) {
+	provider := NewJetStreamProvider(JetStreamConfig{})
+
+	err := provider.Queue(&Request{Function: "figlet"})
+	if err == nil {
+		t.Fatal("want an error, got nil")
+	}
+	if !errors.Is(err, ErrJetStreamUnavailable) {
+		t.Fatalf("want error to wrap ErrJetStreamUnavailable, got %s", err.Error())
+	}
+}
+
+func Test_JetStreamConfig_DefaultsStreamNameAndDurableAndAckWait(t *testing.T) {
+	provider := NewJetStreamProvider(JetStreamConfig{})
+
+	err := provider.Queue(&Request{Function: "figlet"})
+	if err == nil {
+		t.Fatal("want an error, got nil")
+	}
+
+	wantMessage := "unable to queue request for figlet via JetStream stream faas-request (durable consumer faas-workers)"
+	if got := err.Error(); len(got) < len(wantMessage) || got[:len(wantMessage)] != wantMessage {
+		t.Fatalf("want error to start with %q, got %q", wantMessage, got)
+	}
+}
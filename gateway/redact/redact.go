@@ -0,0 +1,113 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package redact provides a small rules engine for stripping PII and
+// credentials from anything the gateway persists outside of the request
+// path, e.g. the debug sample sink, before it is written to disk.
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+)
+
+// placeholder replaces a redacted value.
+const placeholder = "***REDACTED***"
+
+// Rule describes one redaction policy. A Rule with Header set redacts a
+// whole header value; a Rule with JSONField set redacts a top-level JSON
+// field in a body that parses as a JSON object; a Rule with only Pattern
+// set redacts matching substrings anywhere in a body.
+type Rule struct {
+	// Header, when set, matches an HTTP header name (case-insensitive) and
+	// replaces its value entirely.
+	Header string
+
+	// JSONField, when set, matches a top-level field name in a body that
+	// parses as a JSON object and replaces its value entirely.
+	JSONField string
+
+	// Pattern, when set, redacts matching substrings in a header value,
+	// JSON field value, or raw body, e.g. an email or credit-card regex.
+	Pattern *regexp.Regexp
+}
+
+// Engine applies a set of Rules to headers and bodies.
+type Engine struct {
+	Rules []Rule
+}
+
+// New builds an Engine from the given rules. A nil or empty Engine is
+// always safe to call and redacts nothing.
+func New(rules ...Rule) Engine {
+	return Engine{Rules: rules}
+}
+
+// RedactHeaders returns a copy of headers with any matching Header/Pattern
+// rules applied.
+func (e Engine) RedactHeaders(headers http.Header) http.Header {
+	if len(e.Rules) == 0 || headers == nil {
+		return headers
+	}
+
+	redacted := make(http.Header, len(headers))
+	for name, values := range headers {
+		out := make([]string, len(values))
+		copy(out, values)
+
+		for _, rule := range e.Rules {
+			if rule.Header != "" && http.CanonicalHeaderKey(rule.Header) == http.CanonicalHeaderKey(name) {
+				for i := range out {
+					out[i] = placeholder
+				}
+				continue
+			}
+			if rule.Pattern != nil {
+				for i := range out {
+					out[i] = rule.Pattern.ReplaceAllString(out[i], placeholder)
+				}
+			}
+		}
+
+		redacted[name] = out
+	}
+
+	return redacted
+}
+
+// RedactBody applies JSONField and Pattern rules to body. If body parses as
+// a JSON object, matching top-level fields are replaced and the object is
+// re-marshalled; Pattern rules are still applied to the result. If body is
+// not a JSON object, only Pattern rules are applied to the raw bytes.
+func (e Engine) RedactBody(body []byte) []byte {
+	if len(e.Rules) == 0 || len(body) == 0 {
+		return body
+	}
+
+	out := body
+
+	var asObject map[string]interface{}
+	if err := json.Unmarshal(body, &asObject); err == nil {
+		for _, rule := range e.Rules {
+			if rule.JSONField == "" {
+				continue
+			}
+			if _, exists := asObject[rule.JSONField]; exists {
+				asObject[rule.JSONField] = placeholder
+			}
+		}
+
+		if reencoded, err := json.Marshal(asObject); err == nil {
+			out = reencoded
+		}
+	}
+
+	for _, rule := range e.Rules {
+		if rule.Pattern != nil {
+			out = rule.Pattern.ReplaceAll(out, []byte(placeholder))
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,60 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package redact
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_RedactHeaders_RedactsMatchingHeaderEntirely(t *testing.T) {
+	engine := New(Rule{Header: "Authorization"})
+
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer secret-token")
+	headers.Set("X-Call-Id", "abc-123")
+
+	redacted := engine.RedactHeaders(headers)
+
+	if redacted.Get("Authorization") != placeholder {
+		t.Errorf("want Authorization redacted, got %q", redacted.Get("Authorization"))
+	}
+	if redacted.Get("X-Call-Id") != "abc-123" {
+		t.Errorf("want X-Call-Id untouched, got %q", redacted.Get("X-Call-Id"))
+	}
+}
+
+func Test_RedactBody_RedactsJSONFieldAndPattern(t *testing.T) {
+	emailPattern := regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
+	engine := New(
+		Rule{JSONField: "ssn"},
+		Rule{Pattern: emailPattern},
+	)
+
+	body := []byte(`{"ssn":"123-45-6789","note":"contact jane@example.com"}`)
+	redacted := string(engine.RedactBody(body))
+
+	if !strings.Contains(redacted, placeholder) {
+		t.Errorf("want redacted body to contain %q, got %s", placeholder, redacted)
+	}
+	if strings.Contains(redacted, "123-45-6789") {
+		t.Errorf("want ssn stripped, got %s", redacted)
+	}
+	if strings.Contains(redacted, "jane@example.com") {
+		t.Errorf("want email stripped, got %s", redacted)
+	}
+}
+
+func Test_RedactBody_LeavesNonMatchingBodyUnchanged(t *testing.T) {
+	engine := New(Rule{JSONField: "ssn"})
+
+	body := []byte(`plain text body`)
+	redacted := engine.RedactBody(body)
+
+	if string(redacted) != string(body) {
+		t.Errorf("want body unchanged, got %s", redacted)
+	}
+}
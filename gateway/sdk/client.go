@@ -0,0 +1,222 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package sdk provides a typed Go client for the gateway's system API, so
+// that platform teams can deploy, list, invoke and query functions without
+// hand-rolling HTTP calls.
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// AuthPlugin applies authentication to an outgoing request, allowing the
+// client to support basic auth, bearer tokens or custom schemes.
+type AuthPlugin interface {
+	Apply(req *http.Request) error
+}
+
+// BasicAuthPlugin authenticates requests with HTTP basic auth.
+type BasicAuthPlugin struct {
+	User     string
+	Password string
+}
+
+// Apply sets the Authorization header for basic auth.
+func (b BasicAuthPlugin) Apply(req *http.Request) error {
+	req.SetBasicAuth(b.User, b.Password)
+	return nil
+}
+
+// Client is a typed client for the OpenFaaS gateway's system API.
+type Client struct {
+	// GatewayURL is the base URL of the gateway, e.g. http://127.0.0.1:8080
+	GatewayURL string
+
+	// HTTPClient performs the underlying HTTP requests.
+	HTTPClient *http.Client
+
+	// Auth, when set, is applied to every outgoing request.
+	Auth AuthPlugin
+
+	// MaxRetries is the number of additional attempts made for requests
+	// that fail with a network error or a 5xx response.
+	MaxRetries int
+
+	// RetryDelay is the pause between retry attempts.
+	RetryDelay time.Duration
+}
+
+// NewClient creates a Client with sensible retry defaults.
+func NewClient(gatewayURL string, auth AuthPlugin) *Client {
+	return &Client{
+		GatewayURL: gatewayURL,
+		HTTPClient: &http.Client{Timeout: time.Second * 30},
+		Auth:       auth,
+		MaxRetries: 3,
+		RetryDelay: time.Millisecond * 200,
+	}
+}
+
+// Deploy creates a new function.
+func (c *Client) Deploy(req requests.CreateFunctionRequest) error {
+	_, _, err := c.doJSON(http.MethodPost, "/system/functions", req)
+	return err
+}
+
+// Update updates an existing function.
+func (c *Client) Update(req requests.CreateFunctionRequest) error {
+	_, _, err := c.doJSON(http.MethodPut, "/system/functions", req)
+	return err
+}
+
+// Delete removes a function by name.
+func (c *Client) Delete(name string) error {
+	_, _, err := c.doJSON(http.MethodDelete, "/system/functions", requests.DeleteFunctionRequest{FunctionName: name})
+	return err
+}
+
+// List returns the deployed functions.
+func (c *Client) List() ([]requests.Function, error) {
+	body, _, err := c.doJSON(http.MethodGet, "/system/functions", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var functions []requests.Function
+	if err := json.Unmarshal(body, &functions); err != nil {
+		return nil, fmt.Errorf("unable to decode function list: %s", err.Error())
+	}
+
+	return functions, nil
+}
+
+// GetFunction returns the status of a single function.
+func (c *Client) GetFunction(name string) (requests.Function, error) {
+	var function requests.Function
+
+	body, _, err := c.doJSON(http.MethodGet, "/system/function/"+name, nil)
+	if err != nil {
+		return function, err
+	}
+
+	if err := json.Unmarshal(body, &function); err != nil {
+		return function, fmt.Errorf("unable to decode function status: %s", err.Error())
+	}
+
+	return function, nil
+}
+
+// Invoke calls a function synchronously and returns its response body.
+func (c *Client) Invoke(name string, body []byte) ([]byte, int, error) {
+	return c.do(http.MethodPost, "/function/"+name, body)
+}
+
+// InvokeAsync queues an invocation, optionally posting the result to callbackURL.
+func (c *Client) InvokeAsync(name string, body []byte, callbackURL string) error {
+	req, reqErr := http.NewRequest(http.MethodPost, c.GatewayURL+"/async-function/"+name, bytes.NewReader(body))
+	if reqErr != nil {
+		return reqErr
+	}
+
+	if len(callbackURL) > 0 {
+		req.Header.Set("X-Callback-Url", callbackURL)
+	}
+
+	_, _, err := c.send(req)
+	return err
+}
+
+// doJSON marshals payload (if non-nil) as the request body and unmarshals a
+// JSON response, returning the raw response body for callers that need to
+// decode it into a specific type.
+func (c *Client) doJSON(method string, path string, payload interface{}) ([]byte, int, error) {
+	var body []byte
+
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return nil, 0, err
+		}
+		body = encoded
+	}
+
+	return c.do(method, path, body)
+}
+
+func (c *Client) do(method string, path string, body []byte) ([]byte, int, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	} else {
+		reader = bytes.NewReader([]byte{})
+	}
+
+	req, reqErr := http.NewRequest(method, c.GatewayURL+path, reader)
+	if reqErr != nil {
+		return nil, 0, reqErr
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.send(req)
+}
+
+// send performs req, applying auth and retrying transient failures up to
+// MaxRetries times.
+func (c *Client) send(req *http.Request) ([]byte, int, error) {
+	if c.Auth != nil {
+		if err := c.Auth.Apply(req); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.RetryDelay)
+
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, 0, err
+				}
+				req.Body = body
+			}
+		}
+
+		res, err := c.HTTPClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		body, readErr := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if readErr != nil {
+			lastErr = readErr
+			continue
+		}
+
+		if res.StatusCode >= http.StatusInternalServerError {
+			lastErr = fmt.Errorf("gateway returned status %d for %s %s", res.StatusCode, req.Method, req.URL.String())
+			continue
+		}
+
+		if res.StatusCode >= http.StatusBadRequest {
+			return body, res.StatusCode, fmt.Errorf("gateway returned status %d for %s %s: %s", res.StatusCode, req.Method, req.URL.String(), string(body))
+		}
+
+		return body, res.StatusCode, nil
+	}
+
+	return nil, 0, lastErr
+}
@@ -0,0 +1,70 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package sdk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_Client_List(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"name": "echo", "replicas": 1}]`))
+	}))
+	defer testServer.Close()
+
+	client := NewClient(testServer.URL, nil)
+
+	functions, err := client.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if len(functions) != 1 || functions[0].Name != "echo" {
+		t.Fatalf("unexpected functions: %+v", functions)
+	}
+}
+
+func Test_Client_RetriesOn5xx(t *testing.T) {
+	attempts := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	client := NewClient(testServer.URL, nil)
+	client.RetryDelay = 0
+
+	err := client.Deploy(requests.CreateFunctionRequest{Service: "echo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if attempts != 2 {
+		t.Errorf("want 2 attempts, got %d", attempts)
+	}
+}
+
+func Test_BasicAuthPlugin_Apply(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+
+	plugin := BasicAuthPlugin{User: "admin", Password: "secret"}
+	if err := plugin.Apply(req); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	user, pass, ok := req.BasicAuth()
+	if !ok || user != "admin" || pass != "secret" {
+		t.Errorf("basic auth not applied correctly")
+	}
+}
@@ -4,26 +4,59 @@
 package main
 
 import (
-	"fmt"
 	"log"
-	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/gorilla/mux"
-	"github.com/openfaas/faas/gateway/handlers"
-
 	"github.com/openfaas/faas-provider/auth"
-	"github.com/openfaas/faas/gateway/metrics"
-	"github.com/openfaas/faas/gateway/plugin"
+	"github.com/openfaas/faas/gateway/bench"
+	"github.com/openfaas/faas/gateway/server"
 	"github.com/openfaas/faas/gateway/types"
-	natsHandler "github.com/openfaas/nats-queue-worker/handler"
 )
 
 func main() {
 
+	if runCLI(os.Args, server.AdminSocketPath) {
+		return
+	}
+
+	if hasArg(os.Args, "--bench") {
+		runBenchMode()
+		return
+	}
+
 	osEnv := types.OsEnv{}
+	var hasEnv types.HasEnv = osEnv
+
+	if configFile := osEnv.Getenv("config_file"); len(configFile) > 0 {
+		fileValues, fileErr := types.LoadYAMLConfigFile(configFile)
+		if fileErr != nil {
+			log.Fatalf("unable to read config_file %s: %s", configFile, fileErr.Error())
+		}
+		hasEnv = types.FileEnv{Env: osEnv, File: fileValues}
+	}
+
+	compatEnv := &types.CompatEnv{Env: hasEnv, Strict: osEnv.Getenv("strict_env_compat") == "true"}
+	hasEnv = compatEnv
+
 	readConfig := types.ReadConfig{}
-	config := readConfig.Read(osEnv)
+	config := readConfig.Read(hasEnv)
+
+	if warnings := compatEnv.Warnings(); len(warnings) > 0 && compatEnv.Strict {
+		for _, warning := range warnings {
+			log.Printf("strict_env_compat: %s", warning)
+		}
+		log.Fatal("strict_env_compat is enabled and deprecated env-vars are set - rename them before starting the gateway")
+	}
+
+	if hasArg(os.Args, "--validate-only") {
+		validateOrExit(config, true)
+		return
+	}
+	validateOrExit(config, false)
 
 	log.Printf("HTTP Read Timeout: %s", config.ReadTimeout)
 	log.Printf("HTTP Write Timeout: %s", config.WriteTimeout)
@@ -48,152 +81,72 @@ func main() {
 		}
 	}
 
-	var faasHandlers types.HandlerSet
-
-	servicePollInterval := time.Second * 5
-
-	metricsOptions := metrics.BuildMetricsOptions()
-	exporter := metrics.NewExporter(metricsOptions, credentials)
-	exporter.StartServiceWatcher(*config.FunctionsProviderURL, metricsOptions, "func", servicePollInterval)
-	metrics.RegisterExporter(exporter)
-
-	reverseProxy := types.NewHTTPClientReverseProxy(config.FunctionsProviderURL, config.UpstreamTimeout)
-
-	loggingNotifier := handlers.LoggingNotifier{}
-	prometheusNotifier := handlers.PrometheusFunctionNotifier{
-		Metrics: &metricsOptions,
-	}
-	functionNotifiers := []handlers.HTTPNotifier{loggingNotifier, prometheusNotifier}
-	forwardingNotifiers := []handlers.HTTPNotifier{loggingNotifier}
-
-	urlResolver := handlers.SingleHostBaseURLResolver{BaseURL: config.FunctionsProviderURL.String()}
-	var functionURLResolver handlers.BaseURLResolver
-	var functionURLTransformer handlers.URLPathTransformer
-	nilURLTransformer := handlers.TransparentURLPathTransformer{}
-
-	if config.DirectFunctions {
-		functionURLResolver = handlers.FunctionAsHostBaseURLResolver{FunctionSuffix: config.DirectFunctionsSuffix}
-		functionURLTransformer = handlers.FunctionPrefixTrimmingURLPathTransformer{}
-	} else {
-		functionURLResolver = urlResolver
-		functionURLTransformer = nilURLTransformer
+	gatewayServer, err := server.New(config, credentials)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	faasHandlers.Proxy = handlers.MakeForwardingProxyHandler(reverseProxy, functionNotifiers, functionURLResolver, functionURLTransformer)
+	watchForShutdown(gatewayServer, config.CacheStatePath)
 
-	faasHandlers.RoutelessProxy = handlers.MakeForwardingProxyHandler(reverseProxy, forwardingNotifiers, urlResolver, nilURLTransformer)
-	faasHandlers.ListFunctions = handlers.MakeForwardingProxyHandler(reverseProxy, forwardingNotifiers, urlResolver, nilURLTransformer)
-	faasHandlers.DeployFunction = handlers.MakeForwardingProxyHandler(reverseProxy, forwardingNotifiers, urlResolver, nilURLTransformer)
-	faasHandlers.DeleteFunction = handlers.MakeForwardingProxyHandler(reverseProxy, forwardingNotifiers, urlResolver, nilURLTransformer)
-	faasHandlers.UpdateFunction = handlers.MakeForwardingProxyHandler(reverseProxy, forwardingNotifiers, urlResolver, nilURLTransformer)
-	faasHandlers.QueryFunction = handlers.MakeForwardingProxyHandler(reverseProxy, forwardingNotifiers, urlResolver, nilURLTransformer)
-	faasHandlers.InfoHandler = handlers.MakeInfoHandler(handlers.MakeForwardingProxyHandler(reverseProxy, forwardingNotifiers, urlResolver, nilURLTransformer))
-
-	alertHandler := plugin.NewExternalServiceQuery(*config.FunctionsProviderURL, credentials)
-	faasHandlers.Alert = handlers.MakeAlertHandler(alertHandler)
+	log.Fatal(gatewayServer.ListenAndServe())
+}
 
-	if config.UseNATS() {
-		log.Println("Async enabled: Using NATS Streaming.")
-		natsQueue, queueErr := natsHandler.CreateNatsQueue(*config.NATSAddress, *config.NATSPort, natsHandler.DefaultNatsConfig{})
-		if queueErr != nil {
-			log.Fatalln(queueErr)
+// watchForShutdown cancels gatewayServer's scale-from-zero shutdown
+// context - so requests parked waiting on a cold-start give up rather
+// than block against a process on its way out - and, if cacheStatePath is
+// set, saves its replica cache beforehand so the next start can reload it
+// instead of starting cold. Runs just before the process exits on
+// SIGINT/SIGTERM.
+func watchForShutdown(gatewayServer *server.Server, cacheStatePath string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+
+		if len(cacheStatePath) > 0 {
+			log.Printf("Saving cache state to %s before shutdown", cacheStatePath)
+			if err := gatewayServer.SaveCacheState(); err != nil {
+				log.Printf("unable to save cache state: %s", err.Error())
+			}
 		}
 
-		faasHandlers.QueuedProxy = handlers.MakeCallIDMiddleware(handlers.MakeQueuedProxy(metricsOptions, true, natsQueue, functionURLTransformer))
-		faasHandlers.AsyncReport = handlers.MakeAsyncReport(metricsOptions)
-	}
-
-	prometheusQuery := metrics.NewPrometheusQuery(config.PrometheusHost, config.PrometheusPort, &http.Client{})
-	faasHandlers.ListFunctions = metrics.AddMetricsHandler(faasHandlers.ListFunctions, prometheusQuery)
-	faasHandlers.Proxy = handlers.MakeCallIDMiddleware(faasHandlers.Proxy)
-
-	faasHandlers.ScaleFunction = handlers.MakeForwardingProxyHandler(reverseProxy, forwardingNotifiers, urlResolver, nilURLTransformer)
-
-	if credentials != nil {
-		faasHandlers.UpdateFunction =
-			auth.DecorateWithBasicAuth(faasHandlers.UpdateFunction, credentials)
-		faasHandlers.DeleteFunction =
-			auth.DecorateWithBasicAuth(faasHandlers.DeleteFunction, credentials)
-		faasHandlers.DeployFunction =
-			auth.DecorateWithBasicAuth(faasHandlers.DeployFunction, credentials)
-		faasHandlers.ListFunctions =
-			auth.DecorateWithBasicAuth(faasHandlers.ListFunctions, credentials)
-		faasHandlers.ScaleFunction =
-			auth.DecorateWithBasicAuth(faasHandlers.ScaleFunction, credentials)
-		faasHandlers.QueryFunction =
-			auth.DecorateWithBasicAuth(faasHandlers.QueryFunction, credentials)
-		faasHandlers.InfoHandler =
-			auth.DecorateWithBasicAuth(faasHandlers.InfoHandler, credentials)
-		faasHandlers.AsyncReport =
-			auth.DecorateWithBasicAuth(faasHandlers.AsyncReport, credentials)
-	}
-
-	r := mux.NewRouter()
-	// max wait time to start a function = maxPollCount * functionPollInterval
+		gatewayServer.Shutdown()
 
-	functionProxy := faasHandlers.Proxy
-
-	if config.ScaleFromZero {
-		scalingConfig := handlers.ScalingConfig{
-			MaxPollCount:         uint(1000),
-			FunctionPollInterval: time.Millisecond * 10,
-			CacheExpiry:          time.Second * 5, // freshness of replica values before going stale
-			ServiceQuery:         alertHandler,
-		}
+		// Give requests parked waiting on a cold-start a brief window to
+		// observe the cancelled shutdown context and write their
+		// response before the process exits out from under them.
+		time.Sleep(time.Second)
+		os.Exit(0)
+	}()
+}
 
-		functionProxy = handlers.MakeScalingHandler(faasHandlers.Proxy, scalingConfig)
-	}
-	// r.StrictSlash(false)	// This didn't work, so register routes twice.
-	r.HandleFunc("/function/{name:[-a-zA-Z_0-9]+}", functionProxy)
-	r.HandleFunc("/function/{name:[-a-zA-Z_0-9]+}/", functionProxy)
-	r.HandleFunc("/function/{name:[-a-zA-Z_0-9]+}/{params:.*}", functionProxy)
-
-	r.HandleFunc("/system/info", faasHandlers.InfoHandler).Methods(http.MethodGet)
-	r.HandleFunc("/system/alert", faasHandlers.Alert).Methods(http.MethodPost)
-
-	r.HandleFunc("/system/function/{name:[-a-zA-Z_0-9]+}", faasHandlers.QueryFunction).Methods(http.MethodGet)
-	r.HandleFunc("/system/functions", faasHandlers.ListFunctions).Methods(http.MethodGet)
-	r.HandleFunc("/system/functions", faasHandlers.DeployFunction).Methods(http.MethodPost)
-	r.HandleFunc("/system/functions", faasHandlers.DeleteFunction).Methods(http.MethodDelete)
-	r.HandleFunc("/system/functions", faasHandlers.UpdateFunction).Methods(http.MethodPut)
-	r.HandleFunc("/system/scale-function/{name:[-a-zA-Z_0-9]+}", faasHandlers.ScaleFunction).Methods(http.MethodPost)
-
-	if faasHandlers.QueuedProxy != nil {
-		r.HandleFunc("/async-function/{name:[-a-zA-Z_0-9]+}/", faasHandlers.QueuedProxy).Methods(http.MethodPost)
-		r.HandleFunc("/async-function/{name:[-a-zA-Z_0-9]+}", faasHandlers.QueuedProxy).Methods(http.MethodPost)
-		r.HandleFunc("/async-function/{name:[-a-zA-Z_0-9]+}/{params:.*}", faasHandlers.QueuedProxy).Methods(http.MethodPost)
-
-		r.HandleFunc("/system/async-report", faasHandlers.AsyncReport)
+// runBenchMode starts the gateway against bench's synthetic in-process
+// functions (echo, sleep, payload-size) instead of a real provider, so
+// operators can load-test gateway throughput, timeouts and scaling
+// middleware on their own without deploying real functions.
+func runBenchMode() {
+	backendServer := bench.NewBackend().NewServer()
+	defer backendServer.Close()
+
+	backendURL, err := url.Parse(backendServer.URL + "/")
+	if err != nil {
+		log.Fatalf("unable to parse bench backend URL: %s", err.Error())
 	}
 
-	fs := http.FileServer(http.Dir("./assets/"))
+	log.Printf("Bench mode: serving synthetic functions %v through the normal proxy path, in isolation from any real provider.", bench.FunctionNames)
 
-	// This URL allows access from the UI to the OpenFaaS store
-	allowedCORSHost := "raw.githubusercontent.com"
-	fsCORS := handlers.DecorateWithCORS(fs, allowedCORSHost)
-
-	uiHandler := http.StripPrefix("/ui", fsCORS)
-	if credentials != nil {
-		r.PathPrefix("/ui/").Handler(auth.DecorateWithBasicAuth(uiHandler.ServeHTTP, credentials)).Methods(http.MethodGet)
-	} else {
-		r.PathPrefix("/ui/").Handler(uiHandler).Methods(http.MethodGet)
+	config := types.GatewayConfig{
+		FunctionsProviderURL: backendURL,
+		UpstreamTimeout:      time.Second * 10,
+		ReadTimeout:          time.Second * 10,
+		WriteTimeout:         time.Second * 10,
 	}
 
-	metricsHandler := metrics.PrometheusHandler()
-	r.Handle("/metrics", metricsHandler)
-	r.HandleFunc("/healthz", handlers.MakeForwardingProxyHandler(reverseProxy, forwardingNotifiers, urlResolver, nilURLTransformer)).Methods(http.MethodGet)
-
-	r.Handle("/", http.RedirectHandler("/ui/", http.StatusMovedPermanently)).Methods(http.MethodGet)
-
-	tcpPort := 8080
-
-	s := &http.Server{
-		Addr:           fmt.Sprintf(":%d", tcpPort),
-		ReadTimeout:    config.ReadTimeout,
-		WriteTimeout:   config.WriteTimeout,
-		MaxHeaderBytes: http.DefaultMaxHeaderBytes, // 1MB - can be overridden by setting Server.MaxHeaderBytes.
-		Handler:        r,
+	gatewayServer, err := server.New(config, nil)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	log.Fatal(s.ListenAndServe())
+	log.Fatal(gatewayServer.ListenAndServe())
 }
@@ -0,0 +1,40 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package server
+
+import "github.com/openfaas/faas/gateway/handlers"
+
+// Option customises a Server before it is built by New. Platform builders
+// embedding the gateway in a bespoke binary use these to swap in their own
+// provider integrations without forking this package.
+type Option func(*options)
+
+type options struct {
+	serviceQuery         handlers.ServiceQuery
+	functionNameResolver handlers.FunctionNameResolver
+}
+
+// WithServiceQuery overrides the handlers.ServiceQuery the gateway uses for
+// replica lookups and scale-from-zero, in place of the default
+// plugin.ExternalServiceQuery that talks HTTP to FunctionsProviderURL. Use
+// this to back the gateway with an orchestrator that isn't the
+// faas-provider REST contract, e.g. when embedding the gateway directly
+// inside a custom control plane.
+func WithServiceQuery(serviceQuery handlers.ServiceQuery) Option {
+	return func(o *options) {
+		o.serviceQuery = serviceQuery
+	}
+}
+
+// WithFunctionNameResolver overrides how the gateway extracts a function
+// name from a request URL, in place of the default resolver which only
+// matches the literal "/function/" prefix. Use this to support custom
+// prefixes, vanity URLs or a namespace separator across every handler that
+// reports a function name (metrics, invocation history, context headers,
+// scaling, pre-stop), without forking them individually.
+func WithFunctionNameResolver(resolver handlers.FunctionNameResolver) Option {
+	return func(o *options) {
+		o.functionNameResolver = resolver
+	}
+}
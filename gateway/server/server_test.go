@@ -0,0 +1,80 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/handlers"
+	"github.com/openfaas/faas/gateway/types"
+)
+
+type fakeServiceQuery struct{}
+
+func (fakeServiceQuery) GetReplicas(service string) (handlers.ServiceQueryResponse, error) {
+	return handlers.ServiceQueryResponse{AvailableReplicas: 1}, nil
+}
+
+func (fakeServiceQuery) SetReplicas(service string, count uint64) error {
+	return nil
+}
+
+func Test_New_RejectsConfigWithoutAProvider(t *testing.T) {
+	_, err := New(types.GatewayConfig{}, nil)
+	if err == nil {
+		t.Fatal("want an error when FunctionsProviderURL is unset")
+	}
+}
+
+func Test_New_BuildsARouterAndUsesAnInjectedServiceQuery(t *testing.T) {
+	providerURL, _ := url.Parse("http://127.0.0.1:0/")
+	config := types.GatewayConfig{
+		FunctionsProviderURL: providerURL,
+		UpstreamTimeout:      time.Second,
+	}
+
+	query := fakeServiceQuery{}
+	gatewayServer, err := New(config, nil, WithServiceQuery(query))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if gatewayServer.Router == nil {
+		t.Fatal("want New to build a router")
+	}
+	if gatewayServer.ServiceQuery != query {
+		t.Error("want the injected ServiceQuery to be used rather than the default plugin.ExternalServiceQuery")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/system/info/capabilities", nil)
+	rr := httptest.NewRecorder()
+	gatewayServer.Router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("want the capabilities route to be registered and respond 200, got %d", rr.Code)
+	}
+}
+
+func Test_normalizeBasePath(t *testing.T) {
+	scenarios := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "", want: ""},
+		{raw: "/", want: ""},
+		{raw: "/faas", want: "/faas"},
+		{raw: "/faas/", want: "/faas"},
+		{raw: "faas", want: "/faas"},
+	}
+
+	for _, s := range scenarios {
+		if got := normalizeBasePath(s.raw); got != s.want {
+			t.Errorf("normalizeBasePath(%q) - want: %q, got: %q", s.raw, s.want, got)
+		}
+	}
+}
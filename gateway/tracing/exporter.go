@@ -0,0 +1,85 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package tracing
+
+import (
+	"encoding/hex"
+	"log"
+)
+
+// SpanExporter hands a finished span off somewhere - a log line, a
+// collector, a test's in-memory slice.
+type SpanExporter interface {
+	Export(span FinishedSpan)
+}
+
+// LogExporter writes each finished span as a single structured-ish log
+// line, in the same style as this package's callers already use for
+// request logging (e.g. handlers.LoggingNotifier). It's the fallback
+// exporter used whenever an OTLP endpoint is configured but can't
+// actually be dialled - see NewExporterFromEnv.
+type LogExporter struct {
+	// ServiceName is attached to every exported span so log lines read
+	// the same way an OTLP Resource's service.name attribute would.
+	ServiceName string
+}
+
+// Export implements SpanExporter.
+func (e LogExporter) Export(span FinishedSpan) {
+	log.Printf("trace service=%s span=%s function=%s trace_id=%s span_id=%s duration_seconds=%f",
+		e.ServiceName,
+		span.Name,
+		span.Function,
+		hex.EncodeToString(span.TraceParent.TraceID[:]),
+		hex.EncodeToString(span.TraceParent.SpanID[:]),
+		span.Duration.Seconds(),
+	)
+}
+
+// NewExporterFromEnv builds a SpanExporter from the standard OpenTelemetry
+// environment variables (OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_SERVICE_NAME),
+// so a deployment already carrying OTel env vars for its other services
+// doesn't need gateway-specific ones too.
+//
+// There is no vendored OTLP client in this tree, so an OTLP endpoint
+// can't actually be dialled here - setting OTEL_EXPORTER_OTLP_ENDPOINT
+// only changes the log line this prints once at startup, warning that
+// spans will be logged locally rather than exported. Wiring a real OTLP
+// exporter in means vendoring go.opentelemetry.io/otel and its
+// exporters/otlp packages and swapping this function's body; the
+// Tracer/SpanExporter split above exists so that's the only change
+// required.
+func NewExporterFromEnv(getenv func(string) string) (exporter SpanExporter, serviceName string) {
+	serviceName = getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "gateway"
+	}
+
+	if endpoint := getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		log.Printf("tracing: OTEL_EXPORTER_OTLP_ENDPOINT=%s set, but no OTLP exporter is vendored in this build - logging spans locally instead", endpoint)
+	}
+
+	return LogExporter{ServiceName: serviceName}, serviceName
+}
+
+// Tracer starts spans and exports them through Exporter once finished.
+// A nil Exporter is valid and simply drops every span - see Span.End.
+type Tracer struct {
+	ServiceName string
+	Exporter    SpanExporter
+}
+
+// NewTracerFromEnv builds a Tracer configured from the standard
+// OpenTelemetry environment variables. See NewExporterFromEnv for what
+// "configured" means without a vendored OTLP client.
+func NewTracerFromEnv(getenv func(string) string) *Tracer {
+	exporter, serviceName := NewExporterFromEnv(getenv)
+	return &Tracer{ServiceName: serviceName, Exporter: exporter}
+}
+
+// StartSpan starts a new child span of parent and returns it; pass the
+// result to (*Span).End(t.Exporter) once the operation finishes.
+func (t *Tracer) StartSpan(name string, parent TraceParent, function string) *Span {
+	return StartSpan(name, parent, function)
+}
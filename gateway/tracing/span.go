@@ -0,0 +1,59 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package tracing
+
+import "time"
+
+// Span is a single timed operation within a trace - a scaling cycle, a
+// proxied call, a queue publish. It carries just enough to export:
+// OpenTelemetry's richer span model (links, events, status codes) isn't
+// reproduced here, since nothing in this package consumes it.
+type Span struct {
+	// Name identifies the operation, e.g. "scale_from_zero" or
+	// "queue_publish". Kept short and snake_cased to match this
+	// repository's existing Prometheus metric names.
+	Name string
+
+	// TraceParent is this span's own identity - its TraceID ties it back
+	// to the request that started the trace, its SpanID identifies this
+	// span specifically.
+	TraceParent TraceParent
+
+	// Function is the function this span concerns, when there is one.
+	Function string
+
+	start time.Time
+}
+
+// StartSpan starts a new child span of parent named name. parent is
+// typically the TraceParent propagated in on an inbound request via
+// FromHeaderOrNew.
+func StartSpan(name string, parent TraceParent, function string) *Span {
+	return &Span{
+		Name:        name,
+		TraceParent: parent.Child(),
+		Function:    function,
+		start:       time.Now(),
+	}
+}
+
+// End finishes the span and exports it. exporter may be nil, in which
+// case the span is simply discarded - callers that have no Tracer
+// configured can still time and propagate spans without a nil check of
+// their own.
+func (s *Span) End(exporter SpanExporter) {
+	if exporter == nil {
+		return
+	}
+	exporter.Export(FinishedSpan{
+		Span:     *s,
+		Duration: time.Since(s.start),
+	})
+}
+
+// FinishedSpan is a Span with its duration filled in, ready to export.
+type FinishedSpan struct {
+	Span
+	Duration time.Duration
+}
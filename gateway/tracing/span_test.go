@@ -0,0 +1,48 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package tracing
+
+import "testing"
+
+type recordingExporter struct {
+	spans []FinishedSpan
+}
+
+func (r *recordingExporter) Export(span FinishedSpan) {
+	r.spans = append(r.spans, span)
+}
+
+func Test_Span_End_ExportsWithTraceIDPreserved(t *testing.T) {
+	parent := NewTraceParent()
+	exporter := &recordingExporter{}
+
+	span := StartSpan("queue_publish", parent, "figlet")
+	span.End(exporter)
+
+	if len(exporter.spans) != 1 {
+		t.Fatalf("want one exported span, got %d", len(exporter.spans))
+	}
+
+	got := exporter.spans[0]
+	if got.TraceParent.TraceID != parent.TraceID {
+		t.Error("want the span's trace ID to match its parent's")
+	}
+	if got.Name != "queue_publish" || got.Function != "figlet" {
+		t.Errorf("unexpected span: %+v", got)
+	}
+}
+
+func Test_Span_End_NilExporterIsNoop(t *testing.T) {
+	span := StartSpan("forward_request", NewTraceParent(), "figlet")
+	span.End(nil)
+}
+
+func Test_NewExporterFromEnv_DefaultsServiceName(t *testing.T) {
+	getenv := func(string) string { return "" }
+
+	_, serviceName := NewExporterFromEnv(getenv)
+	if serviceName != "gateway" {
+		t.Errorf("want default service name \"gateway\", got %q", serviceName)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package tracing provides W3C Trace Context propagation and minimal span
+// timing for the gateway and async path.
+//
+// This repository vendors no OpenTelemetry SDK or OTLP exporter (it
+// predates both, and this tree has no dependency manager that could pull
+// them in), so this package is a from-scratch, dependency-free stand-in:
+// it generates and parses the same "traceparent" header W3C Trace Context
+// and OpenTelemetry both use, and times named spans against it. A real
+// OTLP exporter is approximated by SpanExporter - see exporter.go for how
+// far that approximation goes.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// traceParentVersion is the only W3C Trace Context version this package
+// knows how to read or write.
+const traceParentVersion = "00"
+
+// Sampled is the "sampled" bit of a traceparent's trailing flags byte -
+// the only flag W3C Trace Context currently defines.
+const Sampled byte = 0x01
+
+// TraceParent is a parsed W3C "traceparent" header value: version-traceid-
+// parentid-flags, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+type TraceParent struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// NewTraceParent starts a new trace with a fresh, random trace ID and
+// span ID, sampled by default since this package has no sampling policy
+// of its own to weigh against.
+func NewTraceParent() TraceParent {
+	tp := TraceParent{Flags: Sampled}
+	// crypto/rand.Read never errors on the platforms Go supports; a nil
+	// TraceID/SpanID would only ever come from an exhausted entropy
+	// source, which is unrecoverable anyway.
+	rand.Read(tp.TraceID[:])
+	rand.Read(tp.SpanID[:])
+	return tp
+}
+
+// Child derives a new span ID for a child span started within the same
+// trace, keeping TraceID and Flags unchanged so the child still resolves
+// to the same trace as its parent.
+func (tp TraceParent) Child() TraceParent {
+	child := TraceParent{TraceID: tp.TraceID, Flags: tp.Flags}
+	rand.Read(child.SpanID[:])
+	return child
+}
+
+// String renders tp as a W3C Trace Context "traceparent" header value.
+func (tp TraceParent) String() string {
+	return strings.Join([]string{
+		traceParentVersion,
+		hex.EncodeToString(tp.TraceID[:]),
+		hex.EncodeToString(tp.SpanID[:]),
+		hex.EncodeToString([]byte{tp.Flags}),
+	}, "-")
+}
+
+// ErrInvalidTraceParent is returned by ParseTraceParent when val isn't a
+// well-formed W3C Trace Context header value.
+var ErrInvalidTraceParent = errors.New("invalid traceparent header")
+
+// ParseTraceParent parses a W3C Trace Context "traceparent" header value.
+// Only version "00" is understood; later versions may add fields this
+// package doesn't know how to read, so they're rejected rather than
+// guessed at.
+func ParseTraceParent(val string) (TraceParent, error) {
+	parts := strings.Split(val, "-")
+	if len(parts) != 4 || parts[0] != traceParentVersion {
+		return TraceParent{}, ErrInvalidTraceParent
+	}
+
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != 16 {
+		return TraceParent{}, ErrInvalidTraceParent
+	}
+
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != 8 {
+		return TraceParent{}, ErrInvalidTraceParent
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return TraceParent{}, ErrInvalidTraceParent
+	}
+
+	var tp TraceParent
+	copy(tp.TraceID[:], traceID)
+	copy(tp.SpanID[:], spanID)
+	tp.Flags = flags[0]
+	return tp, nil
+}
+
+// TraceParentHeader is the HTTP header W3C Trace Context propagates
+// itself in; OpenTelemetry's HTTP propagators use the same name.
+const TraceParentHeader = "traceparent"
+
+// FromHeaderOrNew parses val as a traceparent header and returns it, or -
+// if val is empty or malformed - starts a brand new trace. Call sites
+// never need to branch on whether an inbound request already carries a
+// trace; they always get a usable TraceParent back.
+func FromHeaderOrNew(val string) TraceParent {
+	if val == "" {
+		return NewTraceParent()
+	}
+	tp, err := ParseTraceParent(val)
+	if err != nil {
+		return NewTraceParent()
+	}
+	return tp
+}
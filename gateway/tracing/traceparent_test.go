@@ -0,0 +1,68 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package tracing
+
+import "testing"
+
+func Test_TraceParent_StringParseRoundTrip(t *testing.T) {
+	tp := NewTraceParent()
+
+	parsed, err := ParseTraceParent(tp.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if parsed.TraceID != tp.TraceID || parsed.SpanID != tp.SpanID || parsed.Flags != tp.Flags {
+		t.Errorf("want %+v, got %+v", tp, parsed)
+	}
+}
+
+func Test_ParseTraceParent_RejectsMalformedInput(t *testing.T) {
+	cases := []string{
+		"",
+		"not-a-traceparent",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-tooshort-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-tooshort-01",
+	}
+
+	for _, val := range cases {
+		if _, err := ParseTraceParent(val); err == nil {
+			t.Errorf("want an error parsing %q, got none", val)
+		}
+	}
+}
+
+func Test_TraceParent_ChildKeepsTraceIDAndFlags(t *testing.T) {
+	parent := NewTraceParent()
+	child := parent.Child()
+
+	if child.TraceID != parent.TraceID {
+		t.Error("want child to keep the parent's trace ID")
+	}
+	if child.Flags != parent.Flags {
+		t.Error("want child to keep the parent's flags")
+	}
+	if child.SpanID == parent.SpanID {
+		t.Error("want child to have its own span ID")
+	}
+}
+
+func Test_FromHeaderOrNew_FallsBackOnEmptyOrInvalidHeader(t *testing.T) {
+	if _, err := ParseTraceParent(FromHeaderOrNew("").String()); err != nil {
+		t.Errorf("want a valid traceparent for an empty header, got error: %s", err)
+	}
+	if _, err := ParseTraceParent(FromHeaderOrNew("garbage").String()); err != nil {
+		t.Errorf("want a valid traceparent for an invalid header, got error: %s", err)
+	}
+}
+
+func Test_FromHeaderOrNew_PreservesAValidHeader(t *testing.T) {
+	tp := NewTraceParent()
+
+	got := FromHeaderOrNew(tp.String())
+	if got.TraceID != tp.TraceID {
+		t.Error("want the trace ID from the header to be preserved")
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"fmt"
+	"log"
+)
+
+// deprecatedEnvVars maps an old env-var name to the current name that
+// replaced it. CompatEnv consults this so that ReadConfig.Read only ever
+// has to know about current names while deployments still set to an old
+// name keep working. Add an entry here whenever a config knob is renamed,
+// instead of teaching ReadConfig.Read the old name too.
+var deprecatedEnvVars = map[string]string{
+	"queue_backend": "queue_provider",
+}
+
+// CompatEnv wraps a HasEnv, falling back to a deprecated env-var's value
+// when its replacement (see deprecatedEnvVars) isn't set, and recording the
+// deprecated usage so it can be warned about or, in Strict mode, treated as
+// a startup error.
+type CompatEnv struct {
+	Env    HasEnv
+	Strict bool
+
+	warnings []string
+}
+
+// Getenv returns Env's value for key. If that's empty and key is the
+// current replacement for a deprecated name, the deprecated name is tried
+// instead; a non-empty result from it is recorded as a deprecation warning
+// and, outside Strict mode, logged immediately.
+func (c *CompatEnv) Getenv(key string) string {
+	if value := c.Env.Getenv(key); len(value) > 0 {
+		return value
+	}
+
+	for deprecated, replacement := range deprecatedEnvVars {
+		if replacement != key {
+			continue
+		}
+
+		value := c.Env.Getenv(deprecated)
+		if len(value) == 0 {
+			continue
+		}
+
+		warning := fmt.Sprintf("%q is deprecated, use %q instead", deprecated, replacement)
+		c.warnings = append(c.warnings, warning)
+		if !c.Strict {
+			log.Printf("WARNING: %s", warning)
+		}
+		return value
+	}
+
+	return ""
+}
+
+// Warnings returns one message per deprecated env-var read so far, in the
+// order first read. Deprecated names that were never set don't appear.
+func (c *CompatEnv) Warnings() []string {
+	return c.warnings
+}
@@ -0,0 +1,55 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import "testing"
+
+type mapEnv map[string]string
+
+func (m mapEnv) Getenv(key string) string {
+	return m[key]
+}
+
+func Test_CompatEnv_PrefersCurrentNameOverDeprecated(t *testing.T) {
+	env := &CompatEnv{Env: mapEnv{"queue_provider": "kafka", "queue_backend": "nats"}}
+
+	if got := env.Getenv("queue_provider"); got != "kafka" {
+		t.Fatalf("want kafka, got %q", got)
+	}
+	if len(env.Warnings()) != 0 {
+		t.Fatalf("want no warnings when the current name is set, got %v", env.Warnings())
+	}
+}
+
+func Test_CompatEnv_FallsBackToDeprecatedNameAndWarns(t *testing.T) {
+	env := &CompatEnv{Env: mapEnv{"queue_backend": "nats"}}
+
+	if got := env.Getenv("queue_provider"); got != "nats" {
+		t.Fatalf("want nats, got %q", got)
+	}
+	if len(env.Warnings()) != 1 {
+		t.Fatalf("want one warning, got %v", env.Warnings())
+	}
+}
+
+func Test_CompatEnv_LeavesUnrelatedKeysUntouched(t *testing.T) {
+	env := &CompatEnv{Env: mapEnv{"basic_auth": "true"}}
+
+	if got := env.Getenv("basic_auth"); got != "true" {
+		t.Fatalf("want true, got %q", got)
+	}
+	if got := env.Getenv("missing_key"); got != "" {
+		t.Fatalf("want empty string for an unset, non-deprecated key, got %q", got)
+	}
+}
+
+func Test_CompatEnv_StrictModeStillRecordsWarningsWithoutLogging(t *testing.T) {
+	env := &CompatEnv{Env: mapEnv{"queue_backend": "nats"}, Strict: true}
+
+	env.Getenv("queue_provider")
+
+	if len(env.Warnings()) != 1 {
+		t.Fatalf("want one warning recorded even in strict mode, got %v", env.Warnings())
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+// HeaderFilterConfig configures which headers are allowed to cross the
+// gateway between callers and functions, replacing a fixed hop-by-hop-only
+// policy with one that also strips or restricts application headers.
+//
+// This is applied globally to every function proxied through a given
+// HTTPClientReverseProxy. Per-function overrides would need a function's
+// annotations threaded into the proxy on every request, which isn't wired
+// up yet.
+type HeaderFilterConfig struct {
+	// DenyInboundHeaders lists header names stripped from requests before
+	// they reach a function, e.g. internal auth headers the gateway itself
+	// consumes.
+	DenyInboundHeaders []string
+
+	// DenyOutboundHeaders lists header names stripped from a function's
+	// response before it reaches the caller, e.g. internal debug headers.
+	DenyOutboundHeaders []string
+
+	// AllowInboundHeaders and AllowOutboundHeaders, when non-empty,
+	// restrict crossing headers to only the named entries instead of
+	// denying specific ones.
+	AllowInboundHeaders  []string
+	AllowOutboundHeaders []string
+}
@@ -4,6 +4,39 @@ package types
 type GatewayInfo struct {
 	Provider *ProviderInfo `json:"provider"`
 	Version  *VersionInfo  `json:"version"`
+
+	// Build describes the Go toolchain this gateway binary was built with.
+	Build *BuildInfo `json:"build,omitempty"`
+
+	// Features lists the optional gateway features enabled in this
+	// configuration, e.g. "scale_from_zero" or "api_keys" - so support and
+	// automation can fingerprint a deployment's configuration from one
+	// endpoint instead of cross-referencing every individual env var.
+	Features []string `json:"features,omitempty"`
+
+	// Backends describes the external systems this gateway is wired up to.
+	Backends *BackendsInfo `json:"backends,omitempty"`
+
+	// UptimeSeconds is how long this gateway process has been running.
+	UptimeSeconds float64 `json:"uptimeSeconds"`
+}
+
+// BuildInfo describes the Go toolchain a gateway binary was built with.
+type BuildInfo struct {
+	GoVersion string `json:"goVersion"`
+}
+
+// BackendsInfo describes the external systems a gateway is configured
+// against, for fingerprinting a deployment without cross-referencing every
+// individual env var.
+type BackendsInfo struct {
+	// QueueType names the async queue backend in use, e.g.
+	// "nats-streaming", or is empty when async invocation is disabled.
+	QueueType string `json:"queueType,omitempty"`
+
+	// AuthMode names how /system/* routes are authenticated, e.g.
+	// "basic", "api_key" or "none".
+	AuthMode string `json:"authMode"`
 }
 
 // ProviderInfo provides information about the configured provider
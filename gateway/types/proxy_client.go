@@ -4,30 +4,55 @@
 package types
 
 import (
+	"context"
 	"net"
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/openfaas/faas/gateway/metrics"
+	"github.com/openfaas/faas/gateway/tracing"
 )
 
-// NewHTTPClientReverseProxy proxies to an upstream host through the use of a http.Client
-func NewHTTPClientReverseProxy(baseURL *url.URL, timeout time.Duration) *HTTPClientReverseProxy {
+// NewHTTPClientReverseProxy proxies to an upstream host through the use of
+// a http.Client. hostOverrides statically maps a hostname to a replacement
+// address the gateway dials instead, for split-horizon DNS or local testing
+// against upstream hosts that don't otherwise resolve; pass nil to dial
+// hosts as normally resolved.
+func NewHTTPClientReverseProxy(baseURL *url.URL, timeout time.Duration, hostOverrides map[string]string) *HTTPClientReverseProxy {
 	h := HTTPClientReverseProxy{
-		BaseURL: baseURL,
-		Timeout: timeout,
+		BaseURL:       baseURL,
+		Timeout:       timeout,
+		HostOverrides: hostOverrides,
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 1 * time.Second,
 	}
 
 	h.Client = &http.Client{
+		// *http.Transport negotiates HTTP/2 automatically over a TLS
+		// upstream, which is what lets a gRPC function reached over HTTPS -
+		// including trailers, since forwardRequest copies res.Trailer with
+		// the http.TrailerPrefix convention - proxy through unmodified.
+		// There is no equivalent for a plaintext (h2c) gRPC upstream: that
+		// needs golang.org/x/net/http2's http2.Transport dialed with
+		// AllowHTTP, which isn't vendored in this tree. A plaintext gRPC
+		// upstream therefore falls back to HTTP/1.1, which carries a
+		// unary call fine but not a streaming one.
 		Transport: &http.Transport{
 			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   timeout,
-				KeepAlive: 1 * time.Second,
-			}).DialContext,
+			DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, resolveHostOverride(addr, h.HostOverrides))
+			},
 			IdleConnTimeout:       120 * time.Millisecond,
 			ExpectContinueTimeout: 1500 * time.Millisecond,
 		},
-		Timeout: timeout,
+		// No Client.Timeout: the per-request deadline is enforced by the
+		// context that forwardRequest attaches to each request instead,
+		// so that it can be relaxed to an idle-only bound once a
+		// function's response starts streaming. See IdleStreamTimeout.
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -36,9 +61,83 @@ func NewHTTPClientReverseProxy(baseURL *url.URL, timeout time.Duration) *HTTPCli
 	return &h
 }
 
+// resolveHostOverride returns addr with its host replaced by
+// overrides[host], if the host part of addr has an entry in overrides.
+func resolveHostOverride(addr string, overrides map[string]string) string {
+	if len(overrides) == 0 {
+		return addr
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	if override, exists := overrides[host]; exists {
+		return net.JoinHostPort(override, port)
+	}
+
+	return addr
+}
+
 // HTTPClientReverseProxy proxy to a remote BaseURL using a http.Client
 type HTTPClientReverseProxy struct {
 	BaseURL *url.URL
 	Client  *http.Client
 	Timeout time.Duration
+
+	// BufferWindow is how long to keep retrying a request that fails to
+	// reach the upstream provider, e.g. while it briefly restarts, before
+	// giving up. Zero disables buffering and fails fast as before.
+	BufferWindow time.Duration
+
+	// BufferRetryInterval is the pause between buffered retry attempts.
+	BufferRetryInterval time.Duration
+
+	// IdleStreamTimeout bounds the gap between bytes received while
+	// streaming a function's response, resetting on every byte. It is
+	// separate from Timeout, which bounds the call's total duration; a
+	// function that keeps emitting data past Timeout is reaped, but one
+	// that streams continuously for longer than Timeout is not killed by
+	// it as long as no single gap exceeds IdleStreamTimeout. Zero
+	// disables idle checking, leaving Timeout as the only bound.
+	IdleStreamTimeout time.Duration
+
+	// Metrics, when set, records buffered vs dropped requests.
+	Metrics *metrics.MetricOptions
+
+	// HeaderFilter restricts which headers cross the gateway in each
+	// direction, on top of always-stripped hop-by-hop headers.
+	HeaderFilter HeaderFilterConfig
+
+	// ForwardedHeaderMode decides whether X-Forwarded-* and Forwarded
+	// headers set by the caller are trusted, appended to, or replaced. See
+	// the ForwardedHeader* constants in the handlers package. Empty
+	// defaults to passthrough.
+	ForwardedHeaderMode string
+
+	// HostOverrides statically maps an upstream hostname to the address
+	// actually dialed for it, bypassing normal DNS resolution. Useful for
+	// split-horizon DNS setups or pointing a function's upstream host at a
+	// local replacement during testing.
+	HostOverrides map[string]string
+
+	// MaxTimeoutOverride bounds how far a caller may raise or lower Timeout
+	// for a single invocation via the X-Timeout-Seconds request header. Zero
+	// (the default) disables the header, leaving Timeout as the only bound.
+	MaxTimeoutOverride time.Duration
+
+	// FlushInterval, when greater than zero, periodically flushes the
+	// response to the client while a function's response streams through,
+	// instead of waiting on io.Copy's internal buffering - needed for
+	// server-sent events or any response written a few bytes at a time.
+	// Zero (the default) leaves responses unflushed, as before this
+	// existed.
+	FlushInterval time.Duration
+
+	// Tracer, when set, wraps each forwarded request in a span named
+	// "forward_request", parented to the caller's W3C traceparent header
+	// if it sent one. Nil leaves forwarding untraced, as before this
+	// existed.
+	Tracer *tracing.Tracer
 }
@@ -0,0 +1,38 @@
+// Copyright (c) OpenFaaS Author(s) 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import "testing"
+
+func Test_ResolveHostOverride_ReplacesHostKeepingPort(t *testing.T) {
+	overrides := map[string]string{
+		"provider.internal": "127.0.0.1",
+	}
+
+	got := resolveHostOverride("provider.internal:8081", overrides)
+	want := "127.0.0.1:8081"
+	if got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
+
+func Test_ResolveHostOverride_PassesThroughUnmatchedHost(t *testing.T) {
+	overrides := map[string]string{
+		"provider.internal": "127.0.0.1",
+	}
+
+	got := resolveHostOverride("other-host:8081", overrides)
+	want := "other-host:8081"
+	if got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
+
+func Test_ResolveHostOverride_NilOverridesIsNoop(t *testing.T) {
+	got := resolveHostOverride("provider.internal:8081", nil)
+	want := "provider.internal:8081"
+	if got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
@@ -4,6 +4,7 @@
 package types
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -260,3 +261,270 @@ func TestRead_BasicAuth_SetTrue(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRead_ScalingWebhookURLDefaultAndOverride(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+
+	if config.UseExternalScaler() != false {
+		t.Log("Default for UseExternalScaler should be false")
+		t.Fail()
+	}
+
+	wantURL := "http://scaler.openfaas:8081/scale"
+	defaults.Setenv("scaling_webhook_url", wantURL)
+	config = readConfig.Read(defaults)
+
+	if config.ScalingWebhookURL != wantURL {
+		t.Logf("config.ScalingWebhookURL, want: %s, got: %s\n", wantURL, config.ScalingWebhookURL)
+		t.Fail()
+	}
+
+	if config.UseExternalScaler() != true {
+		t.Log("UseExternalScaler should be true once scaling_webhook_url is set")
+		t.Fail()
+	}
+}
+
+func TestRead_HostOverrides(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	if config.HostOverrides != nil {
+		t.Log("Default for HostOverrides should be nil")
+		t.Fail()
+	}
+
+	defaults.Setenv("upstream_host_overrides", "provider.internal=127.0.0.1:8081, malformed, =noname, noaddress=")
+	config = readConfig.Read(defaults)
+
+	if len(config.HostOverrides) != 1 {
+		t.Fatalf("want 1 valid host override, got %d: %v", len(config.HostOverrides), config.HostOverrides)
+	}
+	if config.HostOverrides["provider.internal"] != "127.0.0.1:8081" {
+		t.Logf("config.HostOverrides[provider.internal], want: 127.0.0.1:8081, got: %s\n", config.HostOverrides["provider.internal"])
+		t.Fail()
+	}
+}
+
+func TestRead_RequireAPIKeysDefaultsToOff(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	if config.RequireAPIKeys != false {
+		t.Log("Default for RequireAPIKeys should be false")
+		t.Fail()
+	}
+
+	defaults.Setenv("require_api_keys", "true")
+	config = readConfig.Read(defaults)
+
+	if config.RequireAPIKeys != true {
+		t.Logf("config.RequireAPIKeys, want: %t, got: %t\n", true, config.RequireAPIKeys)
+		t.Fail()
+	}
+}
+
+func TestRead_FunctionTrashRetentionDefaultAndOverride(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	want := 24 * time.Hour
+	if config.FunctionTrashRetention != want {
+		t.Logf("config.FunctionTrashRetention, want: %s, got: %s\n", want, config.FunctionTrashRetention)
+		t.Fail()
+	}
+
+	defaults.Setenv("function_trash_retention", "2h")
+	config = readConfig.Read(defaults)
+
+	want = 2 * time.Hour
+	if config.FunctionTrashRetention != want {
+		t.Logf("config.FunctionTrashRetention, want: %s, got: %s\n", want, config.FunctionTrashRetention)
+		t.Fail()
+	}
+}
+
+func TestRead_ChangeFreezeBreakGlassIdentities(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	if len(config.ChangeFreezeBreakGlassIdentities) != 0 {
+		t.Logf("config.ChangeFreezeBreakGlassIdentities, want: empty, got: %v\n", config.ChangeFreezeBreakGlassIdentities)
+		t.Fail()
+	}
+
+	defaults.Setenv("change_freeze_break_glass_identities", "alice,bob")
+	config = readConfig.Read(defaults)
+
+	if len(config.ChangeFreezeBreakGlassIdentities) != 2 || config.ChangeFreezeBreakGlassIdentities[0] != "alice" {
+		t.Logf("config.ChangeFreezeBreakGlassIdentities, want: [alice bob], got: %v\n", config.ChangeFreezeBreakGlassIdentities)
+		t.Fail()
+	}
+}
+
+func TestRead_GatewayRegionDefaultsToEmpty(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	if len(config.GatewayRegion) != 0 {
+		t.Logf("config.GatewayRegion, want: empty, got: %v\n", config.GatewayRegion)
+		t.Fail()
+	}
+
+	defaults.Setenv("gateway_region", "eu-west-1")
+	config = readConfig.Read(defaults)
+
+	if config.GatewayRegion != "eu-west-1" {
+		t.Logf("config.GatewayRegion, want: eu-west-1, got: %v\n", config.GatewayRegion)
+		t.Fail()
+	}
+}
+
+func TestRead_AuthCacheTTLDefaultAndOverride(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	if config.AuthCacheTTL != time.Duration(0) {
+		t.Logf("config.AuthCacheTTL, want: 0, got: %v\n", config.AuthCacheTTL)
+		t.Fail()
+	}
+
+	defaults.Setenv("auth_cache_ttl", "5s")
+	config = readConfig.Read(defaults)
+
+	if config.AuthCacheTTL != 5*time.Second {
+		t.Logf("config.AuthCacheTTL, want: 5s, got: %v\n", config.AuthCacheTTL)
+		t.Fail()
+	}
+}
+
+func TestRead_QueueProviderDefaultsToEmptyAndKafkaSettingsParse(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	if config.QueueProvider != "" {
+		t.Logf("config.QueueProvider, want: \"\", got: %v\n", config.QueueProvider)
+		t.Fail()
+	}
+
+	defaults.Setenv("queue_provider", "kafka")
+	defaults.Setenv("kafka_brokers", "kafka-1:9092, kafka-2:9092")
+	defaults.Setenv("kafka_topic_prefix", "faas-async.")
+	config = readConfig.Read(defaults)
+
+	if config.QueueProvider != "kafka" {
+		t.Logf("config.QueueProvider, want: kafka, got: %v\n", config.QueueProvider)
+		t.Fail()
+	}
+
+	wantBrokers := []string{"kafka-1:9092", "kafka-2:9092"}
+	if !reflect.DeepEqual(config.KafkaBrokers, wantBrokers) {
+		t.Logf("config.KafkaBrokers, want: %v, got: %v\n", wantBrokers, config.KafkaBrokers)
+		t.Fail()
+	}
+
+	if config.KafkaTopicPrefix != "faas-async." {
+		t.Logf("config.KafkaTopicPrefix, want: faas-async., got: %v\n", config.KafkaTopicPrefix)
+		t.Fail()
+	}
+}
+
+func TestRead_ResponseCompressionDefaultAndOverride(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	if config.ResponseCompression {
+		t.Error("want ResponseCompression to default to false")
+	}
+	if config.ResponseCompressionMinBytes != 860 {
+		t.Errorf("want ResponseCompressionMinBytes to default to 860, got %d", config.ResponseCompressionMinBytes)
+	}
+
+	defaults.Setenv("response_compression", "true")
+	defaults.Setenv("response_compression_min_bytes", "2048")
+	config = readConfig.Read(defaults)
+
+	if !config.ResponseCompression {
+		t.Error("want ResponseCompression true once overridden")
+	}
+	if config.ResponseCompressionMinBytes != 2048 {
+		t.Errorf("want ResponseCompressionMinBytes 2048, got %d", config.ResponseCompressionMinBytes)
+	}
+}
+
+func TestRead_MaxRequestBodyBytesDefaultAndOverride(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	if config.MaxRequestBodyBytes != 0 {
+		t.Errorf("want MaxRequestBodyBytes to default to 0 (unlimited), got %d", config.MaxRequestBodyBytes)
+	}
+
+	defaults.Setenv("max_request_body_bytes", "1048576")
+	config = readConfig.Read(defaults)
+
+	if config.MaxRequestBodyBytes != 1048576 {
+		t.Errorf("want MaxRequestBodyBytes 1048576, got %d", config.MaxRequestBodyBytes)
+	}
+}
+
+func TestRead_ServerTimingDefaultAndOverride(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	if config.ServerTiming {
+		t.Error("want ServerTiming to default to false")
+	}
+
+	defaults.Setenv("server_timing", "true")
+	config = readConfig.Read(defaults)
+
+	if !config.ServerTiming {
+		t.Error("want ServerTiming true once overridden")
+	}
+}
+
+func TestRead_OIDCConfigDefaultAndOverride(t *testing.T) {
+	defaults := NewEnvBucket()
+	readConfig := ReadConfig{}
+
+	config := readConfig.Read(defaults)
+	if config.UseOIDCAuth() {
+		t.Error("want UseOIDCAuth to default to false with no OIDCIssuer set")
+	}
+
+	defaults.Setenv("oidc_issuer", "https://issuer.example.com")
+	defaults.Setenv("oidc_audience", "gateway")
+	defaults.Setenv("oidc_jwks_url", "https://issuer.example.com/keys")
+	defaults.Setenv("oidc_jwks_cache_ttl", "10m")
+	config = readConfig.Read(defaults)
+
+	if !config.UseOIDCAuth() {
+		t.Error("want UseOIDCAuth true once OIDCIssuer is set")
+	}
+	if config.OIDCIssuer != "https://issuer.example.com" {
+		t.Errorf("want OIDCIssuer to be set from env, got %q", config.OIDCIssuer)
+	}
+	if config.OIDCAudience != "gateway" {
+		t.Errorf("want OIDCAudience to be set from env, got %q", config.OIDCAudience)
+	}
+	if config.OIDCJWKSURL != "https://issuer.example.com/keys" {
+		t.Errorf("want OIDCJWKSURL to be set from env, got %q", config.OIDCJWKSURL)
+	}
+	if config.OIDCJWKSCacheTTL != 10*time.Minute {
+		t.Errorf("want OIDCJWKSCacheTTL to be 10m, got %s", config.OIDCJWKSCacheTTL)
+	}
+}
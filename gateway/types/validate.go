@@ -0,0 +1,106 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ValidationProblem describes a single configuration or connectivity issue
+// found during startup validation, along with a remediation hint.
+type ValidationProblem struct {
+	Field   string
+	Message string
+	Remedy  string
+}
+
+func (v ValidationProblem) String() string {
+	return fmt.Sprintf("%s: %s (%s)", v.Field, v.Message, v.Remedy)
+}
+
+// Validate checks the gateway configuration for type/range errors and, where
+// dialTimeout is greater than zero, verifies reachability of the configured
+// provider/NATS/Prometheus endpoints. It is used both at startup to fail
+// fast, and by the `--validate-only` flag for use in CI.
+func Validate(cfg GatewayConfig, dialTimeout time.Duration) []ValidationProblem {
+	var problems []ValidationProblem
+
+	if !cfg.UseExternalProvider() {
+		problems = append(problems, ValidationProblem{
+			Field:   "functions_provider_url",
+			Message: "not set",
+			Remedy:  "set functions_provider_url to the URL of your faas-provider, e.g. http://faas-swarm:8080/",
+		})
+	} else if dialTimeout > 0 {
+		if err := checkHTTPReachable(cfg.FunctionsProviderURL.String(), dialTimeout); err != nil {
+			problems = append(problems, ValidationProblem{
+				Field:   "functions_provider_url",
+				Message: err.Error(),
+				Remedy:  "check the provider is running and reachable from the gateway",
+			})
+		}
+	}
+
+	if cfg.UseNATS() && dialTimeout > 0 {
+		addr := fmt.Sprintf("%s:%d", *cfg.NATSAddress, *cfg.NATSPort)
+		if err := checkTCPReachable(addr, dialTimeout); err != nil {
+			problems = append(problems, ValidationProblem{
+				Field:   "faas_nats_address/faas_nats_port",
+				Message: err.Error(),
+				Remedy:  "check the NATS Streaming server is running and reachable from the gateway",
+			})
+		}
+	}
+
+	if dialTimeout > 0 {
+		addr := fmt.Sprintf("%s:%d", cfg.PrometheusHost, cfg.PrometheusPort)
+		if err := checkTCPReachable(addr, dialTimeout); err != nil {
+			problems = append(problems, ValidationProblem{
+				Field:   "faas_prometheus_host/faas_prometheus_port",
+				Message: err.Error(),
+				Remedy:  "check Prometheus is running and reachable from the gateway",
+			})
+		}
+	}
+
+	if cfg.ReadTimeout <= 0 {
+		problems = append(problems, ValidationProblem{
+			Field:   "read_timeout",
+			Message: "must be greater than zero",
+			Remedy:  "set read_timeout to a duration such as 8s",
+		})
+	}
+
+	if cfg.WriteTimeout <= 0 {
+		problems = append(problems, ValidationProblem{
+			Field:   "write_timeout",
+			Message: "must be greater than zero",
+			Remedy:  "set write_timeout to a duration such as 8s",
+		})
+	}
+
+	return problems
+}
+
+func checkTCPReachable(addr string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+func checkHTTPReachable(url string, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+	res, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
@@ -0,0 +1,41 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Validate_MissingProviderURL(t *testing.T) {
+	cfg := GatewayConfig{
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	}
+
+	problems := Validate(cfg, 0)
+
+	if len(problems) != 1 {
+		t.Fatalf("want 1 problem, got %d: %v", len(problems), problems)
+	}
+
+	if problems[0].Field != "functions_provider_url" {
+		t.Errorf("want problem about functions_provider_url, got %s", problems[0].Field)
+	}
+}
+
+func Test_Validate_ZeroTimeouts(t *testing.T) {
+	defaults := NewEnvBucket()
+	defaults.Setenv("functions_provider_url", "http://127.0.0.1:8081/")
+	readConfig := ReadConfig{}
+	cfg := readConfig.Read(defaults)
+	cfg.ReadTimeout = 0
+	cfg.WriteTimeout = 0
+
+	problems := Validate(cfg, 0)
+
+	if len(problems) != 2 {
+		t.Fatalf("want 2 problems, got %d: %v", len(problems), problems)
+	}
+}
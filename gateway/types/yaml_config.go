@@ -0,0 +1,124 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// yamlKeyToEnvVar maps the dotted keys produced by parsing the gateway's
+// YAML config file onto the flat env-var names understood by ReadConfig.Read,
+// so that nested settings such as timeout hierarchies can be expressed in
+// the file while still allowing an env-var to override them.
+var yamlKeyToEnvVar = map[string]string{
+	"provider.url":             "functions_provider_url",
+	"timeouts.read":            "read_timeout",
+	"timeouts.write":           "write_timeout",
+	"timeouts.upstream":        "upstream_timeout",
+	"nats.address":             "faas_nats_address",
+	"nats.port":                "faas_nats_port",
+	"prometheus.host":          "faas_prometheus_host",
+	"prometheus.port":          "faas_prometheus_port",
+	"routing.direct_functions": "direct_functions",
+	"routing.direct_suffix":    "direct_functions_suffix",
+	"auth.basic":               "basic_auth",
+	"auth.secret_mount_path":   "secret_mount_path",
+	"scaling.from_zero":        "scale_from_zero",
+	"scaling.webhook_url":      "scaling_webhook_url",
+}
+
+// LoadYAMLConfigFile reads a gateway config file in the small YAML subset
+// supported (key: value pairs, with one level of nesting via indentation)
+// and returns it as a flat map of env-var name to value, ready to be merged
+// with OS env-vars by FileEnv.
+func LoadYAMLConfigFile(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := parseFlatYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse config file %s: %s", path, err.Error())
+	}
+
+	envValues := make(map[string]string)
+	for key, value := range fields {
+		envVar, known := yamlKeyToEnvVar[key]
+		if !known {
+			return nil, fmt.Errorf("unrecognised config key %q in %s", key, path)
+		}
+		envValues[envVar] = value
+	}
+
+	return envValues, nil
+}
+
+// parseFlatYAML parses a minimal YAML subset - "key: value" pairs, comments
+// starting with '#', and one level of nesting expressed via indentation
+// (e.g. `timeouts:` followed by indented `read: 8s`) - into a flat map keyed
+// by dotted path, e.g. "timeouts.read" => "8s".
+func parseFlatYAML(data []byte) (map[string]string, error) {
+	result := make(map[string]string)
+
+	type frame struct {
+		indent int
+		prefix string
+	}
+	var stack []frame
+
+	for lineNo, rawLine := range strings.Split(string(data), "\n") {
+		line := strings.TrimRight(rawLine, " \r\t")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		parts := strings.SplitN(trimmed, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNo+1)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		prefix := ""
+		if len(stack) > 0 {
+			prefix = stack[len(stack)-1].prefix + "."
+		}
+		fullKey := prefix + key
+
+		if value == "" {
+			stack = append(stack, frame{indent: indent, prefix: fullKey})
+			continue
+		}
+
+		result[fullKey] = strings.Trim(value, `"'`)
+	}
+
+	return result, nil
+}
+
+// FileEnv wraps a HasEnv so that OS env-vars take priority, falling back to
+// values loaded from a YAML config file. This lets a gateway config file
+// hold defaults for nested settings while env-vars remain the override.
+type FileEnv struct {
+	Env  HasEnv
+	File map[string]string
+}
+
+// Getenv returns the OS env-var if set, otherwise the value from the file.
+func (f FileEnv) Getenv(key string) string {
+	if v := f.Env.Getenv(key); len(v) > 0 {
+		return v
+	}
+	return f.File[key]
+}
@@ -0,0 +1,68 @@
+// Copyright (c) OpenFaaS Author(s). All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func Test_LoadYAMLConfigFile(t *testing.T) {
+	contents := `
+provider:
+  url: http://faas-swarm:8080/
+timeouts:
+  read: 10s
+  write: 10s
+scaling:
+  from_zero: true
+`
+	tmpFile, err := ioutil.TempFile("", "gateway-config-*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(contents); err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+
+	envValues, err := LoadYAMLConfigFile(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	want := map[string]string{
+		"functions_provider_url": "http://faas-swarm:8080/",
+		"read_timeout":           "10s",
+		"write_timeout":          "10s",
+		"scale_from_zero":        "true",
+	}
+
+	for k, v := range want {
+		if envValues[k] != v {
+			t.Errorf("key %s: want %q, got %q", k, v, envValues[k])
+		}
+	}
+}
+
+func Test_FileEnv_OSEnvOverridesFile(t *testing.T) {
+	osEnv := NewEnvBucket()
+	osEnv.Setenv("read_timeout", "5s")
+
+	fileEnv := FileEnv{
+		Env:  osEnv,
+		File: map[string]string{"read_timeout": "10s", "write_timeout": "10s"},
+	}
+
+	if v := fileEnv.Getenv("read_timeout"); v != "5s" {
+		t.Errorf("want OS env-var to win, got %s", v)
+	}
+
+	if v := fileEnv.Getenv("write_timeout"); v != "10s" {
+		t.Errorf("want file value when unset in OS env, got %s", v)
+	}
+}